@@ -1,7 +1,14 @@
 package main
 
-import "github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api"
+import (
+	"os"
+
+	// Embeds the timezone database, needed to evaluate the observers quiet hours.
+	_ "time/tzdata"
+
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api"
+)
 
 func main() {
-	api.StartApp()
+	os.Exit(api.Run(os.Args[1:]))
 }
@@ -0,0 +1,23 @@
+//go:generate mockgen --source=account_data_repository.go --destination=../../infrastructure/repository/mocks/account_data.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// AccountDataRepositoryType define IoC key for account data repository
+const AccountDataRepositoryType = "AccountDataRepository"
+
+// AccountDataRepository gathers the personal data of a user for exporting it, and keeps the account deletion requests.
+type AccountDataRepository interface {
+	GetChildren(uint) ([]model.Children, error)
+	GetAddresses(uint) ([]model.Address, error)
+	GetLinks(uint) ([]model.ObservedUserObserverUser, error)
+	GetGuardianships(uint) ([]model.Guardian, error)
+	GetHandovers(uint) ([]model.PickupHandover, error)
+	GetDeletion(uint) (*model.AccountDeletion, error)
+	SaveDeletion(model.AccountDeletion) error
+	GetDueDeletions(int64) ([]model.AccountDeletion, error)
+	Erase(uint, int64) error
+}
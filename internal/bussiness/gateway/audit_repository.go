@@ -0,0 +1,22 @@
+//go:generate mockgen --source=audit_repository.go --destination=../../infrastructure/repository/mocks/audit.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+const (
+	// AuditRepositoryType define IoC key for audit repository
+	AuditRepositoryType = "AuditRepository"
+	// RequestInfoType define IoC key for the information of the HTTP request
+	RequestInfoType = "RequestInfo"
+)
+
+// AuditRepository is an append-only store of audit entries. Append chains the entry to the last one, whose hash
+// GetHead returns.
+type AuditRepository interface {
+	Append(model.AuditEntry) (*model.AuditEntry, error)
+	Find(model.AuditQuery) ([]model.AuditEntry, error)
+	GetHead() (string, error)
+}
@@ -0,0 +1,31 @@
+//go:generate mockgen --source=calendar.go --destination=../../infrastructure/repository/mocks/calendar.go
+
+package gateway
+
+import (
+	"io"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+const (
+	// CalendarRepositoryType define IoC key for calendar repository
+	CalendarRepositoryType = "CalendarRepository"
+	// CalendarCodecType define IoC key for the iCalendar codec
+	CalendarCodecType = "CalendarCodec"
+)
+
+// CalendarRepository is an interface that provides the necessary methods for the company calendars and
+// the calendars a family depends on.
+type CalendarRepository interface {
+	GetCompanyCalendar(string) ([]model.CalendarEntry, error)
+	SaveCompanyCalendar(string, []model.CalendarEntry) error
+	GetFamilySchools(uint) ([]uint, error)
+	GetFamilyCompanies(uint) ([]string, error)
+}
+
+// CalendarCodec encodes and decodes calendars in iCalendar format (RFC 5545).
+type CalendarCodec interface {
+	Encode(model.Calendar) ([]byte, error)
+	Decode(io.Reader, string) ([]model.CalendarEntry, error)
+}
@@ -0,0 +1,22 @@
+//go:generate mockgen --source=field_cipher.go --destination=../../infrastructure/repository/mocks/field_cipher.go
+
+package gateway
+
+// FieldCipherType define IoC key for field cipher
+const FieldCipherType = "FieldCipher"
+
+// KeyProvider provides the key encryption keys used to wrap the data keys of encrypted fields,
+// and the key used to compute blind indexes.
+type KeyProvider interface {
+	CurrentKey() (string, []byte)
+	Key(string) ([]byte, error)
+	IndexKey() []byte
+}
+
+// FieldCipher encrypts sensitive fields before storing them, and computes blind indexes to look them up.
+type FieldCipher interface {
+	Encrypt(string) (string, error)
+	Decrypt(string) (string, error)
+	BlindIndex(string) string
+	NeedsRotation(string) bool
+}
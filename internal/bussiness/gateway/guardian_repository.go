@@ -0,0 +1,21 @@
+//go:generate mockgen --source=guardian_repository.go --destination=../../infrastructure/repository/mocks/guardian.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// GuardianRepositoryType define IoC key for guardian repository
+const GuardianRepositoryType = "GuardianRepository"
+
+// GuardianRepository is an interface that provides the necessary methods for the guardians of the children
+// and the invitations to become one.
+type GuardianRepository interface {
+	GetGuardians(uint) ([]model.Guardian, error)
+	SaveGuardian(model.Guardian) error
+	DeleteGuardian(uint, uint) error
+	GetInvitation(string) (*model.GuardianInvitation, error)
+	GetPendingInvitations(uint) ([]model.GuardianInvitation, error)
+	SaveInvitation(model.GuardianInvitation) error
+}
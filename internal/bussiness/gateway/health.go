@@ -0,0 +1,24 @@
+//go:generate mockgen --source=health.go --destination=../../infrastructure/repository/mocks/health.go
+
+package gateway
+
+import (
+	"context"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// HealthCheckerType define IoC key for health checker
+const HealthCheckerType = "HealthChecker"
+
+// HealthCheck checks that a component the service depends on, such as the database, is working.
+type HealthCheck interface {
+	Name() string
+	Check(context.Context) error
+}
+
+// HealthChecker runs the registered health checks and reports the health of the service.
+type HealthChecker interface {
+	Register(HealthCheck)
+	Check(context.Context) model.HealthReport
+}
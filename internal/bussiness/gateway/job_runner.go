@@ -0,0 +1,17 @@
+//go:generate mockgen --source=job_runner.go --destination=../../infrastructure/repository/mocks/job_runner.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// JobRunnerType define IoC key for job runner
+const JobRunnerType = "JobRunner"
+
+// JobRunner runs the long jobs started by the requests in the background, so they outlive the request.
+type JobRunner interface {
+	// Start runs the job unless one of the same name is already running on this server, and reports whether it
+	// started it. The job gets a service locator of its own, acting as the principal.
+	Start(name string, principal *model.Principal, job func(ServiceLocator) error) bool
+}
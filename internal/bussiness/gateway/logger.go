@@ -0,0 +1,11 @@
+package gateway
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// LoggerType define IoC key for the logger of the request
+const LoggerType = "Logger"
+
+// Logger logs with the fields of the request, such as its ID and its user.
+type Logger = log.FieldLogger
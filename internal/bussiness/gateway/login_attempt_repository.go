@@ -0,0 +1,20 @@
+//go:generate mockgen --source=login_attempt_repository.go --destination=../../infrastructure/repository/mocks/login_attempt.go
+
+package gateway
+
+import (
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// LoginAttemptRepositoryType define IoC key for login attempt repository
+const LoginAttemptRepositoryType = "LoginAttemptRepository"
+
+// LoginAttemptRepository is an interface that provides the necessary methods for the login attempt repository.
+type LoginAttemptRepository interface {
+	Get(string, string) (*model.LoginAttempt, error)
+	RegisterFailure(string, string, time.Time, time.Duration) (*model.LoginAttempt, error)
+	Block(string, string, int64) error
+	Delete(string, string) error
+}
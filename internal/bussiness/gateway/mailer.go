@@ -0,0 +1,11 @@
+//go:generate mockgen --source=mailer.go --destination=../../infrastructure/repository/mocks/mailer.go
+
+package gateway
+
+// MailerType define IoC key for mailer
+const MailerType = "Mailer"
+
+// Mailer renders an email template with the given data and delivers it to the recipient.
+type Mailer interface {
+	Send(to string, template string, data map[string]string) error
+}
@@ -0,0 +1,12 @@
+//go:generate mockgen --source=metric_collector.go --destination=../../infrastructure/repository/mocks/metric_collector.go
+
+package gateway
+
+// MetricCollectorType define IoC key for metric collector
+const MetricCollectorType = "MetricCollector"
+
+// MetricCollector counts the domain activity exposed as metrics.
+type MetricCollector interface {
+	CountLoginFailure(reason string)
+	CountNotification(channel string, outcome string)
+}
@@ -0,0 +1,24 @@
+//go:generate mockgen --source=notification_repository.go --destination=../../infrastructure/repository/mocks/notification.go
+
+package gateway
+
+import (
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// NotificationRepositoryType define IoC key for notification repository
+const NotificationRepositoryType = "NotificationRepository"
+
+// NotificationRepository is an interface that provides the necessary methods for the notification repository.
+type NotificationRepository interface {
+	GetSettings(uint) (*model.NotificationSettings, error)
+	SaveSettings(model.NotificationSettings) (*model.NotificationSettings, error)
+	SaveDigestItem(model.Notification) error
+	GetDigestItems(uint) ([]model.Notification, error)
+	ClaimDigestItems(uint, string, time.Duration) ([]model.Notification, error)
+	ReleaseDigestItems(string) error
+	DeleteDigestItems(string) error
+	GetObserversWithDueDigest() ([]uint, error)
+}
@@ -0,0 +1,15 @@
+//go:generate mockgen --source=notification_sender.go --destination=../../infrastructure/repository/mocks/notification_sender.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// NotificationSenderType define IoC key for notification sender
+const NotificationSenderType = "NotificationSender"
+
+// NotificationSender delivers a notification through a single channel (push, email, sms).
+type NotificationSender interface {
+	Send(string, model.Notification) error
+}
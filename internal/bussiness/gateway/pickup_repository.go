@@ -0,0 +1,27 @@
+//go:generate mockgen --source=pickup_repository.go --destination=../../infrastructure/repository/mocks/pickup.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// PickupRepositoryType define IoC key for pickup repository
+const PickupRepositoryType = "PickupRepository"
+
+// PickupRepository is an interface that provides the necessary methods for the people authorized to receive
+// the children, their pickup PINs and the record of the handovers.
+type PickupRepository interface {
+	GetPickups(uint) ([]model.AuthorizedPickup, error)
+	GetPickup(uint, uint) (*model.AuthorizedPickup, error)
+	SavePickup(model.AuthorizedPickup) (*model.AuthorizedPickup, error)
+	DeletePickup(uint, uint) error
+	GetEncryptedIDNumbers(uint, int) ([]model.EncryptedValue, error)
+	UpdateEncryptedIDNumber(model.EncryptedValue) error
+	GetPIN(uint) (*model.PickupPIN, error)
+	SavePIN(model.PickupPIN) error
+	RedeemPIN(uint, string, int64) (bool, error)
+	IsDriverOf(uint, uint) (bool, error)
+	SaveHandover(model.PickupHandover) error
+	GetHandovers(uint) ([]model.PickupHandover, error)
+}
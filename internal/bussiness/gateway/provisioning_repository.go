@@ -0,0 +1,22 @@
+//go:generate mockgen --source=provisioning_repository.go --destination=../../infrastructure/repository/mocks/provisioning.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// ProvisioningRepositoryType define IoC key for provisioning repository
+const ProvisioningRepositoryType = "ProvisioningRepository"
+
+// ProvisioningRepository is an interface that provides the necessary methods to create the accounts, school buses
+// and children the operators set up.
+type ProvisioningRepository interface {
+	CreateObserver(model.User) (*model.User, error)
+	CreateDriver(model.ObservedUser) (*model.User, error)
+	FindSchoolBus(string) (*model.SchoolBus, error)
+	SaveSchoolBus(model.SchoolBus) (*model.SchoolBus, error)
+	AssignSchoolBus(uint, uint) error
+	LinkObserver(uint, uint) error
+	SaveChild(model.Children) (*model.Children, error)
+}
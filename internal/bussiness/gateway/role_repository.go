@@ -0,0 +1,20 @@
+//go:generate mockgen --source=role_repository.go --destination=../../infrastructure/repository/mocks/role.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+const (
+	// RoleRepositoryType define IoC key for role repository
+	RoleRepositoryType = "RoleRepository"
+	// PrincipalType define IoC key for the authenticated principal of the request
+	PrincipalType = "Principal"
+)
+
+// RoleRepository is an interface that provides the necessary methods for the role repository.
+type RoleRepository interface {
+	GetRoles(uint) ([]model.RoleAssignment, error)
+	SetRoles(uint, []model.RoleAssignment) error
+}
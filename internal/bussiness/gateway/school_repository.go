@@ -0,0 +1,19 @@
+//go:generate mockgen --source=school_repository.go --destination=../../infrastructure/repository/mocks/school.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// SchoolRepositoryType define IoC key for school repository
+const SchoolRepositoryType = "SchoolRepository"
+
+// SchoolRepository is an interface that provides the necessary methods for the school repository.
+type SchoolRepository interface {
+	GetSchool(uint) (*model.School, error)
+	GetSchools() ([]model.School, error)
+	SaveSchool(model.School) (*model.School, error)
+	GetChild(uint) (*model.Children, error)
+	SetChildSchool(uint, uint) error
+}
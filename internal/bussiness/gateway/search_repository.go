@@ -0,0 +1,15 @@
+//go:generate mockgen --source=search_repository.go --destination=../../infrastructure/repository/mocks/search.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+// SearchRepositoryType define IoC key for search repository
+const SearchRepositoryType = "SearchRepository"
+
+// SearchRepository finds the users and children matching a full-text search, best matches first.
+type SearchRepository interface {
+	Search(model.SearchQuery) ([]model.SearchResult, error)
+}
@@ -0,0 +1,27 @@
+//go:generate mockgen --source=token.go --destination=../../infrastructure/repository/mocks/token.go
+
+package gateway
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+const (
+	// TokenRepositoryType define IoC key for token repository
+	TokenRepositoryType = "TokenRepository"
+	// TokenServiceType define IoC key for token service
+	TokenServiceType = "TokenService"
+)
+
+// TokenRepository keeps track of the issued tokens so each one can only be used once.
+type TokenRepository interface {
+	Save(model.Token) error
+	Consume(string) (bool, error)
+	Revoke(uint, string) error
+}
+
+// TokenService signs tokens and verifies their signature and expiration.
+type TokenService interface {
+	Sign(model.Token) (string, error)
+	Parse(string) (*model.Token, error)
+}
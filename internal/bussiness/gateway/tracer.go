@@ -0,0 +1,17 @@
+//go:generate mockgen --source=tracer.go --destination=../../infrastructure/repository/mocks/tracer.go
+
+package gateway
+
+// TracerType define IoC key for tracer
+const TracerType = "Tracer"
+
+// Span is an operation being traced.
+type Span interface {
+	// End ends the span, marking it as failed when err is not nil.
+	End(err error)
+}
+
+// Tracer starts the spans of the use cases as children of the span of the request.
+type Tracer interface {
+	Start(name string) Span
+}
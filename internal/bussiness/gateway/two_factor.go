@@ -0,0 +1,39 @@
+//go:generate mockgen --source=two_factor.go --destination=../../infrastructure/repository/mocks/two_factor.go
+
+package gateway
+
+import (
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+const (
+	// TwoFactorRepositoryType define IoC key for two-factor repository
+	TwoFactorRepositoryType = "TwoFactorRepository"
+	// TOTPServiceType define IoC key for TOTP service
+	TOTPServiceType = "TOTPService"
+)
+
+// TwoFactorRepository is an interface that provides the necessary methods for the second factors of the users,
+// their recovery codes and the policies of the companies.
+type TwoFactorRepository interface {
+	Get(uint) (*model.TwoFactor, error)
+	Save(model.TwoFactor) error
+	Delete(uint) error
+	UseStep(uint, int64) (bool, error)
+	ReplaceRecoveryCodes(uint, []string) error
+	UseRecoveryCode(uint, string) (bool, error)
+	GetEncryptedSecrets(uint, int) ([]model.EncryptedValue, error)
+	UpdateEncryptedSecret(model.EncryptedValue) error
+	GetPolicy(string) (*model.TwoFactorPolicy, error)
+	SavePolicy(model.TwoFactorPolicy) error
+}
+
+// TOTPService generates and validates time-based one-time passwords (RFC 6238).
+type TOTPService interface {
+	NewSecret() (string, error)
+	ProvisioningURI(secret, account string) string
+	QRCode(uri string) (string, error)
+	Validate(secret, code string, at time.Time) (int64, bool)
+}
@@ -15,8 +15,19 @@ const UserRepositoryType = "UserRepository"
 type UserRepository interface {
 	Save(model.User) (*model.User, error)
 	Get(uint) (*model.User, error)
-	GetUsers(string, string) (*[]model.User, error)
+	GetUsers(model.UserQuery) ([]model.User, error)
+	CountUsers(model.UserQuery) (int, error)
 	FindByUsername(string) (*model.User, error)
+	FindByEmail(string) (*model.User, error)
+	SetEmailVerified(uint, bool) error
+	SetPendingEmail(uint, string) error
+	ConfirmPendingEmail(uint, string) error
+	UpdatePassword(uint, string) error
+	UpdateProfile(model.User) error
+	SetEnabled(uint, bool) error
+	FindByIDNumber(string) (*model.User, error)
+	GetEncryptedIDNumbers(uint, int) ([]model.EncryptedValue, error)
+	UpdateEncryptedIDNumber(model.EncryptedValue) error
 	GetObservedUser(*model.ObservedUser) (*model.IUser, error)
 	GetObserverUser(*model.ObserverUser) (*model.IUser, error)
 }
@@ -0,0 +1,25 @@
+package model
+
+// EmailRequest is the body used to ask for a verification or password reset email.
+type EmailRequest struct {
+	Email string `json:"email"`
+}
+
+// TokenConfirmation is the body used to consume a token sent by email.
+type TokenConfirmation struct {
+	Token    string `json:"token"`
+	Password string `json:"password,omitempty"`
+}
+
+// ProfileUpdate is the body of a partial update of the own profile. Fields left out keep their value.
+type ProfileUpdate struct {
+	Name     *string `json:"name,omitempty"`
+	LastName *string `json:"last_name,omitempty"`
+	Email    *string `json:"email,omitempty"`
+}
+
+// PasswordChange is the body used to replace the own password, knowing the current one.
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
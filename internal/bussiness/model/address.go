@@ -0,0 +1,18 @@
+package model
+
+type Address struct {
+	ID             uint   `json:"id" gorm:"primaryKey,autoIncrement"`
+	Street         string `json:"street"`
+	Number         string `json:"number"`
+	Floor          string `json:"floor,omitempty"`
+	Apartment      string `json:"apartment,omitempty"`
+	ZipCode        string `json:"zip_code"`
+	City           string `json:"city"`
+	State          string `json:"state"`
+	Country        string `json:"country"`
+	Latitude       string `json:"latitude"`
+	Longitude      string `json:"longitude"`
+	ObserverUserID uint   `json:"observer_user_id"`
+	CreatedAt      string `json:"created_at"`
+	UpdatedAt      string `json:"updated_at"`
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+const (
+	AuditActionLogin                = "auth.login"
+	AuditActionLoginFailed          = "auth.login_failed"
+	AuditActionTwoFactorVerified    = "auth.two_factor_verified"
+	AuditActionTwoFactorFailed      = "auth.two_factor_failed"
+	AuditActionTwoFactorEnabled     = "auth.two_factor_enabled"
+	AuditActionTwoFactorDisabled    = "auth.two_factor_disabled"
+	AuditActionRecoveryCodes        = "auth.recovery_codes_regenerated"
+	AuditActionEmailVerified        = "account.email_verified"
+	AuditActionPasswordReset        = "account.password_reset"
+	AuditActionPasswordChanged      = "account.password_changed"
+	AuditActionDataExported         = "account.data_exported"
+	AuditActionDeletionRequested    = "account.deletion_requested"
+	AuditActionDeletionCancelled    = "account.deletion_cancelled"
+	AuditActionAccountErased        = "account.erased"
+	AuditActionPreferencesChanged   = "profile.notification_preferences_changed"
+	AuditActionProfileUpdated       = "profile.updated"
+	AuditActionUserStatusChanged    = "admin.user_status_changed"
+	AuditActionRolesChanged         = "admin.roles_changed"
+	AuditActionTwoFactorPolicySet   = "admin.two_factor_policy_changed"
+	AuditActionReencryptionFinished = "admin.reencryption_finished"
+	AuditActionAuditLogVerified     = "admin.audit_log_verified"
+	AuditActionSchoolCreated        = "admin.school_created"
+	AuditActionSchoolUpdated        = "admin.school_updated"
+	AuditActionCalendarChanged      = "admin.calendar_changed"
+	AuditActionUserCreated          = "admin.user_created"
+	AuditActionBusAssigned          = "admin.bus_assigned"
+	AuditActionObserverLinked       = "admin.observer_linked"
+	AuditActionChildAdded           = "admin.child_added"
+
+	AuditActionGuardianInvited           = "guardian.invited"
+	AuditActionGuardianInvitationRevoked = "guardian.invitation_revoked"
+	AuditActionGuardianAdded             = "guardian.added"
+	AuditActionGuardianUpdated           = "guardian.updated"
+	AuditActionGuardianRemoved           = "guardian.removed"
+
+	AuditActionPickupAdded        = "pickup.added"
+	AuditActionPickupUpdated      = "pickup.updated"
+	AuditActionPickupRemoved      = "pickup.removed"
+	AuditActionPickupPINGenerated = "pickup.pin_generated"
+	AuditActionPickupHandedOver   = "pickup.handed_over"
+	AuditActionPickupRejected     = "pickup.rejected"
+
+	AuditTargetUser     = "user"
+	AuditTargetCompany  = "company"
+	AuditTargetAuditLog = "audit_log"
+	AuditTargetSchool   = "school"
+	AuditTargetChild    = "child"
+
+	defaultAuditQueryLimit = 50
+	maxAuditQueryLimit     = 500
+)
+
+// AuditEntry records who did what on which resource. Entries are chained: Hash covers the entry and
+// the hash of the previous one, so altering or removing an entry breaks every following hash.
+// OccurredAt is in unix milliseconds, and ActorID is zero for anonymous requests such as failed logins.
+type AuditEntry struct {
+	ID            uint              `json:"id"`
+	OccurredAt    int64             `json:"occurred_at"`
+	Action        string            `json:"action"`
+	ActorID       uint              `json:"actor_id,omitempty"`
+	ActorUsername string            `json:"actor_username,omitempty"`
+	TargetType    string            `json:"target_type,omitempty"`
+	TargetID      string            `json:"target_id,omitempty"`
+	IP            string            `json:"ip,omitempty"`
+	RequestID     string            `json:"request_id,omitempty"`
+	Details       map[string]string `json:"details,omitempty"`
+	PrevHash      string            `json:"prev_hash"`
+	Hash          string            `json:"hash"`
+}
+
+// ComputeHash returns the SHA-256 of the entry content chained to PrevHash. The ID is not covered,
+// since it is assigned by the database after the hash is computed.
+func (e AuditEntry) ComputeHash() string {
+	details, _ := json.Marshal(e.Details)
+
+	content := strings.Join([]string{
+		e.PrevHash,
+		strconv.FormatInt(e.OccurredAt, 10),
+		e.Action,
+		strconv.FormatUint(uint64(e.ActorID), 10),
+		e.ActorUsername,
+		e.TargetType,
+		e.TargetID,
+		e.IP,
+		e.RequestID,
+		string(details),
+	}, "\x1f")
+
+	sum := sha256.Sum256([]byte(content))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditQuery filters the audit log. Entries are returned by ascending ID, starting after AfterID.
+// From and To are unix milliseconds.
+type AuditQuery struct {
+	Action     string
+	ActorID    uint
+	TargetType string
+	TargetID   string
+	From       int64
+	To         int64
+	AfterID    uint
+	Limit      int
+}
+
+// Normalize applies the default limit and caps it.
+func (q *AuditQuery) Normalize() {
+	if q.Limit <= 0 {
+		q.Limit = defaultAuditQueryLimit
+	}
+
+	if q.Limit > maxAuditQueryLimit {
+		q.Limit = maxAuditQueryLimit
+	}
+}
+
+// AuditVerification is the result of checking the hash chain of the audit log.
+// BrokenAt is the ID of the first entry whose hash does not match. Truncated tells that the last entries were
+// removed: the head of the chain, the hash of the last entry appended, is not in the log.
+type AuditVerification struct {
+	Valid     bool `json:"valid"`
+	Entries   int  `json:"entries"`
+	BrokenAt  uint `json:"broken_at,omitempty"`
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// RequestInfo identifies the HTTP request being served, for the audit log.
+type RequestInfo struct {
+	ID       string
+	ClientIP string
+}
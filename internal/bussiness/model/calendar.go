@@ -0,0 +1,105 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	CalendarEntryHoliday         = "holiday"
+	CalendarEntryInService       = "in_service"
+	CalendarEntryHalfDay         = "half_day"
+	CalendarEntrySpecialSchedule = "special_schedule"
+
+	CalendarDateLayout = "2006-01-02"
+)
+
+// CalendarEntryKinds lists every kind of calendar entry.
+var CalendarEntryKinds = []string{CalendarEntryHoliday, CalendarEntryInService, CalendarEntryHalfDay, CalendarEntrySpecialSchedule}
+
+// CalendarEntry changes a single date of a calendar. Holidays and in-service days have no classes, so no trips.
+// Half days end at End, and special schedules replace the bell schedule with Start and End, even on weekdays
+// without classes. Date is in CalendarDateLayout and the times in HH:MM.
+type CalendarEntry struct {
+	Date  string `json:"date"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// NoService reports whether the entry cancels the classes of the day.
+func (e CalendarEntry) NoService() bool {
+	return e.Kind == CalendarEntryHoliday || e.Kind == CalendarEntryInService
+}
+
+// Calendar is the calendar of a school or a company, as exchanged in iCalendar format. ID identifies the owner,
+// such as "school-1" or "company-bus a".
+type Calendar struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Timezone string          `json:"timezone"`
+	Entries  []CalendarEntry `json:"entries"`
+}
+
+// ServiceDay is a school day resolved from the bell schedule and the school and company calendars. Kind and Name
+// come from the calendar entry that changed the day, if any.
+type ServiceDay struct {
+	Date    string `json:"date"`
+	Service bool   `json:"service"`
+	Start   string `json:"start,omitempty"`
+	End     string `json:"end,omitempty"`
+	Kind    string `json:"kind,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+// CalendarEntryOn returns the entry of the date, if any.
+func CalendarEntryOn(entries []CalendarEntry, date string) (CalendarEntry, bool) {
+	for _, entry := range entries {
+		if entry.Date == date {
+			return entry, true
+		}
+	}
+
+	return CalendarEntry{}, false
+}
+
+// ServiceDay resolves the school day of t, in the school timezone. A company day without service cancels the
+// trips regardless of the school, otherwise the school calendar prevails over the company one, and both over
+// the bell schedule.
+func (s School) ServiceDay(t time.Time, company []CalendarEntry) ServiceDay {
+	local := t.In(s.Location())
+	day := ServiceDay{Date: local.Format(CalendarDateLayout)}
+
+	for _, schedule := range s.BellSchedules {
+		if schedule.Weekday == local.Weekday() {
+			day.Service, day.Start, day.End = true, schedule.Start, schedule.End
+		}
+	}
+
+	companyEntry, companyOK := CalendarEntryOn(company, day.Date)
+	entry, ok := CalendarEntryOn(s.Calendar, day.Date)
+
+	switch {
+	case companyOK && companyEntry.NoService():
+		entry = companyEntry
+	case !ok && companyOK:
+		entry = companyEntry
+	case !ok:
+		return day
+	}
+
+	day.Kind, day.Name = entry.Kind, entry.Name
+
+	switch entry.Kind {
+	case CalendarEntryHoliday, CalendarEntryInService:
+		day.Service, day.Start, day.End = false, "", ""
+	case CalendarEntryHalfDay:
+		if day.Service && entry.End != "" {
+			day.End = entry.End
+		}
+	case CalendarEntrySpecialSchedule:
+		day.Service, day.Start, day.End = true, entry.Start, entry.End
+	}
+
+	return day
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchoolServiceDay(t *testing.T) {
+	school := School{
+		Timezone:      "UTC",
+		BellSchedules: []BellSchedule{{Weekday: time.Monday, Start: "08:00", End: "17:00"}},
+		Calendar: []CalendarEntry{
+			{Date: "2023-07-10", Kind: CalendarEntryHalfDay, End: "12:00"},
+			{Date: "2023-07-17", Kind: CalendarEntrySpecialSchedule, Start: "10:00", End: "15:00"},
+			{Date: "2023-07-24", Kind: CalendarEntryHoliday, Name: "Winter break"},
+		},
+	}
+
+	monday := func(day int) time.Time {
+		return time.Date(2023, 7, day, 9, 0, 0, 0, time.UTC)
+	}
+
+	t.Run("ServiceDay follows the bell schedule", func(t *testing.T) {
+		assert.Equal(t, ServiceDay{Date: "2023-07-03", Service: true, Start: "08:00", End: "17:00"}, school.ServiceDay(monday(3), nil))
+		assert.False(t, school.ServiceDay(monday(4), nil).Service)
+	})
+
+	t.Run("ServiceDay applies the school calendar", func(t *testing.T) {
+		assert.Equal(t, ServiceDay{Date: "2023-07-10", Service: true, Start: "08:00", End: "12:00", Kind: CalendarEntryHalfDay}, school.ServiceDay(monday(10), nil))
+		assert.Equal(t, ServiceDay{Date: "2023-07-17", Service: true, Start: "10:00", End: "15:00", Kind: CalendarEntrySpecialSchedule}, school.ServiceDay(monday(17), nil))
+		assert.Equal(t, ServiceDay{Date: "2023-07-24", Kind: CalendarEntryHoliday, Name: "Winter break"}, school.ServiceDay(monday(24), nil))
+	})
+
+	t.Run("ServiceDay company day without service prevails", func(t *testing.T) {
+		company := []CalendarEntry{{Date: "2023-07-17", Kind: CalendarEntryInService, Name: "Fleet maintenance"}}
+
+		day := school.ServiceDay(monday(17), company)
+		assert.False(t, day.Service)
+		assert.Equal(t, CalendarEntryInService, day.Kind)
+	})
+
+	t.Run("ServiceDay school calendar prevails over the company schedule", func(t *testing.T) {
+		company := []CalendarEntry{
+			{Date: "2023-07-03", Kind: CalendarEntryHalfDay, End: "11:00"},
+			{Date: "2023-07-10", Kind: CalendarEntrySpecialSchedule, Start: "09:00", End: "16:00"},
+		}
+
+		assert.Equal(t, "11:00", school.ServiceDay(monday(3), company).End)
+		assert.Equal(t, "12:00", school.ServiceDay(monday(10), company).End)
+	})
+}
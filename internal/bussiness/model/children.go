@@ -1,10 +1,12 @@
 package model
 
+// Children is a child of an observer user. SchoolName is the name of the school SchoolID refers to.
 type Children struct {
 	ID              uint   `json:"id" gorm:"primaryKey,autoIncrement"`
 	ObserverUserID  uint   `json:"observer_user_id"`
 	Name            string `json:"name"`
 	LastName        string `json:"last_name"`
+	SchoolID        uint   `json:"school_id"`
 	SchoolName      string `json:"school_name"`
 	SchoolStartTime string `json:"school_start_time"`
 	SchoolEndTime   string `json:"school_end_time"`
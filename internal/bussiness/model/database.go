@@ -1,8 +1,16 @@
 package model
 
+import "time"
+
 type Database struct {
 	User     string
 	Password string
 	Host     string
 	Name     string
+	// Params are added to the DSN, such as charset=utf8.
+	Params          map[string]string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
@@ -0,0 +1,17 @@
+package model
+
+const (
+	EmailTemplateVerifyEmail   = "verify_email"
+	EmailTemplateResetPassword = "reset_password"
+
+	EmailTemplateGuardianInvitation = "guardian_invitation"
+	EmailTemplateEmailChanged       = "email_changed"
+)
+
+// Email is a rendered message ready to be delivered.
+type Email struct {
+	From    string
+	To      string
+	Subject string
+	Body    string
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	GuardianRolePrimary         = "primary"
+	GuardianRoleSecondary       = "secondary"
+	GuardianRoleTemporaryPickup = "temporary_pickup"
+
+	GuardianInvitationPending  = "pending"
+	GuardianInvitationAccepted = "accepted"
+	GuardianInvitationRevoked  = "revoked"
+
+	// ChildAccessTrack allows following the bus carrying the child.
+	ChildAccessTrack = "track"
+	// ChildAccessCheckIn allows receiving the check-in notifications of the child.
+	ChildAccessCheckIn = "check_in"
+	// ChildAccessPickup allows picking the child up.
+	ChildAccessPickup = "pickup"
+	// ChildAccessManage allows inviting and removing the other guardians of the child.
+	ChildAccessManage = "manage"
+)
+
+// GuardianRoles lists the roles a primary guardian can grant. The primary guardian is the observer user
+// the child belongs to, and cannot be granted.
+var GuardianRoles = []string{GuardianRoleSecondary, GuardianRoleTemporaryPickup}
+
+// guardianAccess is what each guardian role allows on the child.
+var guardianAccess = map[string][]string{
+	GuardianRolePrimary:         {ChildAccessTrack, ChildAccessCheckIn, ChildAccessPickup, ChildAccessManage},
+	GuardianRoleSecondary:       {ChildAccessTrack, ChildAccessCheckIn, ChildAccessPickup},
+	GuardianRoleTemporaryPickup: {ChildAccessTrack, ChildAccessPickup},
+}
+
+// Guardian relates an observer user to a child they look after. StartsAt and EndsAt bound delegations in time,
+// such as a grandmother picking the child up for a week. Times are unix seconds, and zero means unbounded.
+type Guardian struct {
+	ChildID        uint   `json:"child_id"`
+	ObserverUserID uint   `json:"observer_user_id"`
+	Name           string `json:"name,omitempty"`
+	LastName       string `json:"last_name,omitempty"`
+	Role           string `json:"role"`
+	StartsAt       int64  `json:"starts_at,omitempty"`
+	EndsAt         int64  `json:"ends_at,omitempty"`
+	InvitedBy      uint   `json:"invited_by,omitempty"`
+	CreatedAt      string `json:"created_at,omitempty"`
+}
+
+// Active reports whether the guardian is within its delegation at the instant.
+func (g Guardian) Active(now time.Time) bool {
+	return (g.StartsAt == 0 || now.Unix() >= g.StartsAt) && (g.EndsAt == 0 || now.Unix() < g.EndsAt)
+}
+
+// Can reports whether the guardian role allows the access at the instant.
+func (g Guardian) Can(access string, now time.Time) bool {
+	if !g.Active(now) {
+		return false
+	}
+
+	for _, granted := range guardianAccess[g.Role] {
+		if granted == access {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GuardianInvitation is sent by the primary guardian to the email of another observer user, who becomes a
+// guardian of the child with Role once they accept it. ID is the ID of the token sent by email.
+// Times are unix seconds.
+type GuardianInvitation struct {
+	ID        string `json:"id"`
+	ChildID   uint   `json:"child_id"`
+	InvitedBy uint   `json:"invited_by"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	StartsAt  int64  `json:"starts_at,omitempty"`
+	EndsAt    int64  `json:"ends_at,omitempty"`
+	Status    string `json:"status"`
+	ExpiresAt int64  `json:"expires_at"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// NotificationAccess returns the access a guardian needs to receive the event type about a child. Events not
+// about a child need none.
+func NotificationAccess(eventType string) string {
+	switch eventType {
+	case NotificationEventCheckIn, NotificationEventHandover:
+		return ChildAccessCheckIn
+	case NotificationEventProximity, NotificationEventArrival:
+		return ChildAccessTrack
+	}
+
+	return ""
+}
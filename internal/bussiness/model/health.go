@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// HealthReport tells whether the service and each of the components it depends on are working.
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+// ComponentHealth is the result of checking a component. CheckedAt tells how old a cached result is. Only the
+// status is exposed, since the error and timings can reveal the internals of the service; they are logged instead.
+type ComponentHealth struct {
+	Status     string    `json:"status"`
+	Error      string    `json:"-"`
+	CheckedAt  time.Time `json:"-"`
+	DurationMS int64     `json:"-"`
+}
+
+// Up reports whether every component is up.
+func (h HealthReport) Up() bool {
+	return h.Status == HealthStatusUp
+}
@@ -0,0 +1,17 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthReportHidesErrors(t *testing.T) {
+	content, err := json.Marshal(HealthReport{Status: HealthStatusDown, Components: map[string]ComponentHealth{
+		"database": {Status: HealthStatusDown, Error: "dial tcp 10.0.0.3:3306: connection refused", CheckedAt: time.Now(), DurationMS: 2},
+	}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"status":"down","components":{"database":{"status":"down"}}}`, string(content))
+}
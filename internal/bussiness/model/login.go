@@ -3,4 +3,5 @@ package model
 type Login struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	ClientIP string `json:"-"`
 }
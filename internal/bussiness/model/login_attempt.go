@@ -0,0 +1,19 @@
+package model
+
+const (
+	LoginAttemptScopeUsername  = "username"
+	LoginAttemptScopeIP        = "ip"
+	LoginAttemptScopeTwoFactor = "two_factor"
+	LoginAttemptScopePickup    = "pickup"
+)
+
+// LoginAttempt counts the consecutive failed logins of a username or an IP address,
+// the failed second factor codes of a user, or the failed pickup verifications of a child.
+// Times are unix seconds.
+type LoginAttempt struct {
+	Scope         string
+	Key           string
+	Failures      int
+	LastFailureAt int64
+	BlockedUntil  int64
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	NotificationEventProximity    = "proximity"
+	NotificationEventArrival      = "arrival"
+	NotificationEventAnnouncement = "announcement"
+	NotificationEventCheckIn      = "check_in"
+	NotificationEventSOS          = "sos"
+	NotificationEventDigest       = "digest"
+	// NotificationEventHandover tells the guardians who received a child from the bus. It is not configurable.
+	NotificationEventHandover = "handover"
+
+	NotificationChannelPush  = "push"
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+
+	DefaultNotificationTimezone       = "America/Argentina/Buenos_Aires"
+	DefaultNotificationDigestInterval = 60
+)
+
+// NotificationEvents lists every event type an observer can configure.
+var NotificationEvents = []string{
+	NotificationEventProximity,
+	NotificationEventArrival,
+	NotificationEventAnnouncement,
+	NotificationEventCheckIn,
+	NotificationEventSOS,
+}
+
+// NotificationChannels lists every channel a notification can be delivered through.
+var NotificationChannels = []string{
+	NotificationChannelPush,
+	NotificationChannelEmail,
+	NotificationChannelSMS,
+}
+
+// Notification is a single event addressed to an observer user.
+type Notification struct {
+	ID             uint   `json:"id"`
+	ObserverUserID uint   `json:"observer_user_id"`
+	EventType      string `json:"event_type"`
+	Title          string `json:"title"`
+	Body           string `json:"body"`
+	CreatedAt      string `json:"created_at,omitempty"`
+}
+
+// NotificationPreference holds the observer choices for one event type.
+type NotificationPreference struct {
+	EventType string   `json:"event_type"`
+	Enabled   bool     `json:"enabled"`
+	Channels  []string `json:"channels"`
+}
+
+// QuietHours is a daily window, in the observer timezone, where only SOS events are delivered.
+// Start may be later than End, in which case the window spans midnight.
+type QuietHours struct {
+	Enabled bool   `json:"enabled"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+// NotificationSettings groups every notification preference of an observer user.
+type NotificationSettings struct {
+	ObserverUserID        uint                     `json:"observer_user_id"`
+	Timezone              string                   `json:"timezone"`
+	QuietHours            QuietHours               `json:"quiet_hours"`
+	DigestEnabled         bool                     `json:"digest_enabled"`
+	DigestIntervalMinutes int                      `json:"digest_interval_minutes"`
+	Preferences           []NotificationPreference `json:"preferences"`
+	CreatedAt             string                   `json:"created_at,omitempty"`
+	UpdatedAt             string                   `json:"updated_at,omitempty"`
+}
+
+// NewNotificationSettings returns the settings used when an observer has not configured anything yet:
+// every event enabled through push, no quiet hours and no digest.
+func NewNotificationSettings(observerUserID uint) NotificationSettings {
+	preferences := make([]NotificationPreference, 0, len(NotificationEvents))
+	for _, event := range NotificationEvents {
+		preferences = append(preferences, NotificationPreference{
+			EventType: event,
+			Enabled:   true,
+			Channels:  []string{NotificationChannelPush},
+		})
+	}
+
+	return NotificationSettings{
+		ObserverUserID:        observerUserID,
+		Timezone:              DefaultNotificationTimezone,
+		DigestIntervalMinutes: DefaultNotificationDigestInterval,
+		Preferences:           preferences,
+	}
+}
+
+// Preference returns the preference configured for the event type, or the default one.
+func (s NotificationSettings) Preference(eventType string) NotificationPreference {
+	for _, preference := range s.Preferences {
+		if preference.EventType == eventType {
+			return preference
+		}
+	}
+
+	return NotificationPreference{EventType: eventType, Enabled: true, Channels: []string{NotificationChannelPush}}
+}
+
+// InQuietHours reports whether the instant falls inside the quiet hours window of the observer.
+func (s NotificationSettings) InQuietHours(instant time.Time) (bool, error) {
+	if !s.QuietHours.Enabled {
+		return false, nil
+	}
+
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return false, err
+	}
+
+	start, err := ParseClock(s.QuietHours.Start)
+	if err != nil {
+		return false, err
+	}
+
+	end, err := ParseClock(s.QuietHours.End)
+	if err != nil {
+		return false, err
+	}
+
+	local := instant.In(location)
+	now := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+
+	if start <= end {
+		return now >= start && now < end, nil
+	}
+
+	return now >= start || now < end, nil
+}
+
+// IsUrgentNotification reports whether the event type is sent right away, whatever the preferences, the quiet hours,
+// the digest and the school calendar.
+func IsUrgentNotification(eventType string) bool {
+	return eventType == NotificationEventSOS || eventType == NotificationEventHandover
+}
+
+// IsLowPriorityNotification reports whether the event type can be batched in a digest.
+func IsLowPriorityNotification(eventType string) bool {
+	return eventType == NotificationEventProximity || eventType == NotificationEventAnnouncement
+}
+
+// IsTripNotification reports whether the event type comes from the school trips, which do not happen on the
+// days without classes.
+func IsTripNotification(eventType string) bool {
+	return eventType == NotificationEventProximity || eventType == NotificationEventArrival || eventType == NotificationEventCheckIn
+}
+
+// ParseClock parses a time of day in HH:MM or HH:MM:SS format into the elapsed time since midnight.
+func ParseClock(clock string) (time.Duration, error) {
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid time of day %q", clock)
+}
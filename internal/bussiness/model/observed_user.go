@@ -9,7 +9,7 @@ type ObservedUser struct {
 }
 
 func NewObservedUser(observed ObservedUser) IUser {
-	return observed
+	return &observed
 }
 
 func (u ObservedUser) GetUserID() uint {
@@ -20,7 +20,7 @@ func (u ObservedUser) GetName() string {
 	return u.User.Name
 }
 
-func (u ObservedUser) SetName(name string) {
+func (u *ObservedUser) SetName(name string) {
 	u.User.Name = name
 }
 
@@ -28,7 +28,7 @@ func (u ObservedUser) GetLastName() string {
 	return u.User.LastName
 }
 
-func (u ObservedUser) SetLastName(lastName string) {
+func (u *ObservedUser) SetLastName(lastName string) {
 	u.User.LastName = lastName
 }
 
@@ -36,7 +36,7 @@ func (u ObservedUser) GetIDNumber() string {
 	return u.User.IDNumber
 }
 
-func (u ObservedUser) SetIDNumber(idNumber string) {
+func (u *ObservedUser) SetIDNumber(idNumber string) {
 	u.User.IDNumber = idNumber
 }
 
@@ -44,7 +44,7 @@ func (u ObservedUser) GetEmail() string {
 	return u.User.Email
 }
 
-func (u ObservedUser) SetEmail(email string) {
+func (u *ObservedUser) SetEmail(email string) {
 	u.User.Email = email
 }
 
@@ -52,7 +52,7 @@ func (u ObservedUser) GetUsername() string {
 	return u.User.Username
 }
 
-func (u ObservedUser) SetUsername(username string) {
+func (u *ObservedUser) SetUsername(username string) {
 	u.User.Username = username
 }
 
@@ -60,7 +60,7 @@ func (u ObservedUser) GetPassword() string {
 	return u.User.Password
 }
 
-func (u ObservedUser) SetPassword(password string) {
+func (u *ObservedUser) SetPassword(password string) {
 	u.User.Password = password
 }
 
@@ -68,7 +68,7 @@ func (u ObservedUser) GetEnabled() bool {
 	return u.User.Enabled
 }
 
-func (u ObservedUser) SetEnabled(enabled bool) {
+func (u *ObservedUser) SetEnabled(enabled bool) {
 	u.User.Enabled = enabled
 }
 
@@ -80,7 +80,7 @@ func (u ObservedUser) GetPrivacyKey() string {
 	return u.PrivacyKey
 }
 
-func (u ObservedUser) SetPrivacyKey(privacyKey string) {
+func (u *ObservedUser) SetPrivacyKey(privacyKey string) {
 	u.PrivacyKey = privacyKey
 }
 
@@ -88,7 +88,7 @@ func (u ObservedUser) GetCompanyName() string {
 	return u.CompanyName
 }
 
-func (u ObservedUser) SetCompanyName(companyName string) {
+func (u *ObservedUser) SetCompanyName(companyName string) {
 	u.CompanyName = companyName
 }
 
@@ -96,7 +96,7 @@ func (u ObservedUser) GetLicensePlate() string {
 	return u.SchoolBus.LicensePlate
 }
 
-func (u ObservedUser) SetLicensePlate(licensePlate string) {
+func (u *ObservedUser) SetLicensePlate(licensePlate string) {
 	u.SchoolBus.LicensePlate = licensePlate
 }
 
@@ -104,11 +104,11 @@ func (u ObservedUser) GetSchoolBusLicense() string {
 	return u.SchoolBus.SchoolBusLicense
 }
 
-func (u ObservedUser) SetSchoolBusLicense(schoolBusLicense string) {
+func (u *ObservedUser) SetSchoolBusLicense(schoolBusLicense string) {
 	u.SchoolBus.SchoolBusLicense = schoolBusLicense
 }
 
-func (u ObservedUser) SetObserverUsers(observerUsers []ObserverUser) {
+func (u *ObservedUser) SetObserverUsers(observerUsers []ObserverUser) {
 	u.ObserverUsers = observerUsers
 }
 
@@ -1,8 +1,8 @@
 package model
 
 type ObservedUserObserverUser struct {
-	ObservedUserID uint   `db:"observed_user_id" gorm:"foreignKey:user"`
-	ObserverUserID uint   `db:"observer_user_id" gorm:"foreignKey:user"`
+	ObservedUserID uint   `db:"observed_user_id" json:"observed_user_id" gorm:"foreignKey:user"`
+	ObserverUserID uint   `db:"observer_user_id" json:"observer_user_id" gorm:"foreignKey:user"`
 	CreatedAt      string `db:"created_at" json:"created_at"`
 	UpdatedAt      string `db:"updated_at" json:"updated_at"`
 }
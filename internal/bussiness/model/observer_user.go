@@ -7,7 +7,7 @@ type ObserverUser struct {
 }
 
 func NewObserverUser(observer ObserverUser) IUser {
-	return observer
+	return &observer
 }
 
 func (observer ObserverUser) GetUserID() uint {
@@ -18,11 +18,11 @@ func (observer ObserverUser) GetName() string {
 	return observer.User.Name
 }
 
-func (observer ObserverUser) SetName(name string) {
+func (observer *ObserverUser) SetName(name string) {
 	observer.User.Name = name
 }
 
-func (observer ObserverUser) SetLastName(lastName string) {
+func (observer *ObserverUser) SetLastName(lastName string) {
 	observer.User.LastName = lastName
 }
 
@@ -30,7 +30,7 @@ func (observer ObserverUser) GetLastName() string {
 	return observer.User.LastName
 }
 
-func (observer ObserverUser) SetIDNumber(IDNumber string) {
+func (observer *ObserverUser) SetIDNumber(IDNumber string) {
 	observer.User.IDNumber = IDNumber
 }
 
@@ -38,7 +38,7 @@ func (observer ObserverUser) GetIDNumber() string {
 	return observer.User.IDNumber
 }
 
-func (observer ObserverUser) SetEmail(email string) {
+func (observer *ObserverUser) SetEmail(email string) {
 	observer.User.Email = email
 }
 
@@ -46,7 +46,7 @@ func (observer ObserverUser) GetEmail() string {
 	return observer.User.Email
 }
 
-func (observer ObserverUser) SetUsername(username string) {
+func (observer *ObserverUser) SetUsername(username string) {
 	observer.User.Username = username
 }
 
@@ -54,7 +54,7 @@ func (observer ObserverUser) GetUsername() string {
 	return observer.User.Username
 }
 
-func (observer ObserverUser) SetPassword(password string) {
+func (observer *ObserverUser) SetPassword(password string) {
 	observer.User.Password = password
 }
 
@@ -66,7 +66,7 @@ func (observer ObserverUser) GetEnabled() bool {
 	return observer.User.Enabled
 }
 
-func (observer ObserverUser) SetEnabled(enabled bool) {
+func (observer *ObserverUser) SetEnabled(enabled bool) {
 	observer.User.Enabled = enabled
 }
 
@@ -74,7 +74,7 @@ func (observer ObserverUser) GetType() string {
 	return observer.User.Type
 }
 
-func (observer ObserverUser) SetChilds(childs []Children) {
+func (observer *ObserverUser) SetChilds(childs []Children) {
 	observer.Children = childs
 }
 
@@ -82,7 +82,7 @@ func (observer ObserverUser) GetChilds() []Children {
 	return observer.Children
 }
 
-func (observer ObserverUser) SetUsersObservee(usersObservee []ObservedUser) {
+func (observer *ObserverUser) SetUsersObservee(usersObservee []ObservedUser) {
 	observer.ObservedUsers = usersObservee
 }
 
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"encoding/json"
+)
+
+const (
+	PickupMethodPIN      = "pin"
+	PickupMethodIDNumber = "id_number"
+
+	// MaxAuthorizedPickups caps the people authorized to receive a child.
+	MaxAuthorizedPickups = 10
+	// MaxPickupPhotoSize caps the size in bytes of the photo of an authorized pickup.
+	MaxPickupPhotoSize = 256 << 10
+	// PickupPINLength is the number of digits of a pickup PIN.
+	PickupPINLength = 6
+)
+
+// PickupRelationships lists the relationships an authorized pickup can have with the child.
+var PickupRelationships = []string{"parent", "grandparent", "sibling", "relative", "nanny", "neighbor", "other"}
+
+// AuthorizedPickup is a person the guardians of a child allow to receive it at the afternoon drop-off.
+// Photo is a JPEG or PNG image, base64 encoded in JSON, so the driver can recognize the person.
+type AuthorizedPickup struct {
+	ID           uint   `json:"id"`
+	ChildID      uint   `json:"child_id"`
+	Name         string `json:"name"`
+	LastName     string `json:"last_name"`
+	IDNumber     string `json:"id_number"`
+	Relationship string `json:"relationship"`
+	Photo        []byte `json:"photo,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+}
+
+// MarshalJSON masks the national ID number, so it is never exposed in the API responses.
+func (p AuthorizedPickup) MarshalJSON() ([]byte, error) {
+	type authorizedPickup AuthorizedPickup
+
+	masked := authorizedPickup(p)
+	masked.IDNumber = MaskIDNumber(p.IDNumber)
+
+	return json.Marshal(masked)
+}
+
+// PickupPIN is a one-time code a guardian generates so whoever shows it can receive the child.
+// Only its hash is stored, and PIN is only set when it is generated. ExpiresAt is unix seconds.
+type PickupPIN struct {
+	ChildID   uint   `json:"child_id"`
+	PIN       string `json:"pin,omitempty"`
+	Hash      string `json:"-"`
+	ExpiresAt int64  `json:"expires_at"`
+	CreatedBy uint   `json:"created_by"`
+}
+
+// PickupVerification is what the driver checks before handing the child over: either the PIN shown by the
+// person, or the ID number of an authorized pickup. ReceivedBy names whoever showed the PIN.
+type PickupVerification struct {
+	PIN        string `json:"pin,omitempty"`
+	IDNumber   string `json:"id_number,omitempty"`
+	ReceivedBy string `json:"received_by,omitempty"`
+}
+
+// PickupHandover records who actually received a child from the driver. AuthorizedPickupID is zero when
+// the child was received with a PIN. OccurredAt is unix seconds.
+type PickupHandover struct {
+	ID                 uint   `json:"id"`
+	ChildID            uint   `json:"child_id"`
+	DriverUserID       uint   `json:"driver_user_id"`
+	Method             string `json:"method"`
+	AuthorizedPickupID uint   `json:"authorized_pickup_id,omitempty"`
+	ReceivedBy         string `json:"received_by"`
+	OccurredAt         int64  `json:"occurred_at"`
+}
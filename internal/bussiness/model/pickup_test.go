@@ -0,0 +1,14 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizedPickupMasksIDNumber(t *testing.T) {
+	content, err := json.Marshal(AuthorizedPickup{ID: 3, IDNumber: "30111222"})
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"id_number":"****1222"`)
+}
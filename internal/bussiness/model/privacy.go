@@ -0,0 +1,56 @@
+package model
+
+// EncryptedValue is a sensitive field as it is stored, with the blind index used to look it up when it has one.
+// ID is the ID of the row holding it, such as the user ID for the ID numbers of the users.
+type EncryptedValue struct {
+	ID         uint
+	Ciphertext string
+	BlindIndex string
+}
+
+// ReencryptionResult summarizes a run of the re-encryption job over every encrypted field.
+type ReencryptionResult struct {
+	Scanned     int `json:"scanned"`
+	Reencrypted int `json:"reencrypted"`
+}
+
+const (
+	AccountDeletionPending   = "pending"
+	AccountDeletionCancelled = "cancelled"
+	AccountDeletionCompleted = "completed"
+)
+
+// AccountData is everything held about a user, as it is handed to them when they ask for a copy of their data.
+// Guardianships are the guardians of their children and the children they are a guardian of. Pickups are the people
+// authorized to receive their children, and Handovers the record of who received them, or the ones the user recorded
+// as a driver. Messages are the notifications not delivered yet. Location history stays empty, since the positions
+// seen by the user are not stored.
+type AccountData struct {
+	User            User                       `json:"user"`
+	Children        []Children                 `json:"children"`
+	Addresses       []Address                  `json:"addresses"`
+	Links           []ObservedUserObserverUser `json:"links"`
+	Guardianships   []Guardian                 `json:"guardianships"`
+	Pickups         []AuthorizedPickup         `json:"pickups"`
+	Handovers       []PickupHandover           `json:"handovers"`
+	Messages        []Notification             `json:"messages"`
+	LocationHistory []LocationSeen             `json:"location_history"`
+}
+
+// LocationSeen is a position of a school bus shown to a user.
+type LocationSeen struct {
+	SchoolBusID uint   `json:"school_bus_id"`
+	Latitude    string `json:"latitude"`
+	Longitude   string `json:"longitude"`
+	SeenAt      int64  `json:"seen_at"`
+}
+
+// AccountDeletion is the request of a user to erase their account. The account is erased once ScheduledAt
+// is reached, unless the request is cancelled before. Times are unix seconds.
+type AccountDeletion struct {
+	UserID      uint   `json:"user_id"`
+	Status      string `json:"status"`
+	RequestedAt int64  `json:"requested_at"`
+	ScheduledAt int64  `json:"scheduled_at"`
+	CompletedAt int64  `json:"completed_at,omitempty"`
+}
@@ -0,0 +1,9 @@
+package model
+
+// UserProvision is an account created by an operator. Drivers also need the company they work for and the
+// school bus they drive, which is registered when its license plate is not known yet.
+type UserProvision struct {
+	User        User      `json:"user"`
+	CompanyName string    `json:"company_name,omitempty"`
+	SchoolBus   SchoolBus `json:"school_bus,omitempty"`
+}
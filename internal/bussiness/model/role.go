@@ -0,0 +1,88 @@
+package model
+
+const (
+	RolePlatformAdmin = "platform_admin"
+	RoleCompanyAdmin  = "company_admin"
+	RoleDriver        = "driver"
+	RoleGuardian      = "guardian"
+
+	PermissionReadUsers           = "users:read"
+	PermissionManageUsers         = "users:manage"
+	PermissionManageRoles         = "roles:manage"
+	PermissionManageNotifications = "notifications:manage"
+	PermissionManagePrivacy       = "privacy:manage"
+	PermissionReadAudit           = "audit:read"
+	PermissionManageSchools       = "schools:manage"
+	PermissionManageCalendars     = "calendars:manage"
+)
+
+// Roles lists every role that can be assigned to a user.
+var Roles = []string{RolePlatformAdmin, RoleCompanyAdmin, RoleDriver, RoleGuardian}
+
+// rolePermissions is the policy that grants permissions to each role.
+var rolePermissions = map[string][]string{
+	RolePlatformAdmin: {PermissionReadUsers, PermissionManageUsers, PermissionManageRoles, PermissionManageNotifications, PermissionManagePrivacy, PermissionReadAudit, PermissionManageSchools, PermissionManageCalendars},
+	RoleCompanyAdmin:  {PermissionReadUsers, PermissionManageUsers, PermissionManageCalendars},
+	RoleDriver:        {},
+	RoleGuardian:      {PermissionManageNotifications},
+}
+
+// RoleAssignment grants a role to a user. Company admins are restricted to the drivers of CompanyName.
+type RoleAssignment struct {
+	Role        string `json:"role"`
+	CompanyName string `json:"company_name,omitempty"`
+}
+
+// Principal is the authenticated user performing a request, with every role it holds.
+type Principal struct {
+	UserID   uint             `json:"user_id"`
+	Username string           `json:"username"`
+	Type     string           `json:"type"`
+	Roles    []RoleAssignment `json:"roles"`
+}
+
+// HasRole reports whether the principal holds the role.
+func (p Principal) HasRole(role string) bool {
+	for _, assignment := range p.Roles {
+		if assignment.Role == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Can reports whether any role of the principal grants the permission.
+func (p Principal) Can(permission string) bool {
+	for _, assignment := range p.Roles {
+		for _, granted := range rolePermissions[assignment.Role] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ManagedCompanies returns the companies the principal administers as company admin.
+func (p Principal) ManagedCompanies() []string {
+	var companies []string
+
+	for _, assignment := range p.Roles {
+		if assignment.Role == RoleCompanyAdmin && assignment.CompanyName != "" {
+			companies = append(companies, assignment.CompanyName)
+		}
+	}
+
+	return companies
+}
+
+// DefaultRole returns the role implied by the user type: drivers are observed users and guardians are observers.
+func DefaultRole(userType string) string {
+	if userType == "observed" {
+		return RoleDriver
+	}
+
+	return RoleGuardian
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	DefaultSchoolTimezone       = DefaultNotificationTimezone
+	DefaultSchoolGeofenceRadius = 150
+	MinSchoolGeofenceRadius     = 25
+	MaxSchoolGeofenceRadius     = 2000
+
+	earthRadiusMeters = 6371000
+)
+
+// School is where the children are taken to. The geofence is a circle of GeofenceRadius meters around the
+// coordinates, used to detect the arrival of the school bus. Schools migrated from the free text school names
+// have no coordinates until an admin sets them.
+type School struct {
+	ID             uint            `json:"id"`
+	Name           string          `json:"name"`
+	Street         string          `json:"street"`
+	Number         string          `json:"number"`
+	City           string          `json:"city"`
+	State          string          `json:"state"`
+	Country        string          `json:"country"`
+	Latitude       *float64        `json:"latitude"`
+	Longitude      *float64        `json:"longitude"`
+	GeofenceRadius int             `json:"geofence_radius"`
+	Timezone       string          `json:"timezone"`
+	BellSchedules  []BellSchedule  `json:"bell_schedules"`
+	Calendar       []CalendarEntry `json:"calendar"`
+	CreatedAt      string          `json:"created_at,omitempty"`
+	UpdatedAt      string          `json:"updated_at,omitempty"`
+}
+
+// BellSchedule is the school day of a weekday, in the school timezone. Weekdays without a schedule have no classes.
+type BellSchedule struct {
+	Weekday time.Weekday `json:"weekday"`
+	Start   string       `json:"start"`
+	End     string       `json:"end"`
+}
+
+// HasLocation reports whether the school coordinates are known.
+func (s School) HasLocation() bool {
+	return s.Latitude != nil && s.Longitude != nil
+}
+
+// Contains reports whether the point is inside the school geofence. It is always false without coordinates.
+func (s School) Contains(latitude, longitude float64) bool {
+	if !s.HasLocation() {
+		return false
+	}
+
+	return Distance(*s.Latitude, *s.Longitude, latitude, longitude) <= float64(s.GeofenceRadius)
+}
+
+// Location returns the school timezone, or UTC when it cannot be loaded.
+func (s School) Location() *time.Location {
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return location
+}
+
+// ScheduleOn returns the schedule of the school day of t, after the school calendar. It is false on holidays,
+// in-service days and weekdays without classes.
+func (s School) ScheduleOn(t time.Time) (BellSchedule, bool) {
+	day := s.ServiceDay(t, nil)
+
+	return BellSchedule{Weekday: t.In(s.Location()).Weekday(), Start: day.Start, End: day.End}, day.Service
+}
+
+// Distance returns the great-circle distance in meters between two coordinates.
+func Distance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	deltaLatitude := toRadians(latitude2 - latitude1)
+	deltaLongitude := toRadians(longitude2 - longitude1)
+
+	a := math.Sin(deltaLatitude/2)*math.Sin(deltaLatitude/2) +
+		math.Cos(toRadians(latitude1))*math.Cos(toRadians(latitude2))*math.Sin(deltaLongitude/2)*math.Sin(deltaLongitude/2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
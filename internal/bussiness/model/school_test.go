@@ -0,0 +1,29 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchoolCalendar(t *testing.T) {
+	latitude, longitude := -31.648105, -60.707722
+	school := School{
+		Latitude: &latitude, Longitude: &longitude, GeofenceRadius: 150, Timezone: DefaultSchoolTimezone,
+		BellSchedules: []BellSchedule{{Weekday: time.Monday, Start: "08:00", End: "12:00"}},
+		Calendar:      []CalendarEntry{{Date: "2023-07-10", Kind: CalendarEntryHoliday}},
+	}
+
+	// 2023-07-04 01:00 UTC is still Monday 2023-07-03 in Buenos Aires
+	schedule, ok := school.ScheduleOn(time.Date(2023, 7, 4, 1, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, "08:00", schedule.Start)
+
+	_, ok = school.ScheduleOn(time.Date(2023, 7, 10, 12, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+
+	assert.True(t, school.Contains(-31.6485, -60.7080))
+	assert.False(t, school.Contains(-31.6420, -60.7045))
+	assert.False(t, School{GeofenceRadius: 150}.Contains(latitude, longitude))
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	SearchKindUser   = "user"
+	SearchKindChild  = "child"
+	SearchKindSchool = "school"
+
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+// accentFolds maps the accented letters used in Spanish and Portuguese names to their plain letter.
+var accentFolds = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// SearchQuery is a full-text search over users, children and schools. Every term must match, either a whole word
+// or the beginning of one. Companies restricts the users and children to the families of those companies' drivers.
+type SearchQuery struct {
+	Text      string
+	Companies []string
+	Limit     int
+}
+
+// Terms returns the words of the search text, lower cased and without accents.
+func (q SearchQuery) Terms() []string {
+	return SearchTerms(q.Text)
+}
+
+// Normalize applies the default limit and caps it.
+func (q *SearchQuery) Normalize() {
+	if q.Limit <= 0 {
+		q.Limit = defaultSearchLimit
+	}
+
+	if q.Limit > maxSearchLimit {
+		q.Limit = maxSearchLimit
+	}
+}
+
+// SearchResult is a user, a child or a school matching a search. FamilyID is the observer user the result belongs
+// to, so a child leads to its guardian, and it is zero for schools. Results with a higher Score match better.
+type SearchResult struct {
+	Kind     string  `json:"kind"`
+	ID       uint    `json:"id"`
+	FamilyID uint    `json:"family_id,omitempty"`
+	Title    string  `json:"title"`
+	Detail   string  `json:"detail,omitempty"`
+	Score    float64 `json:"score"`
+}
+
+// SearchTerms splits a text into lower cased words without accents. Anything but letters and digits separates words.
+func SearchTerms(text string) []string {
+	folded := accentFolds.Replace(strings.ToLower(text))
+
+	return strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,19 @@
+package model
+
+// Session is returned after a successful login. AccessToken must be sent as a Bearer token
+// in the Authorization header of the following requests.
+// When the user must provide a second factor, TwoFactor tells whether it must be verified or enrolled first,
+// and ChallengeToken must be sent to complete the login instead of an access token.
+type Session struct {
+	AccessToken    string   `json:"access_token,omitempty"`
+	ExpiresAt      int64    `json:"expires_at"`
+	User           IUser    `json:"user,omitempty"`
+	TwoFactor      string   `json:"two_factor,omitempty"`
+	ChallengeToken string   `json:"challenge_token,omitempty"`
+	RecoveryCodes  []string `json:"recovery_codes,omitempty"`
+}
+
+// UserStatus is the body used by admins to enable or disable a user.
+type UserStatus struct {
+	Enabled bool `json:"enabled"`
+}
@@ -0,0 +1,21 @@
+package model
+
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+	TokenPurposeAccess        = "access"
+	TokenPurposeTwoFactor     = "two_factor"
+	TokenPurposeEnrollment    = "two_factor_enrollment"
+
+	TokenPurposeGuardianInvitation = "guardian_invitation"
+)
+
+// Token holds the claims of a signed, expiring and single-use token sent to a user.
+type Token struct {
+	ID        string `json:"jti"`
+	UserID    uint   `json:"sub"`
+	Purpose   string `json:"pur"`
+	Email     string `json:"eml,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+	Version   uint   `json:"ver,omitempty"`
+}
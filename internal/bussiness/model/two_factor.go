@@ -0,0 +1,48 @@
+package model
+
+const (
+	TwoFactorRequired           = "required"
+	TwoFactorEnrollmentRequired = "enrollment_required"
+)
+
+// TwoFactor is the TOTP second factor of a user. It is pending until the user confirms it with a first code.
+// LastUsedStep is the TOTP time step of the last accepted code, so a code cannot be used twice.
+type TwoFactor struct {
+	UserID       uint
+	Secret       string
+	Enabled      bool
+	LastUsedStep int64
+}
+
+// TwoFactorEnrollment is returned when a user starts enrolling an authenticator app. QRCode is a PNG data URI
+// encoding ProvisioningURI.
+type TwoFactorEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	QRCode          string `json:"qr_code"`
+}
+
+// TwoFactorCode is the body used to confirm, disable or prove a second factor. Either Code or RecoveryCode must be set.
+type TwoFactorCode struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// TwoFactorLogin completes a login that requires a second factor, using the challenge token returned by the login.
+type TwoFactorLogin struct {
+	ChallengeToken string `json:"challenge_token"`
+	TwoFactorCode
+	ClientIP string `json:"-"`
+}
+
+// RecoveryCodes are the single-use codes that replace the authenticator app when it is lost.
+// They are only shown once, when they are generated.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// TwoFactorPolicy defines whether the drivers of a company must use a second factor.
+type TwoFactorPolicy struct {
+	CompanyName string `json:"company_name"`
+	Required    bool   `json:"required"`
+}
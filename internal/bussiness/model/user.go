@@ -1,5 +1,7 @@
 package model
 
+import "encoding/json"
+
 type IUser interface {
 	SetName(name string)
 	SetLastName(lastName string)
@@ -21,20 +23,48 @@ type IUser interface {
 }
 
 type User struct {
-	ID        uint   `db:"id" json:"id" gorm:"primaryKey,autoIncrement"`
-	Name      string `db:"name" json:"name"`
-	LastName  string `db:"last_name" json:"last_name"`
-	IDNumber  string `db:"id_number" json:"id_number"`
-	Username  string `db:"username" json:"username,omitempty" gorm:"unique"`
-	Password  string `db:"password" json:"password,omitempty"`
-	Email     string `db:"email" json:"email,omitempty" gorm:"unique"`
-	Enabled   bool   `db:"enabled" json:"enabled,omitempty"`
-	Type      string `db:"type" json:"type,omitempty"`
-	CreatedAt string `db:"created_at" json:"created_at,omitempty"`
-	UpdatedAt string `db:"updated_at" json:"updated_at,omitempty"`
-}
-
-func (u User) SetName(name string) {
+	ID            uint   `db:"id" json:"id" gorm:"primaryKey,autoIncrement"`
+	Name          string `db:"name" json:"name"`
+	LastName      string `db:"last_name" json:"last_name"`
+	IDNumber      string `db:"id_number" json:"id_number"`
+	Username      string `db:"username" json:"username,omitempty" gorm:"unique"`
+	Password      string `db:"password" json:"password,omitempty"`
+	Email         string `db:"email" json:"email,omitempty" gorm:"unique"`
+	Enabled       bool   `db:"enabled" json:"enabled,omitempty"`
+	Type          string `db:"type" json:"type,omitempty"`
+	CreatedAt     string `db:"created_at" json:"created_at,omitempty"`
+	UpdatedAt     string `db:"updated_at" json:"updated_at,omitempty"`
+	EmailVerified bool   `db:"email_verified" json:"email_verified,omitempty" gorm:"->"`
+	IDNumberIndex string `db:"id_number_index" json:"-"`
+	TokenVersion  uint   `db:"token_version" json:"-" gorm:"->"`
+	PendingEmail  string `db:"pending_email" json:"pending_email,omitempty" gorm:"->"`
+}
+
+// MarshalJSON masks the national ID number and leaves the password out, so they are never exposed in the API responses.
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User
+
+	masked := user(u)
+	masked.IDNumber = MaskIDNumber(u.IDNumber)
+	masked.Password = ""
+
+	return json.Marshal(masked)
+}
+
+// MaskIDNumber hides every character of the ID number but the last four, e.g. ****5678.
+func MaskIDNumber(idNumber string) string {
+	if idNumber == "" {
+		return ""
+	}
+
+	if len(idNumber) <= 4 {
+		return "****"
+	}
+
+	return "****" + idNumber[len(idNumber)-4:]
+}
+
+func (u *User) SetName(name string) {
 	u.Name = name
 }
 
@@ -42,7 +72,7 @@ func (u User) GetName() string {
 	return u.Name
 }
 
-func (u User) SetLastName(lastName string) {
+func (u *User) SetLastName(lastName string) {
 	u.LastName = lastName
 }
 
@@ -50,7 +80,7 @@ func (u User) GetLastName() string {
 	return u.LastName
 }
 
-func (u User) SetIDNumber(idNumber string) {
+func (u *User) SetIDNumber(idNumber string) {
 	u.IDNumber = idNumber
 }
 
@@ -58,7 +88,7 @@ func (u User) GetIDNumber() string {
 	return u.IDNumber
 }
 
-func (u User) SetEmail(email string) {
+func (u *User) SetEmail(email string) {
 	u.Email = email
 }
 
@@ -66,7 +96,7 @@ func (u User) GetEmail() string {
 	return u.Email
 }
 
-func (u User) SetUsername(username string) {
+func (u *User) SetUsername(username string) {
 	u.Username = username
 }
 
@@ -74,10 +104,22 @@ func (u User) GetUsername() string {
 	return u.Username
 }
 
-func (u User) SetPassword(password string) {
+func (u *User) SetPassword(password string) {
 	u.Password = password
 }
 
 func (u User) GetPassword() string {
 	return u.Password
 }
+
+func (u *User) SetEnabled(enabled bool) {
+	u.Enabled = enabled
+}
+
+func (u User) GetEnabled() bool {
+	return u.Enabled
+}
+
+func (u User) GetType() string {
+	return u.Type
+}
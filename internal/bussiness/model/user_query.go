@@ -0,0 +1,103 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	UserSortID        = "id"
+	UserSortName      = "name"
+	UserSortLastName  = "last_name"
+	UserSortCreatedAt = "created_at"
+
+	defaultUserQueryLimit = 20
+	maxUserQueryLimit     = 100
+)
+
+// UserSorts lists every field the users can be sorted by.
+var UserSorts = []string{UserSortID, UserSortName, UserSortLastName, UserSortCreatedAt}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// UserQuery filters and sorts the users. The users are returned by pages, each one starting after the cursor
+// of the previous one. Companies restricts the users to the drivers of those companies, while CompanyName is
+// the filter asked by the client.
+type UserQuery struct {
+	Type        string
+	Enabled     *bool
+	CompanyName string
+	Companies   []string
+	Sort        string
+	Descending  bool
+	Cursor      *UserCursor
+	Limit       int
+}
+
+// Normalize applies the default sort and limit, and caps the limit.
+func (q *UserQuery) Normalize() {
+	if q.Sort == "" {
+		q.Sort = UserSortID
+	}
+
+	if q.Limit <= 0 {
+		q.Limit = defaultUserQueryLimit
+	}
+
+	if q.Limit > maxUserQueryLimit {
+		q.Limit = maxUserQueryLimit
+	}
+}
+
+// UserCursor is the position of the last user of a page: the value of the sort field and the ID,
+// which breaks the ties.
+type UserCursor struct {
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// NewUserCursor returns the cursor positioned at the user for the given sort field.
+func NewUserCursor(user User, sort string) UserCursor {
+	cursor := UserCursor{ID: user.ID}
+
+	switch sort {
+	case UserSortName:
+		cursor.Value = user.Name
+	case UserSortLastName:
+		cursor.Value = user.LastName
+	case UserSortCreatedAt:
+		cursor.Value = user.CreatedAt
+	}
+
+	return cursor
+}
+
+// Encode returns the opaque representation of the cursor handed to the clients.
+func (c UserCursor) Encode() string {
+	b, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeUserCursor parses a cursor returned by Encode.
+func DecodeUserCursor(value string) (*UserCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var cursor UserCursor
+	if err = json.Unmarshal(b, &cursor); err != nil || cursor.ID == 0 {
+		return nil, errInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// UserPage is a page of users. NextCursor is empty on the last page. Total counts every user matching the filters.
+type UserPage struct {
+	Users      []User `json:"users"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"-"`
+}
@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -14,6 +17,14 @@ var (
 	ErrConflict            = errors.New("conflict")
 	ErrNotFound            = errors.New("not found")
 	ErrInternalServerError = errors.New("impossible to solve")
+	ErrInvalidToken        = errors.New("invalid or expired token")
+	ErrEmailNotVerified    = errors.New("email not verified")
+	ErrInvalidCredentials  = errors.New("invalid username or password")
+	ErrAccountDisabled     = errors.New("account disabled")
+	ErrTooManyAttempts     = errors.New("too many attempts")
+	ErrUnauthorized        = errors.New("authentication required")
+	ErrForbidden           = errors.New("forbidden")
+	ErrInvalidTwoFactor    = errors.New("invalid two-factor code")
 )
 
 // Error is our custom commercial agreements implementation.
@@ -60,6 +71,34 @@ func NewErrorf(status int, format string, args ...interface{}) error {
 	}
 }
 
+// RetryError is returned when the client must wait before retrying the request.
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+// NewRetryError creates a RetryError wrapping err.
+func NewRetryError(err error, retryAfter time.Duration) error {
+	return &RetryError{Err: err, RetryAfter: retryAfter}
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s, retry in %d seconds", e.Err.Error(), e.retryAfterSeconds())
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Headers returns the Retry-After header, so it implements Headerer.
+func (e *RetryError) Headers() http.Header {
+	return http.Header{"Retry-After": []string{strconv.Itoa(e.retryAfterSeconds())}}
+}
+
+func (e *RetryError) retryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
 // Headerer is checked by DefaultErrorEncoder. If an error value implements
 // Headerer, the provided headers will be applied to the response writer, after
 // the Content-Type is set.
@@ -72,3 +111,9 @@ var DefaultNotFoundHandler = func(w http.ResponseWriter, r *http.Request) {
 	err := NewErrorf(http.StatusNotFound, "resource %s not found", r.URL.Path)
 	_ = EncodeJSON(w, err, http.StatusNotFound)
 }
+
+// DefaultMethodNotAllowedHandler handler for routing paths that do not support the method of the request.
+var DefaultMethodNotAllowedHandler = func(w http.ResponseWriter, r *http.Request) {
+	err := NewErrorf(http.StatusMethodNotAllowed, "method %s not allowed on %s", r.Method, r.URL.Path)
+	_ = EncodeJSON(w, err, http.StatusMethodNotAllowed)
+}
@@ -0,0 +1,431 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	AccountUseCaseType = "AccountUseCase"
+
+	verifyEmailTTL    = 24 * time.Hour
+	resetPasswordTTL  = time.Hour
+	minPasswordLength = 8
+	maxPasswordLength = 45
+	maxProfileLength  = 45
+)
+
+type (
+	AccountUseCase interface {
+		RequestEmailVerification(string, gateway.ServiceLocator) error
+		VerifyEmail(string, gateway.ServiceLocator) error
+		RequestPasswordReset(string, gateway.ServiceLocator) error
+		ResetPassword(model.TokenConfirmation, gateway.ServiceLocator) error
+		UpdateProfile(model.ProfileUpdate, gateway.ServiceLocator) (*model.User, error)
+		ChangePassword(model.PasswordChange, gateway.ServiceLocator) (*model.Session, error)
+	}
+
+	accountUseCase struct {
+		baseURL string
+		now     func() time.Time
+	}
+)
+
+// NewAccountUseCase creates the use case for the account recovery flows. The links sent by email point to baseURL.
+func NewAccountUseCase(baseURL string) AccountUseCase {
+	return &accountUseCase{
+		baseURL: baseURL,
+		now:     time.Now,
+	}
+}
+
+// RequestEmailVerification sends a verification link to the email if it belongs to an unverified user.
+// The result does not reveal whether the email is registered.
+func (a accountUseCase) RequestEmailVerification(email string, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "AccountUseCase.RequestEmailVerification")
+	defer func() { span.End(err) }()
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.FindByEmail(email)
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	if user == nil || user.EmailVerified {
+		return nil
+	}
+
+	return a.sendToken(*user, model.TokenPurposeVerifyEmail, verifyEmailTTL, model.EmailTemplateVerifyEmail, "verify-email", locator)
+}
+
+// VerifyEmail consumes a verification token and marks the email of its user as verified. A token sent to the
+// pending email of the user replaces the current email with it.
+func (a accountUseCase) VerifyEmail(value string, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "AccountUseCase.VerifyEmail")
+	defer func() { span.End(err) }()
+
+	token, err := a.consume(value, model.TokenPurposeVerifyEmail, locator)
+	if err != nil {
+		return err
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(token.UserID)
+	if err != nil {
+		return web.ErrInvalidToken
+	}
+
+	switch token.Email {
+	case user.PendingEmail:
+		if err = repository.ConfirmPendingEmail(user.ID, token.Email); err != nil {
+			if errors.Is(err, web.ErrConflict) {
+				return err
+			}
+			return web.ErrInternalServerError
+		}
+	case user.Email:
+		if err = repository.SetEmailVerified(user.ID, true); err != nil {
+			return web.ErrInternalServerError
+		}
+	default:
+		// The email changed after the token was issued.
+		return web.ErrInvalidToken
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:        model.AuditActionEmailVerified,
+		ActorID:       user.ID,
+		ActorUsername: user.Username,
+		TargetType:    model.AuditTargetUser,
+		TargetID:      userTarget(user.ID),
+	})
+
+	return nil
+}
+
+// RequestPasswordReset sends a password reset link to the email if it belongs to a user.
+// The result does not reveal whether the email is registered.
+func (a accountUseCase) RequestPasswordReset(email string, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "AccountUseCase.RequestPasswordReset")
+	defer func() { span.End(err) }()
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.FindByEmail(email)
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	if user == nil {
+		return nil
+	}
+
+	return a.sendToken(*user, model.TokenPurposeResetPassword, resetPasswordTTL, model.EmailTemplateResetPassword, "reset-password", locator)
+}
+
+// ResetPassword consumes a password reset token and replaces the password of its user.
+// Any other pending reset token of the user is revoked, and so are its access tokens.
+func (a accountUseCase) ResetPassword(confirmation model.TokenConfirmation, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "AccountUseCase.ResetPassword")
+	defer func() { span.End(err) }()
+
+	if len(confirmation.Password) < minPasswordLength || len(confirmation.Password) > maxPasswordLength {
+		return fmt.Errorf("%w: password must have between %d and %d characters", web.ErrBadRequest, minPasswordLength, maxPasswordLength)
+	}
+
+	token, err := a.consume(confirmation.Token, model.TokenPurposeResetPassword, locator)
+	if err != nil {
+		return err
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	if err = repository.UpdatePassword(token.UserID, confirmation.Password); err != nil {
+		return web.ErrInternalServerError
+	}
+
+	tokenRepository := locator.GetInstance(gateway.TokenRepositoryType).(gateway.TokenRepository)
+	if err = tokenRepository.Revoke(token.UserID, model.TokenPurposeResetPassword); err != nil {
+		logger(locator).WithError(err).Error("pending reset password tokens could not be revoked")
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionPasswordReset,
+		ActorID:    token.UserID,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(token.UserID),
+	})
+
+	return nil
+}
+
+// UpdateProfile applies a partial update to the profile of the authenticated user. A new email is kept pending
+// until it is verified, so a verification link is sent to it and the current email is told about the change.
+func (a accountUseCase) UpdateProfile(update model.ProfileUpdate, locator gateway.ServiceLocator) (_ *model.User, err error) {
+	span := startSpan(locator, "AccountUseCase.UpdateProfile")
+	defer func() { span.End(err) }()
+
+	principal, err := GetPrincipal(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateProfileUpdate(&update); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(principal.UserID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	emailChanged := update.Email != nil && !strings.EqualFold(*update.Email, user.Email)
+	if emailChanged {
+		other, err := repository.FindByEmail(*update.Email)
+		if err != nil {
+			return nil, web.ErrInternalServerError
+		}
+
+		if other != nil && other.ID != user.ID {
+			return nil, fmt.Errorf("%w: email already in use", web.ErrConflict)
+		}
+	}
+
+	var changed []string
+
+	if update.Name != nil && *update.Name != user.Name {
+		user.SetName(*update.Name)
+		changed = append(changed, "name")
+	}
+
+	if update.LastName != nil && *update.LastName != user.LastName {
+		user.SetLastName(*update.LastName)
+		changed = append(changed, "last_name")
+	}
+
+	if len(changed) > 0 {
+		if err = repository.UpdateProfile(*user); err != nil {
+			return nil, web.ErrInternalServerError
+		}
+	}
+
+	if emailChanged {
+		if err = repository.SetPendingEmail(user.ID, *update.Email); err != nil {
+			return nil, web.ErrInternalServerError
+		}
+		changed = append(changed, "pending_email")
+	}
+
+	if len(changed) > 0 {
+		recordAudit(locator, model.AuditEntry{
+			Action:     model.AuditActionProfileUpdated,
+			TargetType: model.AuditTargetUser,
+			TargetID:   userTarget(user.ID),
+			Details:    map[string]string{"fields": strings.Join(changed, ",")},
+		})
+
+		// Read it again to obtain the updated_at set by the database.
+		if user, err = repository.Get(principal.UserID); err != nil {
+			return nil, repositoryError(err)
+		}
+	}
+
+	if emailChanged {
+		a.notifyEmailChange(*user, locator)
+	}
+
+	user.SetPassword("")
+
+	return user, nil
+}
+
+// ChangePassword replaces the password of the authenticated user. The current password is checked with the
+// same throttling as the login, and any pending password reset link is revoked. Every access token of the user
+// is revoked too, so a new one is returned for the session that changed the password.
+func (a accountUseCase) ChangePassword(change model.PasswordChange, locator gateway.ServiceLocator) (_ *model.Session, err error) {
+	span := startSpan(locator, "AccountUseCase.ChangePassword")
+	defer func() { span.End(err) }()
+
+	principal, err := GetPrincipal(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(change.NewPassword) < minPasswordLength || len(change.NewPassword) > maxPasswordLength {
+		return nil, fmt.Errorf("%w: password must have between %d and %d characters", web.ErrBadRequest, minPasswordLength, maxPasswordLength)
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(principal.UserID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	now := a.now()
+	attempts, err := checkAttempts(map[string]string{model.LoginAttemptScopeUsername: strings.ToLower(user.Username)}, now, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(user.Password), []byte(change.CurrentPassword)) != 1 {
+		registerFailures(attempts, now, locator)
+		return nil, fmt.Errorf("%w: current password does not match", web.ErrIncorrectPassword)
+	}
+
+	resetAttempts(attempts, locator)
+
+	if err = repository.UpdatePassword(user.ID, change.NewPassword); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	tokenRepository := locator.GetInstance(gateway.TokenRepositoryType).(gateway.TokenRepository)
+	if err = tokenRepository.Revoke(user.ID, model.TokenPurposeResetPassword); err != nil {
+		logger(locator).WithError(err).Error("pending reset password tokens could not be revoked")
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionPasswordChanged,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(user.ID),
+	})
+
+	// Read it again to obtain the token version bumped by the new password.
+	if user, err = repository.Get(user.ID); err != nil {
+		return nil, repositoryError(err)
+	}
+
+	return newAccessToken(user.ID, user.TokenVersion, now, locator)
+}
+
+// notifyEmailChange sends a verification link to the pending email of the user, and tells the current email about
+// the change so its owner can react if someone else requested it.
+func (a accountUseCase) notifyEmailChange(user model.User, locator gateway.ServiceLocator) {
+	pending := user
+	pending.Email = user.PendingEmail
+
+	err := a.sendToken(pending, model.TokenPurposeVerifyEmail, verifyEmailTTL, model.EmailTemplateVerifyEmail, "verify-email", locator)
+	if err != nil {
+		logger(locator).WithError(err).Error("verification of the new email could not be sent")
+	}
+
+	mailer := locator.GetInstance(gateway.MailerType).(gateway.Mailer)
+	err = mailer.Send(user.Email, model.EmailTemplateEmailChanged, map[string]string{
+		"name":      user.Name,
+		"email":     user.Email,
+		"new_email": user.PendingEmail,
+	})
+	if err != nil {
+		logger(locator).WithError(err).Error("email change could not be notified to the current email")
+	}
+}
+
+// validateProfileUpdate trims the updated fields and checks they fit in the Users table.
+func validateProfileUpdate(update *model.ProfileUpdate) error {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"name", update.Name},
+		{"last_name", update.LastName},
+		{"email", update.Email},
+	}
+
+	for _, field := range fields {
+		if field.value == nil {
+			continue
+		}
+
+		*field.value = strings.TrimSpace(*field.value)
+		if *field.value == "" || len(*field.value) > maxProfileLength {
+			return fmt.Errorf("%w: %s must have between 1 and %d characters", web.ErrBadRequest, field.name, maxProfileLength)
+		}
+	}
+
+	if update.Email != nil {
+		address, err := mail.ParseAddress(*update.Email)
+		if err != nil || address.Address != *update.Email {
+			return fmt.Errorf("%w: invalid email", web.ErrBadRequest)
+		}
+	}
+
+	return nil
+}
+
+func (a accountUseCase) sendToken(user model.User, purpose string, ttl time.Duration, template, path string, locator gateway.ServiceLocator) error {
+	id, err := newTokenID()
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	token := model.Token{
+		ID:        id,
+		UserID:    user.ID,
+		Purpose:   purpose,
+		Email:     user.Email,
+		ExpiresAt: a.now().Add(ttl).Unix(),
+	}
+
+	tokenService := locator.GetInstance(gateway.TokenServiceType).(gateway.TokenService)
+	signed, err := tokenService.Sign(token)
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	tokenRepository := locator.GetInstance(gateway.TokenRepositoryType).(gateway.TokenRepository)
+	if err = tokenRepository.Save(token); err != nil {
+		return web.ErrInternalServerError
+	}
+
+	mailer := locator.GetInstance(gateway.MailerType).(gateway.Mailer)
+	err = mailer.Send(user.Email, template, map[string]string{
+		"name":       user.Name,
+		"email":      user.Email,
+		"link":       fmt.Sprintf("%s/%s?token=%s", a.baseURL, path, url.QueryEscape(signed)),
+		"expires_in": ttl.String(),
+	})
+	if err != nil {
+		logger(locator).WithError(err).Error("email could not be sent")
+		return web.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (a accountUseCase) consume(value string, purpose string, locator gateway.ServiceLocator) (*model.Token, error) {
+	tokenService := locator.GetInstance(gateway.TokenServiceType).(gateway.TokenService)
+	token, err := tokenService.Parse(value)
+	if err != nil || token.Purpose != purpose {
+		return nil, web.ErrInvalidToken
+	}
+
+	tokenRepository := locator.GetInstance(gateway.TokenRepositoryType).(gateway.TokenRepository)
+	consumed, err := tokenRepository.Consume(token.ID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if !consumed {
+		return nil, web.ErrInvalidToken
+	}
+
+	return token, nil
+}
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type accountMocks struct {
+	users    *mock_gateway.MockUserRepository
+	tokens   *mock_gateway.MockTokenRepository
+	signer   *mock_gateway.MockTokenService
+	mailer   *mock_gateway.MockMailer
+	attempts *mock_gateway.MockLoginAttemptRepository
+	audit    *auditLog
+	locator  gateway.ServiceLocator
+}
+
+func newAccountMocks(t *testing.T) accountMocks {
+	ctrl := gomock.NewController(t)
+	m := accountMocks{
+		users:    mock_gateway.NewMockUserRepository(ctrl),
+		tokens:   mock_gateway.NewMockTokenRepository(ctrl),
+		signer:   mock_gateway.NewMockTokenService(ctrl),
+		mailer:   mock_gateway.NewMockMailer(ctrl),
+		attempts: mock_gateway.NewMockLoginAttemptRepository(ctrl),
+	}
+
+	iocContext := ioc.NewContext()
+	iocContext.Bind(gateway.UserRepositoryType).ToInstance(m.users)
+	iocContext.Bind(gateway.TokenRepositoryType).ToInstance(m.tokens)
+	iocContext.Bind(gateway.TokenServiceType).ToInstance(m.signer)
+	iocContext.Bind(gateway.MailerType).ToInstance(m.mailer)
+	iocContext.Bind(gateway.LoginAttemptRepositoryType).ToInstance(m.attempts)
+	m.audit = bindAuditLog(iocContext)
+	m.locator = ioc.NewInjector(iocContext)
+
+	return m
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	useCase := NewAccountUseCase("https://dondeestan.app")
+	user := model.User{ID: 1, Name: "Juan", Email: "jperez@mail.com"}
+
+	t.Run("RequestPasswordReset successful", func(t *testing.T) {
+		m := newAccountMocks(t)
+		m.users.EXPECT().FindByEmail(user.Email).Return(&user, nil)
+		m.signer.EXPECT().Sign(gomock.Any()).Return("signed", nil)
+		m.tokens.EXPECT().Save(gomock.Any()).Return(nil)
+		m.mailer.EXPECT().Send(user.Email, model.EmailTemplateResetPassword, gomock.Any()).
+			DoAndReturn(func(to, template string, data map[string]string) error {
+				assert.Equal(t, "https://dondeestan.app/reset-password?token=signed", data["link"])
+				return nil
+			})
+
+		assert.NoError(t, useCase.RequestPasswordReset(user.Email, m.locator))
+	})
+
+	t.Run("RequestPasswordReset unknown email", func(t *testing.T) {
+		m := newAccountMocks(t)
+		m.users.EXPECT().FindByEmail("unknown@mail.com").Return(nil, nil)
+
+		assert.NoError(t, useCase.RequestPasswordReset("unknown@mail.com", m.locator))
+	})
+}
+
+func TestResetPassword(t *testing.T) {
+	var (
+		useCase = NewAccountUseCase("https://dondeestan.app")
+		token   = model.Token{ID: "4f1c2a", UserID: 1, Purpose: model.TokenPurposeResetPassword}
+	)
+
+	t.Run("ResetPassword successful", func(t *testing.T) {
+		m := newAccountMocks(t)
+		m.signer.EXPECT().Parse("signed").Return(&token, nil)
+		m.tokens.EXPECT().Consume(token.ID).Return(true, nil)
+		m.users.EXPECT().UpdatePassword(uint(1), "new-password").Return(nil)
+		m.tokens.EXPECT().Revoke(uint(1), model.TokenPurposeResetPassword).Return(nil)
+
+		err := useCase.ResetPassword(model.TokenConfirmation{Token: "signed", Password: "new-password"}, m.locator)
+		assert.NoError(t, err)
+	})
+
+	t.Run("ResetPassword token already used", func(t *testing.T) {
+		m := newAccountMocks(t)
+		m.signer.EXPECT().Parse("signed").Return(&token, nil)
+		m.tokens.EXPECT().Consume(token.ID).Return(false, nil)
+
+		err := useCase.ResetPassword(model.TokenConfirmation{Token: "signed", Password: "new-password"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrInvalidToken))
+	})
+
+	t.Run("ResetPassword token with other purpose", func(t *testing.T) {
+		m := newAccountMocks(t)
+		m.signer.EXPECT().Parse("signed").Return(&model.Token{ID: "4f1c2a", UserID: 1, Purpose: model.TokenPurposeVerifyEmail}, nil)
+
+		err := useCase.ResetPassword(model.TokenConfirmation{Token: "signed", Password: "new-password"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrInvalidToken))
+	})
+
+	t.Run("ResetPassword short password", func(t *testing.T) {
+		m := newAccountMocks(t)
+
+		err := useCase.ResetPassword(model.TokenConfirmation{Token: "signed", Password: "short"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	var (
+		useCase = NewAccountUseCase("https://dondeestan.app")
+		token   = model.Token{ID: "4f1c2a", UserID: 1, Purpose: model.TokenPurposeVerifyEmail, Email: "jperez@mail.com"}
+	)
+
+	t.Run("VerifyEmail successful", func(t *testing.T) {
+		m := newAccountMocks(t)
+		m.signer.EXPECT().Parse("signed").Return(&token, nil)
+		m.tokens.EXPECT().Consume(token.ID).Return(true, nil)
+		m.users.EXPECT().Get(uint(1)).Return(&model.User{ID: 1, Email: "jperez@mail.com"}, nil)
+		m.users.EXPECT().SetEmailVerified(uint(1), true).Return(nil)
+
+		assert.NoError(t, useCase.VerifyEmail("signed", m.locator))
+	})
+
+	t.Run("VerifyEmail email changed", func(t *testing.T) {
+		m := newAccountMocks(t)
+		m.signer.EXPECT().Parse("signed").Return(&token, nil)
+		m.tokens.EXPECT().Consume(token.ID).Return(true, nil)
+		m.users.EXPECT().Get(uint(1)).Return(&model.User{ID: 1, Email: "juan@mail.com"}, nil)
+
+		err := useCase.VerifyEmail("signed", m.locator)
+		assert.True(t, errors.Is(err, web.ErrInvalidToken))
+	})
+
+	t.Run("VerifyEmail confirms the pending email", func(t *testing.T) {
+		m := newAccountMocks(t)
+		m.signer.EXPECT().Parse("signed").Return(&token, nil)
+		m.tokens.EXPECT().Consume(token.ID).Return(true, nil)
+		m.users.EXPECT().Get(uint(1)).Return(&model.User{ID: 1, Email: "juan@mail.com", PendingEmail: "jperez@mail.com"}, nil)
+		m.users.EXPECT().ConfirmPendingEmail(uint(1), "jperez@mail.com").Return(nil)
+
+		assert.NoError(t, useCase.VerifyEmail("signed", m.locator))
+		assert.Equal(t, []string{model.AuditActionEmailVerified}, m.audit.actions())
+	})
+
+	t.Run("VerifyEmail pending email taken meanwhile", func(t *testing.T) {
+		m := newAccountMocks(t)
+		m.signer.EXPECT().Parse("signed").Return(&token, nil)
+		m.tokens.EXPECT().Consume(token.ID).Return(true, nil)
+		m.users.EXPECT().Get(uint(1)).Return(&model.User{ID: 1, Email: "juan@mail.com", PendingEmail: "jperez@mail.com"}, nil)
+		m.users.EXPECT().ConfirmPendingEmail(uint(1), "jperez@mail.com").Return(fmt.Errorf("%w: email already in use", web.ErrConflict))
+
+		err := useCase.VerifyEmail("signed", m.locator)
+		assert.True(t, errors.Is(err, web.ErrConflict))
+	})
+}
+
+func TestUpdateProfile(t *testing.T) {
+	useCase := NewAccountUseCase("https://dondeestan.app")
+	user := model.User{ID: 1, Name: "Juan", LastName: "Perez", Username: "jperez", Password: "jperez1234", Email: "jperez@mail.com", EmailVerified: true}
+
+	newMocks := func(t *testing.T) accountMocks {
+		m := newAccountMocks(t)
+		m.locator.Context().Bind(gateway.PrincipalType).ToInstance(&model.Principal{UserID: 1, Username: "jperez"})
+		return m
+	}
+
+	name := func(s string) *string { return &s }
+
+	t.Run("UpdateProfile name only", func(t *testing.T) {
+		m := newMocks(t)
+		current := user
+		m.users.EXPECT().Get(uint(1)).Return(&current, nil)
+		updated := user
+		updated.Name = "Juan Pablo"
+		m.users.EXPECT().UpdateProfile(updated).Return(nil)
+		reread := updated
+		reread.UpdatedAt = "2022-12-10 17:49:30"
+		m.users.EXPECT().Get(uint(1)).Return(&reread, nil)
+
+		saved, err := useCase.UpdateProfile(model.ProfileUpdate{Name: name(" Juan Pablo ")}, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "Juan Pablo", saved.Name)
+		assert.Equal(t, "2022-12-10 17:49:30", saved.UpdatedAt)
+		assert.Empty(t, saved.Password)
+		assert.Equal(t, []string{model.AuditActionProfileUpdated}, m.audit.actions())
+	})
+
+	t.Run("UpdateProfile email pending of verification", func(t *testing.T) {
+		m := newMocks(t)
+		current := user
+		m.users.EXPECT().Get(uint(1)).Return(&current, nil)
+		m.users.EXPECT().FindByEmail("juan@mail.com").Return(nil, nil)
+		m.users.EXPECT().SetPendingEmail(uint(1), "juan@mail.com").Return(nil)
+		updated := user
+		updated.PendingEmail = "juan@mail.com"
+		m.users.EXPECT().Get(uint(1)).Return(&updated, nil)
+		m.signer.EXPECT().Sign(gomock.Any()).DoAndReturn(func(token model.Token) (string, error) {
+			assert.Equal(t, "juan@mail.com", token.Email)
+			return "signed", nil
+		})
+		m.tokens.EXPECT().Save(gomock.Any()).Return(nil)
+		m.mailer.EXPECT().Send("juan@mail.com", model.EmailTemplateVerifyEmail, gomock.Any()).Return(nil)
+		m.mailer.EXPECT().Send("jperez@mail.com", model.EmailTemplateEmailChanged, gomock.Any()).
+			DoAndReturn(func(to, template string, data map[string]string) error {
+				assert.Equal(t, "juan@mail.com", data["new_email"])
+				return nil
+			})
+
+		saved, err := useCase.UpdateProfile(model.ProfileUpdate{Email: name("juan@mail.com")}, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "jperez@mail.com", saved.Email)
+		assert.Equal(t, "juan@mail.com", saved.PendingEmail)
+		assert.True(t, saved.EmailVerified)
+	})
+
+	t.Run("UpdateProfile email in use", func(t *testing.T) {
+		m := newMocks(t)
+		current := user
+		m.users.EXPECT().Get(uint(1)).Return(&current, nil)
+		m.users.EXPECT().FindByEmail("juan@mail.com").Return(&model.User{ID: 2}, nil)
+
+		_, err := useCase.UpdateProfile(model.ProfileUpdate{Email: name("juan@mail.com")}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrConflict))
+	})
+
+	t.Run("UpdateProfile invalid email", func(t *testing.T) {
+		m := newMocks(t)
+
+		_, err := useCase.UpdateProfile(model.ProfileUpdate{Email: name("Juan <juan@mail.com>")}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("ChangePassword successful", func(t *testing.T) {
+		m := newMocks(t)
+		current := user
+		m.users.EXPECT().Get(uint(1)).Return(&current, nil)
+		m.attempts.EXPECT().Get(model.LoginAttemptScopeUsername, "jperez").Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeUsername, Key: "jperez"}, nil)
+		m.users.EXPECT().UpdatePassword(uint(1), "new-password").Return(nil)
+		m.tokens.EXPECT().Revoke(uint(1), model.TokenPurposeResetPassword).Return(nil)
+		bumped := user
+		bumped.TokenVersion = 1
+		m.users.EXPECT().Get(uint(1)).Return(&bumped, nil)
+		m.signer.EXPECT().Sign(gomock.Any()).DoAndReturn(func(token model.Token) (string, error) {
+			assert.Equal(t, model.TokenPurposeAccess, token.Purpose)
+			assert.Equal(t, uint(1), token.Version)
+			return "access", nil
+		})
+
+		session, err := useCase.ChangePassword(model.PasswordChange{CurrentPassword: "jperez1234", NewPassword: "new-password"}, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "access", session.AccessToken)
+		assert.Equal(t, []string{model.AuditActionPasswordChanged}, m.audit.actions())
+	})
+
+	t.Run("ChangePassword throttles the lowercase username", func(t *testing.T) {
+		m := newMocks(t)
+		current := user
+		current.Username = "JPerez"
+		m.users.EXPECT().Get(uint(1)).Return(&current, nil)
+		m.attempts.EXPECT().Get(model.LoginAttemptScopeUsername, "jperez").Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeUsername, Key: "jperez"}, nil)
+		m.attempts.EXPECT().RegisterFailure(model.LoginAttemptScopeUsername, "jperez", gomock.Any(), 15*time.Minute).Return(&model.LoginAttempt{Failures: 1}, nil)
+
+		_, err := useCase.ChangePassword(model.PasswordChange{CurrentPassword: "other", NewPassword: "new-password"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrIncorrectPassword))
+	})
+
+	t.Run("ChangePassword wrong current password", func(t *testing.T) {
+		m := newMocks(t)
+		current := user
+		m.users.EXPECT().Get(uint(1)).Return(&current, nil)
+		m.attempts.EXPECT().Get(model.LoginAttemptScopeUsername, "jperez").Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeUsername, Key: "jperez"}, nil)
+		m.attempts.EXPECT().RegisterFailure(model.LoginAttemptScopeUsername, "jperez", gomock.Any(), 15*time.Minute).Return(&model.LoginAttempt{Failures: 1}, nil)
+
+		_, err := useCase.ChangePassword(model.PasswordChange{CurrentPassword: "other", NewPassword: "new-password"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrIncorrectPassword))
+	})
+
+	t.Run("ChangePassword without principal", func(t *testing.T) {
+		m := newAccountMocks(t)
+
+		_, err := useCase.ChangePassword(model.PasswordChange{CurrentPassword: "jperez1234", NewPassword: "new-password"}, m.locator)
+		assert.Equal(t, web.ErrUnauthorized, err)
+	})
+}
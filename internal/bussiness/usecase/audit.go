@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	AuditUseCaseType = "AuditUseCase"
+
+	maxAuditBatch = 500
+)
+
+type (
+	AuditUseCase interface {
+		Find(model.AuditQuery, gateway.ServiceLocator) ([]model.AuditEntry, error)
+		Verify(gateway.ServiceLocator) (*model.AuditVerification, error)
+	}
+
+	auditUseCase struct{}
+)
+
+func NewAuditUseCase() AuditUseCase {
+	return &auditUseCase{}
+}
+
+// Find obtains the audit entries matching the query. Only platform admins can read the audit log.
+func (a auditUseCase) Find(query model.AuditQuery, locator gateway.ServiceLocator) (_ []model.AuditEntry, err error) {
+	span := startSpan(locator, "AuditUseCase.Find")
+	defer func() { span.End(err) }()
+
+	if _, err := Authorize(locator, model.PermissionReadAudit); err != nil {
+		return nil, err
+	}
+
+	if query.From != 0 && query.To != 0 && query.From >= query.To {
+		return nil, fmt.Errorf("%w: from must be before to", web.ErrBadRequest)
+	}
+
+	query.Normalize()
+
+	repository := locator.GetInstance(gateway.AuditRepositoryType).(gateway.AuditRepository)
+	entries, err := repository.Find(query)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return entries, nil
+}
+
+// Verify walks the whole audit log checking that every entry is chained to the previous one and that
+// its content matches its hash, and that the last entries were not removed: the head of the chain, read before
+// walking so the entries appended meanwhile do not matter, must be the hash of one of the entries.
+func (a auditUseCase) Verify(locator gateway.ServiceLocator) (_ *model.AuditVerification, err error) {
+	span := startSpan(locator, "AuditUseCase.Verify")
+	defer func() { span.End(err) }()
+
+	if _, err := Authorize(locator, model.PermissionReadAudit); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.AuditRepositoryType).(gateway.AuditRepository)
+	head, err := repository.GetHead()
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	query := model.AuditQuery{Limit: maxAuditBatch}
+	verification := model.AuditVerification{Valid: true}
+	prevHash := ""
+	reachedHead := head == ""
+
+	for {
+		entries, err := repository.Find(query)
+		if err != nil {
+			return nil, web.ErrInternalServerError
+		}
+
+		for _, entry := range entries {
+			if entry.PrevHash != prevHash || entry.ComputeHash() != entry.Hash {
+				verification.Valid = false
+				verification.BrokenAt = entry.ID
+				break
+			}
+
+			verification.Entries++
+			prevHash = entry.Hash
+			query.AfterID = entry.ID
+			reachedHead = reachedHead || entry.Hash == head
+		}
+
+		if !verification.Valid || len(entries) < query.Limit {
+			break
+		}
+	}
+
+	if verification.Valid && !reachedHead {
+		verification.Valid = false
+		verification.Truncated = true
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionAuditLogVerified,
+		TargetType: model.AuditTargetAuditLog,
+		Details:    map[string]string{"valid": fmt.Sprint(verification.Valid)},
+	})
+
+	return &verification, nil
+}
+
+// recordAudit appends an entry to the audit log. The actor defaults to the principal of the request,
+// and the client IP and request ID are taken from the request being served, if any.
+// Failing to record an entry is logged but does not fail the action being audited.
+func recordAudit(locator gateway.ServiceLocator, entry model.AuditEntry) {
+	entry.OccurredAt = time.Now().UnixMilli()
+
+	if principal, ok := locator.GetInstance(gateway.PrincipalType).(*model.Principal); ok && entry.ActorID == 0 {
+		entry.ActorID = principal.UserID
+		entry.ActorUsername = principal.Username
+	}
+
+	if request, ok := locator.GetInstance(gateway.RequestInfoType).(*model.RequestInfo); ok {
+		entry.IP = request.ClientIP
+		entry.RequestID = request.ID
+	}
+
+	repository := locator.GetInstance(gateway.AuditRepositoryType).(gateway.AuditRepository)
+	if _, err := repository.Append(entry); err != nil {
+		logger(locator).WithError(err).Errorf("audit entry %s could not be recorded", entry.Action)
+	}
+}
+
+// userTarget returns the audit target ID of a user.
+func userTarget(userID uint) string {
+	return fmt.Sprint(userID)
+}
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	"github.com/stretchr/testify/assert"
+)
+
+// auditLog is an in-memory audit repository that chains the entries like the real one.
+type auditLog struct {
+	entries []model.AuditEntry
+	head    string
+}
+
+func bindAuditLog(iocContext ioc.Context) *auditLog {
+	log := &auditLog{}
+	iocContext.Bind(gateway.AuditRepositoryType).ToInstance(log)
+
+	return log
+}
+
+func (a *auditLog) Append(entry model.AuditEntry) (*model.AuditEntry, error) {
+	entry.PrevHash = a.head
+	entry.ID = uint(len(a.entries) + 1)
+	entry.Hash = entry.ComputeHash()
+	a.entries = append(a.entries, entry)
+	a.head = entry.Hash
+
+	return &entry, nil
+}
+
+func (a *auditLog) Find(query model.AuditQuery) ([]model.AuditEntry, error) {
+	var entries []model.AuditEntry
+
+	for _, entry := range a.entries {
+		if entry.ID > query.AfterID && (query.Action == "" || entry.Action == query.Action) && len(entries) < query.Limit {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (a *auditLog) GetHead() (string, error) {
+	return a.head, nil
+}
+
+func (a *auditLog) actions() []string {
+	actions := make([]string, len(a.entries))
+	for i, entry := range a.entries {
+		actions[i] = entry.Action
+	}
+
+	return actions
+}
+
+func TestRecordAudit(t *testing.T) {
+	iocContext := ioc.NewContext()
+	log := bindAuditLog(iocContext)
+	iocContext.Bind(gateway.PrincipalType).ToInstance(&model.Principal{UserID: 9, Username: "admin"})
+	iocContext.Bind(gateway.RequestInfoType).ToInstance(&model.RequestInfo{ID: "req-1", ClientIP: "10.0.0.1"})
+
+	recordAudit(ioc.NewInjector(iocContext), model.AuditEntry{Action: model.AuditActionRolesChanged, TargetType: model.AuditTargetUser, TargetID: "2"})
+
+	entry := log.entries[0]
+	assert.Equal(t, uint(9), entry.ActorID)
+	assert.Equal(t, "admin", entry.ActorUsername)
+	assert.Equal(t, "10.0.0.1", entry.IP)
+	assert.Equal(t, "req-1", entry.RequestID)
+	assert.NotZero(t, entry.OccurredAt)
+}
+
+func TestAuditUseCase(t *testing.T) {
+	useCase := NewAuditUseCase()
+
+	newLocator := func(principal model.Principal) (*auditLog, gateway.ServiceLocator) {
+		iocContext := ioc.NewContext()
+		log := bindAuditLog(iocContext)
+		iocContext.Bind(gateway.PrincipalType).ToInstance(&principal)
+
+		return log, ioc.NewInjector(iocContext)
+	}
+
+	admin := model.Principal{UserID: 1, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}}
+
+	t.Run("Find applies the default limit", func(t *testing.T) {
+		log, locator := newLocator(admin)
+		for i := 0; i < 60; i++ {
+			_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin})
+		}
+
+		entries, err := useCase.Find(model.AuditQuery{Action: model.AuditActionLogin}, locator)
+		assert.NoError(t, err)
+		assert.Len(t, entries, 50)
+	})
+
+	t.Run("Find forbidden for company admins", func(t *testing.T) {
+		_, locator := newLocator(model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "bus"}}})
+
+		entries, err := useCase.Find(model.AuditQuery{}, locator)
+		assert.Nil(t, entries)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("Verify valid chain", func(t *testing.T) {
+		log, locator := newLocator(admin)
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 2})
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLoginFailed, Details: map[string]string{"username": "jperez"}})
+
+		verification, err := useCase.Verify(locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.AuditVerification{Valid: true, Entries: 2}, verification)
+		assert.Equal(t, model.AuditActionAuditLogVerified, log.entries[2].Action)
+	})
+
+	t.Run("Verify tampered entry", func(t *testing.T) {
+		log, locator := newLocator(admin)
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 2})
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 3})
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 4})
+		log.entries[1].ActorID = 5
+
+		verification, err := useCase.Verify(locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.AuditVerification{Valid: false, Entries: 1, BrokenAt: 2}, verification)
+	})
+
+	t.Run("Verify removed entry", func(t *testing.T) {
+		log, locator := newLocator(admin)
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 2})
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 3})
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 4})
+		log.entries = append(log.entries[:1], log.entries[2:]...)
+
+		verification, err := useCase.Verify(locator)
+		assert.NoError(t, err)
+		assert.False(t, verification.Valid)
+		assert.Equal(t, uint(3), verification.BrokenAt)
+	})
+
+	t.Run("Verify removed last entries", func(t *testing.T) {
+		log, locator := newLocator(admin)
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 2})
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 3})
+		log.entries = log.entries[:1]
+
+		verification, err := useCase.Verify(locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.AuditVerification{Valid: false, Entries: 1, Truncated: true}, verification)
+	})
+
+	t.Run("Verify emptied log", func(t *testing.T) {
+		log, locator := newLocator(admin)
+		_, _ = log.Append(model.AuditEntry{Action: model.AuditActionLogin, ActorID: 2})
+		log.entries = nil
+
+		verification, err := useCase.Verify(locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.AuditVerification{Valid: false, Truncated: true}, verification)
+	})
+}
@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	AuthorizationUseCaseType = "AuthorizationUseCase"
+
+	accessTokenTTL = 12 * time.Hour
+)
+
+type (
+	AuthorizationUseCase interface {
+		Authenticate(string, gateway.ServiceLocator) (*model.Principal, error)
+		GetRoles(uint, gateway.ServiceLocator) ([]model.RoleAssignment, error)
+		SetRoles(uint, []model.RoleAssignment, gateway.ServiceLocator) ([]model.RoleAssignment, error)
+		SetUserEnabled(uint, bool, gateway.ServiceLocator) error
+	}
+
+	authorizationUseCase struct{}
+)
+
+func NewAuthorizationUseCase() AuthorizationUseCase {
+	return &authorizationUseCase{}
+}
+
+// Authenticate verifies an access token and loads the principal of its user. Disabled users are rejected,
+// so disabling an account revokes its access immediately, and so are the tokens issued before the last password
+// change of the user.
+func (a authorizationUseCase) Authenticate(accessToken string, locator gateway.ServiceLocator) (_ *model.Principal, err error) {
+	span := startSpan(locator, "AuthorizationUseCase.Authenticate")
+	defer func() { span.End(err) }()
+
+	tokenService := locator.GetInstance(gateway.TokenServiceType).(gateway.TokenService)
+	token, err := tokenService.Parse(accessToken)
+	if err != nil || token.Purpose != model.TokenPurposeAccess {
+		return nil, web.ErrUnauthorized
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(token.UserID)
+	if err != nil || !user.Enabled || token.Version != user.TokenVersion {
+		return nil, web.ErrUnauthorized
+	}
+
+	roles, err := a.roles(*user, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Principal{
+		UserID:   user.ID,
+		Username: user.Username,
+		Type:     user.Type,
+		Roles:    roles,
+	}, nil
+}
+
+// GetRoles obtains every role of a user, including the one implied by its type.
+func (a authorizationUseCase) GetRoles(userID uint, locator gateway.ServiceLocator) (_ []model.RoleAssignment, err error) {
+	span := startSpan(locator, "AuthorizationUseCase.GetRoles")
+	defer func() { span.End(err) }()
+
+	if _, err := Authorize(locator, model.PermissionManageRoles); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(userID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	return a.roles(*user, locator)
+}
+
+// SetRoles replaces the roles explicitly granted to a user. Only platform admins can do it.
+func (a authorizationUseCase) SetRoles(userID uint, roles []model.RoleAssignment, locator gateway.ServiceLocator) (_ []model.RoleAssignment, err error) {
+	span := startSpan(locator, "AuthorizationUseCase.SetRoles")
+	defer func() { span.End(err) }()
+
+	if _, err := Authorize(locator, model.PermissionManageRoles); err != nil {
+		return nil, err
+	}
+
+	for _, role := range roles {
+		if !contains(model.Roles, role.Role) {
+			return nil, fmt.Errorf("%w: unknown role %s", web.ErrBadRequest, role.Role)
+		}
+
+		if role.Role == model.RoleCompanyAdmin && role.CompanyName == "" {
+			return nil, fmt.Errorf("%w: company admins require a company name", web.ErrBadRequest)
+		}
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(userID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	roleRepository := locator.GetInstance(gateway.RoleRepositoryType).(gateway.RoleRepository)
+	if err = roleRepository.SetRoles(userID, roles); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	granted := make([]string, len(roles))
+	for i, role := range roles {
+		granted[i] = strings.TrimSuffix(role.Role+":"+role.CompanyName, ":")
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionRolesChanged,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(userID),
+		Details:    map[string]string{"roles": strings.Join(granted, ",")},
+	})
+
+	return a.roles(*user, locator)
+}
+
+// SetUserEnabled enables or disables a user. Platform admins can manage any user, while company admins
+// can only manage the drivers of their companies.
+func (a authorizationUseCase) SetUserEnabled(userID uint, enabled bool, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "AuthorizationUseCase.SetUserEnabled")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionManageUsers)
+	if err != nil {
+		return err
+	}
+
+	if principal.UserID == userID {
+		return fmt.Errorf("%w: users cannot change their own status", web.ErrBadRequest)
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(userID)
+	if err != nil {
+		return repositoryError(err)
+	}
+
+	if !principal.HasRole(model.RolePlatformAdmin) {
+		companyName, err := driverCompany(*user, repository)
+		if err != nil {
+			return err
+		}
+
+		if !contains(principal.ManagedCompanies(), companyName) {
+			return web.ErrForbidden
+		}
+	}
+
+	if err = repository.SetEnabled(userID, enabled); err != nil {
+		return web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionUserStatusChanged,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(userID),
+		Details:    map[string]string{"enabled": strconv.FormatBool(enabled)},
+	})
+
+	return nil
+}
+
+func (a authorizationUseCase) roles(user model.User, locator gateway.ServiceLocator) ([]model.RoleAssignment, error) {
+	roleRepository := locator.GetInstance(gateway.RoleRepositoryType).(gateway.RoleRepository)
+	granted, err := roleRepository.GetRoles(user.ID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	roles := []model.RoleAssignment{{Role: model.DefaultRole(user.Type)}}
+	for _, role := range granted {
+		if role.Role != roles[0].Role || role.CompanyName != "" {
+			roles = append(roles, role)
+		}
+	}
+
+	return roles, nil
+}
+
+// Authorize obtains the principal of the request and checks that it holds the permission.
+// Every use case acting on behalf of a user must call it, or AuthorizeSelf, before doing anything.
+func Authorize(locator gateway.ServiceLocator, permission string) (*model.Principal, error) {
+	principal, err := GetPrincipal(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	if !principal.Can(permission) {
+		return nil, web.ErrForbidden
+	}
+
+	return principal, nil
+}
+
+// AuthorizeSelf checks that the principal of the request is the given user, or a platform admin.
+func AuthorizeSelf(locator gateway.ServiceLocator, userID uint) (*model.Principal, error) {
+	principal, err := GetPrincipal(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.UserID != userID && !principal.HasRole(model.RolePlatformAdmin) {
+		return nil, web.ErrForbidden
+	}
+
+	return principal, nil
+}
+
+// GetPrincipal obtains the authenticated principal of the request.
+func GetPrincipal(locator gateway.ServiceLocator) (*model.Principal, error) {
+	principal, ok := locator.GetInstance(gateway.PrincipalType).(*model.Principal)
+	if !ok || principal == nil {
+		return nil, web.ErrUnauthorized
+	}
+
+	return principal, nil
+}
+
+// driverCompany returns the company of a driver. Other users do not belong to a company.
+func driverCompany(user model.User, repository gateway.UserRepository) (string, error) {
+	if user.Type != observed {
+		return "", web.ErrForbidden
+	}
+
+	u, err := repository.GetObservedUser(&model.ObservedUser{User: user})
+	if err != nil {
+		return "", web.ErrInternalServerError
+	}
+
+	driver, ok := (*u).(*model.ObservedUser)
+	if !ok {
+		return "", web.ErrInternalServerError
+	}
+
+	return driver.CompanyName, nil
+}
+
+// repositoryError keeps the errors the repositories already map to a status, such as not found,
+// and hides any other one.
+func repositoryError(err error) error {
+	var webErr *web.Error
+	if errors.As(err, &webErr) {
+		return err
+	}
+
+	return web.ErrInternalServerError
+}
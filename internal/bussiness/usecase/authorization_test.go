@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticate(t *testing.T) {
+	useCase := NewAuthorizationUseCase()
+
+	newLocator := func(t *testing.T) (*mock_gateway.MockTokenService, *mock_gateway.MockUserRepository, *mock_gateway.MockRoleRepository, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		tokens := mock_gateway.NewMockTokenService(ctrl)
+		users := mock_gateway.NewMockUserRepository(ctrl)
+		roles := mock_gateway.NewMockRoleRepository(ctrl)
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.TokenServiceType).ToInstance(tokens)
+		iocContext.Bind(gateway.UserRepositoryType).ToInstance(users)
+		iocContext.Bind(gateway.RoleRepositoryType).ToInstance(roles)
+
+		return tokens, users, roles, ioc.NewInjector(iocContext)
+	}
+
+	t.Run("Authenticate successful", func(t *testing.T) {
+		tokens, users, roles, locator := newLocator(t)
+		tokens.EXPECT().Parse("signed").Return(&model.Token{UserID: 2, Purpose: model.TokenPurposeAccess}, nil)
+		users.EXPECT().Get(uint(2)).Return(&model.User{ID: 2, Username: "mdominguez", Enabled: true, Type: observer}, nil)
+		roles.EXPECT().GetRoles(uint(2)).Return([]model.RoleAssignment{{Role: model.RolePlatformAdmin}}, nil)
+
+		principal, err := useCase.Authenticate("signed", locator)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.RoleAssignment{{Role: model.RoleGuardian}, {Role: model.RolePlatformAdmin}}, principal.Roles)
+		assert.True(t, principal.Can(model.PermissionManageRoles))
+	})
+
+	t.Run("Authenticate token with other purpose", func(t *testing.T) {
+		tokens, _, _, locator := newLocator(t)
+		tokens.EXPECT().Parse("signed").Return(&model.Token{UserID: 2, Purpose: model.TokenPurposeResetPassword}, nil)
+
+		principal, err := useCase.Authenticate("signed", locator)
+		assert.Nil(t, principal)
+		assert.Equal(t, web.ErrUnauthorized, err)
+	})
+
+	t.Run("Authenticate disabled user", func(t *testing.T) {
+		tokens, users, _, locator := newLocator(t)
+		tokens.EXPECT().Parse("signed").Return(&model.Token{UserID: 2, Purpose: model.TokenPurposeAccess}, nil)
+		users.EXPECT().Get(uint(2)).Return(&model.User{ID: 2, Enabled: false, Type: observer}, nil)
+
+		principal, err := useCase.Authenticate("signed", locator)
+		assert.Nil(t, principal)
+		assert.Equal(t, web.ErrUnauthorized, err)
+	})
+
+	t.Run("Authenticate token issued before a password change", func(t *testing.T) {
+		tokens, users, _, locator := newLocator(t)
+		tokens.EXPECT().Parse("signed").Return(&model.Token{UserID: 2, Purpose: model.TokenPurposeAccess, Version: 1}, nil)
+		users.EXPECT().Get(uint(2)).Return(&model.User{ID: 2, Enabled: true, Type: observer, TokenVersion: 2}, nil)
+
+		principal, err := useCase.Authenticate("signed", locator)
+		assert.Nil(t, principal)
+		assert.Equal(t, web.ErrUnauthorized, err)
+	})
+}
+
+func TestSetUserEnabled(t *testing.T) {
+	var (
+		useCase = NewAuthorizationUseCase()
+		driver  = model.User{ID: 1, Type: observed, Enabled: true}
+	)
+
+	newLocator := func(t *testing.T, principal *model.Principal) (*mock_gateway.MockUserRepository, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		users := mock_gateway.NewMockUserRepository(ctrl)
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.UserRepositoryType).ToInstance(users)
+		bindAuditLog(iocContext)
+		if principal != nil {
+			iocContext.Bind(gateway.PrincipalType).ToInstance(principal)
+		}
+
+		return users, ioc.NewInjector(iocContext)
+	}
+
+	companyDriver := func(company string) *model.IUser {
+		u := model.NewObservedUser(model.ObservedUser{User: driver, CompanyName: company})
+		return &u
+	}
+
+	t.Run("SetUserEnabled platform admin", func(t *testing.T) {
+		users, locator := newLocator(t, &model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}})
+		users.EXPECT().Get(uint(1)).Return(&driver, nil)
+		users.EXPECT().SetEnabled(uint(1), false).Return(nil)
+
+		assert.NoError(t, useCase.SetUserEnabled(1, false, locator))
+	})
+
+	t.Run("SetUserEnabled company admin of the driver company", func(t *testing.T) {
+		users, locator := newLocator(t, &model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "company school bus"}}})
+		users.EXPECT().Get(uint(1)).Return(&driver, nil)
+		users.EXPECT().GetObservedUser(gomock.Any()).Return(companyDriver("company school bus"), nil)
+		users.EXPECT().SetEnabled(uint(1), false).Return(nil)
+
+		assert.NoError(t, useCase.SetUserEnabled(1, false, locator))
+	})
+
+	t.Run("SetUserEnabled company admin of other company", func(t *testing.T) {
+		users, locator := newLocator(t, &model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "other company"}}})
+		users.EXPECT().Get(uint(1)).Return(&driver, nil)
+		users.EXPECT().GetObservedUser(gomock.Any()).Return(companyDriver("company school bus"), nil)
+
+		assert.Equal(t, web.ErrForbidden, useCase.SetUserEnabled(1, false, locator))
+	})
+
+	t.Run("SetUserEnabled guardian", func(t *testing.T) {
+		_, locator := newLocator(t, &model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}})
+
+		assert.Equal(t, web.ErrForbidden, useCase.SetUserEnabled(1, false, locator))
+	})
+
+	t.Run("SetUserEnabled without principal", func(t *testing.T) {
+		_, locator := newLocator(t, nil)
+
+		assert.Equal(t, web.ErrUnauthorized, useCase.SetUserEnabled(1, false, locator))
+	})
+
+	t.Run("SetUserEnabled own account", func(t *testing.T) {
+		_, locator := newLocator(t, &model.Principal{UserID: 1, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}})
+
+		assert.True(t, errors.Is(useCase.SetUserEnabled(1, false, locator), web.ErrBadRequest))
+	})
+}
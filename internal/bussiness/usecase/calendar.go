@@ -0,0 +1,369 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	CalendarUseCaseType = "CalendarUseCase"
+
+	maxCalendarEntryNameLength = 100
+)
+
+type (
+	CalendarUseCase interface {
+		GetCompanyCalendar(string, gateway.ServiceLocator) ([]model.CalendarEntry, error)
+		SetCompanyCalendar(string, []model.CalendarEntry, gateway.ServiceLocator) ([]model.CalendarEntry, error)
+		ImportCompanyCalendar(string, io.Reader, gateway.ServiceLocator) ([]model.CalendarEntry, error)
+		ExportCompanyCalendar(string, gateway.ServiceLocator) ([]byte, error)
+		ImportSchoolCalendar(uint, io.Reader, gateway.ServiceLocator) (*model.School, error)
+		ExportSchoolCalendar(uint, gateway.ServiceLocator) ([]byte, error)
+		ServiceDay(uint, string, string, gateway.ServiceLocator) (*model.ServiceDay, error)
+	}
+
+	calendarUseCase struct{}
+)
+
+func NewCalendarUseCase() CalendarUseCase {
+	return &calendarUseCase{}
+}
+
+// GetCompanyCalendar obtains the calendar of a company. Every authenticated user can read it.
+func (c calendarUseCase) GetCompanyCalendar(companyName string, locator gateway.ServiceLocator) (_ []model.CalendarEntry, err error) {
+	span := startSpan(locator, "CalendarUseCase.GetCompanyCalendar")
+	defer func() { span.End(err) }()
+
+	if _, err := GetPrincipal(locator); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.CalendarRepositoryType).(gateway.CalendarRepository)
+	entries, err := repository.GetCompanyCalendar(companyName)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return entries, nil
+}
+
+// SetCompanyCalendar replaces the calendar of a company. Company admins can only change their own companies.
+func (c calendarUseCase) SetCompanyCalendar(companyName string, entries []model.CalendarEntry, locator gateway.ServiceLocator) (_ []model.CalendarEntry, err error) {
+	span := startSpan(locator, "CalendarUseCase.SetCompanyCalendar")
+	defer func() { span.End(err) }()
+
+	if err := authorizeCompanyCalendar(companyName, locator); err != nil {
+		return nil, err
+	}
+
+	return saveCompanyCalendar(companyName, entries, locator)
+}
+
+// ImportCompanyCalendar adds the events of an iCalendar to the calendar of a company. The imported events replace
+// the entries of the same dates.
+func (c calendarUseCase) ImportCompanyCalendar(companyName string, reader io.Reader, locator gateway.ServiceLocator) (_ []model.CalendarEntry, err error) {
+	span := startSpan(locator, "CalendarUseCase.ImportCompanyCalendar")
+	defer func() { span.End(err) }()
+
+	if err := authorizeCompanyCalendar(companyName, locator); err != nil {
+		return nil, err
+	}
+
+	imported, err := decodeCalendar(reader, model.DefaultSchoolTimezone, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.CalendarRepositoryType).(gateway.CalendarRepository)
+	current, err := repository.GetCompanyCalendar(companyName)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return saveCompanyCalendar(companyName, mergeCalendar(current, imported), locator)
+}
+
+// ExportCompanyCalendar returns the calendar of a company in iCalendar format.
+func (c calendarUseCase) ExportCompanyCalendar(companyName string, locator gateway.ServiceLocator) (_ []byte, err error) {
+	span := startSpan(locator, "CalendarUseCase.ExportCompanyCalendar")
+	defer func() { span.End(err) }()
+
+	entries, err := c.GetCompanyCalendar(companyName, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeCalendar(model.Calendar{
+		ID:       "company-" + companyName,
+		Name:     companyName,
+		Timezone: model.DefaultSchoolTimezone,
+		Entries:  entries,
+	}, locator)
+}
+
+// ImportSchoolCalendar adds the events of an iCalendar, read in the school timezone, to the calendar of a school.
+// The imported events replace the entries of the same dates.
+func (c calendarUseCase) ImportSchoolCalendar(schoolID uint, reader io.Reader, locator gateway.ServiceLocator) (_ *model.School, err error) {
+	span := startSpan(locator, "CalendarUseCase.ImportSchoolCalendar")
+	defer func() { span.End(err) }()
+
+	if _, err := Authorize(locator, model.PermissionManageSchools); err != nil {
+		return nil, err
+	}
+
+	school, err := findSchool(schoolID, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	imported, err := decodeCalendar(reader, school.Timezone, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	school.Calendar = mergeCalendar(school.Calendar, imported)
+	if err = validateCalendar(school.Calendar); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.SchoolRepositoryType).(gateway.SchoolRepository)
+	saved, err := repository.SaveSchool(*school)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionCalendarChanged,
+		TargetType: model.AuditTargetSchool,
+		TargetID:   fmt.Sprint(schoolID),
+		Details:    map[string]string{"imported": fmt.Sprint(len(imported))},
+	})
+
+	return saved, nil
+}
+
+// ExportSchoolCalendar returns the calendar of a school in iCalendar format.
+func (c calendarUseCase) ExportSchoolCalendar(schoolID uint, locator gateway.ServiceLocator) (_ []byte, err error) {
+	span := startSpan(locator, "CalendarUseCase.ExportSchoolCalendar")
+	defer func() { span.End(err) }()
+
+	if _, err := GetPrincipal(locator); err != nil {
+		return nil, err
+	}
+
+	school, err := findSchool(schoolID, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeCalendar(model.Calendar{
+		ID:       fmt.Sprintf("school-%d", school.ID),
+		Name:     school.Name,
+		Timezone: school.Timezone,
+		Entries:  school.Calendar,
+	}, locator)
+}
+
+// ServiceDay resolves whether a school has classes on a date, and its schedule, after the school calendar and
+// the calendar of the company, when given.
+func (c calendarUseCase) ServiceDay(schoolID uint, companyName string, date string, locator gateway.ServiceLocator) (_ *model.ServiceDay, err error) {
+	span := startSpan(locator, "CalendarUseCase.ServiceDay")
+	defer func() { span.End(err) }()
+
+	if _, err := GetPrincipal(locator); err != nil {
+		return nil, err
+	}
+
+	school, err := findSchool(schoolID, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	day, err := time.ParseInLocation(model.CalendarDateLayout, date, school.Location())
+	if err != nil {
+		return nil, fmt.Errorf("%w: date must be in YYYY-MM-DD format", web.ErrBadRequest)
+	}
+
+	var company []model.CalendarEntry
+	if companyName != "" {
+		repository := locator.GetInstance(gateway.CalendarRepositoryType).(gateway.CalendarRepository)
+		if company, err = repository.GetCompanyCalendar(companyName); err != nil {
+			return nil, web.ErrInternalServerError
+		}
+	}
+
+	serviceDay := school.ServiceDay(day, company)
+
+	return &serviceDay, nil
+}
+
+// familyHasService reports whether any child of an observer user has classes at the instant, after the calendars
+// of their schools and of the companies driving them. Families without children are always in service, since
+// nothing is known about them.
+func familyHasService(observerUserID uint, at time.Time, locator gateway.ServiceLocator) (bool, error) {
+	calendars := locator.GetInstance(gateway.CalendarRepositoryType).(gateway.CalendarRepository)
+	schoolIDs, err := calendars.GetFamilySchools(observerUserID)
+	if err != nil || len(schoolIDs) == 0 {
+		return true, err
+	}
+
+	companies, err := calendars.GetFamilyCompanies(observerUserID)
+	if err != nil {
+		return true, err
+	}
+
+	var company []model.CalendarEntry
+	for _, companyName := range companies {
+		entries, err := calendars.GetCompanyCalendar(companyName)
+		if err != nil {
+			return true, err
+		}
+		company = append(company, entries...)
+	}
+
+	schools := locator.GetInstance(gateway.SchoolRepositoryType).(gateway.SchoolRepository)
+	for _, schoolID := range schoolIDs {
+		school, err := schools.GetSchool(schoolID)
+		if err != nil {
+			return true, err
+		}
+
+		if school != nil && school.ServiceDay(at, company).Service {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func authorizeCompanyCalendar(companyName string, locator gateway.ServiceLocator) error {
+	principal, err := Authorize(locator, model.PermissionManageCalendars)
+	if err != nil {
+		return err
+	}
+
+	if !principal.HasRole(model.RolePlatformAdmin) && !contains(principal.ManagedCompanies(), companyName) {
+		return web.ErrForbidden
+	}
+
+	return nil
+}
+
+func saveCompanyCalendar(companyName string, entries []model.CalendarEntry, locator gateway.ServiceLocator) ([]model.CalendarEntry, error) {
+	if err := validateCalendar(entries); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.CalendarRepositoryType).(gateway.CalendarRepository)
+	if err := repository.SaveCompanyCalendar(companyName, entries); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionCalendarChanged,
+		TargetType: model.AuditTargetCompany,
+		TargetID:   companyName,
+		Details:    map[string]string{"entries": fmt.Sprint(len(entries))},
+	})
+
+	saved, err := repository.GetCompanyCalendar(companyName)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return saved, nil
+}
+
+func decodeCalendar(reader io.Reader, timezone string, locator gateway.ServiceLocator) ([]model.CalendarEntry, error) {
+	codec := locator.GetInstance(gateway.CalendarCodecType).(gateway.CalendarCodec)
+
+	entries, err := codec.Decode(reader, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid iCalendar: %s", web.ErrBadRequest, err.Error())
+	}
+
+	return entries, nil
+}
+
+func encodeCalendar(calendar model.Calendar, locator gateway.ServiceLocator) ([]byte, error) {
+	codec := locator.GetInstance(gateway.CalendarCodecType).(gateway.CalendarCodec)
+
+	content, err := codec.Encode(calendar)
+	if err != nil {
+		logger(locator).WithError(err).Error("calendar could not be encoded")
+		return nil, web.ErrInternalServerError
+	}
+
+	return content, nil
+}
+
+// mergeCalendar adds the imported entries to the current ones, replacing those of the same dates, sorted by date.
+func mergeCalendar(current []model.CalendarEntry, imported []model.CalendarEntry) []model.CalendarEntry {
+	byDate := make(map[string]model.CalendarEntry, len(current)+len(imported))
+	for _, entry := range current {
+		byDate[entry.Date] = entry
+	}
+
+	for _, entry := range imported {
+		byDate[entry.Date] = entry
+	}
+
+	merged := make([]model.CalendarEntry, 0, len(byDate))
+	for _, entry := range byDate {
+		merged = append(merged, entry)
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return merged[i].Date < merged[j].Date
+	})
+
+	return merged
+}
+
+// validateCalendar checks the entries, which need distinct dates, and clears the times that do not apply to their kind.
+func validateCalendar(entries []model.CalendarEntry) error {
+	dates := map[string]bool{}
+
+	for i := range entries {
+		entry := &entries[i]
+
+		if _, err := time.Parse(model.CalendarDateLayout, entry.Date); err != nil || dates[entry.Date] {
+			return fmt.Errorf("%w: calendar entries need distinct dates in YYYY-MM-DD format", web.ErrBadRequest)
+		}
+		dates[entry.Date] = true
+
+		if !contains(model.CalendarEntryKinds, entry.Kind) {
+			return fmt.Errorf("%w: calendar entry kind must be one of %v", web.ErrBadRequest, model.CalendarEntryKinds)
+		}
+
+		entry.Name = strings.TrimSpace(entry.Name)
+		if len(entry.Name) > maxCalendarEntryNameLength {
+			return fmt.Errorf("%w: calendar entry names must have up to %d characters", web.ErrBadRequest, maxCalendarEntryNameLength)
+		}
+
+		switch entry.Kind {
+		case model.CalendarEntryHoliday, model.CalendarEntryInService:
+			entry.Start, entry.End = "", ""
+		case model.CalendarEntryHalfDay:
+			entry.Start = ""
+			if _, err := model.ParseClock(entry.End); err != nil {
+				return fmt.Errorf("%w: half day of %s needs its end time", web.ErrBadRequest, entry.Date)
+			}
+		case model.CalendarEntrySpecialSchedule:
+			start, errStart := model.ParseClock(entry.Start)
+			end, errEnd := model.ParseClock(entry.End)
+			if errStart != nil || errEnd != nil || start >= end {
+				return fmt.Errorf("%w: special schedule of %s needs a start time before its end time", web.ErrBadRequest, entry.Date)
+			}
+		}
+	}
+
+	return nil
+}
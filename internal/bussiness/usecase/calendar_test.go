@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendars(t *testing.T) {
+	var (
+		useCase      = NewCalendarUseCase()
+		platform     = model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}}
+		companyAdmin = model.Principal{UserID: 8, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "Transportes Santa Fe"}}}
+		guardian     = model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}}
+		laSalle      = model.School{
+			ID: 1, Name: "La Salle", GeofenceRadius: 150, Timezone: model.DefaultSchoolTimezone,
+			BellSchedules: []model.BellSchedule{{Weekday: time.Monday, Start: "08:00", End: "12:00"}},
+			Calendar:      []model.CalendarEntry{{Date: "2023-07-10", Kind: model.CalendarEntryHoliday}},
+		}
+	)
+
+	type mocks struct {
+		calendars *mock_gateway.MockCalendarRepository
+		codec     *mock_gateway.MockCalendarCodec
+		schools   *mock_gateway.MockSchoolRepository
+	}
+
+	newLocator := func(t *testing.T, principal model.Principal) (mocks, *auditLog, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		m := mocks{
+			calendars: mock_gateway.NewMockCalendarRepository(ctrl),
+			codec:     mock_gateway.NewMockCalendarCodec(ctrl),
+			schools:   mock_gateway.NewMockSchoolRepository(ctrl),
+		}
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.CalendarRepositoryType).ToInstance(m.calendars)
+		iocContext.Bind(gateway.CalendarCodecType).ToInstance(m.codec)
+		iocContext.Bind(gateway.SchoolRepositoryType).ToInstance(m.schools)
+		iocContext.Bind(gateway.PrincipalType).ToInstance(&principal)
+		audit := bindAuditLog(iocContext)
+
+		return m, audit, ioc.NewInjector(iocContext)
+	}
+
+	t.Run("SetCompanyCalendar by its company admin", func(t *testing.T) {
+		m, audit, locator := newLocator(t, companyAdmin)
+		entries := []model.CalendarEntry{{Date: "2023-07-11", Kind: model.CalendarEntryInService, Name: "Fleet maintenance"}}
+		m.calendars.EXPECT().SaveCompanyCalendar("Transportes Santa Fe", entries).Return(nil)
+		m.calendars.EXPECT().GetCompanyCalendar("Transportes Santa Fe").Return(entries, nil)
+
+		saved, err := useCase.SetCompanyCalendar("Transportes Santa Fe", []model.CalendarEntry{
+			{Date: "2023-07-11", Kind: model.CalendarEntryInService, Name: " Fleet maintenance ", Start: "08:00"},
+		}, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, entries, saved)
+		assert.Equal(t, []string{model.AuditActionCalendarChanged}, audit.actions())
+	})
+
+	t.Run("SetCompanyCalendar of another company is forbidden", func(t *testing.T) {
+		_, _, locator := newLocator(t, companyAdmin)
+
+		_, err := useCase.SetCompanyCalendar("Micros del Litoral", nil, locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("SetCompanyCalendar with repeated dates", func(t *testing.T) {
+		_, _, locator := newLocator(t, platform)
+
+		_, err := useCase.SetCompanyCalendar("Transportes Santa Fe", []model.CalendarEntry{
+			{Date: "2023-07-11", Kind: model.CalendarEntryHoliday},
+			{Date: "2023-07-11", Kind: model.CalendarEntryInService},
+		}, locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("ImportSchoolCalendar merges the imported entries", func(t *testing.T) {
+		m, audit, locator := newLocator(t, platform)
+		imported := []model.CalendarEntry{
+			{Date: "2023-07-10", Kind: model.CalendarEntryHalfDay, End: "10:00"},
+			{Date: "2023-07-03", Kind: model.CalendarEntryHoliday, Name: "Bridge holiday"},
+		}
+		merged := laSalle
+		merged.Calendar = []model.CalendarEntry{imported[1], imported[0]}
+
+		m.schools.EXPECT().GetSchool(uint(1)).Return(&laSalle, nil)
+		m.codec.EXPECT().Decode(gomock.Any(), model.DefaultSchoolTimezone).Return(imported, nil)
+		m.schools.EXPECT().SaveSchool(merged).Return(&merged, nil)
+
+		school, err := useCase.ImportSchoolCalendar(1, strings.NewReader("BEGIN:VCALENDAR"), locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &merged, school)
+		assert.Equal(t, []string{model.AuditActionCalendarChanged}, audit.actions())
+	})
+
+	t.Run("ImportSchoolCalendar invalid content", func(t *testing.T) {
+		m, _, locator := newLocator(t, platform)
+		m.schools.EXPECT().GetSchool(uint(1)).Return(&laSalle, nil)
+		m.codec.EXPECT().Decode(gomock.Any(), model.DefaultSchoolTimezone).Return(nil, errors.New("the content is not an iCalendar"))
+
+		_, err := useCase.ImportSchoolCalendar(1, strings.NewReader("name,date"), locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("ImportSchoolCalendar without permission", func(t *testing.T) {
+		_, _, locator := newLocator(t, guardian)
+
+		_, err := useCase.ImportSchoolCalendar(1, strings.NewReader(""), locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("ServiceDay with the company calendar", func(t *testing.T) {
+		m, _, locator := newLocator(t, guardian)
+		m.schools.EXPECT().GetSchool(uint(1)).Return(&laSalle, nil)
+		m.calendars.EXPECT().GetCompanyCalendar("Transportes Santa Fe").
+			Return([]model.CalendarEntry{{Date: "2023-07-17", Kind: model.CalendarEntryInService}}, nil)
+
+		day, err := useCase.ServiceDay(1, "Transportes Santa Fe", "2023-07-17", locator)
+		assert.NoError(t, err)
+		assert.False(t, day.Service)
+		assert.Equal(t, model.CalendarEntryInService, day.Kind)
+	})
+
+	t.Run("ServiceDay invalid date", func(t *testing.T) {
+		m, _, locator := newLocator(t, guardian)
+		m.schools.EXPECT().GetSchool(uint(1)).Return(&laSalle, nil)
+
+		_, err := useCase.ServiceDay(1, "", "17/07/2023", locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+}
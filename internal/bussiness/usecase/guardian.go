@@ -0,0 +1,451 @@
+package usecase
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	GuardianUseCaseType = "GuardianUseCase"
+
+	guardianInvitationTTL = 7 * 24 * time.Hour
+	// maxTemporaryPickupDays caps how long a temporary pickup delegation can last.
+	maxTemporaryPickupDays = 31
+)
+
+type (
+	GuardianUseCase interface {
+		List(uint, gateway.ServiceLocator) ([]model.Guardian, error)
+		Invite(model.GuardianInvitation, gateway.ServiceLocator) (*model.GuardianInvitation, error)
+		ListInvitations(uint, gateway.ServiceLocator) ([]model.GuardianInvitation, error)
+		RevokeInvitation(uint, string, gateway.ServiceLocator) error
+		Accept(string, gateway.ServiceLocator) (*model.Guardian, error)
+		Update(model.Guardian, gateway.ServiceLocator) (*model.Guardian, error)
+		Remove(uint, uint, gateway.ServiceLocator) error
+	}
+
+	guardianUseCase struct {
+		baseURL string
+		now     func() time.Time
+	}
+)
+
+// NewGuardianUseCase creates the use case for the guardians of the children. The invitation links sent by email
+// point to baseURL.
+func NewGuardianUseCase(baseURL string) GuardianUseCase {
+	return &guardianUseCase{
+		baseURL: baseURL,
+		now:     time.Now,
+	}
+}
+
+// List obtains every guardian of a child. Only its active guardians can see them.
+func (g guardianUseCase) List(childID uint, locator gateway.ServiceLocator) (_ []model.Guardian, err error) {
+	span := startSpan(locator, "GuardianUseCase.List")
+	defer func() { span.End(err) }()
+
+	_, guardians, err := authorizeChild(locator, childID, model.ChildAccessTrack, g.now())
+	if err != nil {
+		return nil, err
+	}
+
+	return guardians, nil
+}
+
+// Invite sends an invitation by email to become a guardian of a child. Only the primary guardian can invite,
+// and temporary pickups must end within maxTemporaryPickupDays.
+func (g guardianUseCase) Invite(invitation model.GuardianInvitation, locator gateway.ServiceLocator) (_ *model.GuardianInvitation, err error) {
+	span := startSpan(locator, "GuardianUseCase.Invite")
+	defer func() { span.End(err) }()
+
+	now := g.now()
+
+	principal, _, err := authorizeChild(locator, invitation.ChildID, model.ChildAccessManage, now)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation.Email = strings.TrimSpace(invitation.Email)
+	if _, err = mail.ParseAddress(invitation.Email); err != nil {
+		return nil, fmt.Errorf("%w: invalid email", web.ErrBadRequest)
+	}
+
+	if err = validateDelegation(invitation.Role, invitation.StartsAt, invitation.EndsAt, now); err != nil {
+		return nil, err
+	}
+
+	schools := locator.GetInstance(gateway.SchoolRepositoryType).(gateway.SchoolRepository)
+	child, err := schools.GetChild(invitation.ChildID)
+	if err != nil || child == nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	id, err := newTokenID()
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	invitation.ID = id
+	invitation.InvitedBy = principal.UserID
+	invitation.Status = model.GuardianInvitationPending
+	invitation.ExpiresAt = now.Add(guardianInvitationTTL).Unix()
+
+	token := model.Token{
+		ID:        id,
+		UserID:    principal.UserID,
+		Purpose:   model.TokenPurposeGuardianInvitation,
+		Email:     invitation.Email,
+		ExpiresAt: invitation.ExpiresAt,
+	}
+
+	tokenService := locator.GetInstance(gateway.TokenServiceType).(gateway.TokenService)
+	signed, err := tokenService.Sign(token)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	tokenRepository := locator.GetInstance(gateway.TokenRepositoryType).(gateway.TokenRepository)
+	if err = tokenRepository.Save(token); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	repository := locator.GetInstance(gateway.GuardianRepositoryType).(gateway.GuardianRepository)
+	if err = repository.SaveInvitation(invitation); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	mailer := locator.GetInstance(gateway.MailerType).(gateway.Mailer)
+	err = mailer.Send(invitation.Email, model.EmailTemplateGuardianInvitation, map[string]string{
+		"inviter":    principal.Username,
+		"child":      child.Name,
+		"role":       invitation.Role,
+		"link":       fmt.Sprintf("%s/guardian-invitations?token=%s", g.baseURL, url.QueryEscape(signed)),
+		"expires_in": guardianInvitationTTL.String(),
+	})
+	if err != nil {
+		logger(locator).WithError(err).Error("guardian invitation could not be sent")
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionGuardianInvited,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(invitation.ChildID),
+		Details:    map[string]string{"invitation": invitation.ID, "role": invitation.Role},
+	})
+
+	return &invitation, nil
+}
+
+// ListInvitations obtains the pending invitations of a child, for its primary guardian.
+func (g guardianUseCase) ListInvitations(childID uint, locator gateway.ServiceLocator) (_ []model.GuardianInvitation, err error) {
+	span := startSpan(locator, "GuardianUseCase.ListInvitations")
+	defer func() { span.End(err) }()
+
+	if _, _, err := authorizeChild(locator, childID, model.ChildAccessManage, g.now()); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.GuardianRepositoryType).(gateway.GuardianRepository)
+	invitations, err := repository.GetPendingInvitations(childID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return invitations, nil
+}
+
+// RevokeInvitation cancels a pending invitation, so it can no longer be accepted.
+func (g guardianUseCase) RevokeInvitation(childID uint, invitationID string, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "GuardianUseCase.RevokeInvitation")
+	defer func() { span.End(err) }()
+
+	if _, _, err := authorizeChild(locator, childID, model.ChildAccessManage, g.now()); err != nil {
+		return err
+	}
+
+	repository := locator.GetInstance(gateway.GuardianRepositoryType).(gateway.GuardianRepository)
+	invitation, err := repository.GetInvitation(invitationID)
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	if invitation == nil || invitation.ChildID != childID || invitation.Status != model.GuardianInvitationPending {
+		return web.ErrNotFound
+	}
+
+	invitation.Status = model.GuardianInvitationRevoked
+	if err = repository.SaveInvitation(*invitation); err != nil {
+		return web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionGuardianInvitationRevoked,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(childID),
+		Details:    map[string]string{"invitation": invitationID},
+	})
+
+	return nil
+}
+
+// Accept consumes an invitation token and makes the principal a guardian of the child. The invitation must
+// have been sent to the email of the principal, who must be an observer user.
+func (g guardianUseCase) Accept(value string, locator gateway.ServiceLocator) (_ *model.Guardian, err error) {
+	span := startSpan(locator, "GuardianUseCase.Accept")
+	defer func() { span.End(err) }()
+
+	principal, err := GetPrincipal(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	users := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := users.Get(principal.UserID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	if user.Type != observer {
+		return nil, web.ErrForbidden
+	}
+
+	tokenService := locator.GetInstance(gateway.TokenServiceType).(gateway.TokenService)
+	token, err := tokenService.Parse(value)
+	if err != nil || token.Purpose != model.TokenPurposeGuardianInvitation {
+		return nil, web.ErrInvalidToken
+	}
+
+	repository := locator.GetInstance(gateway.GuardianRepositoryType).(gateway.GuardianRepository)
+	invitation, err := repository.GetInvitation(token.ID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if invitation == nil || invitation.Status != model.GuardianInvitationPending {
+		return nil, web.ErrInvalidToken
+	}
+
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, web.ErrForbidden
+	}
+
+	if invitation.EndsAt != 0 && g.now().Unix() >= invitation.EndsAt {
+		return nil, fmt.Errorf("%w: the delegation already ended", web.ErrBadRequest)
+	}
+
+	guardians, err := repository.GetGuardians(invitation.ChildID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if _, ok := findGuardian(guardians, user.ID); ok {
+		return nil, fmt.Errorf("%w: already a guardian of the child", web.ErrConflict)
+	}
+
+	tokenRepository := locator.GetInstance(gateway.TokenRepositoryType).(gateway.TokenRepository)
+	consumed, err := tokenRepository.Consume(token.ID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if !consumed {
+		return nil, web.ErrInvalidToken
+	}
+
+	guardian := model.Guardian{
+		ChildID:        invitation.ChildID,
+		ObserverUserID: user.ID,
+		Name:           user.Name,
+		LastName:       user.LastName,
+		Role:           invitation.Role,
+		StartsAt:       invitation.StartsAt,
+		EndsAt:         invitation.EndsAt,
+		InvitedBy:      invitation.InvitedBy,
+	}
+
+	if err = repository.SaveGuardian(guardian); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	invitation.Status = model.GuardianInvitationAccepted
+	if err = repository.SaveInvitation(*invitation); err != nil {
+		logger(locator).WithError(err).Error("guardian invitation could not be marked as accepted")
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionGuardianAdded,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(guardian.ChildID),
+		Details:    map[string]string{"guardian": userTarget(user.ID), "role": guardian.Role},
+	})
+
+	return &guardian, nil
+}
+
+// Update changes the role or the delegation of a guardian, such as extending a temporary pickup.
+// The primary guardian cannot be changed.
+func (g guardianUseCase) Update(guardian model.Guardian, locator gateway.ServiceLocator) (_ *model.Guardian, err error) {
+	span := startSpan(locator, "GuardianUseCase.Update")
+	defer func() { span.End(err) }()
+
+	now := g.now()
+
+	_, guardians, err := authorizeChild(locator, guardian.ChildID, model.ChildAccessManage, now)
+	if err != nil {
+		return nil, err
+	}
+
+	current, ok := findGuardian(guardians, guardian.ObserverUserID)
+	if !ok {
+		return nil, web.ErrNotFound
+	}
+
+	if current.Role == model.GuardianRolePrimary {
+		return nil, fmt.Errorf("%w: the primary guardian cannot be changed", web.ErrBadRequest)
+	}
+
+	if err = validateDelegation(guardian.Role, guardian.StartsAt, guardian.EndsAt, now); err != nil {
+		return nil, err
+	}
+
+	current.Role, current.StartsAt, current.EndsAt = guardian.Role, guardian.StartsAt, guardian.EndsAt
+
+	repository := locator.GetInstance(gateway.GuardianRepositoryType).(gateway.GuardianRepository)
+	if err = repository.SaveGuardian(current); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionGuardianUpdated,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(current.ChildID),
+		Details:    map[string]string{"guardian": userTarget(current.ObserverUserID), "role": current.Role},
+	})
+
+	return &current, nil
+}
+
+// Remove takes a guardian away from a child. The primary guardian can remove anyone else, and the other
+// guardians can only remove themselves.
+func (g guardianUseCase) Remove(childID uint, observerUserID uint, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "GuardianUseCase.Remove")
+	defer func() { span.End(err) }()
+
+	principal, err := GetPrincipal(locator)
+	if err != nil {
+		return err
+	}
+
+	repository := locator.GetInstance(gateway.GuardianRepositoryType).(gateway.GuardianRepository)
+
+	var guardians []model.Guardian
+	if principal.UserID == observerUserID {
+		if guardians, err = repository.GetGuardians(childID); err != nil {
+			return web.ErrInternalServerError
+		}
+	} else if _, guardians, err = authorizeChild(locator, childID, model.ChildAccessManage, g.now()); err != nil {
+		return err
+	}
+
+	guardian, ok := findGuardian(guardians, observerUserID)
+	if !ok {
+		return web.ErrNotFound
+	}
+
+	if guardian.Role == model.GuardianRolePrimary {
+		return fmt.Errorf("%w: the primary guardian cannot be removed", web.ErrBadRequest)
+	}
+
+	if err = repository.DeleteGuardian(childID, observerUserID); err != nil {
+		return web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionGuardianRemoved,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(childID),
+		Details:    map[string]string{"guardian": userTarget(observerUserID)},
+	})
+
+	return nil
+}
+
+// AuthorizeChild checks that the principal of the request is a guardian of the child whose role currently
+// allows the access, or a platform admin. Following the bus of a child requires model.ChildAccessTrack.
+func AuthorizeChild(locator gateway.ServiceLocator, childID uint, access string) (*model.Principal, error) {
+	principal, _, err := authorizeChild(locator, childID, access, time.Now())
+
+	return principal, err
+}
+
+// authorizeChild works as AuthorizeChild at the instant, and also returns the guardians of the child.
+func authorizeChild(locator gateway.ServiceLocator, childID uint, access string, now time.Time) (*model.Principal, []model.Guardian, error) {
+	principal, err := GetPrincipal(locator)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	repository := locator.GetInstance(gateway.GuardianRepositoryType).(gateway.GuardianRepository)
+	guardians, err := repository.GetGuardians(childID)
+	if err != nil {
+		return nil, nil, web.ErrInternalServerError
+	}
+
+	if len(guardians) == 0 {
+		return nil, nil, web.ErrNotFound
+	}
+
+	if principal.HasRole(model.RolePlatformAdmin) {
+		return principal, guardians, nil
+	}
+
+	if guardian, ok := findGuardian(guardians, principal.UserID); ok && guardian.Can(access, now) {
+		return principal, guardians, nil
+	}
+
+	return nil, nil, web.ErrForbidden
+}
+
+func findGuardian(guardians []model.Guardian, observerUserID uint) (model.Guardian, bool) {
+	for _, guardian := range guardians {
+		if guardian.ObserverUserID == observerUserID {
+			return guardian, true
+		}
+	}
+
+	return model.Guardian{}, false
+}
+
+// validateDelegation checks the role granted and its time bounds, which must end after they start and in the future.
+func validateDelegation(role string, startsAt int64, endsAt int64, now time.Time) error {
+	if !contains(model.GuardianRoles, role) {
+		return fmt.Errorf("%w: guardian role must be one of %v", web.ErrBadRequest, model.GuardianRoles)
+	}
+
+	if startsAt < 0 || endsAt < 0 {
+		return fmt.Errorf("%w: invalid delegation bounds", web.ErrBadRequest)
+	}
+
+	start := now.Unix()
+	if startsAt > start {
+		start = startsAt
+	}
+
+	if endsAt != 0 && endsAt <= start {
+		return fmt.Errorf("%w: the delegation must end after it starts and in the future", web.ErrBadRequest)
+	}
+
+	if role == model.GuardianRoleTemporaryPickup && (endsAt == 0 || endsAt-start > maxTemporaryPickupDays*24*60*60) {
+		return fmt.Errorf("%w: temporary pickups must end within %d days", web.ErrBadRequest, maxTemporaryPickupDays)
+	}
+
+	return nil
+}
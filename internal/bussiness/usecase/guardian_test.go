@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type guardianMocks struct {
+	guardians *mock_gateway.MockGuardianRepository
+	schools   *mock_gateway.MockSchoolRepository
+	users     *mock_gateway.MockUserRepository
+	tokens    *mock_gateway.MockTokenRepository
+	signer    *mock_gateway.MockTokenService
+	mailer    *mock_gateway.MockMailer
+	audit     *auditLog
+	locator   gateway.ServiceLocator
+}
+
+func newGuardianMocks(t *testing.T, principal model.Principal) guardianMocks {
+	ctrl := gomock.NewController(t)
+	m := guardianMocks{
+		guardians: mock_gateway.NewMockGuardianRepository(ctrl),
+		schools:   mock_gateway.NewMockSchoolRepository(ctrl),
+		users:     mock_gateway.NewMockUserRepository(ctrl),
+		tokens:    mock_gateway.NewMockTokenRepository(ctrl),
+		signer:    mock_gateway.NewMockTokenService(ctrl),
+		mailer:    mock_gateway.NewMockMailer(ctrl),
+	}
+
+	iocContext := ioc.NewContext()
+	iocContext.Bind(gateway.GuardianRepositoryType).ToInstance(m.guardians)
+	iocContext.Bind(gateway.SchoolRepositoryType).ToInstance(m.schools)
+	iocContext.Bind(gateway.UserRepositoryType).ToInstance(m.users)
+	iocContext.Bind(gateway.TokenRepositoryType).ToInstance(m.tokens)
+	iocContext.Bind(gateway.TokenServiceType).ToInstance(m.signer)
+	iocContext.Bind(gateway.MailerType).ToInstance(m.mailer)
+	iocContext.Bind(gateway.PrincipalType).ToInstance(&principal)
+	m.audit = bindAuditLog(iocContext)
+	m.locator = ioc.NewInjector(iocContext)
+
+	return m
+}
+
+func TestGuardians(t *testing.T) {
+	var (
+		now       = time.Date(2023, 7, 3, 12, 0, 0, 0, time.UTC)
+		week      = now.AddDate(0, 0, 7).Unix()
+		useCase   = guardianUseCase{baseURL: "https://dondeestan.app", now: func() time.Time { return now }}
+		parent    = model.Principal{UserID: 2, Username: "jperez", Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}}
+		grandma   = model.Principal{UserID: 5, Username: "mgomez", Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}}
+		guardians = []model.Guardian{
+			{ChildID: 1, ObserverUserID: 2, Role: model.GuardianRolePrimary},
+			{ChildID: 1, ObserverUserID: 5, Role: model.GuardianRoleTemporaryPickup, EndsAt: week, InvitedBy: 2},
+		}
+		invitation = model.GuardianInvitation{
+			ID: "4f1c2a", ChildID: 1, InvitedBy: 2, Email: "mgomez@mail.com", Role: model.GuardianRoleTemporaryPickup,
+			EndsAt: week, Status: model.GuardianInvitationPending, ExpiresAt: now.Add(guardianInvitationTTL).Unix(),
+		}
+	)
+
+	t.Run("Invite by the primary guardian", func(t *testing.T) {
+		m := newGuardianMocks(t, parent)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians[:1], nil)
+		m.schools.EXPECT().GetChild(uint(1)).Return(&model.Children{ID: 1, Name: "Sofía", ObserverUserID: 2}, nil)
+		m.signer.EXPECT().Sign(gomock.Any()).Return("signed", nil)
+		m.tokens.EXPECT().Save(gomock.Any()).Return(nil)
+		m.guardians.EXPECT().SaveInvitation(gomock.Any()).Return(nil)
+		m.mailer.EXPECT().Send("mgomez@mail.com", model.EmailTemplateGuardianInvitation, gomock.Any()).Return(nil)
+
+		sent, err := useCase.Invite(model.GuardianInvitation{ChildID: 1, Email: " mgomez@mail.com ", Role: model.GuardianRoleTemporaryPickup, EndsAt: week}, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), sent.InvitedBy)
+		assert.Equal(t, model.GuardianInvitationPending, sent.Status)
+		assert.Equal(t, []string{model.AuditActionGuardianInvited}, m.audit.actions())
+	})
+
+	t.Run("Invite by another guardian is forbidden", func(t *testing.T) {
+		m := newGuardianMocks(t, grandma)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil)
+
+		_, err := useCase.Invite(model.GuardianInvitation{ChildID: 1, Email: "other@mail.com", Role: model.GuardianRoleSecondary}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("Invite with invalid delegations", func(t *testing.T) {
+		for _, invalid := range []model.GuardianInvitation{
+			{ChildID: 1, Email: "mgomez@mail.com", Role: model.GuardianRolePrimary},
+			{ChildID: 1, Email: "mgomez@mail.com", Role: model.GuardianRoleTemporaryPickup},
+			{ChildID: 1, Email: "mgomez@mail.com", Role: model.GuardianRoleTemporaryPickup, EndsAt: now.AddDate(0, 2, 0).Unix()},
+			{ChildID: 1, Email: "mgomez@mail.com", Role: model.GuardianRoleSecondary, StartsAt: week, EndsAt: week},
+		} {
+			m := newGuardianMocks(t, parent)
+			m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians[:1], nil)
+
+			_, err := useCase.Invite(invalid, m.locator)
+			assert.True(t, errors.Is(err, web.ErrBadRequest), invalid)
+		}
+	})
+
+	t.Run("Accept makes the principal a guardian", func(t *testing.T) {
+		m := newGuardianMocks(t, grandma)
+		accepted := invitation
+		accepted.Status = model.GuardianInvitationAccepted
+
+		m.users.EXPECT().Get(uint(5)).Return(&model.User{ID: 5, Name: "María", LastName: "Gómez", Email: "MGomez@mail.com", Type: observer}, nil)
+		m.signer.EXPECT().Parse("signed").Return(&model.Token{ID: "4f1c2a", UserID: 2, Purpose: model.TokenPurposeGuardianInvitation}, nil)
+		pending := invitation
+		m.guardians.EXPECT().GetInvitation("4f1c2a").Return(&pending, nil)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians[:1], nil)
+		m.tokens.EXPECT().Consume("4f1c2a").Return(true, nil)
+		m.guardians.EXPECT().SaveGuardian(model.Guardian{
+			ChildID: 1, ObserverUserID: 5, Name: "María", LastName: "Gómez", Role: model.GuardianRoleTemporaryPickup, EndsAt: week, InvitedBy: 2,
+		}).Return(nil)
+		m.guardians.EXPECT().SaveInvitation(accepted).Return(nil)
+
+		guardian, err := useCase.Accept("signed", m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, model.GuardianRoleTemporaryPickup, guardian.Role)
+		assert.Equal(t, []string{model.AuditActionGuardianAdded}, m.audit.actions())
+	})
+
+	t.Run("Accept an invitation sent to another email", func(t *testing.T) {
+		m := newGuardianMocks(t, grandma)
+		m.users.EXPECT().Get(uint(5)).Return(&model.User{ID: 5, Email: "other@mail.com", Type: observer}, nil)
+		m.signer.EXPECT().Parse("signed").Return(&model.Token{ID: "4f1c2a", Purpose: model.TokenPurposeGuardianInvitation}, nil)
+		m.guardians.EXPECT().GetInvitation("4f1c2a").Return(&invitation, nil)
+
+		_, err := useCase.Accept("signed", m.locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("Accept a revoked invitation", func(t *testing.T) {
+		m := newGuardianMocks(t, grandma)
+		revoked := invitation
+		revoked.Status = model.GuardianInvitationRevoked
+
+		m.users.EXPECT().Get(uint(5)).Return(&model.User{ID: 5, Email: "mgomez@mail.com", Type: observer}, nil)
+		m.signer.EXPECT().Parse("signed").Return(&model.Token{ID: "4f1c2a", Purpose: model.TokenPurposeGuardianInvitation}, nil)
+		m.guardians.EXPECT().GetInvitation("4f1c2a").Return(&revoked, nil)
+
+		_, err := useCase.Accept("signed", m.locator)
+		assert.True(t, errors.Is(err, web.ErrInvalidToken))
+	})
+
+	t.Run("List after the delegation ended is forbidden", func(t *testing.T) {
+		m := newGuardianMocks(t, grandma)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil)
+		later := guardianUseCase{now: func() time.Time { return now.AddDate(0, 0, 8) }}
+
+		_, err := later.List(1, m.locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("Remove themselves", func(t *testing.T) {
+		m := newGuardianMocks(t, grandma)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil)
+		m.guardians.EXPECT().DeleteGuardian(uint(1), uint(5)).Return(nil)
+
+		assert.NoError(t, useCase.Remove(1, 5, m.locator))
+		assert.Equal(t, []string{model.AuditActionGuardianRemoved}, m.audit.actions())
+	})
+
+	t.Run("Remove another guardian is forbidden", func(t *testing.T) {
+		m := newGuardianMocks(t, grandma)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil)
+
+		err := useCase.Remove(1, 2, m.locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("Remove the primary guardian", func(t *testing.T) {
+		m := newGuardianMocks(t, parent)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil).Times(2)
+
+		err := useCase.Remove(1, 3, m.locator)
+		assert.True(t, errors.Is(err, web.ErrNotFound))
+
+		err = useCase.Remove(1, 2, m.locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+}
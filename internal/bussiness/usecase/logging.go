@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	log "github.com/sirupsen/logrus"
+)
+
+// logger returns the logger of the request the locator serves, or the standard logger when none is bound.
+func logger(locator gateway.ServiceLocator) gateway.Logger {
+	if l, ok := locator.GetInstance(gateway.LoggerType).(gateway.Logger); ok {
+		return l
+	}
+
+	return log.StandardLogger()
+}
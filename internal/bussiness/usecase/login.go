@@ -1,10 +1,13 @@
 package usecase
 
 import (
+	"crypto/subtle"
+	"strings"
+	"time"
+
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
-	"strings"
 )
 
 const (
@@ -13,52 +16,441 @@ const (
 	observer         = "observer"
 )
 
+// loginThrottle defines how failed logins are slowed down and locked out for a scope.
+// After freeAttempts failures each new attempt must wait twice as long as the previous one, up to maxDelay,
+// and after lockoutAttempts failures the scope is locked for lockoutDuration. Failures older than window are forgotten.
+type loginThrottle struct {
+	freeAttempts    int
+	maxDelay        time.Duration
+	lockoutAttempts int
+	lockoutDuration time.Duration
+	window          time.Duration
+}
+
+var loginThrottles = map[string]loginThrottle{
+	model.LoginAttemptScopeUsername: {
+		freeAttempts:    3,
+		maxDelay:        30 * time.Second,
+		lockoutAttempts: 10,
+		lockoutDuration: 15 * time.Minute,
+		window:          15 * time.Minute,
+	},
+	model.LoginAttemptScopeIP: {
+		freeAttempts:    10,
+		maxDelay:        30 * time.Second,
+		lockoutAttempts: 50,
+		lockoutDuration: 15 * time.Minute,
+		window:          15 * time.Minute,
+	},
+	model.LoginAttemptScopeTwoFactor: {
+		freeAttempts:    3,
+		maxDelay:        30 * time.Second,
+		lockoutAttempts: 10,
+		lockoutDuration: 15 * time.Minute,
+		window:          15 * time.Minute,
+	},
+	model.LoginAttemptScopePickup: {
+		freeAttempts:    3,
+		maxDelay:        30 * time.Second,
+		lockoutAttempts: 5,
+		lockoutDuration: 30 * time.Minute,
+		window:          time.Hour,
+	},
+}
+
 type (
 	LoginUseCase interface {
-		Login(model.Login, gateway.ServiceLocator) (*model.IUser, error)
+		Login(model.Login, gateway.ServiceLocator) (*model.Session, error)
+		VerifyTwoFactor(model.TwoFactorLogin, gateway.ServiceLocator) (*model.Session, error)
+		StartTwoFactorEnrollment(string, gateway.ServiceLocator) (*model.TwoFactorEnrollment, error)
 	}
 
-	loginUseCase struct{}
+	loginUseCase struct {
+		requireEmailVerification bool
+		now                      func() time.Time
+	}
 )
 
-func NewLoginUseCase() LoginUseCase {
-	return &loginUseCase{}
+// NewLoginUseCase creates the login use case. When requireEmailVerification is set, users whose email
+// was not verified cannot log in.
+func NewLoginUseCase(requireEmailVerification bool) LoginUseCase {
+	return &loginUseCase{
+		requireEmailVerification: requireEmailVerification,
+		now:                      time.Now,
+	}
 }
 
-func (l loginUseCase) Login(login model.Login, locator gateway.ServiceLocator) (*model.IUser, error) {
+// Login authenticates the user and issues an access token. Unknown usernames and wrong passwords return the
+// same error, and repeated failures for the same username or client IP are delayed and then temporarily locked out.
+// Users with a second factor, or that must enroll one, get a challenge token instead of the access token.
+func (l loginUseCase) Login(login model.Login, locator gateway.ServiceLocator) (_ *model.Session, err error) {
+	span := startSpan(locator, "LoginUseCase.Login")
+	defer func() { span.End(err) }()
+
+	keys := map[string]string{model.LoginAttemptScopeUsername: strings.ToLower(login.Username)}
+	if login.ClientIP != "" {
+		keys[model.LoginAttemptScopeIP] = login.ClientIP
+	}
+
+	attempts, err := checkAttempts(keys, l.now(), locator)
+	if err != nil {
+		recordFailedLogin(login, nil, "too_many_attempts", locator)
+		return nil, err
+	}
+
 	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
 	user, err := repository.FindByUsername(login.Username)
 	if err != nil {
 		return nil, web.ErrInternalServerError
 	}
 
-	if user == nil {
-		return nil, web.ErrNotFound
+	if user == nil || subtle.ConstantTimeCompare([]byte(user.Password), []byte(login.Password)) != 1 {
+		registerFailures(attempts, l.now(), locator)
+		recordFailedLogin(login, user, "invalid_credentials", locator)
+		return nil, web.ErrInvalidCredentials
+	}
+
+	resetAttempts(attempts, locator)
+
+	if !user.Enabled {
+		recordFailedLogin(login, user, "account_disabled", locator)
+		return nil, web.ErrAccountDisabled
+	}
+
+	if l.requireEmailVerification && !user.EmailVerified {
+		recordFailedLogin(login, user, "email_not_verified", locator)
+		return nil, web.ErrEmailNotVerified
+	}
+
+	u, err := getFullUser(*user, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := l.twoFactorStatus(*user, u, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	switch twoFactor {
+	case model.TwoFactorRequired:
+		return l.newChallenge(*user, model.TokenPurposeTwoFactor, locator)
+	case model.TwoFactorEnrollmentRequired:
+		return l.newChallenge(*user, model.TokenPurposeEnrollment, locator)
+	}
+
+	return l.newSession(u, user.TokenVersion, locator)
+}
+
+// VerifyTwoFactor completes a login with the challenge token returned by Login and a code of the authenticator app,
+// or a recovery code. When the challenge requires enrollment, the code confirms the new second factor and the
+// recovery codes are returned along with the session.
+func (l loginUseCase) VerifyTwoFactor(login model.TwoFactorLogin, locator gateway.ServiceLocator) (_ *model.Session, err error) {
+	span := startSpan(locator, "LoginUseCase.VerifyTwoFactor")
+	defer func() { span.End(err) }()
+
+	user, token, err := l.parseChallenge(login.ChallengeToken, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	var recoveryCodes []string
+
+	switch token.Purpose {
+	case model.TokenPurposeTwoFactor:
+		twoFactor, err := getTwoFactor(user.ID, locator)
+		if err != nil {
+			return nil, err
+		}
+
+		if twoFactor == nil || !twoFactor.Enabled {
+			return nil, web.ErrInvalidToken
+		}
+
+		if err = verifyTwoFactorCode(*twoFactor, login.TwoFactorCode, l.now(), locator); err != nil {
+			return nil, err
+		}
+	case model.TokenPurposeEnrollment:
+		if recoveryCodes, err = confirmTwoFactor(user.ID, login.Code, l.now(), locator); err != nil {
+			return nil, err
+		}
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	u, err := getFullUser(*user, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := l.newSession(u, user.TokenVersion, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	session.RecoveryCodes = recoveryCodes
+
+	return session, nil
+}
+
+// StartTwoFactorEnrollment starts the enrollment of a second factor for a user that must have one to log in.
+func (l loginUseCase) StartTwoFactorEnrollment(challengeToken string, locator gateway.ServiceLocator) (_ *model.TwoFactorEnrollment, err error) {
+	span := startSpan(locator, "LoginUseCase.StartTwoFactorEnrollment")
+	defer func() { span.End(err) }()
+
+	user, token, err := l.parseChallenge(challengeToken, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Purpose != model.TokenPurposeEnrollment {
+		return nil, web.ErrInvalidToken
+	}
+
+	return enrollTwoFactor(*user, locator)
+}
+
+// twoFactorStatus tells whether the user must verify a second factor, enroll one, or neither.
+func (l loginUseCase) twoFactorStatus(user model.User, u model.IUser, locator gateway.ServiceLocator) (string, error) {
+	twoFactor, err := getTwoFactor(user.ID, locator)
+	if err != nil {
+		return "", err
+	}
+
+	if twoFactor != nil && twoFactor.Enabled {
+		return model.TwoFactorRequired, nil
+	}
+
+	driver, ok := u.(*model.ObservedUser)
+	if !ok {
+		return "", nil
+	}
+
+	required, err := twoFactorRequired(driver.CompanyName, locator)
+	if err != nil || !required {
+		return "", err
+	}
+
+	return model.TwoFactorEnrollmentRequired, nil
+}
+
+// newChallenge signs a short-lived token that only allows completing the login with a second factor.
+func (l loginUseCase) newChallenge(user model.User, purpose string, locator gateway.ServiceLocator) (*model.Session, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	token := model.Token{
+		ID:        id,
+		UserID:    user.ID,
+		Purpose:   purpose,
+		ExpiresAt: l.now().Add(challengeTTL).Unix(),
+	}
+
+	tokenService := locator.GetInstance(gateway.TokenServiceType).(gateway.TokenService)
+	challengeToken, err := tokenService.Sign(token)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	twoFactor := model.TwoFactorRequired
+	if purpose == model.TokenPurposeEnrollment {
+		twoFactor = model.TwoFactorEnrollmentRequired
+	}
+
+	return &model.Session{
+		ExpiresAt:      token.ExpiresAt,
+		TwoFactor:      twoFactor,
+		ChallengeToken: challengeToken,
+	}, nil
+}
+
+// parseChallenge verifies a challenge token and loads its user, which must still be enabled.
+func (l loginUseCase) parseChallenge(challengeToken string, locator gateway.ServiceLocator) (*model.User, *model.Token, error) {
+	tokenService := locator.GetInstance(gateway.TokenServiceType).(gateway.TokenService)
+	token, err := tokenService.Parse(challengeToken)
+	if err != nil || (token.Purpose != model.TokenPurposeTwoFactor && token.Purpose != model.TokenPurposeEnrollment) {
+		return nil, nil, web.ErrInvalidToken
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(token.UserID)
+	if err != nil {
+		return nil, nil, web.ErrInvalidToken
 	}
 
-	if !strings.EqualFold(user.Password, login.Password) {
-		return nil, web.ErrIncorrectPassword
+	if !user.Enabled {
+		return nil, nil, web.ErrAccountDisabled
 	}
 
+	return user, token, nil
+}
+
+// recordFailedLogin records a rejected login. The user is nil when the username does not exist.
+func recordFailedLogin(login model.Login, user *model.User, reason string, locator gateway.ServiceLocator) {
+	entry := model.AuditEntry{
+		Action:  model.AuditActionLoginFailed,
+		Details: map[string]string{"username": login.Username, "reason": reason},
+	}
+
+	if user != nil {
+		entry.TargetType = model.AuditTargetUser
+		entry.TargetID = userTarget(user.ID)
+	}
+
+	recordAudit(locator, entry)
+	metricCollector(locator).CountLoginFailure(reason)
+}
+
+// getFullUser loads the driver or guardian data of the user.
+func getFullUser(user model.User, repository gateway.UserRepository) (model.IUser, error) {
 	var (
 		u   *model.IUser
-		odu model.ObservedUser
-		oru model.ObserverUser
+		err error
 	)
 
-	odu.User = *user
-	oru.User = *user
-
 	switch user.Type {
 	case observed:
-		u, err = repository.GetObservedUser(&odu)
+		u, err = repository.GetObservedUser(&model.ObservedUser{User: user})
 	case observer:
-		u, err = repository.GetObserverUser(&oru)
+		u, err = repository.GetObserverUser(&model.ObserverUser{User: user})
+	default:
+		return nil, web.ErrInternalServerError
 	}
 
 	if err != nil {
 		return nil, err
 	}
 
-	return u, nil
+	return *u, nil
+}
+
+// newSession signs an access token for the user and records the login.
+func (l loginUseCase) newSession(user model.IUser, tokenVersion uint, locator gateway.ServiceLocator) (*model.Session, error) {
+	session, err := newAccessToken(user.GetUserID(), tokenVersion, l.now(), locator)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:        model.AuditActionLogin,
+		ActorID:       user.GetUserID(),
+		ActorUsername: user.GetUsername(),
+		TargetType:    model.AuditTargetUser,
+		TargetID:      userTarget(user.GetUserID()),
+	})
+
+	session.User = user
+
+	return session, nil
+}
+
+// newAccessToken signs an access token for the given token version of the user, which is only valid until the
+// version is bumped.
+func newAccessToken(userID, tokenVersion uint, now time.Time, locator gateway.ServiceLocator) (*model.Session, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	token := model.Token{
+		ID:        id,
+		UserID:    userID,
+		Purpose:   model.TokenPurposeAccess,
+		ExpiresAt: now.Add(accessTokenTTL).Unix(),
+		Version:   tokenVersion,
+	}
+
+	tokenService := locator.GetInstance(gateway.TokenServiceType).(gateway.TokenService)
+	accessToken, err := tokenService.Sign(token)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return &model.Session{
+		AccessToken: accessToken,
+		ExpiresAt:   token.ExpiresAt,
+	}, nil
+}
+
+// checkAttempts obtains the failure counters of the given scopes and keys and fails when any of them is still blocked.
+func checkAttempts(keys map[string]string, now time.Time, locator gateway.ServiceLocator) ([]model.LoginAttempt, error) {
+	repository := locator.GetInstance(gateway.LoginAttemptRepositoryType).(gateway.LoginAttemptRepository)
+
+	var (
+		attempts   []model.LoginAttempt
+		retryAfter time.Duration
+	)
+
+	for scope, key := range keys {
+		attempt, err := repository.Get(scope, key)
+		if err != nil {
+			return nil, web.ErrInternalServerError
+		}
+
+		if wait := time.Unix(attempt.BlockedUntil, 0).Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+
+		attempts = append(attempts, *attempt)
+	}
+
+	if retryAfter > 0 {
+		return nil, web.NewRetryError(web.ErrTooManyAttempts, retryAfter)
+	}
+
+	return attempts, nil
+}
+
+func registerFailures(attempts []model.LoginAttempt, now time.Time, locator gateway.ServiceLocator) {
+	repository := locator.GetInstance(gateway.LoginAttemptRepositoryType).(gateway.LoginAttemptRepository)
+
+	for _, attempt := range attempts {
+		throttle := loginThrottles[attempt.Scope]
+
+		registered, err := repository.RegisterFailure(attempt.Scope, attempt.Key, now, throttle.window)
+		if err != nil {
+			logger(locator).WithError(err).Error("failed login could not be registered")
+			continue
+		}
+
+		if delay := throttle.delay(registered.Failures); delay > 0 {
+			if err = repository.Block(attempt.Scope, attempt.Key, now.Add(delay).Unix()); err != nil {
+				logger(locator).WithError(err).Error("failed login could not be throttled")
+			}
+		}
+	}
+}
+
+func resetAttempts(attempts []model.LoginAttempt, locator gateway.ServiceLocator) {
+	repository := locator.GetInstance(gateway.LoginAttemptRepositoryType).(gateway.LoginAttemptRepository)
+
+	for _, attempt := range attempts {
+		// A successful login from an IP does not forgive the failures made for other usernames.
+		if attempt.Scope == model.LoginAttemptScopeIP || attempt.Failures == 0 {
+			continue
+		}
+
+		if err := repository.Delete(attempt.Scope, attempt.Key); err != nil {
+			logger(locator).WithError(err).Error("failed logins could not be reset")
+		}
+	}
+}
+
+// delay returns how long the scope must wait after the given number of consecutive failures.
+func (t loginThrottle) delay(failures int) time.Duration {
+	if failures >= t.lockoutAttempts {
+		return t.lockoutDuration
+	}
+
+	if failures <= t.freeAttempts {
+		return 0
+	}
+
+	delay := time.Second << (failures - t.freeAttempts - 1)
+	if delay > t.maxDelay {
+		return t.maxDelay
+	}
+
+	return delay
 }
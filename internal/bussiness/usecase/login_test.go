@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogin(t *testing.T) {
+	var (
+		now     = time.Date(2022, 12, 10, 17, 49, 30, 0, time.UTC)
+		useCase = &loginUseCase{now: func() time.Time { return now }}
+		login   = model.Login{Username: "jperez", Password: "jperez1234", ClientIP: "10.0.0.1"}
+		user    = model.User{ID: 1, Username: "jperez", Password: "jperez1234", Enabled: true, Type: observed}
+	)
+
+	newLocator := func(t *testing.T) (*mock_gateway.MockUserRepository, *mock_gateway.MockLoginAttemptRepository, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		users := mock_gateway.NewMockUserRepository(ctrl)
+		attempts := mock_gateway.NewMockLoginAttemptRepository(ctrl)
+		tokens := mock_gateway.NewMockTokenService(ctrl)
+		tokens.EXPECT().Sign(gomock.Any()).Return("signed", nil).AnyTimes()
+		twoFactors := mock_gateway.NewMockTwoFactorRepository(ctrl)
+		twoFactors.EXPECT().Get(gomock.Any()).Return(nil, nil).AnyTimes()
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.UserRepositoryType).ToInstance(users)
+		iocContext.Bind(gateway.LoginAttemptRepositoryType).ToInstance(attempts)
+		iocContext.Bind(gateway.TokenServiceType).ToInstance(tokens)
+		iocContext.Bind(gateway.TwoFactorRepositoryType).ToInstance(twoFactors)
+		bindAuditLog(iocContext)
+
+		return users, attempts, ioc.NewInjector(iocContext)
+	}
+
+	emptyAttempts := func(attempts *mock_gateway.MockLoginAttemptRepository) {
+		attempts.EXPECT().Get(model.LoginAttemptScopeUsername, "jperez").Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeUsername, Key: "jperez"}, nil)
+		attempts.EXPECT().Get(model.LoginAttemptScopeIP, "10.0.0.1").Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeIP, Key: "10.0.0.1"}, nil)
+	}
+
+	t.Run("Login successful", func(t *testing.T) {
+		users, attempts, locator := newLocator(t)
+		emptyAttempts(attempts)
+		users.EXPECT().FindByUsername("jperez").Return(&user, nil)
+		expected := model.NewObservedUser(model.ObservedUser{User: user})
+		users.EXPECT().GetObservedUser(gomock.Any()).Return(&expected, nil)
+
+		session, err := useCase.Login(login, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "signed", session.AccessToken)
+		assert.Equal(t, now.Add(accessTokenTTL).Unix(), session.ExpiresAt)
+		assert.Equal(t, expected, session.User)
+	})
+
+	t.Run("Login unknown username and wrong password return the same error", func(t *testing.T) {
+		users, attempts, locator := newLocator(t)
+		emptyAttempts(attempts)
+		users.EXPECT().FindByUsername("jperez").Return(nil, nil)
+		attempts.EXPECT().RegisterFailure(gomock.Any(), gomock.Any(), now, 15*time.Minute).Times(2).Return(&model.LoginAttempt{Failures: 1}, nil)
+
+		_, errUnknown := useCase.Login(login, locator)
+
+		emptyAttempts(attempts)
+		users.EXPECT().FindByUsername("jperez").Return(&model.User{ID: 1, Username: "jperez", Password: "other", Enabled: true}, nil)
+		attempts.EXPECT().RegisterFailure(gomock.Any(), gomock.Any(), now, 15*time.Minute).Times(2).Return(&model.LoginAttempt{Failures: 1}, nil)
+
+		_, errPassword := useCase.Login(login, locator)
+
+		assert.Equal(t, web.ErrInvalidCredentials, errUnknown)
+		assert.Equal(t, errUnknown, errPassword)
+	})
+
+	t.Run("Login failure after free attempts is delayed", func(t *testing.T) {
+		users, attempts, locator := newLocator(t)
+		attempts.EXPECT().Get(model.LoginAttemptScopeUsername, "jperez").
+			Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeUsername, Key: "jperez", Failures: 3, LastFailureAt: now.Add(-time.Minute).Unix()}, nil)
+		attempts.EXPECT().Get(model.LoginAttemptScopeIP, "10.0.0.1").Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeIP, Key: "10.0.0.1"}, nil)
+		users.EXPECT().FindByUsername("jperez").Return(nil, nil)
+		attempts.EXPECT().RegisterFailure(model.LoginAttemptScopeUsername, "jperez", now, 15*time.Minute).
+			Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeUsername, Key: "jperez", Failures: 4, LastFailureAt: now.Unix()}, nil)
+		attempts.EXPECT().Block(model.LoginAttemptScopeUsername, "jperez", now.Add(time.Second).Unix()).Return(nil)
+		attempts.EXPECT().RegisterFailure(model.LoginAttemptScopeIP, "10.0.0.1", now, 15*time.Minute).
+			Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeIP, Key: "10.0.0.1", Failures: 1, LastFailureAt: now.Unix()}, nil)
+
+		_, err := useCase.Login(login, locator)
+		assert.Equal(t, web.ErrInvalidCredentials, err)
+	})
+
+	t.Run("Login locked out", func(t *testing.T) {
+		_, attempts, locator := newLocator(t)
+		attempts.EXPECT().Get(model.LoginAttemptScopeUsername, "jperez").
+			Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeUsername, Key: "jperez", Failures: 10, BlockedUntil: now.Add(10 * time.Minute).Unix()}, nil)
+		attempts.EXPECT().Get(model.LoginAttemptScopeIP, "10.0.0.1").Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeIP, Key: "10.0.0.1"}, nil)
+
+		_, err := useCase.Login(login, locator)
+
+		var retryErr *web.RetryError
+		assert.True(t, errors.Is(err, web.ErrTooManyAttempts))
+		assert.True(t, errors.As(err, &retryErr))
+		assert.Equal(t, 10*time.Minute, retryErr.RetryAfter)
+	})
+
+	t.Run("Login disabled account", func(t *testing.T) {
+		users, attempts, locator := newLocator(t)
+		emptyAttempts(attempts)
+		disabled := user
+		disabled.Enabled = false
+		users.EXPECT().FindByUsername("jperez").Return(&disabled, nil)
+
+		_, err := useCase.Login(login, locator)
+		assert.Equal(t, web.ErrAccountDisabled, err)
+	})
+
+	t.Run("Login failures are counted by reason", func(t *testing.T) {
+		users, attempts, locator := newLocator(t)
+		collector := mock_gateway.NewMockMetricCollector(gomock.NewController(t))
+		locator.Context().Bind(gateway.MetricCollectorType).ToInstance(collector)
+		emptyAttempts(attempts)
+		disabled := user
+		disabled.Enabled = false
+		users.EXPECT().FindByUsername("jperez").Return(&disabled, nil)
+		collector.EXPECT().CountLoginFailure("account_disabled")
+
+		_, err := useCase.Login(login, locator)
+		assert.Equal(t, web.ErrAccountDisabled, err)
+	})
+
+	t.Run("Login resets username failures", func(t *testing.T) {
+		users, attempts, locator := newLocator(t)
+		attempts.EXPECT().Get(model.LoginAttemptScopeUsername, "jperez").
+			Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeUsername, Key: "jperez", Failures: 2}, nil)
+		attempts.EXPECT().Get(model.LoginAttemptScopeIP, "10.0.0.1").
+			Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeIP, Key: "10.0.0.1", Failures: 2}, nil)
+		users.EXPECT().FindByUsername("jperez").Return(&user, nil)
+		attempts.EXPECT().Delete(model.LoginAttemptScopeUsername, "jperez").Return(nil)
+		expected := model.NewObservedUser(model.ObservedUser{User: user})
+		users.EXPECT().GetObservedUser(gomock.Any()).Return(&expected, nil)
+
+		_, err := useCase.Login(login, locator)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+)
+
+const (
+	notificationSent   = "sent"
+	notificationFailed = "failed"
+)
+
+type discardMetrics struct{}
+
+func (discardMetrics) CountLoginFailure(string) {}
+
+func (discardMetrics) CountNotification(string, string) {}
+
+// metricCollector returns the metric collector of the locator, or one discarding the metrics when none is bound.
+func metricCollector(locator gateway.ServiceLocator) gateway.MetricCollector {
+	if collector, ok := locator.GetInstance(gateway.MetricCollectorType).(gateway.MetricCollector); ok {
+		return collector
+	}
+
+	return discardMetrics{}
+}
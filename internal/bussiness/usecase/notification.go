@@ -0,0 +1,375 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	NotificationUseCaseType = "NotificationUseCase"
+
+	// digestClaimTTL is how long the items of a digest stay claimed by the replica sending it, after which another
+	// replica can deliver them if the first one died halfway.
+	digestClaimTTL = 5 * time.Minute
+
+	maxNotificationTitleLength = 255
+	maxNotificationBodyLength  = 1024
+)
+
+type (
+	NotificationUseCase interface {
+		GetPreferences(uint, gateway.ServiceLocator) (*model.NotificationSettings, error)
+		UpdatePreferences(model.NotificationSettings, gateway.ServiceLocator) (*model.NotificationSettings, error)
+		Dispatch(model.Notification, time.Time, gateway.ServiceLocator) error
+		DispatchToGuardians(uint, model.Notification, time.Time, gateway.ServiceLocator) error
+		Notify(uint, model.Notification, gateway.ServiceLocator) error
+		FlushDigests(time.Time, gateway.ServiceLocator) error
+	}
+
+	notificationUseCase struct {
+		now func() time.Time
+	}
+)
+
+func NewNotificationUseCase() NotificationUseCase {
+	return &notificationUseCase{
+		now: time.Now,
+	}
+}
+
+func (n notificationUseCase) GetPreferences(observerUserID uint, locator gateway.ServiceLocator) (_ *model.NotificationSettings, err error) {
+	span := startSpan(locator, "NotificationUseCase.GetPreferences")
+	defer func() { span.End(err) }()
+
+	if err := authorizeNotificationSettings(locator, observerUserID); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.NotificationRepositoryType).(gateway.NotificationRepository)
+	settings, err := repository.GetSettings(observerUserID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return settings, nil
+}
+
+func (n notificationUseCase) UpdatePreferences(settings model.NotificationSettings, locator gateway.ServiceLocator) (_ *model.NotificationSettings, err error) {
+	span := startSpan(locator, "NotificationUseCase.UpdatePreferences")
+	defer func() { span.End(err) }()
+
+	if err := authorizeNotificationSettings(locator, settings.ObserverUserID); err != nil {
+		return nil, err
+	}
+
+	if err := validateNotificationSettings(&settings); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.NotificationRepositoryType).(gateway.NotificationRepository)
+	saved, err := repository.SaveSettings(settings)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionPreferencesChanged,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(settings.ObserverUserID),
+	})
+
+	return saved, nil
+}
+
+// Dispatch enforces the observer preferences before sending a notification: disabled events are dropped,
+// events inside quiet hours or batched by the digest mode are queued, and SOS and handover events are always sent
+// right away. Trip events are dropped on the days none of the schools of the family has classes.
+func (n notificationUseCase) Dispatch(notification model.Notification, now time.Time, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "NotificationUseCase.Dispatch")
+	defer func() { span.End(err) }()
+
+	repository := locator.GetInstance(gateway.NotificationRepositoryType).(gateway.NotificationRepository)
+	settings, err := repository.GetSettings(notification.ObserverUserID)
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	preference := settings.Preference(notification.EventType)
+
+	if model.IsUrgentNotification(notification.EventType) {
+		return send(preference.Channels, notification, locator)
+	}
+
+	if !preference.Enabled {
+		return nil
+	}
+
+	if model.IsTripNotification(notification.EventType) {
+		service, err := familyHasService(notification.ObserverUserID, now, locator)
+		if err != nil {
+			logger(locator).WithError(err).Error("school calendar could not be consulted")
+		}
+
+		if !service {
+			return nil
+		}
+	}
+
+	quiet, err := settings.InQuietHours(now)
+	if err != nil {
+		logger(locator).WithError(err).Error("quiet hours could not be evaluated")
+	}
+
+	if quiet || (settings.DigestEnabled && model.IsLowPriorityNotification(notification.EventType)) {
+		if err = repository.SaveDigestItem(notification); err != nil {
+			return web.ErrInternalServerError
+		}
+		return nil
+	}
+
+	return send(preference.Channels, notification, locator)
+}
+
+// DispatchToGuardians dispatches a notification about a child to each of its guardians whose role allows
+// receiving it at the instant, such as the check-in notifications, which temporary pickups do not receive.
+func (n notificationUseCase) DispatchToGuardians(childID uint, notification model.Notification, now time.Time, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "NotificationUseCase.DispatchToGuardians")
+	defer func() { span.End(err) }()
+
+	repository := locator.GetInstance(gateway.GuardianRepositoryType).(gateway.GuardianRepository)
+	guardians, err := repository.GetGuardians(childID)
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	access := model.NotificationAccess(notification.EventType)
+
+	var failed error
+	for _, guardian := range guardians {
+		if !guardian.Active(now) || (access != "" && !guardian.Can(access, now)) {
+			continue
+		}
+
+		notification.ObserverUserID = guardian.ObserverUserID
+		if err = n.Dispatch(notification, now, locator); err != nil {
+			logger(locator).WithError(err).Error("notification could not be dispatched to a guardian")
+			failed = err
+		}
+	}
+
+	return failed
+}
+
+// Notify dispatches an event reported by a driver of the bus of a child, such as the bus getting close or an
+// announcement, to the guardians of the child.
+func (n notificationUseCase) Notify(childID uint, notification model.Notification, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "NotificationUseCase.Notify")
+	defer func() { span.End(err) }()
+
+	if _, err := authorizeDriver(locator, childID); err != nil {
+		return err
+	}
+
+	if !contains(model.NotificationEvents, notification.EventType) {
+		return fmt.Errorf("%w: unknown event type %s", web.ErrBadRequest, notification.EventType)
+	}
+
+	notification.Title = strings.TrimSpace(notification.Title)
+	notification.Body = strings.TrimSpace(notification.Body)
+	if notification.Title == "" || len(notification.Title) > maxNotificationTitleLength {
+		return fmt.Errorf("%w: title is required and must have up to %d characters", web.ErrBadRequest, maxNotificationTitleLength)
+	}
+
+	if len(notification.Body) > maxNotificationBodyLength {
+		return fmt.Errorf("%w: body must have up to %d characters", web.ErrBadRequest, maxNotificationBodyLength)
+	}
+
+	notification.ID, notification.CreatedAt = 0, ""
+
+	return n.DispatchToGuardians(childID, notification, n.now(), locator)
+}
+
+// FlushDigests sends a single notification per observer summarizing the queued events whose digest is due. The
+// items of each digest are claimed before sending, so replicas flushing at the same time do not deliver them twice.
+// A failing observer does not hold back the digests of the others.
+func (n notificationUseCase) FlushDigests(now time.Time, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "NotificationUseCase.FlushDigests")
+	defer func() { span.End(err) }()
+
+	repository := locator.GetInstance(gateway.NotificationRepositoryType).(gateway.NotificationRepository)
+	observerUserIDs, err := repository.GetObserversWithDueDigest()
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	failed := 0
+	for _, observerUserID := range observerUserIDs {
+		if err = flushDigest(observerUserID, now, repository, locator); err != nil {
+			logger(locator).WithError(err).WithField("observer_user_id", observerUserID).Error("notification digest could not be flushed")
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d notification digests could not be flushed", web.ErrInternalServerError, failed, len(observerUserIDs))
+	}
+
+	return nil
+}
+
+func flushDigest(observerUserID uint, now time.Time, repository gateway.NotificationRepository, locator gateway.ServiceLocator) error {
+	settings, err := repository.GetSettings(observerUserID)
+	if err != nil {
+		return err
+	}
+
+	if quiet, _ := settings.InQuietHours(now); quiet {
+		return nil
+	}
+
+	claim, err := newTokenID()
+	if err != nil {
+		return err
+	}
+
+	items, err := repository.ClaimDigestItems(observerUserID, claim, digestClaimTTL)
+	if err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	if err = send(digestChannels(settings, items), newDigest(observerUserID, items), locator); err != nil {
+		if releaseErr := repository.ReleaseDigestItems(claim); releaseErr != nil {
+			logger(locator).WithError(releaseErr).Error("notification digest claim could not be released")
+		}
+		return err
+	}
+
+	return repository.DeleteDigestItems(claim)
+}
+
+// authorizeNotificationSettings checks that the principal can manage notifications, and that the settings are its own
+// unless it is a platform admin.
+func authorizeNotificationSettings(locator gateway.ServiceLocator, observerUserID uint) error {
+	if _, err := Authorize(locator, model.PermissionManageNotifications); err != nil {
+		return err
+	}
+
+	_, err := AuthorizeSelf(locator, observerUserID)
+
+	return err
+}
+
+func send(channels []string, notification model.Notification, locator gateway.ServiceLocator) error {
+	sender := locator.GetInstance(gateway.NotificationSenderType).(gateway.NotificationSender)
+	metrics := metricCollector(locator)
+
+	for _, channel := range channels {
+		if err := sender.Send(channel, notification); err != nil {
+			metrics.CountNotification(channel, notificationFailed)
+			logger(locator).WithError(err).Error("notification could not be sent")
+			return web.ErrInternalServerError
+		}
+
+		metrics.CountNotification(channel, notificationSent)
+	}
+
+	return nil
+}
+
+func newDigest(observerUserID uint, items []model.Notification) model.Notification {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, item.Title)
+	}
+
+	return model.Notification{
+		ObserverUserID: observerUserID,
+		EventType:      model.NotificationEventDigest,
+		Title:          fmt.Sprintf("You have %d new notifications", len(items)),
+		Body:           strings.Join(lines, "\n"),
+	}
+}
+
+func digestChannels(settings *model.NotificationSettings, items []model.Notification) []string {
+	var channels []string
+
+	for _, item := range items {
+		for _, channel := range settings.Preference(item.EventType).Channels {
+			if !contains(channels, channel) {
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	return channels
+}
+
+func validateNotificationSettings(settings *model.NotificationSettings) error {
+	if settings.Timezone == "" {
+		settings.Timezone = model.DefaultNotificationTimezone
+	}
+
+	if _, err := time.LoadLocation(settings.Timezone); err != nil {
+		return fmt.Errorf("%w: invalid timezone %s", web.ErrBadRequest, settings.Timezone)
+	}
+
+	if settings.QuietHours.Enabled {
+		if _, err := model.ParseClock(settings.QuietHours.Start); err != nil {
+			return fmt.Errorf("%w: %s", web.ErrBadRequest, err.Error())
+		}
+		if _, err := model.ParseClock(settings.QuietHours.End); err != nil {
+			return fmt.Errorf("%w: %s", web.ErrBadRequest, err.Error())
+		}
+	}
+
+	if settings.DigestIntervalMinutes == 0 {
+		settings.DigestIntervalMinutes = model.DefaultNotificationDigestInterval
+	}
+
+	if settings.DigestIntervalMinutes < 0 {
+		return fmt.Errorf("%w: digest interval must be positive", web.ErrBadRequest)
+	}
+
+	var eventTypes []string
+	for _, preference := range settings.Preferences {
+		if !contains(model.NotificationEvents, preference.EventType) {
+			return fmt.Errorf("%w: unknown event type %s", web.ErrBadRequest, preference.EventType)
+		}
+
+		if contains(eventTypes, preference.EventType) {
+			return fmt.Errorf("%w: duplicate event type %s", web.ErrBadRequest, preference.EventType)
+		}
+		eventTypes = append(eventTypes, preference.EventType)
+
+		if preference.EventType == model.NotificationEventSOS && (!preference.Enabled || len(preference.Channels) == 0) {
+			return fmt.Errorf("%w: sos notifications cannot be disabled", web.ErrBadRequest)
+		}
+
+		for _, channel := range preference.Channels {
+			if !contains(model.NotificationChannels, channel) {
+				return fmt.Errorf("%w: unknown channel %s", web.ErrBadRequest, channel)
+			}
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
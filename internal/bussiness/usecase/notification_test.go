@@ -0,0 +1,433 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// familyCalendar is a calendar repository for a family whose children attend the given schools.
+type familyCalendar struct {
+	gateway.CalendarRepository
+	schools []uint
+}
+
+func (f familyCalendar) GetFamilySchools(uint) ([]uint, error) {
+	return f.schools, nil
+}
+
+func (f familyCalendar) GetFamilyCompanies(uint) ([]string, error) {
+	return nil, nil
+}
+
+// familySchools is a school repository holding the schools of a family.
+type familySchools struct {
+	gateway.SchoolRepository
+	schools map[uint]model.School
+}
+
+func (f familySchools) GetSchool(id uint) (*model.School, error) {
+	if school, ok := f.schools[id]; ok {
+		return &school, nil
+	}
+
+	return nil, nil
+}
+
+func newNotificationLocator(repository gateway.NotificationRepository, sender gateway.NotificationSender, schools ...model.School) gateway.ServiceLocator {
+	calendar := familyCalendar{}
+	schoolRepository := familySchools{schools: map[uint]model.School{}}
+	for _, school := range schools {
+		calendar.schools = append(calendar.schools, school.ID)
+		schoolRepository.schools[school.ID] = school
+	}
+
+	iocContext := ioc.NewContext()
+	iocContext.Bind(gateway.NotificationRepositoryType).ToInstance(repository)
+	iocContext.Bind(gateway.NotificationSenderType).ToInstance(sender)
+	iocContext.Bind(gateway.CalendarRepositoryType).ToInstance(calendar)
+	iocContext.Bind(gateway.SchoolRepositoryType).ToInstance(schoolRepository)
+	bindAuditLog(iocContext)
+	iocContext.Bind(gateway.PrincipalType).ToInstance(&model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}})
+
+	return ioc.NewInjector(iocContext)
+}
+
+func TestDispatch(t *testing.T) {
+	var (
+		useCase      = NewNotificationUseCase()
+		noon         = time.Date(2022, 12, 10, 15, 0, 0, 0, time.UTC)
+		midnight     = time.Date(2022, 12, 11, 3, 0, 0, 0, time.UTC)
+		notification = model.Notification{ObserverUserID: 2, EventType: model.NotificationEventArrival, Title: "arrival"}
+	)
+
+	quietSettings := model.NewNotificationSettings(2)
+	quietSettings.QuietHours = model.QuietHours{Enabled: true, Start: "22:00", End: "07:00"}
+
+	t.Run("Dispatch successful", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := model.NewNotificationSettings(2)
+
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+		sender.EXPECT().Send(model.NotificationChannelPush, notification).Return(nil)
+
+		err := useCase.Dispatch(notification, noon, newNotificationLocator(repository, sender))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dispatch trip event on a school holiday is dropped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := model.NewNotificationSettings(2)
+		school := model.School{
+			ID:            1,
+			Timezone:      "UTC",
+			BellSchedules: []model.BellSchedule{{Weekday: time.Saturday, Start: "08:00", End: "17:00"}},
+			Calendar:      []model.CalendarEntry{{Date: "2022-12-10", Kind: model.CalendarEntryHoliday}},
+		}
+
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+
+		err := useCase.Dispatch(notification, noon, newNotificationLocator(repository, sender, school))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dispatch trip event on a school day is sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := model.NewNotificationSettings(2)
+		school := model.School{
+			ID:            1,
+			Timezone:      "UTC",
+			BellSchedules: []model.BellSchedule{{Weekday: time.Saturday, Start: "08:00", End: "17:00"}},
+		}
+
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+		sender.EXPECT().Send(model.NotificationChannelPush, notification).Return(nil)
+
+		err := useCase.Dispatch(notification, noon, newNotificationLocator(repository, sender, school))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dispatch disabled event is dropped", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := model.NewNotificationSettings(2)
+		settings.Preferences[1].Enabled = false
+
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+
+		err := useCase.Dispatch(notification, noon, newNotificationLocator(repository, sender))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dispatch in quiet hours is queued", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+
+		repository.EXPECT().GetSettings(uint(2)).Return(&quietSettings, nil)
+		repository.EXPECT().SaveDigestItem(notification).Return(nil)
+
+		err := useCase.Dispatch(notification, midnight, newNotificationLocator(repository, sender))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dispatch sos ignores quiet hours", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		sos := model.Notification{ObserverUserID: 2, EventType: model.NotificationEventSOS, Title: "sos"}
+
+		repository.EXPECT().GetSettings(uint(2)).Return(&quietSettings, nil)
+		sender.EXPECT().Send(model.NotificationChannelPush, sos).Return(nil)
+
+		err := useCase.Dispatch(sos, midnight, newNotificationLocator(repository, sender))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dispatch handover on a school holiday in quiet hours is sent", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := quietSettings
+		settings.DigestEnabled = true
+		school := model.School{
+			ID:            1,
+			Timezone:      "UTC",
+			BellSchedules: []model.BellSchedule{{Weekday: time.Sunday, Start: "08:00", End: "17:00"}},
+			Calendar:      []model.CalendarEntry{{Date: "2022-12-11", Kind: model.CalendarEntryHoliday}},
+		}
+		handover := model.Notification{ObserverUserID: 2, EventType: model.NotificationEventHandover, Title: "Child handed over"}
+
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+		sender.EXPECT().Send(model.NotificationChannelPush, handover).Return(nil)
+
+		err := useCase.Dispatch(handover, midnight, newNotificationLocator(repository, sender, school))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dispatch low priority in digest mode is queued", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := model.NewNotificationSettings(2)
+		settings.DigestEnabled = true
+		announcement := model.Notification{ObserverUserID: 2, EventType: model.NotificationEventAnnouncement, Title: "announcement"}
+
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+		repository.EXPECT().SaveDigestItem(announcement).Return(nil)
+
+		err := useCase.Dispatch(announcement, noon, newNotificationLocator(repository, sender))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Dispatch settings error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+
+		repository.EXPECT().GetSettings(uint(2)).Return(nil, errors.New("db error"))
+
+		err := useCase.Dispatch(notification, noon, newNotificationLocator(repository, sender))
+		assert.True(t, errors.Is(err, web.ErrInternalServerError))
+	})
+}
+
+func TestDispatchToGuardians(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repository := mock_gateway.NewMockNotificationRepository(ctrl)
+	sender := mock_gateway.NewMockNotificationSender(ctrl)
+	guardians := mock_gateway.NewMockGuardianRepository(ctrl)
+
+	iocContext := ioc.NewContext()
+	iocContext.Bind(gateway.NotificationRepositoryType).ToInstance(repository)
+	iocContext.Bind(gateway.NotificationSenderType).ToInstance(sender)
+	iocContext.Bind(gateway.GuardianRepositoryType).ToInstance(guardians)
+	iocContext.Bind(gateway.CalendarRepositoryType).ToInstance(familyCalendar{})
+	bindAuditLog(iocContext)
+	locator := ioc.NewInjector(iocContext)
+
+	noon := time.Date(2022, 12, 10, 15, 0, 0, 0, time.UTC)
+	checkIn := model.Notification{EventType: model.NotificationEventCheckIn, Title: "check-in"}
+	settings := model.NewNotificationSettings(2)
+	secondarySettings := model.NewNotificationSettings(3)
+
+	guardians.EXPECT().GetGuardians(uint(1)).Return([]model.Guardian{
+		{ChildID: 1, ObserverUserID: 2, Role: model.GuardianRolePrimary},
+		{ChildID: 1, ObserverUserID: 3, Role: model.GuardianRoleSecondary},
+		{ChildID: 1, ObserverUserID: 4, Role: model.GuardianRoleSecondary, EndsAt: noon.Add(-time.Hour).Unix()},
+		{ChildID: 1, ObserverUserID: 5, Role: model.GuardianRoleTemporaryPickup, EndsAt: noon.Add(time.Hour).Unix()},
+	}, nil)
+	repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+	repository.EXPECT().GetSettings(uint(3)).Return(&secondarySettings, nil)
+	sender.EXPECT().Send(model.NotificationChannelPush, model.Notification{ObserverUserID: 2, EventType: model.NotificationEventCheckIn, Title: "check-in"}).Return(nil)
+	sender.EXPECT().Send(model.NotificationChannelPush, model.Notification{ObserverUserID: 3, EventType: model.NotificationEventCheckIn, Title: "check-in"}).Return(nil)
+
+	assert.NoError(t, NewNotificationUseCase().DispatchToGuardians(1, checkIn, noon, locator))
+}
+
+func TestNotify(t *testing.T) {
+	noon := time.Date(2022, 12, 10, 15, 0, 0, 0, time.UTC)
+	useCase := &notificationUseCase{now: func() time.Time { return noon }}
+	proximity := model.Notification{EventType: model.NotificationEventProximity, Title: " The bus is close "}
+
+	newLocator := func(ctrl *gomock.Controller, repository gateway.NotificationRepository, sender gateway.NotificationSender, driver bool) (gateway.ServiceLocator, *mock_gateway.MockGuardianRepository) {
+		pickups := mock_gateway.NewMockPickupRepository(ctrl)
+		pickups.EXPECT().IsDriverOf(uint(9), uint(1)).Return(driver, nil)
+		guardians := mock_gateway.NewMockGuardianRepository(ctrl)
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.NotificationRepositoryType).ToInstance(repository)
+		iocContext.Bind(gateway.NotificationSenderType).ToInstance(sender)
+		iocContext.Bind(gateway.GuardianRepositoryType).ToInstance(guardians)
+		iocContext.Bind(gateway.PickupRepositoryType).ToInstance(pickups)
+		iocContext.Bind(gateway.CalendarRepositoryType).ToInstance(familyCalendar{})
+		iocContext.Bind(gateway.PrincipalType).ToInstance(&model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RoleDriver}}})
+
+		return ioc.NewInjector(iocContext), guardians
+	}
+
+	t.Run("Notify dispatches to the guardians", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := model.NewNotificationSettings(2)
+		locator, guardians := newLocator(ctrl, repository, sender, true)
+
+		guardians.EXPECT().GetGuardians(uint(1)).Return([]model.Guardian{{ChildID: 1, ObserverUserID: 2, Role: model.GuardianRolePrimary}}, nil)
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+		sender.EXPECT().Send(model.NotificationChannelPush, model.Notification{ObserverUserID: 2, EventType: model.NotificationEventProximity, Title: "The bus is close"}).Return(nil)
+
+		assert.NoError(t, useCase.Notify(1, proximity, locator))
+	})
+
+	t.Run("Notify by someone who does not drive the child", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		locator, _ := newLocator(ctrl, mock_gateway.NewMockNotificationRepository(ctrl), mock_gateway.NewMockNotificationSender(ctrl), false)
+
+		err := useCase.Notify(1, proximity, locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("Notify unknown event type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		locator, _ := newLocator(ctrl, mock_gateway.NewMockNotificationRepository(ctrl), mock_gateway.NewMockNotificationSender(ctrl), true)
+
+		err := useCase.Notify(1, model.Notification{EventType: model.NotificationEventDigest, Title: "digest"}, locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+}
+
+func TestFlushDigests(t *testing.T) {
+	useCase := NewNotificationUseCase()
+	noon := time.Date(2022, 12, 10, 15, 0, 0, 0, time.UTC)
+	items := []model.Notification{{ID: 7, ObserverUserID: 2, EventType: model.NotificationEventAnnouncement, Title: "announcement"}}
+
+	t.Run("FlushDigests sends and deletes the claimed items", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := model.NewNotificationSettings(2)
+
+		var claim string
+		repository.EXPECT().GetObserversWithDueDigest().Return([]uint{2}, nil)
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+		repository.EXPECT().ClaimDigestItems(uint(2), gomock.Any(), digestClaimTTL).
+			DoAndReturn(func(_ uint, c string, _ time.Duration) ([]model.Notification, error) {
+				claim = c
+				return items, nil
+			})
+		sender.EXPECT().Send(model.NotificationChannelPush, newDigest(2, items)).Return(nil)
+		repository.EXPECT().DeleteDigestItems(gomock.Any()).DoAndReturn(func(c string) error {
+			assert.Equal(t, claim, c)
+			return nil
+		})
+
+		assert.NoError(t, useCase.FlushDigests(noon, newNotificationLocator(repository, sender)))
+	})
+
+	t.Run("FlushDigests skips the items claimed by another replica", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := model.NewNotificationSettings(2)
+
+		repository.EXPECT().GetObserversWithDueDigest().Return([]uint{2}, nil)
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+		repository.EXPECT().ClaimDigestItems(uint(2), gomock.Any(), digestClaimTTL).Return(nil, nil)
+
+		assert.NoError(t, useCase.FlushDigests(noon, newNotificationLocator(repository, sender)))
+	})
+
+	t.Run("FlushDigests continues past a failing observer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		sender := mock_gateway.NewMockNotificationSender(ctrl)
+		settings := model.NewNotificationSettings(2)
+		otherSettings := model.NewNotificationSettings(3)
+		otherItems := []model.Notification{{ID: 8, ObserverUserID: 3, EventType: model.NotificationEventAnnouncement, Title: "announcement"}}
+
+		repository.EXPECT().GetObserversWithDueDigest().Return([]uint{2, 3, 4}, nil)
+		repository.EXPECT().GetSettings(uint(2)).Return(&settings, nil)
+		repository.EXPECT().ClaimDigestItems(uint(2), gomock.Any(), digestClaimTTL).Return(items, nil)
+		sender.EXPECT().Send(model.NotificationChannelPush, newDigest(2, items)).Return(errors.New("push error"))
+		repository.EXPECT().ReleaseDigestItems(gomock.Any()).Return(nil)
+		repository.EXPECT().GetSettings(uint(3)).Return(&otherSettings, nil)
+		repository.EXPECT().ClaimDigestItems(uint(3), gomock.Any(), digestClaimTTL).Return(otherItems, nil)
+		sender.EXPECT().Send(model.NotificationChannelPush, newDigest(3, otherItems)).Return(nil)
+		repository.EXPECT().DeleteDigestItems(gomock.Any()).Return(nil)
+		repository.EXPECT().GetSettings(uint(4)).Return(nil, errors.New("db error"))
+
+		err := useCase.FlushDigests(noon, newNotificationLocator(repository, sender))
+		assert.True(t, errors.Is(err, web.ErrInternalServerError))
+	})
+}
+
+func TestUpdatePreferences(t *testing.T) {
+	useCase := NewNotificationUseCase()
+
+	t.Run("UpdatePreferences of other user is forbidden", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		settings := model.NewNotificationSettings(3)
+
+		saved, err := useCase.UpdatePreferences(settings, newNotificationLocator(repository, nil))
+		assert.Nil(t, saved)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("UpdatePreferences without the notification permission is forbidden", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		settings := model.NewNotificationSettings(2)
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.NotificationRepositoryType).ToInstance(repository)
+		iocContext.Bind(gateway.PrincipalType).ToInstance(&model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleDriver}}})
+
+		saved, err := useCase.UpdatePreferences(settings, ioc.NewInjector(iocContext))
+		assert.Nil(t, saved)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("UpdatePreferences sos cannot be disabled", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		settings := model.NewNotificationSettings(2)
+		settings.Preferences[4].Enabled = false
+
+		saved, err := useCase.UpdatePreferences(settings, newNotificationLocator(repository, nil))
+		assert.Nil(t, saved)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("UpdatePreferences duplicate event type", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		settings := model.NewNotificationSettings(2)
+		settings.Preferences = append(settings.Preferences, settings.Preferences[0])
+
+		saved, err := useCase.UpdatePreferences(settings, newNotificationLocator(repository, nil))
+		assert.Nil(t, saved)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+		assert.Contains(t, err.Error(), "duplicate event type")
+	})
+
+	t.Run("UpdatePreferences invalid timezone", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		settings := model.NewNotificationSettings(2)
+		settings.Timezone = "Mars/Olympus_Mons"
+
+		saved, err := useCase.UpdatePreferences(settings, newNotificationLocator(repository, nil))
+		assert.Nil(t, saved)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("UpdatePreferences successful", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		repository := mock_gateway.NewMockNotificationRepository(ctrl)
+		settings := model.NewNotificationSettings(2)
+		settings.QuietHours = model.QuietHours{Enabled: true, Start: "22:00", End: "07:00"}
+
+		repository.EXPECT().SaveSettings(settings).Return(&settings, nil)
+
+		saved, err := useCase.UpdatePreferences(settings, newNotificationLocator(repository, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, &settings, saved)
+	})
+}
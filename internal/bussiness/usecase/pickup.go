@@ -0,0 +1,401 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	PickupUseCaseType = "PickupUseCase"
+
+	pickupPINTTL          = 12 * time.Hour
+	maxPickupNameLength   = 45
+	minPickupIDNumberSize = 6
+	maxPickupIDNumberSize = 20
+)
+
+var (
+	jpegSignature = []byte{0xFF, 0xD8, 0xFF}
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+)
+
+type (
+	PickupUseCase interface {
+		List(uint, gateway.ServiceLocator) ([]model.AuthorizedPickup, error)
+		Create(model.AuthorizedPickup, gateway.ServiceLocator) (*model.AuthorizedPickup, error)
+		Update(model.AuthorizedPickup, gateway.ServiceLocator) (*model.AuthorizedPickup, error)
+		Delete(uint, uint, gateway.ServiceLocator) error
+		GeneratePIN(uint, gateway.ServiceLocator) (*model.PickupPIN, error)
+		Verify(uint, model.PickupVerification, gateway.ServiceLocator) (*model.PickupHandover, error)
+		History(uint, gateway.ServiceLocator) ([]model.PickupHandover, error)
+	}
+
+	pickupUseCase struct {
+		now func() time.Time
+	}
+)
+
+func NewPickupUseCase() PickupUseCase {
+	return &pickupUseCase{
+		now: time.Now,
+	}
+}
+
+// List obtains the people authorized to receive a child, for its guardians and the drivers of its bus.
+func (p pickupUseCase) List(childID uint, locator gateway.ServiceLocator) (_ []model.AuthorizedPickup, err error) {
+	span := startSpan(locator, "PickupUseCase.List")
+	defer func() { span.End(err) }()
+
+	if _, err := authorizeDriver(locator, childID); err != nil {
+		if _, _, err = authorizeChild(locator, childID, model.ChildAccessTrack, p.now()); err != nil {
+			return nil, err
+		}
+	}
+
+	repository := locator.GetInstance(gateway.PickupRepositoryType).(gateway.PickupRepository)
+	pickups, err := repository.GetPickups(childID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return pickups, nil
+}
+
+// Create authorizes a new person to receive a child, up to model.MaxAuthorizedPickups. Only the primary guardian
+// can change who receives the child.
+func (p pickupUseCase) Create(pickup model.AuthorizedPickup, locator gateway.ServiceLocator) (_ *model.AuthorizedPickup, err error) {
+	span := startSpan(locator, "PickupUseCase.Create")
+	defer func() { span.End(err) }()
+
+	pickup.ID = 0
+
+	return p.save(pickup, model.AuditActionPickupAdded, locator)
+}
+
+// Update replaces the data of an authorized pickup.
+func (p pickupUseCase) Update(pickup model.AuthorizedPickup, locator gateway.ServiceLocator) (_ *model.AuthorizedPickup, err error) {
+	span := startSpan(locator, "PickupUseCase.Update")
+	defer func() { span.End(err) }()
+
+	return p.save(pickup, model.AuditActionPickupUpdated, locator)
+}
+
+// Delete takes the authorization to receive a child away from a person.
+func (p pickupUseCase) Delete(childID uint, pickupID uint, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "PickupUseCase.Delete")
+	defer func() { span.End(err) }()
+
+	if _, _, err := authorizeChild(locator, childID, model.ChildAccessManage, p.now()); err != nil {
+		return err
+	}
+
+	repository := locator.GetInstance(gateway.PickupRepositoryType).(gateway.PickupRepository)
+	pickup, err := repository.GetPickup(childID, pickupID)
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	if pickup == nil {
+		return web.ErrNotFound
+	}
+
+	if err = repository.DeletePickup(childID, pickupID); err != nil {
+		return web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionPickupRemoved,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(childID),
+		Details:    map[string]string{"pickup": fmt.Sprint(pickupID)},
+	})
+
+	return nil
+}
+
+// GeneratePIN creates a one-time PIN that lets whoever shows it receive the child, replacing the previous one.
+// Every guardian allowed to pick the child up can generate it, and the PIN is only returned this once.
+func (p pickupUseCase) GeneratePIN(childID uint, locator gateway.ServiceLocator) (_ *model.PickupPIN, err error) {
+	span := startSpan(locator, "PickupUseCase.GeneratePIN")
+	defer func() { span.End(err) }()
+
+	now := p.now()
+
+	principal, _, err := authorizeChild(locator, childID, model.ChildAccessPickup, now)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(model.PickupPINLength), nil)
+	value, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	pin := model.PickupPIN{
+		ChildID:   childID,
+		PIN:       fmt.Sprintf("%0*d", model.PickupPINLength, value),
+		ExpiresAt: now.Add(pickupPINTTL).Unix(),
+		CreatedBy: principal.UserID,
+	}
+	pin.Hash = hashPickupPIN(childID, pin.PIN)
+
+	repository := locator.GetInstance(gateway.PickupRepositoryType).(gateway.PickupRepository)
+	if err = repository.SavePIN(pin); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionPickupPINGenerated,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(childID),
+	})
+
+	return &pin, nil
+}
+
+// Verify checks, for a driver of the bus of the child, that the person receiving it shows the pickup PIN or the
+// ID number of an authorized pickup. The handover is recorded and the guardians are notified. Failed verifications
+// are throttled per child, so the PIN cannot be guessed, and an accepted PIN cannot be used again.
+func (p pickupUseCase) Verify(childID uint, verification model.PickupVerification, locator gateway.ServiceLocator) (_ *model.PickupHandover, err error) {
+	span := startSpan(locator, "PickupUseCase.Verify")
+	defer func() { span.End(err) }()
+
+	now := p.now()
+
+	principal, err := authorizeDriver(locator, childID)
+	if err != nil {
+		return nil, err
+	}
+
+	verification.PIN = strings.TrimSpace(verification.PIN)
+	verification.IDNumber = normalizeIDNumber(verification.IDNumber)
+	verification.ReceivedBy = strings.TrimSpace(verification.ReceivedBy)
+
+	if (verification.PIN == "") == (verification.IDNumber == "") {
+		return nil, fmt.Errorf("%w: either the PIN or the ID number is required", web.ErrBadRequest)
+	}
+
+	if verification.PIN != "" && (verification.ReceivedBy == "" || len(verification.ReceivedBy) > 2*maxPickupNameLength) {
+		return nil, fmt.Errorf("%w: the name of who receives the child is required along with the PIN", web.ErrBadRequest)
+	}
+
+	attempts, err := checkAttempts(map[string]string{model.LoginAttemptScopePickup: fmt.Sprint(childID)}, now, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.PickupRepositoryType).(gateway.PickupRepository)
+	handover := model.PickupHandover{
+		ChildID:      childID,
+		DriverUserID: principal.UserID,
+		OccurredAt:   now.Unix(),
+	}
+
+	var authorized bool
+	if verification.PIN != "" {
+		// Redeeming deletes the PIN in the same statement that checks it, so concurrent verifications cannot
+		// both accept it.
+		authorized, err = repository.RedeemPIN(childID, hashPickupPIN(childID, verification.PIN), now.Unix())
+		handover.Method, handover.ReceivedBy = model.PickupMethodPIN, verification.ReceivedBy
+	} else {
+		var pickup *model.AuthorizedPickup
+		if pickup, err = p.findPickup(childID, verification.IDNumber, repository); pickup != nil {
+			authorized = true
+			handover.AuthorizedPickupID = pickup.ID
+			handover.ReceivedBy = strings.TrimSpace(pickup.Name + " " + pickup.LastName)
+		}
+		handover.Method = model.PickupMethodIDNumber
+	}
+
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if !authorized {
+		registerFailures(attempts, now, locator)
+		recordAudit(locator, model.AuditEntry{
+			Action:     model.AuditActionPickupRejected,
+			TargetType: model.AuditTargetChild,
+			TargetID:   fmt.Sprint(childID),
+			Details:    map[string]string{"method": handover.Method},
+		})
+
+		return nil, fmt.Errorf("%w: the person is not authorized to receive the child", web.ErrForbidden)
+	}
+
+	resetAttempts(attempts, locator)
+
+	if err = repository.SaveHandover(handover); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionPickupHandedOver,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(childID),
+		Details:    map[string]string{"method": handover.Method, "received_by": handover.ReceivedBy},
+	})
+
+	notifications := locator.GetInstance(NotificationUseCaseType).(NotificationUseCase)
+	err = notifications.DispatchToGuardians(childID, model.Notification{
+		EventType: model.NotificationEventHandover,
+		Title:     "Child handed over",
+		Body:      fmt.Sprintf("The driver handed your child over to %s.", handover.ReceivedBy),
+	}, now, locator)
+	if err != nil {
+		logger(locator).WithError(err).Error("handover notification could not be dispatched")
+	}
+
+	return &handover, nil
+}
+
+// History obtains who received a child lately, for its guardians.
+func (p pickupUseCase) History(childID uint, locator gateway.ServiceLocator) (_ []model.PickupHandover, err error) {
+	span := startSpan(locator, "PickupUseCase.History")
+	defer func() { span.End(err) }()
+
+	if _, _, err := authorizeChild(locator, childID, model.ChildAccessTrack, p.now()); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.PickupRepositoryType).(gateway.PickupRepository)
+	handovers, err := repository.GetHandovers(childID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return handovers, nil
+}
+
+func (p pickupUseCase) save(pickup model.AuthorizedPickup, action string, locator gateway.ServiceLocator) (*model.AuthorizedPickup, error) {
+	if _, _, err := authorizeChild(locator, pickup.ChildID, model.ChildAccessManage, p.now()); err != nil {
+		return nil, err
+	}
+
+	if err := validatePickup(&pickup); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.PickupRepositoryType).(gateway.PickupRepository)
+	pickups, err := repository.GetPickups(pickup.ChildID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	found := pickup.ID == 0
+	for _, other := range pickups {
+		if other.ID == pickup.ID {
+			found = true
+		} else if normalizeIDNumber(other.IDNumber) == pickup.IDNumber {
+			return nil, fmt.Errorf("%w: the ID number is already authorized", web.ErrConflict)
+		}
+	}
+
+	if !found {
+		return nil, web.ErrNotFound
+	}
+
+	if pickup.ID == 0 && len(pickups) >= model.MaxAuthorizedPickups {
+		return nil, fmt.Errorf("%w: up to %d people can be authorized", web.ErrBadRequest, model.MaxAuthorizedPickups)
+	}
+
+	saved, err := repository.SavePickup(pickup)
+	if err != nil || saved == nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     action,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(saved.ChildID),
+		Details:    map[string]string{"pickup": fmt.Sprint(saved.ID)},
+	})
+
+	return saved, nil
+}
+
+func (p pickupUseCase) findPickup(childID uint, idNumber string, repository gateway.PickupRepository) (*model.AuthorizedPickup, error) {
+	pickups, err := repository.GetPickups(childID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pickup := range pickups {
+		if subtle.ConstantTimeCompare([]byte(normalizeIDNumber(pickup.IDNumber)), []byte(idNumber)) == 1 {
+			return &pickup, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// authorizeDriver checks that the principal of the request drives the bus of the child.
+func authorizeDriver(locator gateway.ServiceLocator, childID uint) (*model.Principal, error) {
+	principal, err := GetPrincipal(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.PickupRepositoryType).(gateway.PickupRepository)
+	driver, err := repository.IsDriverOf(principal.UserID, childID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if !driver {
+		return nil, web.ErrForbidden
+	}
+
+	return principal, nil
+}
+
+func validatePickup(pickup *model.AuthorizedPickup) error {
+	pickup.Name = strings.TrimSpace(pickup.Name)
+	pickup.LastName = strings.TrimSpace(pickup.LastName)
+	if pickup.Name == "" || pickup.LastName == "" || len(pickup.Name) > maxPickupNameLength || len(pickup.LastName) > maxPickupNameLength {
+		return fmt.Errorf("%w: name and last name are required and must have up to %d characters", web.ErrBadRequest, maxPickupNameLength)
+	}
+
+	pickup.IDNumber = normalizeIDNumber(pickup.IDNumber)
+	if len(pickup.IDNumber) < minPickupIDNumberSize || len(pickup.IDNumber) > maxPickupIDNumberSize {
+		return fmt.Errorf("%w: the ID number must have between %d and %d characters", web.ErrBadRequest, minPickupIDNumberSize, maxPickupIDNumberSize)
+	}
+
+	if !contains(model.PickupRelationships, pickup.Relationship) {
+		return fmt.Errorf("%w: relationship must be one of %v", web.ErrBadRequest, model.PickupRelationships)
+	}
+
+	if len(pickup.Photo) > model.MaxPickupPhotoSize {
+		return fmt.Errorf("%w: the photo must have up to %d bytes", web.ErrBadRequest, model.MaxPickupPhotoSize)
+	}
+
+	if len(pickup.Photo) > 0 && !bytes.HasPrefix(pickup.Photo, jpegSignature) && !bytes.HasPrefix(pickup.Photo, pngSignature) {
+		return fmt.Errorf("%w: the photo must be a JPEG or PNG image", web.ErrBadRequest)
+	}
+
+	return nil
+}
+
+// normalizeIDNumber drops the separators people write ID numbers with, such as 30.111.222.
+func normalizeIDNumber(idNumber string) string {
+	return strings.ToUpper(strings.NewReplacer(".", "", "-", "", " ", "").Replace(strings.TrimSpace(idNumber)))
+}
+
+// hashPickupPIN binds the PIN to the child, so equal PINs of different children have different hashes.
+func hashPickupPIN(childID uint, pin string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", childID, pin)))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// guardianNotifications records the notifications dispatched to the guardians of a child.
+type guardianNotifications struct {
+	NotificationUseCase
+	sent []model.Notification
+}
+
+func (g *guardianNotifications) DispatchToGuardians(_ uint, notification model.Notification, _ time.Time, _ gateway.ServiceLocator) error {
+	g.sent = append(g.sent, notification)
+
+	return nil
+}
+
+type pickupMocks struct {
+	pickups       *mock_gateway.MockPickupRepository
+	guardians     *mock_gateway.MockGuardianRepository
+	attempts      *mock_gateway.MockLoginAttemptRepository
+	notifications *guardianNotifications
+	audit         *auditLog
+	locator       gateway.ServiceLocator
+}
+
+func newPickupMocks(t *testing.T, principal model.Principal) pickupMocks {
+	ctrl := gomock.NewController(t)
+	m := pickupMocks{
+		pickups:       mock_gateway.NewMockPickupRepository(ctrl),
+		guardians:     mock_gateway.NewMockGuardianRepository(ctrl),
+		attempts:      mock_gateway.NewMockLoginAttemptRepository(ctrl),
+		notifications: &guardianNotifications{},
+	}
+
+	iocContext := ioc.NewContext()
+	iocContext.Bind(gateway.PickupRepositoryType).ToInstance(m.pickups)
+	iocContext.Bind(gateway.GuardianRepositoryType).ToInstance(m.guardians)
+	iocContext.Bind(gateway.LoginAttemptRepositoryType).ToInstance(m.attempts)
+	iocContext.Bind(NotificationUseCaseType).ToInstance(m.notifications)
+	iocContext.Bind(gateway.PrincipalType).ToInstance(&principal)
+	m.audit = bindAuditLog(iocContext)
+	m.locator = ioc.NewInjector(iocContext)
+
+	return m
+}
+
+func TestPickups(t *testing.T) {
+	var (
+		now       = time.Date(2023, 7, 3, 17, 0, 0, 0, time.UTC)
+		useCase   = pickupUseCase{now: func() time.Time { return now }}
+		parent    = model.Principal{UserID: 2, Username: "jperez", Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}}
+		driver    = model.Principal{UserID: 7, Username: "chofer"}
+		guardians = []model.Guardian{
+			{ChildID: 1, ObserverUserID: 2, Role: model.GuardianRolePrimary},
+			{ChildID: 1, ObserverUserID: 5, Role: model.GuardianRoleTemporaryPickup, EndsAt: now.AddDate(0, 0, 7).Unix()},
+		}
+		grandma = model.AuthorizedPickup{ID: 3, ChildID: 1, Name: "Rosa", LastName: "Pérez", IDNumber: "30111222", Relationship: "grandparent"}
+		attempt = model.LoginAttempt{Scope: model.LoginAttemptScopePickup, Key: "1"}
+	)
+
+	t.Run("Create by the primary guardian", func(t *testing.T) {
+		m := newPickupMocks(t, parent)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil)
+		m.pickups.EXPECT().GetPickups(uint(1)).Return([]model.AuthorizedPickup{}, nil)
+		m.pickups.EXPECT().SavePickup(model.AuthorizedPickup{ChildID: 1, Name: "Rosa", LastName: "Pérez", IDNumber: "30111222", Relationship: "grandparent", Photo: pngSignature}).
+			Return(&grandma, nil)
+
+		created, err := useCase.Create(model.AuthorizedPickup{ChildID: 1, Name: " Rosa", LastName: "Pérez", IDNumber: "30.111.222", Relationship: "grandparent", Photo: pngSignature}, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &grandma, created)
+		assert.Equal(t, []string{model.AuditActionPickupAdded}, m.audit.actions())
+	})
+
+	t.Run("Create with a repeated ID number", func(t *testing.T) {
+		m := newPickupMocks(t, parent)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil)
+		m.pickups.EXPECT().GetPickups(uint(1)).Return([]model.AuthorizedPickup{grandma}, nil)
+
+		_, err := useCase.Create(model.AuthorizedPickup{ChildID: 1, Name: "Rosa", LastName: "Gómez", IDNumber: "30111222", Relationship: "nanny"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrConflict))
+	})
+
+	t.Run("Create with a photo that is not an image", func(t *testing.T) {
+		m := newPickupMocks(t, parent)
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil)
+
+		_, err := useCase.Create(model.AuthorizedPickup{ChildID: 1, Name: "Rosa", LastName: "Pérez", IDNumber: "30111222", Relationship: "grandparent", Photo: []byte("GIF89a")}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("Create by a temporary pickup is forbidden", func(t *testing.T) {
+		m := newPickupMocks(t, model.Principal{UserID: 5, Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}})
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil)
+
+		_, err := useCase.Create(grandma, m.locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("GeneratePIN by a temporary pickup", func(t *testing.T) {
+		m := newPickupMocks(t, model.Principal{UserID: 5, Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}})
+		m.guardians.EXPECT().GetGuardians(uint(1)).Return(guardians, nil)
+
+		var saved model.PickupPIN
+		m.pickups.EXPECT().SavePIN(gomock.Any()).DoAndReturn(func(pin model.PickupPIN) error {
+			saved = pin
+			return nil
+		})
+
+		pin, err := useCase.GeneratePIN(1, m.locator)
+		assert.NoError(t, err)
+		assert.Len(t, pin.PIN, model.PickupPINLength)
+		assert.Equal(t, hashPickupPIN(1, pin.PIN), saved.Hash)
+		assert.Equal(t, now.Add(pickupPINTTL).Unix(), saved.ExpiresAt)
+		assert.Equal(t, uint(5), saved.CreatedBy)
+	})
+
+	t.Run("Verify with the PIN", func(t *testing.T) {
+		m := newPickupMocks(t, driver)
+		m.pickups.EXPECT().IsDriverOf(uint(7), uint(1)).Return(true, nil)
+		m.attempts.EXPECT().Get(model.LoginAttemptScopePickup, "1").Return(&attempt, nil)
+		m.pickups.EXPECT().RedeemPIN(uint(1), hashPickupPIN(1, "042917"), now.Unix()).Return(true, nil)
+		m.pickups.EXPECT().SaveHandover(model.PickupHandover{ChildID: 1, DriverUserID: 7, Method: model.PickupMethodPIN, ReceivedBy: "Tía Carla", OccurredAt: now.Unix()}).Return(nil)
+
+		handover, err := useCase.Verify(1, model.PickupVerification{PIN: "042917", ReceivedBy: "Tía Carla"}, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "Tía Carla", handover.ReceivedBy)
+		assert.Equal(t, []string{model.AuditActionPickupHandedOver}, m.audit.actions())
+		assert.Len(t, m.notifications.sent, 1)
+		assert.Equal(t, model.NotificationEventHandover, m.notifications.sent[0].EventType)
+	})
+
+	t.Run("Verify with the ID number of an authorized pickup", func(t *testing.T) {
+		m := newPickupMocks(t, driver)
+		m.pickups.EXPECT().IsDriverOf(uint(7), uint(1)).Return(true, nil)
+		m.attempts.EXPECT().Get(model.LoginAttemptScopePickup, "1").Return(&attempt, nil)
+		m.pickups.EXPECT().GetPickups(uint(1)).Return([]model.AuthorizedPickup{grandma}, nil)
+		m.pickups.EXPECT().SaveHandover(model.PickupHandover{ChildID: 1, DriverUserID: 7, Method: model.PickupMethodIDNumber, AuthorizedPickupID: 3, ReceivedBy: "Rosa Pérez", OccurredAt: now.Unix()}).Return(nil)
+
+		handover, err := useCase.Verify(1, model.PickupVerification{IDNumber: "30 111 222"}, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), handover.AuthorizedPickupID)
+	})
+
+	t.Run("Verify with a wrong PIN registers the failure", func(t *testing.T) {
+		m := newPickupMocks(t, driver)
+		m.pickups.EXPECT().IsDriverOf(uint(7), uint(1)).Return(true, nil)
+		m.attempts.EXPECT().Get(model.LoginAttemptScopePickup, "1").Return(&attempt, nil)
+		m.pickups.EXPECT().RedeemPIN(uint(1), hashPickupPIN(1, "111111"), now.Unix()).Return(false, nil)
+		m.attempts.EXPECT().RegisterFailure(model.LoginAttemptScopePickup, "1", now, time.Hour).
+			Return(&model.LoginAttempt{Scope: model.LoginAttemptScopePickup, Key: "1", Failures: 1, LastFailureAt: now.Unix()}, nil)
+
+		_, err := useCase.Verify(1, model.PickupVerification{PIN: "111111", ReceivedBy: "Tía Carla"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+		assert.Equal(t, []string{model.AuditActionPickupRejected}, m.audit.actions())
+	})
+
+	t.Run("Verify with an expired PIN", func(t *testing.T) {
+		m := newPickupMocks(t, driver)
+		m.pickups.EXPECT().IsDriverOf(uint(7), uint(1)).Return(true, nil)
+		m.attempts.EXPECT().Get(model.LoginAttemptScopePickup, "1").Return(&attempt, nil)
+		m.pickups.EXPECT().RedeemPIN(uint(1), hashPickupPIN(1, "042917"), now.Unix()).Return(false, nil)
+		m.attempts.EXPECT().RegisterFailure(model.LoginAttemptScopePickup, "1", now, time.Hour).Return(&model.LoginAttempt{Failures: 1}, nil)
+
+		_, err := useCase.Verify(1, model.PickupVerification{PIN: "042917", ReceivedBy: "Tía Carla"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("Verify when the PIN cannot be redeemed", func(t *testing.T) {
+		m := newPickupMocks(t, driver)
+		m.pickups.EXPECT().IsDriverOf(uint(7), uint(1)).Return(true, nil)
+		m.attempts.EXPECT().Get(model.LoginAttemptScopePickup, "1").Return(&attempt, nil)
+		m.pickups.EXPECT().RedeemPIN(uint(1), hashPickupPIN(1, "042917"), now.Unix()).Return(false, errors.New("connection reset"))
+
+		handover, err := useCase.Verify(1, model.PickupVerification{PIN: "042917", ReceivedBy: "Tía Carla"}, m.locator)
+		assert.Nil(t, handover)
+		assert.True(t, errors.Is(err, web.ErrInternalServerError))
+		assert.Empty(t, m.notifications.sent)
+	})
+
+	t.Run("Verify while locked out", func(t *testing.T) {
+		m := newPickupMocks(t, driver)
+		m.pickups.EXPECT().IsDriverOf(uint(7), uint(1)).Return(true, nil)
+		m.attempts.EXPECT().Get(model.LoginAttemptScopePickup, "1").
+			Return(&model.LoginAttempt{Scope: model.LoginAttemptScopePickup, Key: "1", Failures: 5, BlockedUntil: now.Add(time.Minute).Unix()}, nil)
+
+		_, err := useCase.Verify(1, model.PickupVerification{PIN: "042917", ReceivedBy: "Tía Carla"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrTooManyAttempts))
+	})
+
+	t.Run("Verify by someone who does not drive the child", func(t *testing.T) {
+		m := newPickupMocks(t, parent)
+		m.pickups.EXPECT().IsDriverOf(uint(2), uint(1)).Return(false, nil)
+
+		_, err := useCase.Verify(1, model.PickupVerification{PIN: "042917", ReceivedBy: "Juan"}, m.locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("List by the driver", func(t *testing.T) {
+		m := newPickupMocks(t, driver)
+		m.pickups.EXPECT().IsDriverOf(uint(7), uint(1)).Return(true, nil)
+		m.pickups.EXPECT().GetPickups(uint(1)).Return([]model.AuthorizedPickup{grandma}, nil)
+
+		pickups, err := useCase.List(1, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.AuthorizedPickup{grandma}, pickups)
+	})
+}
@@ -0,0 +1,364 @@
+package usecase
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	PrivacyUseCaseType = "PrivacyUseCase"
+
+	reencryptionBatchSize = 100
+	reencryptionJob       = "privacy.reencryption"
+
+	// accountDeletionGracePeriod is how long a deletion request can be cancelled before the account is erased.
+	accountDeletionGracePeriod = 30 * 24 * time.Hour
+)
+
+type (
+	PrivacyUseCase interface {
+		StartReencryption(gateway.ServiceLocator) error
+		ReencryptFields(gateway.ServiceLocator) (*model.ReencryptionResult, error)
+		ExportAccountData(uint, gateway.ServiceLocator) (*model.AccountData, error)
+		GetAccountDeletion(uint, gateway.ServiceLocator) (*model.AccountDeletion, error)
+		RequestAccountDeletion(uint, gateway.ServiceLocator) (*model.AccountDeletion, error)
+		CancelAccountDeletion(uint, gateway.ServiceLocator) error
+		EraseDueAccounts(time.Time, gateway.ServiceLocator) error
+	}
+
+	privacyUseCase struct {
+		now func() time.Time
+	}
+
+	// encryptedField reads and replaces the stored values of a field encrypted at rest. Indexed fields have a
+	// blind index to look them up, refreshed along with the value.
+	encryptedField struct {
+		get     func(uint, int) ([]model.EncryptedValue, error)
+		update  func(model.EncryptedValue) error
+		indexed bool
+	}
+)
+
+func NewPrivacyUseCase() PrivacyUseCase {
+	return &privacyUseCase{now: time.Now}
+}
+
+// StartReencryption starts ReencryptFields in the background. A run already underway on this server is a
+// conflict; runs on several servers at once only redo each other's work, since the job can be run again.
+func (p privacyUseCase) StartReencryption(locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "PrivacyUseCase.StartReencryption")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionManagePrivacy)
+	if err != nil {
+		return err
+	}
+
+	runner := locator.GetInstance(gateway.JobRunnerType).(gateway.JobRunner)
+	started := runner.Start(reencryptionJob, principal, func(jobLocator gateway.ServiceLocator) error {
+		_, err := p.ReencryptFields(jobLocator)
+		return err
+	})
+
+	if !started {
+		return fmt.Errorf("%w: a re-encryption is already running", web.ErrConflict)
+	}
+
+	return nil
+}
+
+// ReencryptFields encrypts with the current key every encrypted field stored in plain text or with an old key: the
+// ID numbers of the users, refreshing their blind index, the TOTP secrets and the ID numbers of the authorized
+// pickups. It walks the rows in batches, so it can be run again if it is interrupted.
+func (p privacyUseCase) ReencryptFields(locator gateway.ServiceLocator) (_ *model.ReencryptionResult, err error) {
+	span := startSpan(locator, "PrivacyUseCase.ReencryptFields")
+	defer func() { span.End(err) }()
+
+	if _, err := Authorize(locator, model.PermissionManagePrivacy); err != nil {
+		return nil, err
+	}
+
+	users := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	twoFactors := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+	pickups := locator.GetInstance(gateway.PickupRepositoryType).(gateway.PickupRepository)
+
+	fields := []encryptedField{
+		{get: users.GetEncryptedIDNumbers, update: users.UpdateEncryptedIDNumber, indexed: true},
+		{get: twoFactors.GetEncryptedSecrets, update: twoFactors.UpdateEncryptedSecret},
+		{get: pickups.GetEncryptedIDNumbers, update: pickups.UpdateEncryptedIDNumber},
+	}
+
+	result := model.ReencryptionResult{}
+	for _, field := range fields {
+		if err := reencrypt(field, &result, locator); err != nil {
+			return nil, err
+		}
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action: model.AuditActionReencryptionFinished,
+		Details: map[string]string{
+			"scanned":     strconv.Itoa(result.Scanned),
+			"reencrypted": strconv.Itoa(result.Reencrypted),
+		},
+	})
+
+	return &result, nil
+}
+
+// reencrypt walks the values of an encrypted field in batches, encrypting again the ones that need it.
+func reencrypt(field encryptedField, result *model.ReencryptionResult, locator gateway.ServiceLocator) error {
+	cipher := locator.GetInstance(gateway.FieldCipherType).(gateway.FieldCipher)
+
+	var lastID uint
+	for {
+		values, err := field.get(lastID, reencryptionBatchSize)
+		if err != nil {
+			return repositoryError(err)
+		}
+
+		for _, value := range values {
+			result.Scanned++
+			lastID = value.ID
+
+			if !cipher.NeedsRotation(value.Ciphertext) && (!field.indexed || value.BlindIndex != "") {
+				continue
+			}
+
+			plaintext, err := cipher.Decrypt(value.Ciphertext)
+			if err != nil {
+				return err
+			}
+
+			if value.Ciphertext, err = cipher.Encrypt(plaintext); err != nil {
+				return err
+			}
+
+			if field.indexed {
+				value.BlindIndex = cipher.BlindIndex(plaintext)
+			}
+
+			if err = field.update(value); err != nil {
+				return repositoryError(err)
+			}
+
+			result.Reencrypted++
+		}
+
+		if len(values) < reencryptionBatchSize {
+			return nil
+		}
+	}
+}
+
+// ExportAccountData gathers everything held about a user and their children. The password is left out.
+func (p privacyUseCase) ExportAccountData(userID uint, locator gateway.ServiceLocator) (_ *model.AccountData, err error) {
+	span := startSpan(locator, "PrivacyUseCase.ExportAccountData")
+	defer func() { span.End(err) }()
+
+	if _, err := AuthorizeSelf(locator, userID); err != nil {
+		return nil, err
+	}
+
+	users := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	repository := locator.GetInstance(gateway.AccountDataRepositoryType).(gateway.AccountDataRepository)
+	notifications := locator.GetInstance(gateway.NotificationRepositoryType).(gateway.NotificationRepository)
+	pickups := locator.GetInstance(gateway.PickupRepositoryType).(gateway.PickupRepository)
+
+	user, err := users.Get(userID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+	user.Password = ""
+
+	data := model.AccountData{
+		User:            *user,
+		Pickups:         []model.AuthorizedPickup{},
+		LocationHistory: []model.LocationSeen{},
+	}
+
+	if data.Children, err = repository.GetChildren(userID); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if data.Addresses, err = repository.GetAddresses(userID); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if data.Links, err = repository.GetLinks(userID); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if data.Guardianships, err = repository.GetGuardianships(userID); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	for _, child := range data.Children {
+		childPickups, err := pickups.GetPickups(child.ID)
+		if err != nil {
+			return nil, web.ErrInternalServerError
+		}
+		data.Pickups = append(data.Pickups, childPickups...)
+	}
+
+	if data.Handovers, err = repository.GetHandovers(userID); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if data.Messages, err = notifications.GetDigestItems(userID); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if data.Messages == nil {
+		data.Messages = []model.Notification{}
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionDataExported,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(userID),
+	})
+
+	return &data, nil
+}
+
+// GetAccountDeletion obtains the deletion request of a user.
+func (p privacyUseCase) GetAccountDeletion(userID uint, locator gateway.ServiceLocator) (_ *model.AccountDeletion, err error) {
+	span := startSpan(locator, "PrivacyUseCase.GetAccountDeletion")
+	defer func() { span.End(err) }()
+
+	if _, err := AuthorizeSelf(locator, userID); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.AccountDataRepositoryType).(gateway.AccountDataRepository)
+	deletion, err := repository.GetDeletion(userID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if deletion == nil {
+		return nil, fmt.Errorf("%w: no deletion was requested", web.ErrNotFound)
+	}
+
+	return deletion, nil
+}
+
+// RequestAccountDeletion schedules the erasure of a user account once the grace period ends.
+// Asking again while a request is pending keeps the original schedule.
+func (p privacyUseCase) RequestAccountDeletion(userID uint, locator gateway.ServiceLocator) (_ *model.AccountDeletion, err error) {
+	span := startSpan(locator, "PrivacyUseCase.RequestAccountDeletion")
+	defer func() { span.End(err) }()
+
+	if _, err := AuthorizeSelf(locator, userID); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.AccountDataRepositoryType).(gateway.AccountDataRepository)
+	deletion, err := repository.GetDeletion(userID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if deletion != nil {
+		switch deletion.Status {
+		case model.AccountDeletionPending:
+			return deletion, nil
+		case model.AccountDeletionCompleted:
+			return nil, fmt.Errorf("%w: the account was already deleted", web.ErrConflict)
+		}
+	}
+
+	now := p.now()
+	deletion = &model.AccountDeletion{
+		UserID:      userID,
+		Status:      model.AccountDeletionPending,
+		RequestedAt: now.Unix(),
+		ScheduledAt: now.Add(accountDeletionGracePeriod).Unix(),
+	}
+
+	if err = repository.SaveDeletion(*deletion); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionDeletionRequested,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(userID),
+		Details:    map[string]string{"scheduled_at": strconv.FormatInt(deletion.ScheduledAt, 10)},
+	})
+
+	return deletion, nil
+}
+
+// CancelAccountDeletion cancels the pending deletion request of a user, within the grace period.
+func (p privacyUseCase) CancelAccountDeletion(userID uint, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "PrivacyUseCase.CancelAccountDeletion")
+	defer func() { span.End(err) }()
+
+	if _, err := AuthorizeSelf(locator, userID); err != nil {
+		return err
+	}
+
+	repository := locator.GetInstance(gateway.AccountDataRepositoryType).(gateway.AccountDataRepository)
+	deletion, err := repository.GetDeletion(userID)
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	if deletion == nil || deletion.Status != model.AccountDeletionPending {
+		return fmt.Errorf("%w: no pending deletion", web.ErrNotFound)
+	}
+
+	deletion.Status = model.AccountDeletionCancelled
+	if err = repository.SaveDeletion(*deletion); err != nil {
+		return web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionDeletionCancelled,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(userID),
+	})
+
+	return nil
+}
+
+// EraseDueAccounts erases the accounts whose deletion grace period is over. It is run by a background worker.
+// An account that cannot be erased does not hold back the others; it is tried again on the next run.
+func (p privacyUseCase) EraseDueAccounts(now time.Time, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "PrivacyUseCase.EraseDueAccounts")
+	defer func() { span.End(err) }()
+
+	repository := locator.GetInstance(gateway.AccountDataRepositoryType).(gateway.AccountDataRepository)
+	deletions, err := repository.GetDueDeletions(now.Unix())
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	failed := 0
+	for _, deletion := range deletions {
+		if err = repository.Erase(deletion.UserID, now.Unix()); err != nil {
+			logger(locator).WithError(err).WithField("user_id", deletion.UserID).Error("account could not be erased")
+			failed++
+			continue
+		}
+
+		recordAudit(locator, model.AuditEntry{
+			Action:     model.AuditActionAccountErased,
+			TargetType: model.AuditTargetUser,
+			TargetID:   userTarget(deletion.UserID),
+		})
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d accounts could not be erased", web.ErrInternalServerError, failed, len(deletions))
+	}
+
+	return nil
+}
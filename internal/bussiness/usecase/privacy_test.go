@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReencryptFields(t *testing.T) {
+	useCase := NewPrivacyUseCase()
+
+	type repositories struct {
+		users      *mock_gateway.MockUserRepository
+		twoFactors *mock_gateway.MockTwoFactorRepository
+		pickups    *mock_gateway.MockPickupRepository
+		cipher     *mock_gateway.MockFieldCipher
+	}
+
+	newLocator := func(t *testing.T, principal model.Principal) (repositories, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		mocks := repositories{
+			users:      mock_gateway.NewMockUserRepository(ctrl),
+			twoFactors: mock_gateway.NewMockTwoFactorRepository(ctrl),
+			pickups:    mock_gateway.NewMockPickupRepository(ctrl),
+			cipher:     mock_gateway.NewMockFieldCipher(ctrl),
+		}
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.UserRepositoryType).ToInstance(mocks.users)
+		iocContext.Bind(gateway.TwoFactorRepositoryType).ToInstance(mocks.twoFactors)
+		iocContext.Bind(gateway.PickupRepositoryType).ToInstance(mocks.pickups)
+		iocContext.Bind(gateway.FieldCipherType).ToInstance(mocks.cipher)
+		iocContext.Bind(gateway.PrincipalType).ToInstance(&principal)
+		bindAuditLog(iocContext)
+
+		return mocks, ioc.NewInjector(iocContext)
+	}
+
+	admin := model.Principal{UserID: 1, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}}
+
+	t.Run("ReencryptFields successful", func(t *testing.T) {
+		mocks, locator := newLocator(t, admin)
+		mocks.users.EXPECT().GetEncryptedIDNumbers(uint(0), reencryptionBatchSize).Return([]model.EncryptedValue{
+			{ID: 3, Ciphertext: "v1:new:current", BlindIndex: "index"},
+			{ID: 5, Ciphertext: "12345678"},
+		}, nil)
+		mocks.cipher.EXPECT().NeedsRotation("v1:new:current").Return(false)
+		mocks.cipher.EXPECT().NeedsRotation("12345678").Return(true)
+		mocks.cipher.EXPECT().Decrypt("12345678").Return("12345678", nil)
+		mocks.cipher.EXPECT().Encrypt("12345678").Return("v1:new:encrypted", nil)
+		mocks.cipher.EXPECT().BlindIndex("12345678").Return("index-5")
+		mocks.users.EXPECT().UpdateEncryptedIDNumber(model.EncryptedValue{ID: 5, Ciphertext: "v1:new:encrypted", BlindIndex: "index-5"}).Return(nil)
+
+		mocks.twoFactors.EXPECT().GetEncryptedSecrets(uint(0), reencryptionBatchSize).Return([]model.EncryptedValue{
+			{ID: 5, Ciphertext: "v1:old:secret"},
+		}, nil)
+		mocks.cipher.EXPECT().NeedsRotation("v1:old:secret").Return(true)
+		mocks.cipher.EXPECT().Decrypt("v1:old:secret").Return("JBSWY3DPEHPK3PXP", nil)
+		mocks.cipher.EXPECT().Encrypt("JBSWY3DPEHPK3PXP").Return("v1:new:secret", nil)
+		mocks.twoFactors.EXPECT().UpdateEncryptedSecret(model.EncryptedValue{ID: 5, Ciphertext: "v1:new:secret"}).Return(nil)
+
+		mocks.pickups.EXPECT().GetEncryptedIDNumbers(uint(0), reencryptionBatchSize).Return([]model.EncryptedValue{
+			{ID: 7, Ciphertext: "v1:new:pickup"},
+		}, nil)
+		mocks.cipher.EXPECT().NeedsRotation("v1:new:pickup").Return(false)
+
+		result, err := useCase.ReencryptFields(locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.ReencryptionResult{Scanned: 4, Reencrypted: 2}, result)
+	})
+
+	t.Run("ReencryptFields walks every batch", func(t *testing.T) {
+		mocks, locator := newLocator(t, admin)
+		batch := make([]model.EncryptedValue, reencryptionBatchSize)
+		for i := range batch {
+			batch[i] = model.EncryptedValue{ID: uint(i + 1), Ciphertext: "v1:new:current", BlindIndex: "index"}
+		}
+		mocks.users.EXPECT().GetEncryptedIDNumbers(uint(0), reencryptionBatchSize).Return(batch, nil)
+		mocks.users.EXPECT().GetEncryptedIDNumbers(uint(reencryptionBatchSize), reencryptionBatchSize).Return(nil, nil)
+		mocks.twoFactors.EXPECT().GetEncryptedSecrets(uint(0), reencryptionBatchSize).Return(nil, nil)
+		mocks.pickups.EXPECT().GetEncryptedIDNumbers(uint(0), reencryptionBatchSize).Return(nil, nil)
+		mocks.cipher.EXPECT().NeedsRotation("v1:new:current").Return(false).Times(reencryptionBatchSize)
+
+		result, err := useCase.ReencryptFields(locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.ReencryptionResult{Scanned: reencryptionBatchSize}, result)
+	})
+
+	t.Run("ReencryptFields forbidden", func(t *testing.T) {
+		_, locator := newLocator(t, model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "bus"}}})
+
+		result, err := useCase.ReencryptFields(locator)
+		assert.Nil(t, result)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+}
+
+func TestStartReencryption(t *testing.T) {
+	useCase := NewPrivacyUseCase()
+	admin := &model.Principal{UserID: 1, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}}
+
+	newLocator := func(t *testing.T, principal *model.Principal) (*mock_gateway.MockJobRunner, gateway.ServiceLocator) {
+		runner := mock_gateway.NewMockJobRunner(gomock.NewController(t))
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.JobRunnerType).ToInstance(runner)
+		iocContext.Bind(gateway.PrincipalType).ToInstance(principal)
+
+		return runner, ioc.NewInjector(iocContext)
+	}
+
+	t.Run("StartReencryption successful", func(t *testing.T) {
+		runner, locator := newLocator(t, admin)
+		runner.EXPECT().Start(reencryptionJob, admin, gomock.Any()).Return(true)
+
+		assert.NoError(t, useCase.StartReencryption(locator))
+	})
+
+	t.Run("StartReencryption already running", func(t *testing.T) {
+		runner, locator := newLocator(t, admin)
+		runner.EXPECT().Start(reencryptionJob, admin, gomock.Any()).Return(false)
+
+		assert.True(t, errors.Is(useCase.StartReencryption(locator), web.ErrConflict))
+	})
+
+	t.Run("StartReencryption forbidden", func(t *testing.T) {
+		_, locator := newLocator(t, &model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}})
+
+		assert.True(t, errors.Is(useCase.StartReencryption(locator), web.ErrForbidden))
+	})
+}
+
+func TestAccountData(t *testing.T) {
+	var (
+		now     = time.Date(2022, 12, 10, 17, 49, 30, 0, time.UTC)
+		useCase = &privacyUseCase{now: func() time.Time { return now }}
+		parent  = model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}}
+	)
+
+	newLocator := func(t *testing.T, principal *model.Principal) (*mock_gateway.MockUserRepository, *mock_gateway.MockAccountDataRepository, *mock_gateway.MockNotificationRepository, *mock_gateway.MockPickupRepository, *auditLog, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		users := mock_gateway.NewMockUserRepository(ctrl)
+		accounts := mock_gateway.NewMockAccountDataRepository(ctrl)
+		notifications := mock_gateway.NewMockNotificationRepository(ctrl)
+		pickups := mock_gateway.NewMockPickupRepository(ctrl)
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.UserRepositoryType).ToInstance(users)
+		iocContext.Bind(gateway.AccountDataRepositoryType).ToInstance(accounts)
+		iocContext.Bind(gateway.NotificationRepositoryType).ToInstance(notifications)
+		iocContext.Bind(gateway.PickupRepositoryType).ToInstance(pickups)
+		log := bindAuditLog(iocContext)
+		if principal != nil {
+			iocContext.Bind(gateway.PrincipalType).ToInstance(principal)
+		}
+
+		return users, accounts, notifications, pickups, log, ioc.NewInjector(iocContext)
+	}
+
+	t.Run("ExportAccountData leaves the password out", func(t *testing.T) {
+		users, accounts, notifications, pickups, log, locator := newLocator(t, &parent)
+		guardians := []model.Guardian{{ChildID: 1, ObserverUserID: 4, Role: model.GuardianRoleSecondary, InvitedBy: 2}}
+		handovers := []model.PickupHandover{{ID: 8, ChildID: 1, DriverUserID: 1, Method: model.PickupMethodPIN, ReceivedBy: "Rosa", OccurredAt: 100}}
+		users.EXPECT().Get(uint(2)).Return(&model.User{ID: 2, Name: "Mariana", IDNumber: "30111222", Password: "secret"}, nil)
+		accounts.EXPECT().GetChildren(uint(2)).Return([]model.Children{{ID: 1, ObserverUserID: 2, Name: "Sofia"}}, nil)
+		accounts.EXPECT().GetAddresses(uint(2)).Return([]model.Address{}, nil)
+		accounts.EXPECT().GetLinks(uint(2)).Return([]model.ObservedUserObserverUser{{ObservedUserID: 1, ObserverUserID: 2}}, nil)
+		accounts.EXPECT().GetGuardianships(uint(2)).Return(guardians, nil)
+		pickups.EXPECT().GetPickups(uint(1)).Return([]model.AuthorizedPickup{{ID: 3, ChildID: 1, Name: "Rosa"}}, nil)
+		accounts.EXPECT().GetHandovers(uint(2)).Return(handovers, nil)
+		notifications.EXPECT().GetDigestItems(uint(2)).Return(nil, nil)
+
+		data, err := useCase.ExportAccountData(2, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.AccountData{
+			User:            model.User{ID: 2, Name: "Mariana", IDNumber: "30111222"},
+			Children:        []model.Children{{ID: 1, ObserverUserID: 2, Name: "Sofia"}},
+			Addresses:       []model.Address{},
+			Links:           []model.ObservedUserObserverUser{{ObservedUserID: 1, ObserverUserID: 2}},
+			Guardianships:   guardians,
+			Pickups:         []model.AuthorizedPickup{{ID: 3, ChildID: 1, Name: "Rosa"}},
+			Handovers:       handovers,
+			Messages:        []model.Notification{},
+			LocationHistory: []model.LocationSeen{},
+		}, data)
+		assert.Equal(t, []string{model.AuditActionDataExported}, log.actions())
+	})
+
+	t.Run("ExportAccountData of another user", func(t *testing.T) {
+		_, _, _, _, _, locator := newLocator(t, &parent)
+
+		data, err := useCase.ExportAccountData(3, locator)
+		assert.Nil(t, data)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("RequestAccountDeletion schedules it after the grace period", func(t *testing.T) {
+		_, accounts, _, _, log, locator := newLocator(t, &parent)
+		expected := model.AccountDeletion{UserID: 2, Status: model.AccountDeletionPending, RequestedAt: now.Unix(), ScheduledAt: now.Add(accountDeletionGracePeriod).Unix()}
+		accounts.EXPECT().GetDeletion(uint(2)).Return(&model.AccountDeletion{UserID: 2, Status: model.AccountDeletionCancelled}, nil)
+		accounts.EXPECT().SaveDeletion(expected).Return(nil)
+
+		deletion, err := useCase.RequestAccountDeletion(2, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &expected, deletion)
+		assert.Equal(t, []string{model.AuditActionDeletionRequested}, log.actions())
+	})
+
+	t.Run("RequestAccountDeletion already pending keeps the schedule", func(t *testing.T) {
+		_, accounts, _, _, _, locator := newLocator(t, &parent)
+		pending := model.AccountDeletion{UserID: 2, Status: model.AccountDeletionPending, RequestedAt: 100, ScheduledAt: 200}
+		accounts.EXPECT().GetDeletion(uint(2)).Return(&pending, nil)
+
+		deletion, err := useCase.RequestAccountDeletion(2, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &pending, deletion)
+	})
+
+	t.Run("CancelAccountDeletion without pending request", func(t *testing.T) {
+		_, accounts, _, _, _, locator := newLocator(t, &parent)
+		accounts.EXPECT().GetDeletion(uint(2)).Return(nil, nil)
+
+		assert.True(t, errors.Is(useCase.CancelAccountDeletion(2, locator), web.ErrNotFound))
+	})
+
+	t.Run("CancelAccountDeletion within the grace period", func(t *testing.T) {
+		_, accounts, _, _, log, locator := newLocator(t, &parent)
+		accounts.EXPECT().GetDeletion(uint(2)).Return(&model.AccountDeletion{UserID: 2, Status: model.AccountDeletionPending, RequestedAt: 100, ScheduledAt: 200}, nil)
+		accounts.EXPECT().SaveDeletion(model.AccountDeletion{UserID: 2, Status: model.AccountDeletionCancelled, RequestedAt: 100, ScheduledAt: 200}).Return(nil)
+
+		assert.NoError(t, useCase.CancelAccountDeletion(2, locator))
+		assert.Equal(t, []string{model.AuditActionDeletionCancelled}, log.actions())
+	})
+
+	t.Run("EraseDueAccounts", func(t *testing.T) {
+		_, accounts, _, _, log, locator := newLocator(t, nil)
+		accounts.EXPECT().GetDueDeletions(now.Unix()).Return([]model.AccountDeletion{{UserID: 2}, {UserID: 5}}, nil)
+		accounts.EXPECT().Erase(uint(2), now.Unix()).Return(nil)
+		accounts.EXPECT().Erase(uint(5), now.Unix()).Return(nil)
+
+		assert.NoError(t, useCase.EraseDueAccounts(now, locator))
+		assert.Equal(t, []string{model.AuditActionAccountErased, model.AuditActionAccountErased}, log.actions())
+	})
+
+	t.Run("EraseDueAccounts goes on past a failing account", func(t *testing.T) {
+		_, accounts, _, _, log, locator := newLocator(t, nil)
+		accounts.EXPECT().GetDueDeletions(now.Unix()).Return([]model.AccountDeletion{{UserID: 2}, {UserID: 5}}, nil)
+		accounts.EXPECT().Erase(uint(2), now.Unix()).Return(errors.New("deadlock"))
+		accounts.EXPECT().Erase(uint(5), now.Unix()).Return(nil)
+
+		err := useCase.EraseDueAccounts(now, locator)
+		assert.True(t, errors.Is(err, web.ErrInternalServerError))
+		assert.Contains(t, err.Error(), "1 of 2 accounts")
+		assert.Equal(t, []string{model.AuditActionAccountErased}, log.actions())
+	})
+}
@@ -0,0 +1,383 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const ProvisioningUseCaseType = "ProvisioningUseCase"
+
+type (
+	ProvisioningUseCase interface {
+		CreateUser(model.UserProvision, gateway.ServiceLocator) (*model.User, error)
+		AssignBus(uint, model.SchoolBus, gateway.ServiceLocator) (*model.SchoolBus, error)
+		LinkObserver(uint, uint, gateway.ServiceLocator) error
+		AddChild(model.Children, gateway.ServiceLocator) (*model.Children, error)
+	}
+
+	provisioningUseCase struct{}
+)
+
+func NewProvisioningUseCase() ProvisioningUseCase {
+	return &provisioningUseCase{}
+}
+
+// CreateUser creates an enabled account. Platform admins can create any user, while company admins can only
+// create drivers for their companies. Usernames, emails and ID numbers cannot be repeated.
+func (p provisioningUseCase) CreateUser(provision model.UserProvision, locator gateway.ServiceLocator) (_ *model.User, err error) {
+	span := startSpan(locator, "ProvisioningUseCase.CreateUser")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionManageUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateUserProvision(&provision); err != nil {
+		return nil, err
+	}
+
+	if !principal.HasRole(model.RolePlatformAdmin) &&
+		(provision.User.Type != observed || !contains(principal.ManagedCompanies(), provision.CompanyName)) {
+		return nil, web.ErrForbidden
+	}
+
+	if err = checkUserAvailable(provision.User, locator); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.ProvisioningRepositoryType).(gateway.ProvisioningRepository)
+
+	var user *model.User
+	if provision.User.Type == observed {
+		bus, err := p.schoolBus(provision.SchoolBus, repository)
+		if err != nil {
+			return nil, err
+		}
+
+		privacyKey, err := newTokenID()
+		if err != nil {
+			return nil, web.ErrInternalServerError
+		}
+
+		user, err = repository.CreateDriver(model.ObservedUser{
+			User:        provision.User,
+			PrivacyKey:  privacyKey,
+			CompanyName: provision.CompanyName,
+			SchoolBus:   *bus,
+		})
+		if err != nil {
+			return nil, web.ErrInternalServerError
+		}
+	} else if user, err = repository.CreateObserver(provision.User); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionUserCreated,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(user.ID),
+		Details:    map[string]string{"username": user.Username, "type": user.Type},
+	})
+
+	user.SetPassword("")
+
+	return user, nil
+}
+
+// AssignBus sets the school bus a driver drives, registering it when its license plate is not known yet.
+// Company admins can only assign the buses of the drivers of their companies.
+func (p provisioningUseCase) AssignBus(driverID uint, bus model.SchoolBus, locator gateway.ServiceLocator) (_ *model.SchoolBus, err error) {
+	span := startSpan(locator, "ProvisioningUseCase.AssignBus")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionManageUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = authorizeDriverCompany(principal, driverID, locator); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.ProvisioningRepositoryType).(gateway.ProvisioningRepository)
+	assigned, err := p.schoolBus(bus, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = repository.AssignSchoolBus(driverID, assigned.ID); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionBusAssigned,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(driverID),
+		Details:    map[string]string{"license_plate": assigned.LicensePlate},
+	})
+
+	return assigned, nil
+}
+
+// LinkObserver lets an observer follow a driver. Company admins can only link the drivers of their companies.
+func (p provisioningUseCase) LinkObserver(driverID uint, observerID uint, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "ProvisioningUseCase.LinkObserver")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionManageUsers)
+	if err != nil {
+		return err
+	}
+
+	if err = authorizeDriverCompany(principal, driverID, locator); err != nil {
+		return err
+	}
+
+	userRepository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := userRepository.Get(observerID)
+	if err != nil {
+		return repositoryError(err)
+	}
+
+	if user.Type != observer {
+		return fmt.Errorf("%w: user %d is not an observer", web.ErrBadRequest, observerID)
+	}
+
+	repository := locator.GetInstance(gateway.ProvisioningRepositoryType).(gateway.ProvisioningRepository)
+	if err = repository.LinkObserver(driverID, observerID); err != nil {
+		return web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionObserverLinked,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(observerID),
+		Details:    map[string]string{"driver_id": userTarget(driverID)},
+	})
+
+	return nil
+}
+
+// AddChild registers a child of an observer attending a school. Only platform admins can add children.
+func (p provisioningUseCase) AddChild(child model.Children, locator gateway.ServiceLocator) (_ *model.Children, err error) {
+	span := startSpan(locator, "ProvisioningUseCase.AddChild")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionManageUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	if !principal.HasRole(model.RolePlatformAdmin) {
+		return nil, web.ErrForbidden
+	}
+
+	if err = validateChild(&child); err != nil {
+		return nil, err
+	}
+
+	userRepository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := userRepository.Get(child.ObserverUserID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	if user.Type != observer {
+		return nil, fmt.Errorf("%w: user %d is not an observer", web.ErrBadRequest, child.ObserverUserID)
+	}
+
+	school, err := findSchool(child.SchoolID, locator)
+	if errors.Is(err, web.ErrNotFound) {
+		return nil, fmt.Errorf("%w: school %d does not exist", web.ErrBadRequest, child.SchoolID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.ProvisioningRepositoryType).(gateway.ProvisioningRepository)
+	saved, err := repository.SaveChild(child)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+	saved.SchoolName = school.Name
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionChildAdded,
+		TargetType: model.AuditTargetChild,
+		TargetID:   fmt.Sprint(saved.ID),
+		Details:    map[string]string{"observer_user_id": userTarget(child.ObserverUserID)},
+	})
+
+	return saved, nil
+}
+
+// schoolBus obtains the bus with the license plate of bus, or registers bus when there is none.
+func (p provisioningUseCase) schoolBus(bus model.SchoolBus, repository gateway.ProvisioningRepository) (*model.SchoolBus, error) {
+	bus.LicensePlate = strings.ToUpper(strings.TrimSpace(bus.LicensePlate))
+	if bus.LicensePlate == "" || len(bus.LicensePlate) > maxProfileLength {
+		return nil, fmt.Errorf("%w: license plate must have between 1 and %d characters", web.ErrBadRequest, maxProfileLength)
+	}
+
+	existing, err := repository.FindSchoolBus(bus.LicensePlate)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	if existing != nil {
+		return existing, nil
+	}
+
+	if err = requireFields(requiredField{"model", &bus.Model}, requiredField{"brand", &bus.Brand}, requiredField{"school_bus_license", &bus.SchoolBusLicense}); err != nil {
+		return nil, fmt.Errorf("%w to register bus %s", err, bus.LicensePlate)
+	}
+
+	saved, err := repository.SaveSchoolBus(bus)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return saved, nil
+}
+
+// authorizeDriverCompany checks that the user is a driver and, unless the principal is a platform admin,
+// that the principal manages its company.
+func authorizeDriverCompany(principal *model.Principal, driverID uint, locator gateway.ServiceLocator) error {
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(driverID)
+	if err != nil {
+		return repositoryError(err)
+	}
+
+	if user.Type != observed {
+		return fmt.Errorf("%w: user %d is not a driver", web.ErrBadRequest, driverID)
+	}
+
+	if principal.HasRole(model.RolePlatformAdmin) {
+		return nil
+	}
+
+	companyName, err := driverCompany(*user, repository)
+	if err != nil {
+		return err
+	}
+
+	if !contains(principal.ManagedCompanies(), companyName) {
+		return web.ErrForbidden
+	}
+
+	return nil
+}
+
+// checkUserAvailable returns a conflict when the username, email or ID number of the user is already taken.
+func checkUserAvailable(user model.User, locator gateway.ServiceLocator) error {
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+
+	lookups := []struct {
+		field string
+		find  func(string) (*model.User, error)
+		value string
+	}{
+		{"username", repository.FindByUsername, user.Username},
+		{"email", repository.FindByEmail, user.Email},
+		{"id_number", repository.FindByIDNumber, user.IDNumber},
+	}
+
+	for _, lookup := range lookups {
+		existing, err := lookup.find(lookup.value)
+		if err != nil {
+			return web.ErrInternalServerError
+		}
+
+		if existing != nil {
+			return fmt.Errorf("%w: %s is already taken", web.ErrConflict, lookup.field)
+		}
+	}
+
+	return nil
+}
+
+func validateUserProvision(provision *model.UserProvision) error {
+	user := &provision.User
+	user.ID = 0
+	user.IDNumber = normalizeIDNumber(user.IDNumber)
+
+	err := requireFields(
+		requiredField{"name", &user.Name},
+		requiredField{"last_name", &user.LastName},
+		requiredField{"id_number", &user.IDNumber},
+		requiredField{"username", &user.Username},
+		requiredField{"email", &user.Email},
+	)
+	if err != nil {
+		return err
+	}
+
+	if address, err := mail.ParseAddress(user.Email); err != nil || address.Address != user.Email {
+		return fmt.Errorf("%w: invalid email", web.ErrBadRequest)
+	}
+
+	if len(user.Password) < minPasswordLength || len(user.Password) > maxPasswordLength {
+		return fmt.Errorf("%w: password must have between %d and %d characters", web.ErrBadRequest, minPasswordLength, maxPasswordLength)
+	}
+
+	switch user.Type {
+	case observer:
+		provision.CompanyName, provision.SchoolBus = "", model.SchoolBus{}
+	case observed:
+		return requireFields(requiredField{"company_name", &provision.CompanyName})
+	default:
+		return fmt.Errorf("%w: type must be %s or %s", web.ErrBadRequest, observed, observer)
+	}
+
+	return nil
+}
+
+func validateChild(child *model.Children) error {
+	child.ID = 0
+
+	if err := requireFields(requiredField{"name", &child.Name}, requiredField{"last_name", &child.LastName}); err != nil {
+		return err
+	}
+
+	start, err := model.ParseClock(child.SchoolStartTime)
+	if err != nil {
+		return fmt.Errorf("%w: %s", web.ErrBadRequest, err.Error())
+	}
+
+	end, err := model.ParseClock(child.SchoolEndTime)
+	if err != nil {
+		return fmt.Errorf("%w: %s", web.ErrBadRequest, err.Error())
+	}
+
+	if start >= end {
+		return fmt.Errorf("%w: the school day must start before it ends", web.ErrBadRequest)
+	}
+
+	return nil
+}
+
+// requiredField is a text field that cannot be empty.
+type requiredField struct {
+	name  string
+	value *string
+}
+
+// requireFields trims the fields and checks they fit in a VARCHAR(45) column.
+func requireFields(fields ...requiredField) error {
+	for _, field := range fields {
+		*field.value = strings.TrimSpace(*field.value)
+		if *field.value == "" || len(*field.value) > maxProfileLength {
+			return fmt.Errorf("%w: %s must have between 1 and %d characters", web.ErrBadRequest, field.name, maxProfileLength)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvisioning(t *testing.T) {
+	var (
+		useCase      = NewProvisioningUseCase()
+		platform     = model.Principal{Username: "cli:root", Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}}
+		companyAdmin = model.Principal{UserID: 8, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "bus a"}}}
+		bus          = model.SchoolBus{ID: 2, LicensePlate: "11AAA222", Model: "Master", Brand: "Renault", SchoolBusLicense: "11222"}
+		driver       = model.UserProvision{
+			User: model.User{
+				Name: "Juan", LastName: "Perez", IDNumber: "12.345.678", Username: "jperez", Password: "jperez1234",
+				Email: "jperez@mail.com", Type: observed,
+			},
+			CompanyName: "bus a",
+			SchoolBus:   model.SchoolBus{LicensePlate: " 11aaa222 "},
+		}
+	)
+
+	type mocks struct {
+		users        *mock_gateway.MockUserRepository
+		provisioning *mock_gateway.MockProvisioningRepository
+	}
+
+	newLocator := func(t *testing.T, principal model.Principal) (mocks, *auditLog, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		m := mocks{
+			users:        mock_gateway.NewMockUserRepository(ctrl),
+			provisioning: mock_gateway.NewMockProvisioningRepository(ctrl),
+		}
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.UserRepositoryType).ToInstance(m.users)
+		iocContext.Bind(gateway.ProvisioningRepositoryType).ToInstance(m.provisioning)
+		iocContext.Bind(gateway.PrincipalType).ToInstance(&principal)
+		audit := bindAuditLog(iocContext)
+
+		return m, audit, ioc.NewInjector(iocContext)
+	}
+
+	expectAvailable := func(m mocks) {
+		m.users.EXPECT().FindByUsername("jperez").Return(nil, nil)
+		m.users.EXPECT().FindByEmail("jperez@mail.com").Return(nil, nil)
+		m.users.EXPECT().FindByIDNumber("12345678").Return(nil, nil)
+	}
+
+	t.Run("CreateUser creates a driver of a known bus", func(t *testing.T) {
+		m, audit, locator := newLocator(t, platform)
+		expectAvailable(m)
+		m.provisioning.EXPECT().FindSchoolBus("11AAA222").Return(&bus, nil)
+		m.provisioning.EXPECT().CreateDriver(gomock.Any()).DoAndReturn(func(created model.ObservedUser) (*model.User, error) {
+			assert.Equal(t, "12345678", created.User.IDNumber)
+			assert.Equal(t, bus, created.SchoolBus)
+			assert.Len(t, created.PrivacyKey, 32)
+
+			user := created.User
+			user.ID = 1
+
+			return &user, nil
+		})
+
+		user, err := useCase.CreateUser(driver, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), user.ID)
+		assert.Empty(t, user.Password)
+		assert.Equal(t, []string{model.AuditActionUserCreated}, audit.actions())
+		assert.Equal(t, "cli:root", audit.entries[0].ActorUsername)
+	})
+
+	t.Run("CreateUser registers an unknown bus", func(t *testing.T) {
+		m, _, locator := newLocator(t, platform)
+		expectAvailable(m)
+		m.provisioning.EXPECT().FindSchoolBus("11AAA222").Return(nil, nil)
+		m.provisioning.EXPECT().SaveSchoolBus(model.SchoolBus{LicensePlate: "11AAA222", Model: "Master", Brand: "Renault", SchoolBusLicense: "11222"}).
+			Return(&bus, nil)
+		m.provisioning.EXPECT().CreateDriver(gomock.Any()).Return(&model.User{ID: 1}, nil)
+
+		provision := driver
+		provision.SchoolBus = model.SchoolBus{LicensePlate: "11AAA222", Model: "Master", Brand: "Renault", SchoolBusLicense: "11222"}
+		_, err := useCase.CreateUser(provision, locator)
+		assert.NoError(t, err)
+	})
+
+	t.Run("CreateUser needs the data of an unknown bus", func(t *testing.T) {
+		m, _, locator := newLocator(t, platform)
+		expectAvailable(m)
+		m.provisioning.EXPECT().FindSchoolBus("11AAA222").Return(nil, nil)
+
+		_, err := useCase.CreateUser(driver, locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("CreateUser with a taken username", func(t *testing.T) {
+		m, _, locator := newLocator(t, platform)
+		m.users.EXPECT().FindByUsername("jperez").Return(&model.User{ID: 1}, nil)
+
+		_, err := useCase.CreateUser(driver, locator)
+		assert.True(t, errors.Is(err, web.ErrConflict))
+	})
+
+	t.Run("CreateUser validates the password", func(t *testing.T) {
+		_, _, locator := newLocator(t, platform)
+
+		provision := driver
+		provision.User.Password = "short"
+		_, err := useCase.CreateUser(provision, locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("Company admins cannot create observers", func(t *testing.T) {
+		_, _, locator := newLocator(t, companyAdmin)
+
+		provision := driver
+		provision.User.Type = observer
+		_, err := useCase.CreateUser(provision, locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("AssignBus", func(t *testing.T) {
+		m, audit, locator := newLocator(t, platform)
+		m.users.EXPECT().Get(uint(1)).Return(&model.User{ID: 1, Type: observed}, nil)
+		m.provisioning.EXPECT().FindSchoolBus("11AAA222").Return(&bus, nil)
+		m.provisioning.EXPECT().AssignSchoolBus(uint(1), uint(2)).Return(nil)
+
+		assigned, err := useCase.AssignBus(1, model.SchoolBus{LicensePlate: "11AAA222"}, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &bus, assigned)
+		assert.Equal(t, []string{model.AuditActionBusAssigned}, audit.actions())
+	})
+
+	t.Run("AssignBus to an observer", func(t *testing.T) {
+		m, _, locator := newLocator(t, platform)
+		m.users.EXPECT().Get(uint(2)).Return(&model.User{ID: 2, Type: observer}, nil)
+
+		_, err := useCase.AssignBus(2, model.SchoolBus{LicensePlate: "11AAA222"}, locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("AddChild is only for platform admins", func(t *testing.T) {
+		_, _, locator := newLocator(t, companyAdmin)
+
+		_, err := useCase.AddChild(model.Children{Name: "Pilar", LastName: "Dominguez", SchoolStartTime: "08:00", SchoolEndTime: "12:00"}, locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+}
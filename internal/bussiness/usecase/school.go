@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	SchoolUseCaseType = "SchoolUseCase"
+
+	maxSchoolNameLength = 100
+)
+
+type (
+	SchoolUseCase interface {
+		Get(uint, gateway.ServiceLocator) (*model.School, error)
+		List(gateway.ServiceLocator) ([]model.School, error)
+		Create(model.School, gateway.ServiceLocator) (*model.School, error)
+		Update(model.School, gateway.ServiceLocator) (*model.School, error)
+		SetChildSchool(uint, uint, gateway.ServiceLocator) (*model.Children, error)
+	}
+
+	schoolUseCase struct{}
+)
+
+func NewSchoolUseCase() SchoolUseCase {
+	return &schoolUseCase{}
+}
+
+// Get obtains a school with its calendar. Every authenticated user can read the schools.
+func (s schoolUseCase) Get(schoolID uint, locator gateway.ServiceLocator) (_ *model.School, err error) {
+	span := startSpan(locator, "SchoolUseCase.Get")
+	defer func() { span.End(err) }()
+
+	if _, err := GetPrincipal(locator); err != nil {
+		return nil, err
+	}
+
+	return findSchool(schoolID, locator)
+}
+
+// List obtains every school sorted by name.
+func (s schoolUseCase) List(locator gateway.ServiceLocator) (_ []model.School, err error) {
+	span := startSpan(locator, "SchoolUseCase.List")
+	defer func() { span.End(err) }()
+
+	if _, err := GetPrincipal(locator); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.SchoolRepositoryType).(gateway.SchoolRepository)
+	schools, err := repository.GetSchools()
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	return schools, nil
+}
+
+// Create registers a new school. School names are unique, regardless of case and accents.
+func (s schoolUseCase) Create(school model.School, locator gateway.ServiceLocator) (_ *model.School, err error) {
+	span := startSpan(locator, "SchoolUseCase.Create")
+	defer func() { span.End(err) }()
+
+	if _, err := Authorize(locator, model.PermissionManageSchools); err != nil {
+		return nil, err
+	}
+
+	school.ID = 0
+
+	return s.save(school, model.AuditActionSchoolCreated, locator)
+}
+
+// Update replaces the data and calendar of an existing school.
+func (s schoolUseCase) Update(school model.School, locator gateway.ServiceLocator) (_ *model.School, err error) {
+	span := startSpan(locator, "SchoolUseCase.Update")
+	defer func() { span.End(err) }()
+
+	if _, err := Authorize(locator, model.PermissionManageSchools); err != nil {
+		return nil, err
+	}
+
+	if _, err := findSchool(school.ID, locator); err != nil {
+		return nil, err
+	}
+
+	return s.save(school, model.AuditActionSchoolUpdated, locator)
+}
+
+// SetChildSchool links a child to the school it attends. Only the primary guardian of the child can change it.
+func (s schoolUseCase) SetChildSchool(childID uint, schoolID uint, locator gateway.ServiceLocator) (_ *model.Children, err error) {
+	span := startSpan(locator, "SchoolUseCase.SetChildSchool")
+	defer func() { span.End(err) }()
+
+	if _, err := AuthorizeChild(locator, childID, model.ChildAccessManage); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.SchoolRepositoryType).(gateway.SchoolRepository)
+	child, err := repository.GetChild(childID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	if child == nil {
+		return nil, web.ErrNotFound
+	}
+
+	school, err := repository.GetSchool(schoolID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	if school == nil {
+		return nil, fmt.Errorf("%w: school %d does not exist", web.ErrBadRequest, schoolID)
+	}
+
+	if err = repository.SetChildSchool(childID, schoolID); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	child.SchoolID = school.ID
+	child.SchoolName = school.Name
+
+	return child, nil
+}
+
+func (s schoolUseCase) save(school model.School, action string, locator gateway.ServiceLocator) (*model.School, error) {
+	if err := validateSchool(&school); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.SchoolRepositoryType).(gateway.SchoolRepository)
+	schools, err := repository.GetSchools()
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	name := strings.Join(model.SearchTerms(school.Name), " ")
+	for _, other := range schools {
+		if other.ID != school.ID && strings.Join(model.SearchTerms(other.Name), " ") == name {
+			return nil, fmt.Errorf("%w: school %s already exists", web.ErrConflict, other.Name)
+		}
+	}
+
+	saved, err := repository.SaveSchool(school)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     action,
+		TargetType: model.AuditTargetSchool,
+		TargetID:   fmt.Sprint(saved.ID),
+		Details:    map[string]string{"name": saved.Name},
+	})
+
+	return saved, nil
+}
+
+// findSchool obtains a school, failing with web.ErrNotFound when it does not exist.
+func findSchool(schoolID uint, locator gateway.ServiceLocator) (*model.School, error) {
+	repository := locator.GetInstance(gateway.SchoolRepositoryType).(gateway.SchoolRepository)
+	school, err := repository.GetSchool(schoolID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	if school == nil {
+		return nil, web.ErrNotFound
+	}
+
+	return school, nil
+}
+
+func validateSchool(school *model.School) error {
+	school.Name = strings.TrimSpace(school.Name)
+	if school.Name == "" || len(school.Name) > maxSchoolNameLength {
+		return fmt.Errorf("%w: name is required and must have up to %d characters", web.ErrBadRequest, maxSchoolNameLength)
+	}
+
+	if (school.Latitude == nil) != (school.Longitude == nil) {
+		return fmt.Errorf("%w: latitude and longitude go together", web.ErrBadRequest)
+	}
+
+	if school.HasLocation() && (*school.Latitude < -90 || *school.Latitude > 90 || *school.Longitude < -180 || *school.Longitude > 180) {
+		return fmt.Errorf("%w: invalid coordinates", web.ErrBadRequest)
+	}
+
+	if school.GeofenceRadius == 0 {
+		school.GeofenceRadius = model.DefaultSchoolGeofenceRadius
+	}
+
+	if school.GeofenceRadius < model.MinSchoolGeofenceRadius || school.GeofenceRadius > model.MaxSchoolGeofenceRadius {
+		return fmt.Errorf("%w: geofence radius must be between %d and %d meters", web.ErrBadRequest, model.MinSchoolGeofenceRadius, model.MaxSchoolGeofenceRadius)
+	}
+
+	if school.Timezone == "" {
+		school.Timezone = model.DefaultSchoolTimezone
+	}
+
+	if _, err := time.LoadLocation(school.Timezone); err != nil {
+		return fmt.Errorf("%w: invalid timezone %s", web.ErrBadRequest, school.Timezone)
+	}
+
+	weekdays := map[time.Weekday]bool{}
+	for _, schedule := range school.BellSchedules {
+		if schedule.Weekday < time.Sunday || schedule.Weekday > time.Saturday || weekdays[schedule.Weekday] {
+			return fmt.Errorf("%w: bell schedules need distinct weekdays from 0 to 6", web.ErrBadRequest)
+		}
+		weekdays[schedule.Weekday] = true
+
+		start, err := model.ParseClock(schedule.Start)
+		if err != nil {
+			return fmt.Errorf("%w: %s", web.ErrBadRequest, err.Error())
+		}
+
+		end, err := model.ParseClock(schedule.End)
+		if err != nil {
+			return fmt.Errorf("%w: %s", web.ErrBadRequest, err.Error())
+		}
+
+		if start >= end {
+			return fmt.Errorf("%w: the school day of weekday %d must start before it ends", web.ErrBadRequest, schedule.Weekday)
+		}
+	}
+
+	return validateCalendar(school.Calendar)
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchools(t *testing.T) {
+	var (
+		useCase  = NewSchoolUseCase()
+		platform = model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}}
+		guardian = model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}}
+		laSalle  = model.School{ID: 1, Name: "La Salle", GeofenceRadius: 150, Timezone: model.DefaultSchoolTimezone}
+	)
+
+	newLocator := func(t *testing.T, principal model.Principal) (*mock_gateway.MockSchoolRepository, *auditLog, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		schools := mock_gateway.NewMockSchoolRepository(ctrl)
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.SchoolRepositoryType).ToInstance(schools)
+		iocContext.Bind(gateway.PrincipalType).ToInstance(&principal)
+		audit := bindAuditLog(iocContext)
+
+		return schools, audit, ioc.NewInjector(iocContext)
+	}
+
+	t.Run("Create applies the defaults", func(t *testing.T) {
+		schools, audit, locator := newLocator(t, platform)
+		schools.EXPECT().GetSchools().Return([]model.School{laSalle}, nil)
+		schools.EXPECT().SaveSchool(model.School{
+			Name:           "Normal 1",
+			GeofenceRadius: model.DefaultSchoolGeofenceRadius,
+			Timezone:       model.DefaultSchoolTimezone,
+			BellSchedules:  []model.BellSchedule{{Weekday: time.Monday, Start: "07:30", End: "12:15"}},
+		}).Return(&model.School{ID: 2, Name: "Normal 1"}, nil)
+
+		school, err := useCase.Create(model.School{
+			Name:          " Normal 1 ",
+			BellSchedules: []model.BellSchedule{{Weekday: time.Monday, Start: "07:30", End: "12:15"}},
+		}, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), school.ID)
+		assert.Equal(t, []string{model.AuditActionSchoolCreated}, audit.actions())
+	})
+
+	t.Run("Create with the name of another school", func(t *testing.T) {
+		schools, _, locator := newLocator(t, platform)
+		schools.EXPECT().GetSchools().Return([]model.School{laSalle}, nil)
+
+		_, err := useCase.Create(model.School{Name: "LA SALLÉ"}, locator)
+		assert.True(t, errors.Is(err, web.ErrConflict))
+	})
+
+	t.Run("Create with an invalid calendar", func(t *testing.T) {
+		_, _, locator := newLocator(t, platform)
+
+		for _, school := range []model.School{
+			{Name: "Normal 1", BellSchedules: []model.BellSchedule{{Weekday: time.Monday, Start: "12:00", End: "08:00"}}},
+			{Name: "Normal 1", BellSchedules: []model.BellSchedule{{Weekday: 7, Start: "08:00", End: "12:00"}}},
+			{Name: "Normal 1", Calendar: []model.CalendarEntry{{Date: "09/07/2023", Kind: model.CalendarEntryHoliday}}},
+			{Name: "Normal 1", Calendar: []model.CalendarEntry{{Date: "2023-07-09", Kind: "vacation"}}},
+			{Name: "Normal 1", Calendar: []model.CalendarEntry{{Date: "2023-07-10", Kind: model.CalendarEntryHalfDay}}},
+			{Name: "Normal 1", Timezone: "Mars/Olympus"},
+			{Name: "Normal 1", GeofenceRadius: 5},
+		} {
+			_, err := useCase.Create(school, locator)
+			assert.True(t, errors.Is(err, web.ErrBadRequest), school)
+		}
+	})
+
+	t.Run("Create without permission", func(t *testing.T) {
+		_, _, locator := newLocator(t, guardian)
+
+		_, err := useCase.Create(model.School{Name: "Normal 1"}, locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("Update missing school", func(t *testing.T) {
+		schools, _, locator := newLocator(t, platform)
+		schools.EXPECT().GetSchool(uint(5)).Return(nil, nil)
+
+		_, err := useCase.Update(model.School{ID: 5, Name: "Normal 1"}, locator)
+		assert.Equal(t, web.ErrNotFound, err)
+	})
+
+	newGuardians := func(t *testing.T, locator gateway.ServiceLocator) *mock_gateway.MockGuardianRepository {
+		guardians := mock_gateway.NewMockGuardianRepository(gomock.NewController(t))
+		locator.Context().Bind(gateway.GuardianRepositoryType).ToInstance(guardians)
+		return guardians
+	}
+
+	t.Run("SetChildSchool by the guardian", func(t *testing.T) {
+		schools, _, locator := newLocator(t, guardian)
+		newGuardians(t, locator).EXPECT().GetGuardians(uint(3)).Return([]model.Guardian{{ChildID: 3, ObserverUserID: 2, Role: model.GuardianRolePrimary}}, nil)
+		schools.EXPECT().GetChild(uint(3)).Return(&model.Children{ID: 3, ObserverUserID: 2, SchoolID: 2, SchoolName: "Normal 1"}, nil)
+		schools.EXPECT().GetSchool(uint(1)).Return(&laSalle, nil)
+		schools.EXPECT().SetChildSchool(uint(3), uint(1)).Return(nil)
+
+		child, err := useCase.SetChildSchool(3, 1, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "La Salle", child.SchoolName)
+	})
+
+	t.Run("SetChildSchool of another family", func(t *testing.T) {
+		_, _, locator := newLocator(t, guardian)
+		newGuardians(t, locator).EXPECT().GetGuardians(uint(4)).Return([]model.Guardian{{ChildID: 4, ObserverUserID: 7, Role: model.GuardianRolePrimary}}, nil)
+
+		_, err := useCase.SetChildSchool(4, 1, locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("SetChildSchool by a secondary guardian", func(t *testing.T) {
+		_, _, locator := newLocator(t, guardian)
+		newGuardians(t, locator).EXPECT().GetGuardians(uint(3)).Return([]model.Guardian{
+			{ChildID: 3, ObserverUserID: 7, Role: model.GuardianRolePrimary},
+			{ChildID: 3, ObserverUserID: 2, Role: model.GuardianRoleSecondary},
+		}, nil)
+
+		_, err := useCase.SetChildSchool(3, 1, locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+}
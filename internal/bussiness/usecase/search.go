@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	SearchUseCaseType = "SearchUseCase"
+
+	minSearchTermLength = 2
+)
+
+type (
+	SearchUseCase interface {
+		Search(model.SearchQuery, gateway.ServiceLocator) ([]model.SearchResult, error)
+	}
+
+	searchUseCase struct{}
+)
+
+func NewSearchUseCase() SearchUseCase {
+	return &searchUseCase{}
+}
+
+// Search finds the users, children and schools matching the text, children also by the name of their school.
+// Company admins only find the families riding with the drivers of their companies, and the guardians of their
+// children.
+func (s searchUseCase) Search(query model.SearchQuery, locator gateway.ServiceLocator) (_ []model.SearchResult, err error) {
+	span := startSpan(locator, "SearchUseCase.Search")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionReadUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	length := 0
+	for _, term := range query.Terms() {
+		length += len(term)
+	}
+
+	if length < minSearchTermLength {
+		return nil, fmt.Errorf("%w: search text must have at least %d letters or digits", web.ErrBadRequest, minSearchTermLength)
+	}
+
+	query.Normalize()
+	query.Companies = nil
+
+	if !principal.HasRole(model.RolePlatformAdmin) {
+		companies := principal.ManagedCompanies()
+		if len(companies) == 0 {
+			return []model.SearchResult{}, nil
+		}
+		query.Companies = companies
+	}
+
+	repository := locator.GetInstance(gateway.SearchRepositoryType).(gateway.SearchRepository)
+	results, err := repository.Search(query)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return results, nil
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch(t *testing.T) {
+	var (
+		useCase      = NewSearchUseCase()
+		platform     = model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}}
+		companyAdmin = model.Principal{UserID: 8, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "bus a"}}}
+		result       = model.SearchResult{Kind: model.SearchKindChild, ID: 3, FamilyID: 2, Title: "Martín Núñez", Score: 2}
+	)
+
+	newLocator := func(t *testing.T, principal model.Principal) (*mock_gateway.MockSearchRepository, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		search := mock_gateway.NewMockSearchRepository(ctrl)
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.SearchRepositoryType).ToInstance(search)
+		iocContext.Bind(gateway.PrincipalType).ToInstance(&principal)
+		bindAuditLog(iocContext)
+
+		return search, ioc.NewInjector(iocContext)
+	}
+
+	t.Run("platform admins search everything", func(t *testing.T) {
+		search, locator := newLocator(t, platform)
+		search.EXPECT().Search(model.SearchQuery{Text: "martin", Limit: 20}).Return([]model.SearchResult{result}, nil)
+
+		results, err := useCase.Search(model.SearchQuery{Text: "martin", Companies: []string{"bus b"}}, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.SearchResult{result}, results)
+	})
+
+	t.Run("company admins search their companies", func(t *testing.T) {
+		search, locator := newLocator(t, companyAdmin)
+		search.EXPECT().Search(model.SearchQuery{Text: "martin", Companies: []string{"bus a"}, Limit: 50}).Return([]model.SearchResult{}, nil)
+
+		results, err := useCase.Search(model.SearchQuery{Text: "martin", Limit: 500}, locator)
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("text too short", func(t *testing.T) {
+		_, locator := newLocator(t, platform)
+
+		results, err := useCase.Search(model.SearchQuery{Text: " ¡? a "}, locator)
+		assert.Nil(t, results)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("without permission", func(t *testing.T) {
+		_, locator := newLocator(t, model.Principal{UserID: 1})
+
+		_, err := useCase.Search(model.SearchQuery{Text: "martin"}, locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+}
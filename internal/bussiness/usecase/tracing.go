@@ -0,0 +1,19 @@
+package usecase
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+)
+
+type discardSpan struct{}
+
+func (discardSpan) End(error) {}
+
+// startSpan starts a span named after the use case with the tracer of the locator. Without a tracer bound, the
+// span is discarded.
+func startSpan(locator gateway.ServiceLocator, name string) gateway.Span {
+	if tracer, ok := locator.GetInstance(gateway.TracerType).(gateway.Tracer); ok {
+		return tracer.Start(name)
+	}
+
+	return discardSpan{}
+}
@@ -0,0 +1,448 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const (
+	TwoFactorUseCaseType = "TwoFactorUseCase"
+
+	challengeTTL       = 10 * time.Minute
+	recoveryCodesCount = 10
+	recoveryCodeSize   = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type (
+	TwoFactorUseCase interface {
+		Enroll(uint, gateway.ServiceLocator) (*model.TwoFactorEnrollment, error)
+		Confirm(uint, model.TwoFactorCode, gateway.ServiceLocator) (*model.RecoveryCodes, error)
+		Disable(uint, model.TwoFactorCode, gateway.ServiceLocator) error
+		RegenerateRecoveryCodes(uint, model.TwoFactorCode, gateway.ServiceLocator) (*model.RecoveryCodes, error)
+		GetPolicy(string, gateway.ServiceLocator) (*model.TwoFactorPolicy, error)
+		SetPolicy(model.TwoFactorPolicy, gateway.ServiceLocator) (*model.TwoFactorPolicy, error)
+	}
+
+	twoFactorUseCase struct {
+		now func() time.Time
+	}
+)
+
+func NewTwoFactorUseCase() TwoFactorUseCase {
+	return &twoFactorUseCase{now: time.Now}
+}
+
+// Enroll starts the enrollment of an authenticator app. The second factor is not required to log in
+// until it is confirmed with a first code.
+func (t twoFactorUseCase) Enroll(userID uint, locator gateway.ServiceLocator) (_ *model.TwoFactorEnrollment, err error) {
+	span := startSpan(locator, "TwoFactorUseCase.Enroll")
+	defer func() { span.End(err) }()
+
+	if _, err := AuthorizeSelf(locator, userID); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(userID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	return enrollTwoFactor(*user, locator)
+}
+
+// Confirm enables the pending second factor of a user and returns its recovery codes.
+func (t twoFactorUseCase) Confirm(userID uint, code model.TwoFactorCode, locator gateway.ServiceLocator) (_ *model.RecoveryCodes, err error) {
+	span := startSpan(locator, "TwoFactorUseCase.Confirm")
+	defer func() { span.End(err) }()
+
+	if _, err := AuthorizeSelf(locator, userID); err != nil {
+		return nil, err
+	}
+
+	codes, err := confirmTwoFactor(userID, code.Code, t.now(), locator)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionTwoFactorEnabled,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(userID),
+	})
+
+	return &model.RecoveryCodes{Codes: codes}, nil
+}
+
+// Disable removes the second factor of a user, which must prove it still holds it. Platform admins can remove
+// the second factor of other users without a code, to recover accounts whose authenticator app was lost.
+// Drivers of companies that require a second factor cannot disable it.
+func (t twoFactorUseCase) Disable(userID uint, code model.TwoFactorCode, locator gateway.ServiceLocator) (err error) {
+	span := startSpan(locator, "TwoFactorUseCase.Disable")
+	defer func() { span.End(err) }()
+
+	principal, err := AuthorizeSelf(locator, userID)
+	if err != nil {
+		return err
+	}
+
+	twoFactor, err := getTwoFactor(userID, locator)
+	if err != nil {
+		return err
+	}
+
+	if twoFactor == nil {
+		return fmt.Errorf("%w: two-factor authentication is not enabled", web.ErrNotFound)
+	}
+
+	if principal.UserID == userID {
+		if err = t.checkNotRequired(userID, locator); err != nil {
+			return err
+		}
+
+		if twoFactor.Enabled {
+			if err = verifyTwoFactorCode(*twoFactor, code, t.now(), locator); err != nil {
+				return err
+			}
+		}
+	}
+
+	repository := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+	if err = repository.Delete(userID); err != nil {
+		return web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionTwoFactorDisabled,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(userID),
+	})
+
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user, invalidating the previous ones.
+func (t twoFactorUseCase) RegenerateRecoveryCodes(userID uint, code model.TwoFactorCode, locator gateway.ServiceLocator) (_ *model.RecoveryCodes, err error) {
+	span := startSpan(locator, "TwoFactorUseCase.RegenerateRecoveryCodes")
+	defer func() { span.End(err) }()
+
+	if _, err := AuthorizeSelf(locator, userID); err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := getTwoFactor(userID, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil || !twoFactor.Enabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is not enabled", web.ErrNotFound)
+	}
+
+	if err = verifyTwoFactorCode(*twoFactor, code, t.now(), locator); err != nil {
+		return nil, err
+	}
+
+	codes, err := replaceRecoveryCodes(userID, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionRecoveryCodes,
+		TargetType: model.AuditTargetUser,
+		TargetID:   userTarget(userID),
+	})
+
+	return &model.RecoveryCodes{Codes: codes}, nil
+}
+
+// GetPolicy obtains the two-factor policy of a company.
+func (t twoFactorUseCase) GetPolicy(companyName string, locator gateway.ServiceLocator) (_ *model.TwoFactorPolicy, err error) {
+	span := startSpan(locator, "TwoFactorUseCase.GetPolicy")
+	defer func() { span.End(err) }()
+
+	if err := authorizeCompany(companyName, locator); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+	policy, err := repository.GetPolicy(companyName)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return policy, nil
+}
+
+// SetPolicy defines whether the drivers of a company must use a second factor. Drivers without one
+// are asked to enroll it the next time they log in.
+func (t twoFactorUseCase) SetPolicy(policy model.TwoFactorPolicy, locator gateway.ServiceLocator) (_ *model.TwoFactorPolicy, err error) {
+	span := startSpan(locator, "TwoFactorUseCase.SetPolicy")
+	defer func() { span.End(err) }()
+
+	if err := authorizeCompany(policy.CompanyName, locator); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+	if err := repository.SavePolicy(policy); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	recordAudit(locator, model.AuditEntry{
+		Action:     model.AuditActionTwoFactorPolicySet,
+		TargetType: model.AuditTargetCompany,
+		TargetID:   policy.CompanyName,
+		Details:    map[string]string{"required": strconv.FormatBool(policy.Required)},
+	})
+
+	return &policy, nil
+}
+
+func (t twoFactorUseCase) checkNotRequired(userID uint, locator gateway.ServiceLocator) error {
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.Get(userID)
+	if err != nil {
+		return repositoryError(err)
+	}
+
+	if user.Type != observed {
+		return nil
+	}
+
+	companyName, err := driverCompany(*user, repository)
+	if err != nil {
+		return err
+	}
+
+	required, err := twoFactorRequired(companyName, locator)
+	if err != nil {
+		return err
+	}
+
+	if required {
+		return fmt.Errorf("%w: two-factor authentication is required by %s", web.ErrForbidden, companyName)
+	}
+
+	return nil
+}
+
+// authorizeCompany checks that the principal can manage the users of the company.
+func authorizeCompany(companyName string, locator gateway.ServiceLocator) error {
+	principal, err := Authorize(locator, model.PermissionManageUsers)
+	if err != nil {
+		return err
+	}
+
+	if companyName == "" {
+		return fmt.Errorf("%w: company name is required", web.ErrBadRequest)
+	}
+
+	if !principal.HasRole(model.RolePlatformAdmin) && !contains(principal.ManagedCompanies(), companyName) {
+		return web.ErrForbidden
+	}
+
+	return nil
+}
+
+// enrollTwoFactor creates a new pending second factor for the user, replacing any other pending one.
+func enrollTwoFactor(user model.User, locator gateway.ServiceLocator) (*model.TwoFactorEnrollment, error) {
+	twoFactor, err := getTwoFactor(user.ID, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor != nil && twoFactor.Enabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is already enabled", web.ErrConflict)
+	}
+
+	totp := locator.GetInstance(gateway.TOTPServiceType).(gateway.TOTPService)
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	repository := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+	if err = repository.Save(model.TwoFactor{UserID: user.ID, Secret: secret}); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	uri := totp.ProvisioningURI(secret, user.Username)
+	qrCode, err := totp.QRCode(uri)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return &model.TwoFactorEnrollment{
+		Secret:          secret,
+		ProvisioningURI: uri,
+		QRCode:          qrCode,
+	}, nil
+}
+
+// confirmTwoFactor enables the pending second factor of the user with a first code and generates its recovery codes.
+func confirmTwoFactor(userID uint, code string, now time.Time, locator gateway.ServiceLocator) ([]string, error) {
+	twoFactor, err := getTwoFactor(userID, locator)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil {
+		return nil, fmt.Errorf("%w: two-factor enrollment was not started", web.ErrBadRequest)
+	}
+
+	if twoFactor.Enabled {
+		return nil, fmt.Errorf("%w: two-factor authentication is already enabled", web.ErrConflict)
+	}
+
+	// The pending second factor is enabled before checking the code, so the code is also recorded as used.
+	twoFactor.Enabled = true
+	if err = verifyTwoFactorCode(*twoFactor, model.TwoFactorCode{Code: code}, now, locator); err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+	if err = repository.Save(*twoFactor); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return replaceRecoveryCodes(userID, locator)
+}
+
+// verifyTwoFactorCode checks a code of the authenticator app, or a recovery code, of the user. Each code can only be
+// used once, and repeated failures are delayed and then temporarily locked out like failed logins.
+func verifyTwoFactorCode(twoFactor model.TwoFactor, code model.TwoFactorCode, now time.Time, locator gateway.ServiceLocator) error {
+	attempts, err := checkAttempts(map[string]string{model.LoginAttemptScopeTwoFactor: fmt.Sprint(twoFactor.UserID)}, now, locator)
+	if err != nil {
+		return err
+	}
+
+	err = checkTwoFactorCode(twoFactor, code, now, locator)
+	if errors.Is(err, web.ErrInvalidTwoFactor) {
+		registerFailures(attempts, now, locator)
+		recordAudit(locator, model.AuditEntry{
+			Action:     model.AuditActionTwoFactorFailed,
+			ActorID:    twoFactor.UserID,
+			TargetType: model.AuditTargetUser,
+			TargetID:   userTarget(twoFactor.UserID),
+		})
+	}
+
+	if err != nil {
+		return err
+	}
+
+	resetAttempts(attempts, locator)
+
+	return nil
+}
+
+func checkTwoFactorCode(twoFactor model.TwoFactor, code model.TwoFactorCode, now time.Time, locator gateway.ServiceLocator) error {
+	repository := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+
+	if code.RecoveryCode != "" {
+		used, err := repository.UseRecoveryCode(twoFactor.UserID, hashRecoveryCode(code.RecoveryCode))
+		if err != nil {
+			return web.ErrInternalServerError
+		}
+
+		if !used {
+			return web.ErrInvalidTwoFactor
+		}
+
+		return nil
+	}
+
+	totp := locator.GetInstance(gateway.TOTPServiceType).(gateway.TOTPService)
+	step, ok := totp.Validate(twoFactor.Secret, strings.TrimSpace(code.Code), now)
+	if !ok {
+		return web.ErrInvalidTwoFactor
+	}
+
+	if !twoFactor.Enabled {
+		return nil
+	}
+
+	used, err := repository.UseStep(twoFactor.UserID, step)
+	if err != nil {
+		return web.ErrInternalServerError
+	}
+
+	if !used {
+		return web.ErrInvalidTwoFactor
+	}
+
+	return nil
+}
+
+// replaceRecoveryCodes generates new recovery codes for the user. Only their hashes are stored.
+func replaceRecoveryCodes(userID uint, locator gateway.ServiceLocator) ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+
+	for i := range codes {
+		b := make([]byte, recoveryCodeSize)
+		if _, err := rand.Read(b); err != nil {
+			return nil, web.ErrInternalServerError
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes[i] = code[:4] + "-" + code[4:8] + "-" + code[8:12] + "-" + code[12:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	repository := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+	if err := repository.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return codes, nil
+}
+
+// hashRecoveryCode hashes a recovery code ignoring case, dashes and spaces. Recovery codes are random,
+// so a fast hash is enough to make the stored values useless.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func getTwoFactor(userID uint, locator gateway.ServiceLocator) (*model.TwoFactor, error) {
+	repository := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+	twoFactor, err := repository.Get(userID)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	return twoFactor, nil
+}
+
+// twoFactorRequired tells whether the company requires its drivers to use a second factor.
+func twoFactorRequired(companyName string, locator gateway.ServiceLocator) (bool, error) {
+	if companyName == "" {
+		return false, nil
+	}
+
+	repository := locator.GetInstance(gateway.TwoFactorRepositoryType).(gateway.TwoFactorRepository)
+	policy, err := repository.GetPolicy(companyName)
+	if err != nil {
+		return false, web.ErrInternalServerError
+	}
+
+	return policy.Required, nil
+}
@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type twoFactorMocks struct {
+	users      *mock_gateway.MockUserRepository
+	attempts   *mock_gateway.MockLoginAttemptRepository
+	tokens     *mock_gateway.MockTokenService
+	twoFactors *mock_gateway.MockTwoFactorRepository
+	totp       *mock_gateway.MockTOTPService
+	audit      *auditLog
+	locator    gateway.ServiceLocator
+}
+
+func newTwoFactorMocks(t *testing.T, principal *model.Principal) twoFactorMocks {
+	ctrl := gomock.NewController(t)
+	m := twoFactorMocks{
+		users:      mock_gateway.NewMockUserRepository(ctrl),
+		attempts:   mock_gateway.NewMockLoginAttemptRepository(ctrl),
+		tokens:     mock_gateway.NewMockTokenService(ctrl),
+		twoFactors: mock_gateway.NewMockTwoFactorRepository(ctrl),
+		totp:       mock_gateway.NewMockTOTPService(ctrl),
+	}
+
+	iocContext := ioc.NewContext()
+	iocContext.Bind(gateway.UserRepositoryType).ToInstance(m.users)
+	iocContext.Bind(gateway.LoginAttemptRepositoryType).ToInstance(m.attempts)
+	iocContext.Bind(gateway.TokenServiceType).ToInstance(m.tokens)
+	iocContext.Bind(gateway.TwoFactorRepositoryType).ToInstance(m.twoFactors)
+	iocContext.Bind(gateway.TOTPServiceType).ToInstance(m.totp)
+	m.audit = bindAuditLog(iocContext)
+	if principal != nil {
+		iocContext.Bind(gateway.PrincipalType).ToInstance(principal)
+	}
+	m.locator = ioc.NewInjector(iocContext)
+
+	return m
+}
+
+func (m twoFactorMocks) emptyTwoFactorAttempts(userID string) {
+	m.attempts.EXPECT().Get(model.LoginAttemptScopeTwoFactor, userID).
+		Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeTwoFactor, Key: userID}, nil)
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	var (
+		now     = time.Date(2022, 12, 10, 17, 49, 30, 0, time.UTC)
+		useCase = &loginUseCase{now: func() time.Time { return now }}
+		login   = model.Login{Username: "jperez", Password: "jperez1234"}
+		user    = model.User{ID: 1, Username: "jperez", Password: "jperez1234", Enabled: true, Type: observed}
+	)
+
+	expectLogin := func(m twoFactorMocks, companyName string) {
+		m.attempts.EXPECT().Get(model.LoginAttemptScopeUsername, "jperez").Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeUsername, Key: "jperez"}, nil)
+		m.users.EXPECT().FindByUsername("jperez").Return(&user, nil)
+		driver := model.NewObservedUser(model.ObservedUser{User: user, CompanyName: companyName})
+		m.users.EXPECT().GetObservedUser(gomock.Any()).Return(&driver, nil)
+	}
+
+	t.Run("Login with second factor returns a challenge", func(t *testing.T) {
+		m := newTwoFactorMocks(t, nil)
+		expectLogin(m, "")
+		m.twoFactors.EXPECT().Get(uint(1)).Return(&model.TwoFactor{UserID: 1, Enabled: true}, nil)
+		m.tokens.EXPECT().Sign(gomock.Any()).DoAndReturn(func(token model.Token) (string, error) {
+			assert.Equal(t, model.TokenPurposeTwoFactor, token.Purpose)
+			assert.Equal(t, now.Add(challengeTTL).Unix(), token.ExpiresAt)
+			return "challenge", nil
+		})
+
+		session, err := useCase.Login(login, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TwoFactorRequired, session.TwoFactor)
+		assert.Equal(t, "challenge", session.ChallengeToken)
+		assert.Empty(t, session.AccessToken)
+		assert.Nil(t, session.User)
+		assert.Empty(t, m.audit.actions())
+	})
+
+	t.Run("Login driver of a company requiring a second factor must enroll", func(t *testing.T) {
+		m := newTwoFactorMocks(t, nil)
+		expectLogin(m, "bus")
+		m.twoFactors.EXPECT().Get(uint(1)).Return(&model.TwoFactor{UserID: 1}, nil)
+		m.twoFactors.EXPECT().GetPolicy("bus").Return(&model.TwoFactorPolicy{CompanyName: "bus", Required: true}, nil)
+		m.tokens.EXPECT().Sign(gomock.Any()).Return("challenge", nil)
+
+		session, err := useCase.Login(login, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, model.TwoFactorEnrollmentRequired, session.TwoFactor)
+		assert.Empty(t, session.AccessToken)
+	})
+
+	t.Run("Login driver of a company without policy", func(t *testing.T) {
+		m := newTwoFactorMocks(t, nil)
+		expectLogin(m, "bus")
+		m.twoFactors.EXPECT().Get(uint(1)).Return(nil, nil)
+		m.twoFactors.EXPECT().GetPolicy("bus").Return(&model.TwoFactorPolicy{CompanyName: "bus"}, nil)
+		m.tokens.EXPECT().Sign(gomock.Any()).Return("signed", nil)
+
+		session, err := useCase.Login(login, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "signed", session.AccessToken)
+		assert.Empty(t, session.TwoFactor)
+	})
+}
+
+func TestVerifyTwoFactor(t *testing.T) {
+	var (
+		now       = time.Date(2022, 12, 10, 17, 49, 30, 0, time.UTC)
+		useCase   = &loginUseCase{now: func() time.Time { return now }}
+		user      = model.User{ID: 1, Username: "jperez", Enabled: true, Type: observer}
+		twoFactor = model.TwoFactor{UserID: 1, Secret: "SECRET", Enabled: true, LastUsedStep: 10}
+	)
+
+	expectChallenge := func(m twoFactorMocks, purpose string) {
+		m.tokens.EXPECT().Parse("challenge").Return(&model.Token{UserID: 1, Purpose: purpose}, nil)
+		m.users.EXPECT().Get(uint(1)).Return(&user, nil)
+	}
+
+	expectSession := func(m twoFactorMocks) {
+		guardian := model.NewObserverUser(model.ObserverUser{User: user})
+		m.users.EXPECT().GetObserverUser(gomock.Any()).Return(&guardian, nil)
+		m.tokens.EXPECT().Sign(gomock.Any()).Return("signed", nil)
+	}
+
+	t.Run("VerifyTwoFactor successful", func(t *testing.T) {
+		m := newTwoFactorMocks(t, nil)
+		expectChallenge(m, model.TokenPurposeTwoFactor)
+		m.twoFactors.EXPECT().Get(uint(1)).Return(&twoFactor, nil)
+		m.emptyTwoFactorAttempts("1")
+		m.totp.EXPECT().Validate("SECRET", "123456", now).Return(int64(11), true)
+		m.twoFactors.EXPECT().UseStep(uint(1), int64(11)).Return(true, nil)
+		expectSession(m)
+
+		login := model.TwoFactorLogin{ChallengeToken: "challenge", TwoFactorCode: model.TwoFactorCode{Code: "123456"}}
+		session, err := useCase.VerifyTwoFactor(login, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "signed", session.AccessToken)
+		assert.Empty(t, session.RecoveryCodes)
+		assert.Equal(t, []string{model.AuditActionLogin}, m.audit.actions())
+	})
+
+	t.Run("VerifyTwoFactor code already used", func(t *testing.T) {
+		m := newTwoFactorMocks(t, nil)
+		expectChallenge(m, model.TokenPurposeTwoFactor)
+		m.twoFactors.EXPECT().Get(uint(1)).Return(&twoFactor, nil)
+		m.emptyTwoFactorAttempts("1")
+		m.totp.EXPECT().Validate("SECRET", "123456", now).Return(int64(10), true)
+		m.twoFactors.EXPECT().UseStep(uint(1), int64(10)).Return(false, nil)
+		m.attempts.EXPECT().RegisterFailure(model.LoginAttemptScopeTwoFactor, "1", now, 15*time.Minute).
+			Return(&model.LoginAttempt{Scope: model.LoginAttemptScopeTwoFactor, Key: "1", Failures: 1, LastFailureAt: now.Unix()}, nil)
+
+		login := model.TwoFactorLogin{ChallengeToken: "challenge", TwoFactorCode: model.TwoFactorCode{Code: "123456"}}
+		session, err := useCase.VerifyTwoFactor(login, m.locator)
+		assert.Nil(t, session)
+		assert.Equal(t, web.ErrInvalidTwoFactor, err)
+		assert.Equal(t, []string{model.AuditActionTwoFactorFailed}, m.audit.actions())
+	})
+
+	t.Run("VerifyTwoFactor with recovery code", func(t *testing.T) {
+		m := newTwoFactorMocks(t, nil)
+		expectChallenge(m, model.TokenPurposeTwoFactor)
+		m.twoFactors.EXPECT().Get(uint(1)).Return(&twoFactor, nil)
+		m.emptyTwoFactorAttempts("1")
+		m.twoFactors.EXPECT().UseRecoveryCode(uint(1), hashRecoveryCode("abcd-efgh-ijkl-mnop")).Return(true, nil)
+		expectSession(m)
+
+		login := model.TwoFactorLogin{ChallengeToken: "challenge", TwoFactorCode: model.TwoFactorCode{RecoveryCode: "ABCD EFGH IJKL MNOP"}}
+		session, err := useCase.VerifyTwoFactor(login, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "signed", session.AccessToken)
+	})
+
+	t.Run("VerifyTwoFactor confirms a required enrollment", func(t *testing.T) {
+		m := newTwoFactorMocks(t, nil)
+		expectChallenge(m, model.TokenPurposeEnrollment)
+		m.twoFactors.EXPECT().Get(uint(1)).Return(&model.TwoFactor{UserID: 1, Secret: "SECRET"}, nil)
+		m.emptyTwoFactorAttempts("1")
+		m.totp.EXPECT().Validate("SECRET", "123456", now).Return(int64(11), true)
+		m.twoFactors.EXPECT().UseStep(uint(1), int64(11)).Return(true, nil)
+		m.twoFactors.EXPECT().Save(model.TwoFactor{UserID: 1, Secret: "SECRET", Enabled: true}).Return(nil)
+		m.twoFactors.EXPECT().ReplaceRecoveryCodes(uint(1), gomock.Any()).DoAndReturn(func(_ uint, hashes []string) error {
+			assert.Len(t, hashes, recoveryCodesCount)
+			return nil
+		})
+		expectSession(m)
+
+		login := model.TwoFactorLogin{ChallengeToken: "challenge", TwoFactorCode: model.TwoFactorCode{Code: "123456"}}
+		session, err := useCase.VerifyTwoFactor(login, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, "signed", session.AccessToken)
+		assert.Len(t, session.RecoveryCodes, recoveryCodesCount)
+		assert.Regexp(t, "^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$", session.RecoveryCodes[0])
+	})
+
+	t.Run("VerifyTwoFactor rejects access tokens", func(t *testing.T) {
+		m := newTwoFactorMocks(t, nil)
+		m.tokens.EXPECT().Parse("challenge").Return(&model.Token{UserID: 1, Purpose: model.TokenPurposeAccess}, nil)
+
+		login := model.TwoFactorLogin{ChallengeToken: "challenge", TwoFactorCode: model.TwoFactorCode{Code: "123456"}}
+		session, err := useCase.VerifyTwoFactor(login, m.locator)
+		assert.Nil(t, session)
+		assert.Equal(t, web.ErrInvalidToken, err)
+	})
+}
+
+func TestTwoFactorUseCase(t *testing.T) {
+	var (
+		now     = time.Date(2022, 12, 10, 17, 49, 30, 0, time.UTC)
+		useCase = &twoFactorUseCase{now: func() time.Time { return now }}
+		driver  = model.User{ID: 1, Username: "jperez", Enabled: true, Type: observed}
+		self    = &model.Principal{UserID: 1, Roles: []model.RoleAssignment{{Role: model.RoleDriver}}}
+	)
+
+	t.Run("Enroll returns the provisioning URI", func(t *testing.T) {
+		m := newTwoFactorMocks(t, self)
+		m.users.EXPECT().Get(uint(1)).Return(&driver, nil)
+		m.twoFactors.EXPECT().Get(uint(1)).Return(nil, nil)
+		m.totp.EXPECT().NewSecret().Return("SECRET", nil)
+		m.twoFactors.EXPECT().Save(model.TwoFactor{UserID: 1, Secret: "SECRET"}).Return(nil)
+		m.totp.EXPECT().ProvisioningURI("SECRET", "jperez").Return("otpauth://totp/DondeEstan:jperez")
+		m.totp.EXPECT().QRCode("otpauth://totp/DondeEstan:jperez").Return("data:image/png;base64,", nil)
+
+		enrollment, err := useCase.Enroll(1, m.locator)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.TwoFactorEnrollment{Secret: "SECRET", ProvisioningURI: "otpauth://totp/DondeEstan:jperez", QRCode: "data:image/png;base64,"}, enrollment)
+	})
+
+	t.Run("Enroll already enabled", func(t *testing.T) {
+		m := newTwoFactorMocks(t, self)
+		m.users.EXPECT().Get(uint(1)).Return(&driver, nil)
+		m.twoFactors.EXPECT().Get(uint(1)).Return(&model.TwoFactor{UserID: 1, Enabled: true}, nil)
+
+		enrollment, err := useCase.Enroll(1, m.locator)
+		assert.Nil(t, enrollment)
+		assert.True(t, errors.Is(err, web.ErrConflict))
+	})
+
+	t.Run("Disable required by the company", func(t *testing.T) {
+		m := newTwoFactorMocks(t, self)
+		m.twoFactors.EXPECT().Get(uint(1)).Return(&model.TwoFactor{UserID: 1, Enabled: true}, nil)
+		m.users.EXPECT().Get(uint(1)).Return(&driver, nil)
+		company := model.NewObservedUser(model.ObservedUser{User: driver, CompanyName: "bus"})
+		m.users.EXPECT().GetObservedUser(gomock.Any()).Return(&company, nil)
+		m.twoFactors.EXPECT().GetPolicy("bus").Return(&model.TwoFactorPolicy{CompanyName: "bus", Required: true}, nil)
+
+		err := useCase.Disable(1, model.TwoFactorCode{Code: "123456"}, m.locator)
+		assert.True(t, errors.Is(err, web.ErrForbidden))
+	})
+
+	t.Run("Disable by a platform admin does not need a code", func(t *testing.T) {
+		m := newTwoFactorMocks(t, &model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}})
+		m.twoFactors.EXPECT().Get(uint(1)).Return(&model.TwoFactor{UserID: 1, Enabled: true}, nil)
+		m.twoFactors.EXPECT().Delete(uint(1)).Return(nil)
+
+		err := useCase.Disable(1, model.TwoFactorCode{}, m.locator)
+		assert.NoError(t, err)
+	})
+
+	t.Run("SetPolicy of another company", func(t *testing.T) {
+		m := newTwoFactorMocks(t, &model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "bus"}}})
+
+		policy, err := useCase.SetPolicy(model.TwoFactorPolicy{CompanyName: "other", Required: true}, m.locator)
+		assert.Nil(t, policy)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("SetPolicy of a managed company", func(t *testing.T) {
+		m := newTwoFactorMocks(t, &model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "bus"}}})
+		m.twoFactors.EXPECT().SavePolicy(model.TwoFactorPolicy{CompanyName: "bus", Required: true}).Return(nil)
+
+		policy, err := useCase.SetPolicy(model.TwoFactorPolicy{CompanyName: "bus", Required: true}, m.locator)
+		assert.NoError(t, err)
+		assert.True(t, policy.Required)
+	})
+}
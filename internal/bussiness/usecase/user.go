@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"fmt"
+
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
@@ -11,6 +13,8 @@ const UserUseCaseType = "UserUseCase"
 type (
 	UserUseCase interface {
 		Get(uint, gateway.ServiceLocator) (*model.User, error)
+		GetByUsername(string, gateway.ServiceLocator) (*model.User, error)
+		List(model.UserQuery, gateway.ServiceLocator) (*model.UserPage, error)
 	}
 
 	userUseCase struct{}
@@ -20,16 +24,128 @@ func NewUserUseCase() UserUseCase {
 	return &userUseCase{}
 }
 
-func (u userUseCase) Get(userID uint, locator gateway.ServiceLocator) (*model.User, error) {
+// Get obtains a user. Platform admins can read any user, while company admins can only read the drivers of
+// their companies.
+func (u userUseCase) Get(userID uint, locator gateway.ServiceLocator) (_ *model.User, err error) {
+	span := startSpan(locator, "UserUseCase.Get")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionReadUsers)
+	if err != nil {
+		return nil, err
+	}
+
 	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
 	user, err := repository.Get(userID)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	return u.readable(principal, user, repository)
+}
+
+// GetByUsername obtains a user by its username, with the same restrictions as Get.
+func (u userUseCase) GetByUsername(username string, locator gateway.ServiceLocator) (_ *model.User, err error) {
+	span := startSpan(locator, "UserUseCase.GetByUsername")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionReadUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	user, err := repository.FindByUsername(username)
+	if err != nil {
+		return nil, repositoryError(err)
+	}
+
+	return u.readable(principal, user, repository)
+}
+
+// List obtains a page of the users matching the query, along with the total of users matching it.
+// Company admins only see the drivers of their companies.
+func (u userUseCase) List(query model.UserQuery, locator gateway.ServiceLocator) (_ *model.UserPage, err error) {
+	span := startSpan(locator, "UserUseCase.List")
+	defer func() { span.End(err) }()
+
+	principal, err := Authorize(locator, model.PermissionReadUsers)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = validateUserQuery(query); err != nil {
+		return nil, err
+	}
+
+	query.Normalize()
+	query.Companies = nil
+
+	if !principal.HasRole(model.RolePlatformAdmin) {
+		companies := principal.ManagedCompanies()
+		if query.CompanyName != "" && !contains(companies, query.CompanyName) {
+			return nil, web.ErrForbidden
+		}
+
+		if len(companies) == 0 {
+			return &model.UserPage{Users: []model.User{}}, nil
+		}
+		query.Companies = companies
+	}
+
+	repository := locator.GetInstance(gateway.UserRepositoryType).(gateway.UserRepository)
+	users, err := repository.GetUsers(query)
 	if err != nil {
 		return nil, web.ErrInternalServerError
 	}
 
+	total, err := repository.CountUsers(query)
+	if err != nil {
+		return nil, web.ErrInternalServerError
+	}
+
+	page := model.UserPage{Users: users, Total: total}
+	if len(users) == query.Limit {
+		page.NextCursor = model.NewUserCursor(users[len(users)-1], query.Sort).Encode()
+	}
+
+	for i := range page.Users {
+		page.Users[i].SetPassword("")
+	}
+
+	return &page, nil
+}
+
+// readable checks that the principal can read the user, and leaves its password out.
+func (u userUseCase) readable(principal *model.Principal, user *model.User, repository gateway.UserRepository) (*model.User, error) {
 	if user == nil {
 		return nil, web.ErrNotFound
 	}
 
+	if !principal.HasRole(model.RolePlatformAdmin) {
+		companyName, err := driverCompany(*user, repository)
+		if err != nil {
+			return nil, err
+		}
+
+		if !contains(principal.ManagedCompanies(), companyName) {
+			return nil, web.ErrForbidden
+		}
+	}
+
+	user.SetPassword("")
+
 	return user, nil
 }
+
+func validateUserQuery(query model.UserQuery) error {
+	if query.Type != "" && query.Type != observed && query.Type != observer {
+		return fmt.Errorf("%w: type must be %s or %s", web.ErrBadRequest, observed, observer)
+	}
+
+	if query.Sort != "" && !contains(model.UserSorts, query.Sort) {
+		return fmt.Errorf("%w: sort must be one of %v", web.ErrBadRequest, model.UserSorts)
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	mock_gateway "github.com/gcoron/donde-estan-ws/internal/infrastructure/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsers(t *testing.T) {
+	var (
+		useCase      = NewUserUseCase()
+		driver       = model.User{ID: 1, Name: "Jose", Password: "jperez1234", Type: observed, Enabled: true}
+		platform     = model.Principal{UserID: 9, Roles: []model.RoleAssignment{{Role: model.RolePlatformAdmin}}}
+		companyAdmin = model.Principal{UserID: 8, Roles: []model.RoleAssignment{{Role: model.RoleCompanyAdmin, CompanyName: "bus a"}}}
+	)
+
+	newLocator := func(t *testing.T, principal model.Principal) (*mock_gateway.MockUserRepository, gateway.ServiceLocator) {
+		ctrl := gomock.NewController(t)
+		users := mock_gateway.NewMockUserRepository(ctrl)
+
+		iocContext := ioc.NewContext()
+		iocContext.Bind(gateway.UserRepositoryType).ToInstance(users)
+		iocContext.Bind(gateway.PrincipalType).ToInstance(&principal)
+		bindAuditLog(iocContext)
+
+		return users, ioc.NewInjector(iocContext)
+	}
+
+	t.Run("Get leaves the password out", func(t *testing.T) {
+		users, locator := newLocator(t, platform)
+		found := driver
+		users.EXPECT().Get(uint(1)).Return(&found, nil)
+
+		user, err := useCase.Get(1, locator)
+		assert.NoError(t, err)
+		assert.Empty(t, user.Password)
+	})
+
+	t.Run("Get driver of another company", func(t *testing.T) {
+		users, locator := newLocator(t, companyAdmin)
+		found := driver
+		users.EXPECT().Get(uint(1)).Return(&found, nil)
+		other := model.NewObservedUser(model.ObservedUser{User: driver, CompanyName: "bus b"})
+		users.EXPECT().GetObservedUser(gomock.Any()).Return(&other, nil)
+
+		user, err := useCase.Get(1, locator)
+		assert.Nil(t, user)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("GetByUsername not found", func(t *testing.T) {
+		users, locator := newLocator(t, platform)
+		users.EXPECT().FindByUsername("nobody").Return(nil, nil)
+
+		user, err := useCase.GetByUsername("nobody", locator)
+		assert.Nil(t, user)
+		assert.Equal(t, web.ErrNotFound, err)
+	})
+
+	t.Run("List returns the next cursor on full pages", func(t *testing.T) {
+		users, locator := newLocator(t, platform)
+		query := model.UserQuery{Sort: model.UserSortName, Limit: 2}
+		users.EXPECT().GetUsers(query).Return([]model.User{driver, {ID: 4, Name: "Maria", Password: "secret"}}, nil)
+		users.EXPECT().CountUsers(query).Return(5, nil)
+
+		page, err := useCase.List(query, locator)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, page.Total)
+		assert.Empty(t, page.Users[1].Password)
+
+		cursor, err := model.DecodeUserCursor(page.NextCursor)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.UserCursor{Value: "Maria", ID: 4}, cursor)
+	})
+
+	t.Run("List last page", func(t *testing.T) {
+		users, locator := newLocator(t, platform)
+		users.EXPECT().GetUsers(gomock.Any()).Return([]model.User{driver}, nil)
+		users.EXPECT().CountUsers(gomock.Any()).Return(1, nil)
+
+		page, err := useCase.List(model.UserQuery{}, locator)
+		assert.NoError(t, err)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("List company admin is restricted to its companies", func(t *testing.T) {
+		users, locator := newLocator(t, companyAdmin)
+		expected := model.UserQuery{Companies: []string{"bus a"}, Sort: model.UserSortID, Limit: 20}
+		users.EXPECT().GetUsers(expected).Return([]model.User{}, nil)
+		users.EXPECT().CountUsers(expected).Return(0, nil)
+
+		_, err := useCase.List(model.UserQuery{Companies: []string{"bus b"}}, locator)
+		assert.NoError(t, err)
+	})
+
+	t.Run("List company admin filtering another company", func(t *testing.T) {
+		_, locator := newLocator(t, companyAdmin)
+
+		_, err := useCase.List(model.UserQuery{CompanyName: "bus b"}, locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+
+	t.Run("List invalid sort", func(t *testing.T) {
+		_, locator := newLocator(t, platform)
+
+		_, err := useCase.List(model.UserQuery{Sort: "password"}, locator)
+		assert.True(t, errors.Is(err, web.ErrBadRequest))
+	})
+
+	t.Run("List guardian", func(t *testing.T) {
+		_, locator := newLocator(t, model.Principal{UserID: 2, Roles: []model.RoleAssignment{{Role: model.RoleGuardian}}})
+
+		_, err := useCase.List(model.UserQuery{}, locator)
+		assert.Equal(t, web.ErrForbidden, err)
+	})
+}
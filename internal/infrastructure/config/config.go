@@ -0,0 +1,291 @@
+// Package config builds the configuration of the web service and the commands from the defaults, an optional
+// YAML or TOML file, the environment variables and the command line flags, in increasing order of precedence.
+//
+// Every setting is named after its environment variable, such as DB_HOST, and its flag is the lower case variable
+// with dashes, such as -db-host. The file is given by the -config flag or the CONFIG_FILE variable, and its format
+// is chosen by its extension.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/mail"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// redacted replaces the secrets when the configuration is printed.
+	redacted = "REDACTED"
+	// minTokenSecretLength keeps the token secret from being guessed offline from a signed token.
+	minTokenSecretLength = 32
+	// placeholderTokenSecret is the value the former .env shipped with, which anyone can sign tokens with.
+	placeholderTokenSecret = "change-me-in-production-to-a-random-secret"
+)
+
+// Config is the whole configuration. Fields tagged with env are settings; the ones tagged as secret are redacted
+// when printed.
+type Config struct {
+	BaseURL  string   `yaml:"base_url" toml:"base_url" env:"APP_BASE_URL" usage:"public URL of the web service, used in the links sent by email"`
+	HTTP     HTTP     `yaml:"http" toml:"http"`
+	TLS      TLS      `yaml:"tls" toml:"tls"`
+	Database Database `yaml:"database" toml:"database"`
+	Log      Log      `yaml:"log" toml:"log"`
+	Health   Health   `yaml:"health" toml:"health"`
+	Tracing  Tracing  `yaml:"tracing" toml:"tracing"`
+	Mail     Mail     `yaml:"mail" toml:"mail"`
+	Security Security `yaml:"security" toml:"security"`
+	Features Features `yaml:"features" toml:"features"`
+}
+
+// HTTP configures the web server.
+type HTTP struct {
+	Addr              string        `yaml:"addr" toml:"addr" env:"HTTP_ADDR" usage:"address the web server listens on"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"HTTP_READ_TIMEOUT" usage:"maximum duration to read a whole request"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" usage:"maximum duration to read the headers of a request"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" usage:"maximum duration to write a response"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" usage:"maximum duration to keep an idle connection open"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" usage:"maximum duration to finish the requests in flight when stopping"`
+	TrustedProxies    string        `yaml:"trusted_proxies" toml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES" usage:"comma separated IPs and CIDR networks of the reverse proxies whose X-Forwarded-For is trusted"`
+	MetricsAddr       string        `yaml:"metrics_addr" toml:"metrics_addr" env:"HTTP_METRICS_ADDR" usage:"address of the listener serving /metrics, apart from the API, none when empty"`
+}
+
+// TLS configures HTTPS. It is enabled when both files are set, which are reloaded when they change or on SIGHUP.
+type TLS struct {
+	CertFile       string        `yaml:"cert_file" toml:"cert_file" env:"TLS_CERT_FILE" usage:"PEM certificate chain file"`
+	KeyFile        string        `yaml:"key_file" toml:"key_file" env:"TLS_KEY_FILE" usage:"PEM private key file"`
+	ReloadInterval time.Duration `yaml:"reload_interval" toml:"reload_interval" env:"TLS_RELOAD_INTERVAL" usage:"how often the certificate files are checked for changes"`
+	RedirectAddr   string        `yaml:"redirect_addr" toml:"redirect_addr" env:"TLS_REDIRECT_ADDR" usage:"address of a plain HTTP listener redirecting to HTTPS, such as :80, none when empty"`
+	HSTSMaxAge     time.Duration `yaml:"hsts_max_age" toml:"hsts_max_age" env:"TLS_HSTS_MAX_AGE" usage:"how long browsers must only use HTTPS, 0 to send no Strict-Transport-Security header"`
+}
+
+// Enabled reports whether HTTPS is configured.
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
+}
+
+// Database configures the MySQL connection and its pool. Params are added to the DSN, such as parseTime=true;
+// from the environment or a flag they are given as a query string.
+type Database struct {
+	User            string            `yaml:"user" toml:"user" env:"DB_USER" usage:"database user"`
+	Password        string            `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true" usage:"database password"`
+	Host            string            `yaml:"host" toml:"host" env:"DB_HOST" usage:"database host and port"`
+	Name            string            `yaml:"name" toml:"name" env:"DB_NAME" usage:"database name"`
+	Params          map[string]string `yaml:"params" toml:"params" env:"DB_PARAMS" usage:"DSN parameters as a query string, such as charset=utf8&timeout=5s"`
+	MaxOpenConns    int               `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" usage:"maximum open connections, 0 for unlimited"`
+	MaxIdleConns    int               `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" usage:"maximum idle connections"`
+	ConnMaxLifetime time.Duration     `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" usage:"maximum duration a connection is reused, 0 for unlimited"`
+	ConnMaxIdleTime time.Duration     `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" usage:"maximum duration a connection stays idle, 0 for unlimited"`
+}
+
+// Log configures the application logs.
+type Log struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL" usage:"minimum level logged: debug, info, warning or error"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" usage:"format of the logs: text or json"`
+}
+
+// Health configures the readiness checks.
+type Health struct {
+	CacheTTL     time.Duration `yaml:"cache_ttl" toml:"cache_ttl" env:"HEALTH_CACHE_TTL" usage:"how long a readiness check result is reused"`
+	CheckTimeout time.Duration `yaml:"check_timeout" toml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" usage:"maximum duration of a readiness check"`
+}
+
+// Tracing configures the OpenTelemetry traces. The OTLP exporter also reads the standard OTEL_EXPORTER_OTLP_*
+// variables for what is not set here, such as OTEL_EXPORTER_OTLP_HEADERS.
+type Tracing struct {
+	Exporter     string  `yaml:"exporter" toml:"exporter" env:"TRACING_EXPORTER" usage:"where spans go: none, stdout, file or otlp"`
+	File         string  `yaml:"file" toml:"file" env:"TRACING_FILE" usage:"file of the file exporter, one JSON span per line"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" usage:"host and port of the OTLP/HTTP collector, localhost:4318 when empty"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" toml:"otlp_insecure" env:"TRACING_OTLP_INSECURE" usage:"send the spans to the collector over plain HTTP"`
+	SampleRatio  float64 `yaml:"sample_ratio" toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" usage:"fraction of the traces started by the service that are recorded, from 0 to 1"`
+	ServiceName  string  `yaml:"service_name" toml:"service_name" env:"TRACING_SERVICE_NAME" usage:"service name of the spans"`
+}
+
+// Mail configures how emails are sent: written to the standard output, to files, or through SMTP.
+type Mail struct {
+	Sink         string `yaml:"sink" toml:"sink" env:"MAIL_SINK" usage:"where emails go: stdout, file or smtp"`
+	Dir          string `yaml:"dir" toml:"dir" env:"MAIL_DIR" usage:"directory of the file sink"`
+	From         string `yaml:"from" toml:"from" env:"MAIL_FROM" usage:"sender address"`
+	SMTPAddr     string `yaml:"smtp_addr" toml:"smtp_addr" env:"SMTP_ADDR" usage:"SMTP server host and port"`
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username" env:"SMTP_USERNAME" usage:"SMTP user"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password" env:"SMTP_PASSWORD" secret:"true" usage:"SMTP password"`
+}
+
+// Security configures the secrets and keys. The token secret must be the same on every replica and across restarts,
+// or the tokens they issued are no longer valid.
+type Security struct {
+	TokenSecret    string `yaml:"token_secret" toml:"token_secret" env:"TOKEN_SECRET" secret:"true" usage:"secret signing the tokens sent to users"`
+	PrivacyKeyFile string `yaml:"privacy_key_file" toml:"privacy_key_file" env:"PRIVACY_KEY_FILE" usage:"JSON file with the keys encrypting the ID numbers"`
+	TOTPIssuer     string `yaml:"totp_issuer" toml:"totp_issuer" env:"TOTP_ISSUER" usage:"issuer shown by the authenticator apps"`
+}
+
+// Features toggles optional behavior.
+type Features struct {
+	RequireEmailVerification bool `yaml:"require_email_verification" toml:"require_email_verification" env:"REQUIRE_EMAIL_VERIFICATION" usage:"reject the logins of users with an unverified email"`
+	DigestWorker             bool `yaml:"digest_worker" toml:"digest_worker" env:"DIGEST_WORKER" usage:"deliver the notification digests periodically"`
+	ErasureWorker            bool `yaml:"erasure_worker" toml:"erasure_worker" env:"ERASURE_WORKER" usage:"erase the accounts whose deletion grace period is over periodically"`
+	DemoData                 bool `yaml:"demo_data" toml:"demo_data" env:"DEMO_DATA" usage:"let the seed command create the demo accounts, whose passwords are public; only for development databases"`
+}
+
+// Default returns the configuration used for the settings given nowhere else.
+func Default() Config {
+	return Config{
+		BaseURL: "http://localhost:8080",
+		HTTP: HTTP{
+			Addr:              ":8080",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+			MetricsAddr:       "127.0.0.1:9090",
+		},
+		TLS: TLS{
+			ReloadInterval: time.Minute,
+			HSTSMaxAge:     365 * 24 * time.Hour,
+		},
+		Database: Database{
+			Params:          map[string]string{"charset": "utf8"},
+			MaxOpenConns:    20,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: time.Minute,
+		},
+		Log:    Log{Level: "info", Format: logging.FormatText},
+		Health: Health{CacheTTL: 5 * time.Second, CheckTimeout: 2 * time.Second},
+		Tracing: Tracing{
+			Exporter:    tracing.ExporterNone,
+			File:        "traces.json",
+			SampleRatio: 1,
+			ServiceName: "donde-estan-ws",
+		},
+		Mail: Mail{
+			Sink: mail.SinkStdout,
+			Dir:  "mails",
+			From: "no-reply@dondeestan.app",
+		},
+		Security: Security{
+			PrivacyKeyFile: "privacy-keys.json",
+			TOTPIssuer:     "DondeEstan",
+		},
+		Features: Features{
+			DigestWorker:  true,
+			ErasureWorker: true,
+		},
+	}
+}
+
+// Validate checks the settings, returning every problem found at once.
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	if u, err := url.Parse(c.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		check(false, "APP_BASE_URL must be an absolute URL")
+	}
+
+	check(c.HTTP.Addr != "", "HTTP_ADDR is required")
+	for name, timeout := range map[string]time.Duration{
+		"HTTP_READ_TIMEOUT":        c.HTTP.ReadTimeout,
+		"HTTP_READ_HEADER_TIMEOUT": c.HTTP.ReadHeaderTimeout,
+		"HTTP_WRITE_TIMEOUT":       c.HTTP.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":        c.HTTP.IdleTimeout,
+		"HTTP_SHUTDOWN_TIMEOUT":    c.HTTP.ShutdownTimeout,
+	} {
+		check(timeout > 0, "%s must be positive", name)
+	}
+
+	_, err := utils.ParseTrustedProxies(c.HTTP.TrustedProxies)
+	check(err == nil, "HTTP_TRUSTED_PROXIES: %v", err)
+
+	check((c.TLS.CertFile == "") == (c.TLS.KeyFile == ""), "TLS_CERT_FILE and TLS_KEY_FILE go together")
+	check(c.TLS.ReloadInterval > 0, "TLS_RELOAD_INTERVAL must be positive")
+	check(c.TLS.RedirectAddr == "" || c.TLS.Enabled(), "TLS_REDIRECT_ADDR requires TLS_CERT_FILE and TLS_KEY_FILE")
+	check(c.TLS.RedirectAddr == "" || c.TLS.RedirectAddr != c.HTTP.Addr, "TLS_REDIRECT_ADDR must differ from HTTP_ADDR")
+	check(c.HTTP.MetricsAddr == "" || (c.HTTP.MetricsAddr != c.HTTP.Addr && c.HTTP.MetricsAddr != c.TLS.RedirectAddr), "HTTP_METRICS_ADDR must differ from HTTP_ADDR and TLS_REDIRECT_ADDR")
+	check(c.TLS.HSTSMaxAge >= 0, "TLS_HSTS_MAX_AGE cannot be negative")
+
+	check(c.Database.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS cannot be negative")
+	check(c.Database.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS cannot be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns, "DB_MAX_IDLE_CONNS cannot exceed DB_MAX_OPEN_CONNS")
+	check(c.Database.ConnMaxLifetime >= 0, "DB_CONN_MAX_LIFETIME cannot be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "DB_CONN_MAX_IDLE_TIME cannot be negative")
+
+	check(c.Health.CacheTTL >= 0, "HEALTH_CACHE_TTL cannot be negative")
+	check(c.Health.CheckTimeout > 0, "HEALTH_CHECK_TIMEOUT must be positive")
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLP:
+	case tracing.ExporterFile:
+		check(c.Tracing.File != "", "TRACING_FILE is required by the file exporter")
+	default:
+		check(false, "TRACING_EXPORTER must be %s, %s, %s or %s", tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterFile, tracing.ExporterOTLP)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+	check(c.Tracing.ServiceName != "", "TRACING_SERVICE_NAME is required")
+
+	_, err = log.ParseLevel(c.Log.Level)
+	check(err == nil, "LOG_LEVEL %q is not a level", c.Log.Level)
+	check(c.Log.Format == logging.FormatText || c.Log.Format == logging.FormatJSON, "LOG_FORMAT must be %s or %s", logging.FormatText, logging.FormatJSON)
+
+	switch c.Mail.Sink {
+	case mail.SinkStdout:
+	case mail.SinkFile:
+		check(c.Mail.Dir != "", "MAIL_DIR is required by the file sink")
+	case mail.SinkSMTP:
+		check(c.Mail.SMTPAddr != "", "SMTP_ADDR is required by the smtp sink")
+	default:
+		check(false, "MAIL_SINK must be %s, %s or %s", mail.SinkStdout, mail.SinkFile, mail.SinkSMTP)
+	}
+	check(c.Mail.From != "", "MAIL_FROM is required")
+
+	check(len(c.Security.TokenSecret) >= minTokenSecretLength, "TOKEN_SECRET is required and must have at least %d characters", minTokenSecretLength)
+	check(c.Security.TokenSecret != placeholderTokenSecret, "TOKEN_SECRET must be a random secret, not the placeholder")
+	check(c.Security.PrivacyKeyFile != "", "PRIVACY_KEY_FILE is required")
+	check(c.Security.TOTPIssuer != "", "TOTP_ISSUER is required")
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	sort.Strings(problems)
+
+	return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+}
+
+// Redacted returns a copy of the configuration with the secrets that are set replaced, to be printed.
+func (c Config) Redacted() Config {
+	c.Database.Params = copyParams(c.Database.Params)
+
+	for _, setting := range settings(&c) {
+		if setting.secret && setting.value.String() != "" {
+			setting.value.SetString(redacted)
+		}
+	}
+
+	return c
+}
+
+func copyParams(params map[string]string) map[string]string {
+	if params == nil {
+		return nil
+	}
+
+	copied := make(map[string]string, len(params))
+	for key, value := range params {
+		copied[key] = value
+	}
+
+	return copied
+}
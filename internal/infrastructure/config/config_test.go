@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func env(variables map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := variables[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+// testTokenSecret is long enough for the validation; Default has no token secret, which must always be given.
+const testTokenSecret = "0123456789abcdef0123456789abcdef"
+
+// validConfig returns the default configuration along with the settings that have no default.
+func validConfig() Config {
+	cfg := Default()
+	cfg.Security.TokenSecret = testTokenSecret
+
+	return cfg
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load("serve", nil, env(map[string]string{"TOKEN_SECRET": testTokenSecret}), io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, validConfig(), cfg)
+
+	_, err = Load("serve", nil, env(nil), io.Discard)
+	assert.EqualError(t, err, "invalid configuration: TOKEN_SECRET is required and must have at least 32 characters")
+
+	_, err = Load("serve", nil, env(map[string]string{"TOKEN_SECRET": placeholderTokenSecret}), io.Discard)
+	assert.EqualError(t, err, "invalid configuration: TOKEN_SECRET must be a random secret, not the placeholder")
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+http:
+  addr: ":9000"
+  read_timeout: 20s
+database:
+  host: db:3306
+  name: dondeestan
+  params:
+    parseTime: "true"
+log:
+  level: debug
+`)
+
+	cfg, err := Load("serve", []string{"-config", path, "-log-level", "warning"}, env(map[string]string{
+		"HTTP_ADDR":            ":9100",
+		"DB_PASSWORD":          "secret",
+		"DB_PARAMS":            "charset=utf8mb4&timeout=5s",
+		"DIGEST_WORKER":        "false",
+		"HTTP_IDLE_TIMEOUT":    "",
+		"TRACING_SAMPLE_RATIO": "0.25",
+		"TOKEN_SECRET":         testTokenSecret,
+	}), io.Discard)
+	assert.NoError(t, err)
+
+	assert.Equal(t, ":9100", cfg.HTTP.Addr)
+	assert.Equal(t, 20*time.Second, cfg.HTTP.ReadTimeout)
+	assert.Equal(t, Default().HTTP.IdleTimeout, cfg.HTTP.IdleTimeout)
+	assert.Equal(t, "db:3306", cfg.Database.Host)
+	assert.Equal(t, "secret", cfg.Database.Password)
+	assert.Equal(t, map[string]string{"charset": "utf8mb4", "timeout": "5s"}, cfg.Database.Params)
+	assert.Equal(t, "warning", cfg.Log.Level)
+	assert.False(t, cfg.Features.DigestWorker)
+	assert.True(t, cfg.Features.ErasureWorker)
+	assert.False(t, cfg.Features.DemoData)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+}
+
+func TestLoadTOMLFromVariable(t *testing.T) {
+	path := writeFile(t, "config.toml", `
+base_url = "https://dondeestan.app"
+
+[http]
+shutdown_timeout = "10s"
+
+[mail]
+sink = "smtp"
+smtp_addr = "smtp.mail.com:587"
+`)
+
+	cfg, err := Load("serve", nil, env(map[string]string{FileVariable: path, "TOKEN_SECRET": testTokenSecret}), io.Discard)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://dondeestan.app", cfg.BaseURL)
+	assert.Equal(t, 10*time.Second, cfg.HTTP.ShutdownTimeout)
+	assert.Equal(t, "smtp.mail.com:587", cfg.Mail.SMTPAddr)
+}
+
+func TestLoadRejectsUnknownSettings(t *testing.T) {
+	yamlPath := writeFile(t, "config.yaml", "http:\n  adress: \":9000\"\n")
+	_, err := Load("serve", []string{"-config", yamlPath}, env(nil), io.Discard)
+	assert.Error(t, err)
+
+	tomlPath := writeFile(t, "config.toml", "[http]\nadress = \":9000\"\n")
+	_, err = Load("serve", []string{"-config", tomlPath}, env(nil), io.Discard)
+	assert.EqualError(t, err, "invalid configuration file "+tomlPath+": unknown settings http.adress")
+
+	_, err = Load("serve", []string{"-config", writeFile(t, "config.json", "{}")}, env(nil), io.Discard)
+	assert.Error(t, err)
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	_, err := Load("serve", nil, env(map[string]string{"DB_MAX_OPEN_CONNS": "many"}), io.Discard)
+	assert.EqualError(t, err, `DB_MAX_OPEN_CONNS: "many" is not an integer`)
+
+	_, err = Load("serve", []string{"-http-write-timeout", "30"}, env(nil), io.Discard)
+	assert.EqualError(t, err, `-http-write-timeout: "30" is not a duration such as 30s or 5m`)
+
+	_, err = Load("serve", []string{"-tracing-sample-ratio", "half"}, env(nil), io.Discard)
+	assert.EqualError(t, err, `-tracing-sample-ratio: "half" is not a number`)
+
+	_, err = Load("serve", []string{"extra"}, env(nil), io.Discard)
+	assert.Error(t, err)
+
+	var output bytes.Buffer
+	_, err = Load("serve", []string{"-h"}, env(nil), &output)
+	assert.True(t, errors.Is(err, flag.ErrHelp))
+	assert.Contains(t, output.String(), "-db-host")
+	assert.Contains(t, output.String(), "(DB_HOST)")
+}
+
+func TestValidate(t *testing.T) {
+	cfg := validConfig()
+	cfg.BaseURL = "dondeestan.app"
+	cfg.HTTP.WriteTimeout = 0
+	cfg.TLS.CertFile = "cert.pem"
+	cfg.Database.MaxIdleConns = 30
+	cfg.Log.Level = "verbose"
+	cfg.Log.Format = "xml"
+	cfg.Mail.Sink = "pigeon"
+
+	assert.EqualError(t, cfg.Validate(), "invalid configuration: "+
+		"APP_BASE_URL must be an absolute URL; "+
+		"DB_MAX_IDLE_CONNS cannot exceed DB_MAX_OPEN_CONNS; "+
+		"HTTP_WRITE_TIMEOUT must be positive; "+
+		"LOG_FORMAT must be text or json; "+
+		`LOG_LEVEL "verbose" is not a level; `+
+		"MAIL_SINK must be stdout, file or smtp; "+
+		"TLS_CERT_FILE and TLS_KEY_FILE go together")
+
+	cfg = validConfig()
+	cfg.TLS.RedirectAddr = ":80"
+	assert.EqualError(t, cfg.Validate(), "invalid configuration: TLS_REDIRECT_ADDR requires TLS_CERT_FILE and TLS_KEY_FILE")
+
+	cfg.TLS.CertFile, cfg.TLS.KeyFile = "cert.pem", "key.pem"
+	assert.NoError(t, cfg.Validate())
+
+	cfg = validConfig()
+	cfg.Mail.Sink = "smtp"
+	assert.EqualError(t, cfg.Validate(), "invalid configuration: SMTP_ADDR is required by the smtp sink")
+
+	cfg.Database.MaxOpenConns = 0
+	cfg.Mail.SMTPAddr = "smtp.mail.com:587"
+	assert.NoError(t, cfg.Validate())
+
+	cfg = validConfig()
+	cfg.HTTP.TrustedProxies = "10.0.0.0/8, proxy"
+	assert.EqualError(t, cfg.Validate(), `invalid configuration: HTTP_TRUSTED_PROXIES: "proxy" is not an IP address or a CIDR network`)
+
+	cfg = validConfig()
+	cfg.HTTP.MetricsAddr = cfg.HTTP.Addr
+	assert.EqualError(t, cfg.Validate(), "invalid configuration: HTTP_METRICS_ADDR must differ from HTTP_ADDR and TLS_REDIRECT_ADDR")
+
+	cfg.HTTP.MetricsAddr = ""
+	assert.NoError(t, cfg.Validate())
+
+	cfg = validConfig()
+	cfg.Tracing.Exporter = "file"
+	cfg.Tracing.File = ""
+	cfg.Tracing.SampleRatio = 1.5
+	assert.EqualError(t, cfg.Validate(), "invalid configuration: "+
+		"TRACING_FILE is required by the file exporter; "+
+		"TRACING_SAMPLE_RATIO must be between 0 and 1")
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Password = "db-secret"
+	cfg.Security.TokenSecret = "token-secret"
+
+	var output bytes.Buffer
+	assert.NoError(t, Print(cfg, &output))
+
+	printed := output.String()
+	assert.NotContains(t, printed, "db-secret")
+	assert.NotContains(t, printed, "token-secret")
+	assert.Contains(t, printed, "password: REDACTED")
+	assert.Contains(t, printed, "token_secret: REDACTED")
+	assert.Contains(t, printed, `smtp_password: ""`)
+	assert.Contains(t, printed, "read_timeout: 15s")
+	assert.Equal(t, "db-secret", cfg.Database.Password)
+
+	var decoded Config
+	assert.NoError(t, decodeFile(writeFile(t, "printed.yaml", printed), &decoded))
+	assert.Equal(t, cfg.HTTP, decoded.HTTP)
+}
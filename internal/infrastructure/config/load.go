@@ -0,0 +1,222 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileVariable is the environment variable giving the configuration file when the -config flag is not set.
+const FileVariable = "CONFIG_FILE"
+
+// setting is a field of the configuration that can be set from the environment and the flags.
+type setting struct {
+	env    string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// flagName derives the flag of a setting from its environment variable: DB_HOST is set by -db-host.
+func (s setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.env), "_", "-")
+}
+
+// set parses the text given in the environment or a flag into the setting.
+func (s setting) set(text string) error {
+	switch s.value.Interface().(type) {
+	case string:
+		s.value.SetString(text)
+	case bool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", text)
+		}
+		s.value.SetBool(parsed)
+	case int:
+		parsed, err := strconv.Atoi(text)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", text)
+		}
+		s.value.SetInt(int64(parsed))
+	case float64:
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", text)
+		}
+		s.value.SetFloat(parsed)
+	case time.Duration:
+		parsed, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 5m", text)
+		}
+		s.value.SetInt(int64(parsed))
+	case map[string]string:
+		query, err := url.ParseQuery(text)
+		if err != nil {
+			return fmt.Errorf("%q is not a query string", text)
+		}
+		params := make(map[string]string, len(query))
+		for key := range query {
+			params[key] = query.Get(key)
+		}
+		s.value.Set(reflect.ValueOf(params))
+	default:
+		return fmt.Errorf("unsupported type %s", s.value.Type())
+	}
+
+	return nil
+}
+
+// settings lists the settings of the configuration, pointing to its fields.
+func settings(c *Config) []setting {
+	return structSettings(reflect.ValueOf(c).Elem())
+}
+
+func structSettings(value reflect.Value) []setting {
+	var found []setting
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			found = append(found, structSettings(value.Field(i))...)
+			continue
+		}
+
+		if env := field.Tag.Get("env"); env != "" {
+			found = append(found, setting{
+				env:    env,
+				usage:  field.Tag.Get("usage"),
+				secret: field.Tag.Get("secret") == "true",
+				value:  value.Field(i),
+			})
+		}
+	}
+
+	return found
+}
+
+// Load builds the configuration from the defaults, the file, the environment and the flags in args, and
+// validates it. lookupEnv reads the environment, as os.LookupEnv does. Asking for help with -h returns
+// flag.ErrHelp after printing the flags to output.
+func Load(name string, args []string, lookupEnv func(string) (string, bool), output io.Writer) (Config, error) {
+	cfg := Default()
+	all := settings(&cfg)
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(output)
+	file := flags.String("config", "", "YAML or TOML configuration file, "+FileVariable+" by default")
+
+	given := make(map[string]string)
+	for _, s := range all {
+		flagName := s.flagName()
+		flags.Func(flagName, s.usage+" ("+s.env+")", func(text string) error {
+			given[flagName] = text
+			return nil
+		})
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	if flags.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments %s", strings.Join(flags.Args(), " "))
+	}
+
+	path := *file
+	if path == "" {
+		path, _ = lookupEnv(FileVariable)
+	}
+
+	if path != "" {
+		if err := decodeFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	for _, s := range all {
+		if text, ok := lookupEnv(s.env); ok && text != "" {
+			if err := s.set(text); err != nil {
+				return Config{}, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+
+	for _, s := range all {
+		if text, ok := given[s.flagName()]; ok {
+			if err := s.set(text); err != nil {
+				return Config{}, fmt.Errorf("-%s: %w", s.flagName(), err)
+			}
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// decodeFile decodes the YAML or TOML file into cfg, overriding the settings it contains. Unknown settings are
+// rejected, so a misspelled one does not go unnoticed.
+func decodeFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("couldn't read the configuration file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err = decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("invalid configuration file %s: %w", path, err)
+		}
+	case ".toml":
+		metadata, err := toml.Decode(string(content), cfg)
+		if err != nil {
+			return fmt.Errorf("invalid configuration file %s: %w", path, err)
+		}
+
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, 0, len(undecoded))
+			for _, key := range undecoded {
+				keys = append(keys, key.String())
+			}
+			sort.Strings(keys)
+
+			return fmt.Errorf("invalid configuration file %s: unknown settings %s", path, strings.Join(keys, ", "))
+		}
+	default:
+		return fmt.Errorf("configuration file %s must end in .yaml, .yml or .toml", path)
+	}
+
+	return nil
+}
+
+// Print writes the configuration as YAML with its secrets redacted.
+func Print(cfg Config, output io.Writer) error {
+	encoder := yaml.NewEncoder(output)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(cfg.Redacted()); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
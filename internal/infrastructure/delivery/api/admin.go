@@ -0,0 +1,264 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/config"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/privacy"
+)
+
+// newFlagSet creates the flag set of a command, printing its usage line before the flags when asked for help.
+func newFlagSet(command string, synopsis string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s %s\n", command, synopsis)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// parseFlags parses the arguments of a command taking the given number of operands, and reports whether they
+// are valid.
+func parseFlags(flags *flag.FlagSet, args []string, operands int) bool {
+	if err := flags.Parse(args); err != nil {
+		return false
+	}
+
+	if flags.NArg() != operands {
+		flags.Usage()
+		return false
+	}
+
+	return true
+}
+
+// busFlags binds the flags describing a school bus, needed only when its license plate is not registered yet.
+func busFlags(flags *flag.FlagSet, bus *model.SchoolBus) {
+	flags.StringVar(&bus.LicensePlate, "plate", "", "license plate of the school bus")
+	flags.StringVar(&bus.Model, "model", "", "model of the school bus, to register it")
+	flags.StringVar(&bus.Brand, "brand", "", "brand of the school bus, to register it")
+	flags.StringVar(&bus.SchoolBusLicense, "license", "", "school transport license of the bus, to register it")
+}
+
+// runUserCreate creates a user, reading its password from the first line of the standard input.
+func runUserCreate(cfg config.Config, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	var provision model.UserProvision
+
+	flags := newFlagSet("user create", "-type observer|observed -name name -last-name name -id-number number -username username -email email [options]", stderr)
+	flags.StringVar(&provision.User.Type, "type", "", "observer for parents, observed for drivers")
+	flags.StringVar(&provision.User.Name, "name", "", "first name")
+	flags.StringVar(&provision.User.LastName, "last-name", "", "last name")
+	flags.StringVar(&provision.User.IDNumber, "id-number", "", "national ID number")
+	flags.StringVar(&provision.User.Username, "username", "", "username to log in")
+	flags.StringVar(&provision.User.Email, "email", "", "email address")
+	flags.BoolVar(&provision.User.EmailVerified, "email-verified", false, "consider the email address verified")
+	flags.StringVar(&provision.CompanyName, "company", "", "company of the driver")
+	busFlags(flags, &provision.SchoolBus)
+
+	if !parseFlags(flags, args, 0) {
+		return ExitCodeInvalidArguments
+	}
+
+	// The password is read from the standard input, so it does not show up in the process list or the shell history.
+	password, err := bufio.NewReader(stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		fmt.Fprintln(stderr, err)
+		return ExitCodeInvalidArguments
+	}
+	provision.User.Password = strings.TrimRight(password, "\r\n")
+
+	return runAsOperator(cfg, stderr, func(locator gateway.ServiceLocator) error {
+		useCase := locator.GetInstance(usecase.ProvisioningUseCaseType).(usecase.ProvisioningUseCase)
+		user, err := useCase.CreateUser(provision, locator)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "created %s %s with ID %d\n", user.Type, user.Username, user.ID)
+
+		return nil
+	})
+}
+
+func runSetUserEnabled(cfg config.Config, command string, enabled bool, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet(command, "<id|username>", stderr)
+	if !parseFlags(flags, args, 1) {
+		return ExitCodeInvalidArguments
+	}
+
+	return runAsOperator(cfg, stderr, func(locator gateway.ServiceLocator) error {
+		user, err := findUser(flags.Arg(0), locator)
+		if err != nil {
+			return err
+		}
+
+		useCase := locator.GetInstance(usecase.AuthorizationUseCaseType).(usecase.AuthorizationUseCase)
+		if err = useCase.SetUserEnabled(user.ID, enabled, locator); err != nil {
+			return err
+		}
+
+		state := "disabled"
+		if enabled {
+			state = "enabled"
+		}
+		fmt.Fprintf(stdout, "%s %s\n", state, user.Username)
+
+		return nil
+	})
+}
+
+func runBusAssign(cfg config.Config, args []string, stdout io.Writer, stderr io.Writer) int {
+	var bus model.SchoolBus
+
+	flags := newFlagSet("bus assign", "-plate plate [-model model -brand brand -license license] <driver id|username>", stderr)
+	busFlags(flags, &bus)
+
+	if !parseFlags(flags, args, 1) {
+		return ExitCodeInvalidArguments
+	}
+
+	return runAsOperator(cfg, stderr, func(locator gateway.ServiceLocator) error {
+		driver, err := findUser(flags.Arg(0), locator)
+		if err != nil {
+			return err
+		}
+
+		useCase := locator.GetInstance(usecase.ProvisioningUseCaseType).(usecase.ProvisioningUseCase)
+		assigned, err := useCase.AssignBus(driver.ID, bus, locator)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "%s drives %s %s %s\n", driver.Username, assigned.LicensePlate, assigned.Brand, assigned.Model)
+
+		return nil
+	})
+}
+
+// runPrivacyKeyInit creates the privacy key file with a new random key. It refuses to replace an existing file,
+// whose keys are needed to read the values encrypted with them.
+func runPrivacyKeyInit(cfg config.Config, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("privacy-key init", "", stderr)
+	if !parseFlags(flags, args, 0) {
+		return ExitCodeInvalidArguments
+	}
+
+	path := cfg.Security.PrivacyKeyFile
+	file, err := privacy.NewKeyFile()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitCodeCommandFailed
+	}
+
+	if err = privacy.CreateKeyFile(path, file); err != nil {
+		fmt.Fprintf(stderr, "couldn't create the privacy key file %s: %v\n", path, err)
+		return ExitCodeCommandFailed
+	}
+
+	fmt.Fprintf(stdout, "created %s with the key %s\n", path, file.Current)
+
+	return ExitCodeOK
+}
+
+// runPrivacyKeyRotate adds a new current key to the key file. Values are re-encrypted in a separate step, once
+// every server has been restarted with the new key: a server still holding the old key file could not decrypt
+// them.
+func runPrivacyKeyRotate(cfg config.Config, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("privacy-key rotate", "", stderr)
+	if !parseFlags(flags, args, 0) {
+		return ExitCodeInvalidArguments
+	}
+
+	path := cfg.Security.PrivacyKeyFile
+	file, err := privacy.ReadKeyFile(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "couldn't read the privacy key file %s: %v\n", path, err)
+		return ExitCodeFailToLoadPrivacyKeys
+	}
+
+	if err = file.Rotate(); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitCodeCommandFailed
+	}
+
+	if _, err = file.KeyProvider(); err != nil {
+		fmt.Fprintf(stderr, "invalid privacy key file %s: %v\n", path, err)
+		return ExitCodeFailToLoadPrivacyKeys
+	}
+
+	if err = privacy.WriteKeyFile(path, file); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitCodeCommandFailed
+	}
+
+	fmt.Fprintf(stdout, "key %s is now the current key of %s\n", file.Current, path)
+	fmt.Fprintln(stdout, "restart every server with the new key file, then run privacy-key reencrypt")
+
+	return ExitCodeOK
+}
+
+func runPrivacyKeyReencrypt(cfg config.Config, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("privacy-key reencrypt", "", stderr)
+	if !parseFlags(flags, args, 0) {
+		return ExitCodeInvalidArguments
+	}
+
+	return runAsOperator(cfg, stderr, func(locator gateway.ServiceLocator) error {
+		useCase := locator.GetInstance(usecase.PrivacyUseCaseType).(usecase.PrivacyUseCase)
+		result, err := useCase.ReencryptFields(locator)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(stdout, "re-encrypted %d of %d encrypted values\n", result.Reencrypted, result.Scanned)
+
+		return nil
+	})
+}
+
+func runExport(cfg config.Config, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("export", "[-o file] <id|username>", stderr)
+	output := flags.String("o", "", "file to write the data to, readable only by its owner, instead of the standard output")
+
+	if !parseFlags(flags, args, 1) {
+		return ExitCodeInvalidArguments
+	}
+
+	return runAsOperator(cfg, stderr, func(locator gateway.ServiceLocator) error {
+		user, err := findUser(flags.Arg(0), locator)
+		if err != nil {
+			return err
+		}
+
+		useCase := locator.GetInstance(usecase.PrivacyUseCaseType).(usecase.PrivacyUseCase)
+		data, err := useCase.ExportAccountData(user.ID, locator)
+		if err != nil {
+			return err
+		}
+
+		content, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		content = append(content, '\n')
+
+		if *output == "" {
+			_, err = stdout.Write(content)
+			return err
+		}
+
+		return os.WriteFile(*output, content, 0o600)
+	})
+}
@@ -1,49 +1,171 @@
 package api
 
 import (
-	"net/http"
+	"fmt"
 	"os"
 
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/config"
 	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/conn"
-	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/route"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/migration"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/health"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/mail"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/metrics"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/privacy"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/token"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/totp"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
 	log "github.com/sirupsen/logrus"
 
+	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// The exit codes of the commands. 0 means success; the others tell what went wrong.
 const (
 	ExitCodeOK = iota
+	// ExitCodeFailToCreateDBConnection means the database could not be reached.
 	ExitCodeFailToCreateDBConnection
+	// ExitCodeFailToCreateMailer means the mail settings are unusable, such as an invalid sender.
+	ExitCodeFailToCreateMailer
+	// ExitCodeFailToLoadPrivacyKeys means the privacy key file is missing or invalid.
+	ExitCodeFailToLoadPrivacyKeys
+	// ExitCodeInvalidArguments means the command or its arguments are wrong.
+	ExitCodeInvalidArguments
+	// ExitCodeFailToMigrate means the schema migrations failed.
+	ExitCodeFailToMigrate
+	// ExitCodeCommandFailed means an administration command failed.
+	ExitCodeCommandFailed
+	// ExitCodeInvalidConfig means a setting is invalid.
+	ExitCodeInvalidConfig
+	// ExitCodeServerFailed means the web server stopped on its own, such as when its address is in use.
+	ExitCodeServerFailed
+	// ExitCodeShutdownTimeout means the requests in flight did not finish within the shutdown timeout.
+	ExitCodeShutdownTimeout
+	// ExitCodeFailToLoadCertificate means the TLS certificate or key file is missing or invalid.
+	ExitCodeFailToLoadCertificate
+	// ExitCodeFailToSetUpTracing means the trace exporter could not be created, such as when its file cannot be
+	// opened.
+	ExitCodeFailToSetUpTracing
 )
 
-// StartApp Start app
-func StartApp() {
-	startServer()
+// StartApp Start app, serving until SIGINT or SIGTERM, and returns the exit code.
+func StartApp(cfg config.Config) int {
+	return startServer(cfg)
 }
 
-func startServer() {
-	dbConnection, err := conn.GetDBConnection(logger.Silent, model.Database{
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		Host:     os.Getenv("DB_HOST"),
-		Name:     os.Getenv("DB_NAME"),
+// newDependencies opens the connections and creates the services shared by the server and the commands. When it
+// fails, it returns the exit code telling what went wrong.
+func newDependencies(cfg config.Config) (middleware.Dependencies, int, error) {
+	dbConnection, err := newDBConnection(cfg.Database)
+	if err != nil {
+		return middleware.Dependencies{}, ExitCodeFailToCreateDBConnection, fmt.Errorf("couldn't establish a connection with the database: %w", err)
+	}
+
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		return middleware.Dependencies{}, ExitCodeFailToCreateMailer, fmt.Errorf("couldn't create the mailer: %w", err)
+	}
+
+	keyProvider, err := privacy.NewLocalKeyProvider(cfg.Security.PrivacyKeyFile)
+	if err != nil {
+		return middleware.Dependencies{}, ExitCodeFailToLoadPrivacyKeys, fmt.Errorf("couldn't load the privacy keys: %w", err)
+	}
+
+	healthChecker, err := newHealthChecker(cfg.Health, dbConnection)
+	if err != nil {
+		return middleware.Dependencies{}, ExitCodeFailToCreateDBConnection, fmt.Errorf("couldn't create the health checks: %w", err)
+	}
+
+	trustedProxies, err := utils.ParseTrustedProxies(cfg.HTTP.TrustedProxies)
+	if err != nil {
+		return middleware.Dependencies{}, ExitCodeInvalidConfig, fmt.Errorf("HTTP_TRUSTED_PROXIES: %w", err)
+	}
+
+	collector := metrics.NewCollector()
+	if err = collector.InstrumentDB(dbConnection); err != nil {
+		return middleware.Dependencies{}, ExitCodeFailToCreateDBConnection, fmt.Errorf("couldn't instrument the database: %w", err)
+	}
+
+	if err = tracing.InstrumentDB(dbConnection); err != nil {
+		return middleware.Dependencies{}, ExitCodeFailToCreateDBConnection, fmt.Errorf("couldn't trace the database: %w", err)
+	}
+
+	return middleware.Dependencies{
+		DB:                       dbConnection,
+		HealthChecker:            healthChecker,
+		Metrics:                  collector,
+		TrustedProxies:           trustedProxies,
+		Mailer:                   mailer,
+		TokenService:             token.NewSigner([]byte(cfg.Security.TokenSecret)),
+		Cipher:                   privacy.NewEnvelopeCipher(keyProvider),
+		TOTPService:              totp.NewService(cfg.Security.TOTPIssuer),
+		BaseURL:                  cfg.BaseURL,
+		RequireEmailVerification: cfg.Features.RequireEmailVerification,
+	}, ExitCodeOK, nil
+}
+
+// newDBConnection connects to the configured database and sizes its connection pool.
+func newDBConnection(database config.Database) (*gorm.DB, error) {
+	return conn.GetDBConnection(logger.Silent, model.Database{
+		User:            database.User,
+		Password:        database.Password,
+		Host:            database.Host,
+		Name:            database.Name,
+		Params:          database.Params,
+		MaxOpenConns:    database.MaxOpenConns,
+		MaxIdleConns:    database.MaxIdleConns,
+		ConnMaxLifetime: database.ConnMaxLifetime,
+		ConnMaxIdleTime: database.ConnMaxIdleTime,
 	})
+}
+
+// newHealthChecker checks that the database answers and that its schema is up to date.
+func newHealthChecker(cfg config.Health, db *gorm.DB) (gateway.HealthChecker, error) {
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatal("couldn't establish a connection with the database", err)
-		os.Exit(ExitCodeFailToCreateDBConnection)
+		return nil, err
 	}
 
-	router := route.NewRouter(dbConnection)
+	migrations, err := migration.Embedded()
+	if err != nil {
+		return nil, err
+	}
 
-	log.Info("server start")
-	log.Println("Listening on :8080")
-	log.Fatal(http.ListenAndServe(":8080", router))
+	checker := health.NewRegistry(cfg.CacheTTL, cfg.CheckTimeout)
+	checker.Register(health.NewDatabaseCheck(sqlDB))
+	checker.Register(health.NewMigrationCheck(migration.NewMigrator(sqlDB, migrations)))
+
+	return checker, nil
+}
 
-	/*if err := router.Run(":8080"); err != nil {
-		log.Fatal("error running server", err)
-	}*/
+// closeDB closes the connection pool once the queries in progress finish.
+func closeDB(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+
+	if err != nil {
+		log.Error("database connections could not be closed. ", err)
+	}
+}
+
+// newMailer creates the mailer using the configured sink: stdout, file or smtp.
+func newMailer(cfg config.Mail) (gateway.Mailer, error) {
+	var sink mail.Sink
+
+	switch cfg.Sink {
+	case mail.SinkFile:
+		sink = mail.NewFileSink(cfg.Dir)
+	case mail.SinkSMTP:
+		sink = mail.NewSMTPSink(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword)
+	default:
+		sink = mail.NewWriterSink(os.Stdout)
+	}
 
-	log.Info("server exit", err)
-	os.Exit(ExitCodeOK)
+	return mail.NewMailer(cfg.From, sink)
 }
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/config"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+const usage = `usage: api [command]
+
+commands:
+  serve                  start the web service, the default command
+  config print           print the configuration with its secrets redacted
+  migrate                apply or revert the schema migrations
+  seed                   create the demo school, driver, observer and child, with DEMO_DATA=true
+  user create            create a user
+  user disable <user>    disable a user, given its ID or username
+  user enable <user>     enable a user again
+  bus assign             set the school bus a driver drives
+  privacy-key init       create the privacy key file with a new key
+  privacy-key rotate     add a new current key to the privacy key file
+  privacy-key reencrypt  re-encrypt the encrypted fields with the current privacy key
+  export <user>          print the data of a user as JSON
+
+Run a command with -h to see its options. serve and config print take the configuration settings as flags,
+such as -db-host; every command reads them from the file set by CONFIG_FILE and the environment.
+`
+
+// operatorPrefix tells apart the audit entries recorded by the commands from the ones recorded by the users.
+const operatorPrefix = "cli:"
+
+// Run runs the command given by the arguments, without the program name, and returns the exit code. Without a
+// command the web service is started. Every command acts as a platform admin through the same use cases as the
+// web service.
+func Run(args []string) int {
+	return run(args, os.Stdin, os.Stdout, os.Stderr)
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	command, operands := "serve", args
+	if len(args) > 0 {
+		command, operands = args[0], args[1:]
+	}
+
+	if subcommands[command] && len(operands) > 0 {
+		command, operands = command+" "+operands[0], operands[1:]
+	}
+
+	switch command {
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return ExitCodeOK
+	}
+
+	// The commands taking configuration flags get their operands; the others read the configuration file from
+	// CONFIG_FILE and the environment only.
+	var configArgs []string
+	if configCommands[command] {
+		configArgs, operands = operands, nil
+	}
+
+	cfg, err := config.Load(command, configArgs, os.LookupEnv, stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitCodeInvalidArguments
+	}
+
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitCodeInvalidConfig
+	}
+
+	logging.Configure(cfg.Log.Level, cfg.Log.Format)
+
+	switch command {
+	case "serve":
+		return StartApp(cfg)
+	case "config print":
+		if err = config.Print(cfg, stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			return ExitCodeCommandFailed
+		}
+		return ExitCodeOK
+	case "migrate":
+		return Migrate(cfg, operands)
+	case "seed":
+		return runSeed(cfg, operands, stdout, stderr)
+	case "user create":
+		return runUserCreate(cfg, operands, stdin, stdout, stderr)
+	case "user disable":
+		return runSetUserEnabled(cfg, "user disable", false, operands, stdout, stderr)
+	case "user enable":
+		return runSetUserEnabled(cfg, "user enable", true, operands, stdout, stderr)
+	case "bus assign":
+		return runBusAssign(cfg, operands, stdout, stderr)
+	case "privacy-key init":
+		return runPrivacyKeyInit(cfg, operands, stdout, stderr)
+	case "privacy-key rotate":
+		return runPrivacyKeyRotate(cfg, operands, stdout, stderr)
+	case "privacy-key reencrypt":
+		return runPrivacyKeyReencrypt(cfg, operands, stdout, stderr)
+	case "export":
+		return runExport(cfg, operands, stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown command %s\n\n%s", command, usage)
+		return ExitCodeInvalidArguments
+	}
+}
+
+// subcommands are the commands grouping other commands, such as user create.
+var subcommands = map[string]bool{"user": true, "bus": true, "privacy-key": true, "config": true}
+
+// configCommands are the commands whose flags are the configuration settings.
+var configCommands = map[string]bool{"serve": true, "config print": true}
+
+// runAsOperator runs fn with a service locator acting as the operator, and returns the exit code.
+func runAsOperator(cfg config.Config, stderr io.Writer, fn func(gateway.ServiceLocator) error) int {
+	dependencies, exitCode, err := newDependencies(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitCode
+	}
+	defer closeDB(dependencies.DB)
+
+	if err = fn(newOperatorLocator(dependencies)); err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitCodeCommandFailed
+	}
+
+	return ExitCodeOK
+}
+
+// newOperatorLocator creates a service locator whose principal is a platform admin named after the operating
+// system user running the command, so the audit log records who ran it.
+func newOperatorLocator(dependencies middleware.Dependencies) gateway.ServiceLocator {
+	locator := middleware.NewServiceLocator(dependencies, context.Background())
+	locator.Context().Bind(gateway.PrincipalType).ToInstance(&model.Principal{
+		Username: operatorName(),
+		Roles:    []model.RoleAssignment{{Role: model.RolePlatformAdmin}},
+	})
+
+	return locator
+}
+
+func operatorName() string {
+	name := os.Getenv("USER")
+	if current, err := user.Current(); err == nil {
+		name = current.Username
+	}
+
+	name = operatorPrefix + name
+	if len(name) > 45 {
+		name = name[:45]
+	}
+
+	return name
+}
+
+// findUser obtains a user given its ID or its username.
+func findUser(reference string, locator gateway.ServiceLocator) (*model.User, error) {
+	useCase := locator.GetInstance(usecase.UserUseCaseType).(usecase.UserUseCase)
+
+	var (
+		found *model.User
+		err   error
+	)
+
+	if id, parseErr := strconv.ParseUint(reference, 10, 32); parseErr == nil {
+		found, err = useCase.Get(uint(id), locator)
+	} else {
+		found, err = useCase.GetByUsername(reference, locator)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("user %s: %w", reference, err)
+	}
+
+	return found, nil
+}
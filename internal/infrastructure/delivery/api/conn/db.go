@@ -3,6 +3,7 @@ package conn
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"time"
 
@@ -17,7 +18,15 @@ func GetDBConnection(logLevel logger.LogLevel, database model.Database) (*gorm.D
 	dbPassword := database.Password
 	dbHost := database.Host
 	dbName := database.Name
-	connectionString := fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8", dbUsername, dbPassword, dbHost, dbName)
+	connectionString := fmt.Sprintf("%s:%s@tcp(%s)/%s", dbUsername, dbPassword, dbHost, dbName)
+
+	params := url.Values{}
+	for key, value := range database.Params {
+		params.Set(key, value)
+	}
+	if len(params) > 0 {
+		connectionString += "?" + params.Encode()
+	}
 
 	newLogger := logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags),
@@ -35,5 +44,15 @@ func GetDBConnection(logLevel logger.LogLevel, database model.Database) (*gorm.D
 		return nil, err
 	}
 
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB.SetMaxOpenConns(database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(database.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(database.ConnMaxIdleTime)
+
 	return db, nil
 }
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// RequestEmailVerification sends an email verification link. It always answers 202 to not reveal registered emails.
+func RequestEmailVerification(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AccountUseCaseType).(usecase.AccountUseCase)
+
+	var request model.EmailRequest
+	if err := decodeBody(r, &request); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post email verification unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err := useCase.RequestEmailVerification(request.Email, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("request email verification failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, nil, http.StatusAccepted)
+}
+
+// VerifyEmail consumes an email verification token.
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AccountUseCaseType).(usecase.AccountUseCase)
+
+	var confirmation model.TokenConfirmation
+	if err := decodeBody(r, &confirmation); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post email verification confirm unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err := useCase.VerifyEmail(confirmation.Token, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("verify email failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, nil, http.StatusNoContent)
+}
+
+// RequestPasswordReset sends a password reset link. It always answers 202 to not reveal registered emails.
+func RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AccountUseCaseType).(usecase.AccountUseCase)
+
+	var request model.EmailRequest
+	if err := decodeBody(r, &request); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post password reset unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err := useCase.RequestPasswordReset(request.Email, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("request password reset failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, nil, http.StatusAccepted)
+}
+
+// ResetPassword consumes a password reset token and sets the new password.
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AccountUseCaseType).(usecase.AccountUseCase)
+
+	var confirmation model.TokenConfirmation
+	if err := decodeBody(r, &confirmation); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post password reset confirm unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err := useCase.ResetPassword(confirmation, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("reset password failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, nil, http.StatusNoContent)
+}
+
+// UpdateProfile partially updates the profile of the authenticated user.
+func UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AccountUseCaseType).(usecase.AccountUseCase)
+
+	var update model.ProfileUpdate
+	if err := decodeBody(r, &update); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("patch profile unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	user, err := useCase.UpdateProfile(update, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("update profile failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, user, http.StatusOK)
+}
+
+// ChangePassword replaces the password of the authenticated user and returns a new access token, since the
+// change revokes every access token of the user.
+func ChangePassword(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AccountUseCaseType).(usecase.AccountUseCase)
+
+	var change model.PasswordChange
+	if err := decodeBody(r, &change); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post password unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	session, err := useCase.ChangePassword(change, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("change password failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, session, http.StatusOK)
+}
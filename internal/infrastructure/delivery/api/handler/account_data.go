@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// exportedUser is the user without the JSON marshaller of model.User, so the owner receives the full ID number.
+type exportedUser model.User
+
+// ExportAccountData returns a ZIP archive with one JSON file for each kind of data held about the user.
+func ExportAccountData(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PrivacyUseCaseType).(usecase.PrivacyUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	data, err := useCase.ExportAccountData(userID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("export account data failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	archive, err := accountArchive(data)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("account data archive failure")
+		utils.RenderError(w, web.ErrInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"account-%d.zip\"", userID))
+	w.Header().Set("Content-Length", strconv.Itoa(archive.Len()))
+	w.WriteHeader(http.StatusOK)
+	_, _ = archive.WriteTo(w)
+}
+
+// GetAccountDeletion returns the deletion request of a user.
+func GetAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PrivacyUseCaseType).(usecase.PrivacyUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	deletion, err := useCase.GetAccountDeletion(userID, serviceLocator)
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, deletion, http.StatusOK)
+}
+
+// RequestAccountDeletion schedules the deletion of a user account.
+func RequestAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PrivacyUseCaseType).(usecase.PrivacyUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	deletion, err := useCase.RequestAccountDeletion(userID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("request account deletion failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, deletion, http.StatusAccepted)
+}
+
+// CancelAccountDeletion cancels the pending deletion of a user account.
+func CancelAccountDeletion(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PrivacyUseCaseType).(usecase.PrivacyUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err = useCase.CancelAccountDeletion(userID, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("cancel account deletion failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// accountArchive builds the ZIP archive of the account data.
+func accountArchive(data *model.AccountData) (*bytes.Buffer, error) {
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"user.json", exportedUser(data.User)},
+		{"children.json", data.Children},
+		{"addresses.json", data.Addresses},
+		{"links.json", data.Links},
+		{"guardianships.json", data.Guardianships},
+		{"pickups.json", data.Pickups},
+		{"handovers.json", data.Handovers},
+		{"messages.json", data.Messages},
+		{"location_history.json", data.LocationHistory},
+	}
+
+	buffer := &bytes.Buffer{}
+	archive := zip.NewWriter(buffer)
+
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.content, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = f.Write(content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer, nil
+}
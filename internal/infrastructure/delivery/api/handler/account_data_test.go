@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountArchiveHasEveryField(t *testing.T) {
+	data := &model.AccountData{
+		User:     model.User{ID: 2, Username: "observer"},
+		Pickups:  []model.AuthorizedPickup{{ID: 1, ChildID: 3}},
+		Children: []model.Children{{ID: 3}},
+	}
+
+	buffer, err := accountArchive(data)
+	assert.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.NoError(t, err)
+
+	files := map[string]bool{}
+	for _, file := range archive.File {
+		files[file.Name] = true
+	}
+
+	fields := reflect.TypeOf(model.AccountData{})
+	for i := 0; i < fields.NumField(); i++ {
+		name := strings.Split(fields.Field(i).Tag.Get("json"), ",")[0] + ".json"
+		assert.True(t, files[name], "the archive has no %s for AccountData.%s", name, fields.Field(i).Name)
+	}
+	assert.Len(t, archive.File, fields.NumField())
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// SetUserStatus enables or disables a user.
+func SetUserStatus(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AuthorizationUseCaseType).(usecase.AuthorizationUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var status model.UserStatus
+	if err = decodeBody(r, &status); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("put user status unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err = useCase.SetUserEnabled(userID, status.Enabled, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("set user status failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, status, http.StatusOK)
+}
+
+// GetUserRoles returns the roles of a user.
+func GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AuthorizationUseCaseType).(usecase.AuthorizationUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	roles, err := useCase.GetRoles(userID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("get user roles failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, roles, http.StatusOK)
+}
+
+// SetUserRoles replaces the roles granted to a user.
+func SetUserRoles(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AuthorizationUseCaseType).(usecase.AuthorizationUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var roles []model.RoleAssignment
+	if err = decodeBody(r, &roles); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("put user roles unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	saved, err := useCase.SetRoles(userID, roles, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("set user roles failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, saved, http.StatusOK)
+}
+
+// GetUser returns a user, without its password.
+func GetUser(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.UserUseCaseType).(usecase.UserUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	user, err := useCase.Get(userID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("get user failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, user, http.StatusOK)
+}
+
+// ListUsers returns a page of users. The total of users matching the filters is sent in the X-Total-Count header
+// and the next page is linked in the Link header.
+func ListUsers(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.UserUseCaseType).(usecase.UserUseCase)
+
+	query, err := userQuery(r)
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	page, err := useCase.List(query, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("list users failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		next := *r.URL
+		values := next.Query()
+		values.Set("cursor", page.NextCursor)
+		next.RawQuery = values.Encode()
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.RequestURI()))
+	}
+
+	_ = web.EncodeJSON(w, page, http.StatusOK)
+}
+
+// userQuery reads the filters of the user listing. The sort field is descending when prefixed with a minus sign.
+func userQuery(r *http.Request) (model.UserQuery, error) {
+	values := r.URL.Query()
+	query := model.UserQuery{
+		Type:        values.Get("type"),
+		CompanyName: values.Get("company"),
+		Sort:        strings.TrimPrefix(values.Get("sort"), "-"),
+		Descending:  strings.HasPrefix(values.Get("sort"), "-"),
+	}
+
+	if value := values.Get("enabled"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return query, fmt.Errorf("%w: invalid enabled", web.ErrBadRequest)
+		}
+		query.Enabled = &enabled
+	}
+
+	if value := values.Get("cursor"); value != "" {
+		cursor, err := model.DecodeUserCursor(value)
+		if err != nil {
+			return query, fmt.Errorf("%w: invalid cursor", web.ErrBadRequest)
+		}
+		query.Cursor = cursor
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return query, fmt.Errorf("%w: invalid limit", web.ErrBadRequest)
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// FindAuditEntries returns the audit entries matching the query parameters: action, actor_id, target_type,
+// target_id, from and to (RFC 3339), after_id and limit.
+func FindAuditEntries(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AuditUseCaseType).(usecase.AuditUseCase)
+
+	query, err := auditQuery(r)
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	entries, err := useCase.Find(query, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("find audit entries failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, entries, http.StatusOK)
+}
+
+// VerifyAuditLog checks the hash chain of the audit log.
+func VerifyAuditLog(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.AuditUseCaseType).(usecase.AuditUseCase)
+
+	verification, err := useCase.Verify(serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("verify audit log failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, verification, http.StatusOK)
+}
+
+func auditQuery(r *http.Request) (model.AuditQuery, error) {
+	values := r.URL.Query()
+	query := model.AuditQuery{
+		Action:     values.Get("action"),
+		TargetType: values.Get("target_type"),
+		TargetID:   values.Get("target_id"),
+	}
+
+	numbers := map[string]*uint{"actor_id": &query.ActorID, "after_id": &query.AfterID}
+	for name, target := range numbers {
+		if value := values.Get(name); value != "" {
+			number, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return query, fmt.Errorf("%w: invalid %s", web.ErrBadRequest, name)
+			}
+			*target = uint(number)
+		}
+	}
+
+	times := map[string]*int64{"from": &query.From, "to": &query.To}
+	for name, target := range times {
+		if value := values.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return query, fmt.Errorf("%w: invalid %s", web.ErrBadRequest, name)
+			}
+			*target = t.UnixMilli()
+		}
+	}
+
+	if value := values.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return query, fmt.Errorf("%w: invalid limit", web.ErrBadRequest)
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+// maxCalendarSize caps the size of the imported iCalendar files.
+const maxCalendarSize = 1 << 20
+
+// GetCompanyCalendar returns the calendar of a company.
+func GetCompanyCalendar(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.CalendarUseCaseType).(usecase.CalendarUseCase)
+
+	entries, err := useCase.GetCompanyCalendar(chi.URLParam(r, "company"), serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("get company calendar failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, entries, http.StatusOK)
+}
+
+// SetCompanyCalendar replaces the calendar of a company.
+func SetCompanyCalendar(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.CalendarUseCaseType).(usecase.CalendarUseCase)
+
+	var entries []model.CalendarEntry
+	if err := decodeBody(r, &entries); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("set company calendar unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	saved, err := useCase.SetCompanyCalendar(chi.URLParam(r, "company"), entries, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("set company calendar failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, saved, http.StatusOK)
+}
+
+// ImportCompanyCalendar adds the events of the iCalendar in the body to the calendar of a company.
+func ImportCompanyCalendar(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.CalendarUseCaseType).(usecase.CalendarUseCase)
+
+	saved, err := useCase.ImportCompanyCalendar(chi.URLParam(r, "company"), calendarBody(w, r), serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("import company calendar failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, saved, http.StatusOK)
+}
+
+// ExportCompanyCalendar returns the calendar of a company in iCalendar format.
+func ExportCompanyCalendar(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.CalendarUseCaseType).(usecase.CalendarUseCase)
+
+	content, err := useCase.ExportCompanyCalendar(chi.URLParam(r, "company"), serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("export company calendar failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	writeCalendar(w, "company-calendar.ics", content)
+}
+
+// ImportSchoolCalendar adds the events of the iCalendar in the body to the calendar of a school.
+func ImportSchoolCalendar(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.CalendarUseCaseType).(usecase.CalendarUseCase)
+
+	schoolID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	school, err := useCase.ImportSchoolCalendar(schoolID, calendarBody(w, r), serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("import school calendar failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, school, http.StatusOK)
+}
+
+// ExportSchoolCalendar returns the calendar of a school in iCalendar format.
+func ExportSchoolCalendar(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.CalendarUseCaseType).(usecase.CalendarUseCase)
+
+	schoolID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	content, err := useCase.ExportSchoolCalendar(schoolID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("export school calendar failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	writeCalendar(w, fmt.Sprintf("school-%d.ics", schoolID), content)
+}
+
+// GetServiceDay returns whether a school has classes on the date query parameter, after its calendar and the
+// calendar of the company query parameter, when given.
+func GetServiceDay(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.CalendarUseCaseType).(usecase.CalendarUseCase)
+
+	schoolID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	values := r.URL.Query()
+	day, err := useCase.ServiceDay(schoolID, values.Get("company"), values.Get("date"), serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("get service day failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, day, http.StatusOK)
+}
+
+func calendarBody(w http.ResponseWriter, r *http.Request) io.Reader {
+	return http.MaxBytesReader(w, r.Body, maxCalendarSize)
+}
+
+func writeCalendar(w http.ResponseWriter, filename string, content []byte) {
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(content)
+}
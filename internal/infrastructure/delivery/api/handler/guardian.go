@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+// ListGuardians returns the guardians of a child.
+func ListGuardians(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.GuardianUseCaseType).(usecase.GuardianUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	guardians, err := useCase.List(childID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("list guardians failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, guardians, http.StatusOK)
+}
+
+// UpdateGuardian changes the role or the delegation of a guardian of a child.
+func UpdateGuardian(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.GuardianUseCaseType).(usecase.GuardianUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	userID, err := getUintURLParam(r, "userId")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var guardian model.Guardian
+	if err = decodeBody(r, &guardian); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("update guardian unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	guardian.ChildID, guardian.ObserverUserID = childID, userID
+	updated, err := useCase.Update(guardian, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("update guardian failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, updated, http.StatusOK)
+}
+
+// RemoveGuardian takes a guardian away from a child.
+func RemoveGuardian(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.GuardianUseCaseType).(usecase.GuardianUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	userID, err := getUintURLParam(r, "userId")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err = useCase.Remove(childID, userID, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("remove guardian failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, nil, http.StatusNoContent)
+}
+
+// InviteGuardian sends an invitation to become a guardian of a child.
+func InviteGuardian(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.GuardianUseCaseType).(usecase.GuardianUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var invitation model.GuardianInvitation
+	if err = decodeBody(r, &invitation); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("invite guardian unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	invitation.ChildID = childID
+	sent, err := useCase.Invite(invitation, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("invite guardian failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, sent, http.StatusCreated)
+}
+
+// ListGuardianInvitations returns the pending invitations of a child.
+func ListGuardianInvitations(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.GuardianUseCaseType).(usecase.GuardianUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	invitations, err := useCase.ListInvitations(childID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("list guardian invitations failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, invitations, http.StatusOK)
+}
+
+// RevokeGuardianInvitation cancels a pending invitation of a child.
+func RevokeGuardianInvitation(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.GuardianUseCaseType).(usecase.GuardianUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err = useCase.RevokeInvitation(childID, chi.URLParam(r, "invitationId"), serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("revoke guardian invitation failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, nil, http.StatusNoContent)
+}
+
+// AcceptGuardianInvitation consumes an invitation token, making the authenticated user a guardian of the child.
+func AcceptGuardianInvitation(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.GuardianUseCaseType).(usecase.GuardianUseCase)
+
+	var confirmation model.TokenConfirmation
+	if err := decodeBody(r, &confirmation); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("accept guardian invitation unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	guardian, err := useCase.Accept(confirmation.Token, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("accept guardian invitation failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, guardian, http.StatusOK)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// Live tells the process is serving requests. It does not check the dependencies, so an unreachable database
+// does not get the process restarted.
+func Live(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	_ = web.EncodeJSON(w, model.HealthReport{Status: model.HealthStatusUp}, http.StatusOK)
+}
+
+// Ready tells whether the service can handle requests, with the status of each dependency check. It answers
+// 503 Service Unavailable when a check fails, so no traffic is routed to the process. Why a check failed is only
+// logged.
+func Ready(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	checker := serviceLocator.GetInstance(gateway.HealthCheckerType).(gateway.HealthChecker)
+
+	report := checker.Check(r.Context())
+
+	code := http.StatusOK
+	if !report.Up() {
+		for name, component := range report.Components {
+			if component.Status == model.HealthStatusUp {
+				continue
+			}
+
+			logging.FromContext(r.Context()).WithField("component", name).WithField("error", component.Error).
+				WithField("duration_ms", component.DurationMS).Warn("service not ready")
+		}
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	_ = web.EncodeJSON(w, report, code)
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/go-chi/chi/v5"
+)
+
+var errEmptyBody = fmt.Errorf("%w: body is empty", web.ErrBadRequest)
+
+// getUintURLParam obtains a positive numeric URL parameter, such as a resource ID.
+func getUintURLParam(r *http.Request, name string) (uint, error) {
+	value, err := strconv.ParseUint(chi.URLParam(r, name), 10, 64)
+	if err != nil || value == 0 {
+		return 0, fmt.Errorf("%w: invalid %s", web.ErrBadRequest, name)
+	}
+
+	return uint(value), nil
+}
+
+// decodeBody unmarshalls the JSON request body into v.
+func decodeBody(r *http.Request, v interface{}) error {
+	var bodyBytes []byte
+
+	if r.Body != nil {
+		bodyBytes, _ = io.ReadAll(r.Body)
+	}
+
+	if len(bodyBytes) <= 0 {
+		return errEmptyBody
+	}
+
+	if err := json.Unmarshal(bodyBytes, v); err != nil {
+		return fmt.Errorf("%w: %s", web.ErrBadRequest, err.Error())
+	}
+
+	return nil
+}
+
+// decodeOptionalBody unmarshalls the JSON request body into v, leaving v untouched when the body is empty.
+func decodeOptionalBody(r *http.Request, v interface{}) error {
+	err := decodeBody(r, v)
+	if errors.Is(err, errEmptyBody) {
+		return nil
+	}
+
+	return err
+}
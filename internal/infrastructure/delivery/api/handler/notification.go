@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// GetNotificationPreferences returns the notification preferences of an observer user.
+func GetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.NotificationUseCaseType).(usecase.NotificationUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	settings, err := useCase.GetPreferences(userID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("get notification preferences failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, settings, http.StatusOK)
+}
+
+// UpdateNotificationPreferences replaces the notification preferences of an observer user.
+func UpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.NotificationUseCaseType).(usecase.NotificationUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var settings model.NotificationSettings
+	if err = decodeBody(r, &settings); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("put notification preferences unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	settings.ObserverUserID = userID
+
+	saved, err := useCase.UpdatePreferences(settings, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("update notification preferences failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, saved, http.StatusOK)
+}
+
+// NotifyGuardians dispatches an event reported by a driver to the guardians of a child.
+func NotifyGuardians(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.NotificationUseCaseType).(usecase.NotificationUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var notification model.Notification
+	if err = decodeBody(r, &notification); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post notification unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err = useCase.Notify(childID, notification, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("notify guardians failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, nil, http.StatusAccepted)
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// maxPickupSize caps the size of an authorized pickup body, whose photo is base64 encoded.
+const maxPickupSize = 512 << 10
+
+// ListPickups returns the people authorized to receive a child.
+func ListPickups(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PickupUseCaseType).(usecase.PickupUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	pickups, err := useCase.List(childID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("list pickups failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, pickups, http.StatusOK)
+}
+
+// CreatePickup authorizes a new person to receive a child.
+func CreatePickup(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PickupUseCaseType).(usecase.PickupUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var pickup model.AuthorizedPickup
+	r.Body = http.MaxBytesReader(w, r.Body, maxPickupSize)
+	if err = decodeBody(r, &pickup); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("create pickup unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	pickup.ChildID = childID
+	created, err := useCase.Create(pickup, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("create pickup failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, created, http.StatusCreated)
+}
+
+// UpdatePickup replaces the data of a person authorized to receive a child.
+func UpdatePickup(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PickupUseCaseType).(usecase.PickupUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	pickupID, err := getUintURLParam(r, "pickupId")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var pickup model.AuthorizedPickup
+	r.Body = http.MaxBytesReader(w, r.Body, maxPickupSize)
+	if err = decodeBody(r, &pickup); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("update pickup unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	pickup.ChildID, pickup.ID = childID, pickupID
+	updated, err := useCase.Update(pickup, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("update pickup failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, updated, http.StatusOK)
+}
+
+// DeletePickup takes the authorization to receive a child away from a person.
+func DeletePickup(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PickupUseCaseType).(usecase.PickupUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	pickupID, err := getUintURLParam(r, "pickupId")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err = useCase.Delete(childID, pickupID, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("delete pickup failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, nil, http.StatusNoContent)
+}
+
+// GeneratePickupPIN returns a new one-time PIN to receive a child.
+func GeneratePickupPIN(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PickupUseCaseType).(usecase.PickupUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	pin, err := useCase.GeneratePIN(childID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("generate pickup PIN failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, pin, http.StatusCreated)
+}
+
+// VerifyPickup lets the driver check who receives a child, and records the handover.
+func VerifyPickup(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PickupUseCaseType).(usecase.PickupUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var verification model.PickupVerification
+	if err = decodeBody(r, &verification); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("verify pickup unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	handover, err := useCase.Verify(childID, verification, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("verify pickup failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, handover, http.StatusCreated)
+}
+
+// ListPickupHandovers returns who received a child lately.
+func ListPickupHandovers(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.PickupUseCaseType).(usecase.PickupUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	handovers, err := useCase.History(childID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("list pickup handovers failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, handovers, http.StatusOK)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// childSchoolRequest is the body to change the school a child attends.
+type childSchoolRequest struct {
+	SchoolID uint `json:"school_id"`
+}
+
+// ListSchools returns every school with its calendar.
+func ListSchools(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.SchoolUseCaseType).(usecase.SchoolUseCase)
+
+	schools, err := useCase.List(serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("list schools failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, schools, http.StatusOK)
+}
+
+// GetSchool returns a school with its calendar.
+func GetSchool(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.SchoolUseCaseType).(usecase.SchoolUseCase)
+
+	schoolID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	school, err := useCase.Get(schoolID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("get school failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, school, http.StatusOK)
+}
+
+// CreateSchool registers a school.
+func CreateSchool(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.SchoolUseCaseType).(usecase.SchoolUseCase)
+
+	var school model.School
+	if err := decodeBody(r, &school); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("create school unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	created, err := useCase.Create(school, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("create school failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, created, http.StatusCreated)
+}
+
+// UpdateSchool replaces the data and calendar of a school.
+func UpdateSchool(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.SchoolUseCaseType).(usecase.SchoolUseCase)
+
+	schoolID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var school model.School
+	if err = decodeBody(r, &school); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("update school unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	school.ID = schoolID
+	updated, err := useCase.Update(school, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("update school failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, updated, http.StatusOK)
+}
+
+// SetChildSchool changes the school a child attends.
+func SetChildSchool(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.SchoolUseCaseType).(usecase.SchoolUseCase)
+
+	childID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var request childSchoolRequest
+	if err = decodeBody(r, &request); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("set child school unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	child, err := useCase.SetChildSchool(childID, request.SchoolID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("set child school failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, child, http.StatusOK)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// Search returns the users and children matching the q query parameter, best matches first.
+func Search(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.SearchUseCaseType).(usecase.SearchUseCase)
+
+	query := model.SearchQuery{Text: r.URL.Query().Get("q")}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RenderError(w, fmt.Errorf("%w: invalid limit", web.ErrBadRequest))
+			return
+		}
+		query.Limit = limit
+	}
+
+	results, err := useCase.Search(query, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("search failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, results, http.StatusOK)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/go-chi/chi/v5"
+)
+
+// VerifyTwoFactorLogin completes a login that requires a second factor.
+func VerifyTwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.LoginUseCaseType).(usecase.LoginUseCase)
+
+	var login model.TwoFactorLogin
+	if err := decodeBody(r, &login); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post two-factor login unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	login.ClientIP = utils.ClientIP(r)
+	session, err := useCase.VerifyTwoFactor(login, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("two-factor login failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, session, http.StatusOK)
+}
+
+// StartLoginTwoFactorEnrollment starts the enrollment of a second factor required to log in.
+func StartLoginTwoFactorEnrollment(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.LoginUseCaseType).(usecase.LoginUseCase)
+
+	var login model.TwoFactorLogin
+	if err := decodeBody(r, &login); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post two-factor enrollment unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	enrollment, err := useCase.StartTwoFactorEnrollment(login.ChallengeToken, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("two-factor enrollment failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, enrollment, http.StatusOK)
+}
+
+// EnrollTwoFactor starts the enrollment of an authenticator app for a user.
+func EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.TwoFactorUseCaseType).(usecase.TwoFactorUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	enrollment, err := useCase.Enroll(userID, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("two-factor enrollment failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, enrollment, http.StatusOK)
+}
+
+// ConfirmTwoFactor enables the second factor of a user with a first code and returns its recovery codes.
+func ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.TwoFactorUseCaseType).(usecase.TwoFactorUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var code model.TwoFactorCode
+	if err = decodeBody(r, &code); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post two-factor confirmation unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	codes, err := useCase.Confirm(userID, code, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("two-factor confirmation failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, codes, http.StatusOK)
+}
+
+// DisableTwoFactor removes the second factor of a user.
+func DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.TwoFactorUseCaseType).(usecase.TwoFactorUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var code model.TwoFactorCode
+	if err = decodeOptionalBody(r, &code); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("delete two-factor unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	if err = useCase.Disable(userID, code, serviceLocator); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("disable two-factor failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user.
+func RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.TwoFactorUseCaseType).(usecase.TwoFactorUseCase)
+
+	userID, err := getUintURLParam(r, "id")
+	if err != nil {
+		utils.RenderError(w, err)
+		return
+	}
+
+	var code model.TwoFactorCode
+	if err = decodeBody(r, &code); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("post recovery codes unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	codes, err := useCase.RegenerateRecoveryCodes(userID, code, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("regenerate recovery codes failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, codes, http.StatusOK)
+}
+
+// GetTwoFactorPolicy returns the two-factor policy of a company.
+func GetTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.TwoFactorUseCaseType).(usecase.TwoFactorUseCase)
+
+	policy, err := useCase.GetPolicy(chi.URLParam(r, "company"), serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("get two-factor policy failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, policy, http.StatusOK)
+}
+
+// SetTwoFactorPolicy defines whether the drivers of a company must use a second factor.
+func SetTwoFactorPolicy(w http.ResponseWriter, r *http.Request) {
+	serviceLocator := context.GetServiceLocator(r.Context())
+	useCase := serviceLocator.GetInstance(usecase.TwoFactorUseCaseType).(usecase.TwoFactorUseCase)
+
+	var policy model.TwoFactorPolicy
+	if err := decodeBody(r, &policy); err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("put two-factor policy unmarshall returns an error")
+		utils.RenderError(w, err)
+		return
+	}
+
+	policy.CompanyName = chi.URLParam(r, "company")
+	saved, err := useCase.SetPolicy(policy, serviceLocator)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("set two-factor policy failure")
+		utils.RenderError(w, err)
+		return
+	}
+
+	_ = web.EncodeJSON(w, saved, http.StatusOK)
+}
@@ -1,49 +1,50 @@
 package middleware
 
 import (
+	"context"
 	"gorm.io/gorm"
 	"net/http"
 
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
 	ctx "github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
 	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware/ioc"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
 	"github.com/gcoron/donde-estan-ws/internal/infrastructure/repository"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/ical"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/metrics"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/notification"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+	mid "github.com/go-chi/chi/v5/middleware"
 )
 
-func Ioc(db *gorm.DB) func(next http.Handler) http.Handler {
+// Dependencies groups the long-lived connections and services shared by every request.
+type Dependencies struct {
+	DB                       *gorm.DB
+	Mailer                   gateway.Mailer
+	TokenService             gateway.TokenService
+	Cipher                   gateway.FieldCipher
+	TOTPService              gateway.TOTPService
+	HealthChecker            gateway.HealthChecker
+	Jobs                     gateway.JobRunner
+	Metrics                  *metrics.Collector
+	TrustedProxies           utils.TrustedProxies
+	BaseURL                  string
+	RequireEmailVerification bool
+}
+
+func Ioc(dependencies Dependencies) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-			// Create context
-			iocContext := ioc.NewContext()
-
-			// Get context from request
-			//ctx := r.Context()
-
-			// Instantiates and resolve all dependencies
-			//metricCollector := repository.NewMetricCollector(nrgin.Transaction(r.Context()))
-			//configurationRepository := repository.NewConfigurationRepository()
-			iocContext.Bind(gateway.UserRepositoryType).ToInstance(repository.NewUserRepository(db, r.Context()))
-
-			// Register UseCase
-			//iocContext.Bind(usecase.GetConfigurationsUseCaseType).ToInstance(usecase.NewGetConfigurationsUseCase())
-			iocContext.Bind(usecase.UserUseCaseType).ToInstance(usecase.NewUserUseCase())
-			iocContext.Bind(usecase.LoginUseCaseType).ToInstance(usecase.NewLoginUseCase())
-
-			// Register Repositories
-			//iocContext.Bind(gateway.MetricCollectorType).ToInstance(metricCollector)
-
-			// Register Services
-			//iocContext.Bind(gateway.LocaleServiceType).ToInstance(service.NewLocaleService(r.Context(), metricCollector, configurationRepository))
-
-			// Set logger in context
-			//lvl := log.NewAtomicLevelAt(log.WarnLevel)
-			//logger := log.NewProductionLogger(&lvl)
-			//ctx = log.Context(ctx, logger)
-
 			// Set injector in context
-			injector := ioc.NewInjector(iocContext)
+			injector := NewServiceLocator(dependencies, r.Context())
+			injector.Context().Bind(gateway.RequestInfoType).ToInstance(&model.RequestInfo{
+				ID:       mid.GetReqID(r.Context()),
+				ClientIP: utils.ClientIP(r),
+			})
 			contx := ctx.SetServiceLocator(r.Context(), injector)
 
 			// New request
@@ -54,6 +55,75 @@ func Ioc(db *gorm.DB) func(next http.Handler) http.Handler {
 	}
 }
 
+// NewServiceLocator Register all your useCases, repositories and services bound to the given context.
+// It is shared by the HTTP middleware and the background workers.
+func NewServiceLocator(dependencies Dependencies, c context.Context) gateway.ServiceLocator {
+	// The statements carry the context, so they are canceled with it and traced in its span.
+	db := dependencies.DB.WithContext(c)
+
+	// Create context
+	iocContext := ioc.NewContext()
+
+	// Instantiates and resolve all dependencies
+	//metricCollector := repository.NewMetricCollector(nrgin.Transaction(r.Context()))
+	//configurationRepository := repository.NewConfigurationRepository()
+	iocContext.Bind(gateway.UserRepositoryType).ToInstance(repository.NewUserRepository(db, c, dependencies.Cipher))
+	iocContext.Bind(gateway.NotificationRepositoryType).ToInstance(repository.NewNotificationRepository(db, c))
+	iocContext.Bind(gateway.TokenRepositoryType).ToInstance(repository.NewTokenRepository(db, c))
+	iocContext.Bind(gateway.LoginAttemptRepositoryType).ToInstance(repository.NewLoginAttemptRepository(db, c))
+	iocContext.Bind(gateway.RoleRepositoryType).ToInstance(repository.NewRoleRepository(db, c))
+	iocContext.Bind(gateway.AuditRepositoryType).ToInstance(repository.NewAuditRepository(db, c))
+	iocContext.Bind(gateway.TwoFactorRepositoryType).ToInstance(repository.NewTwoFactorRepository(db, c, dependencies.Cipher))
+	iocContext.Bind(gateway.AccountDataRepositoryType).ToInstance(repository.NewAccountDataRepository(db, c))
+	iocContext.Bind(gateway.SearchRepositoryType).ToInstance(repository.NewSearchRepository(db, c))
+	iocContext.Bind(gateway.SchoolRepositoryType).ToInstance(repository.NewSchoolRepository(db, c))
+	iocContext.Bind(gateway.CalendarRepositoryType).ToInstance(repository.NewCalendarRepository(db, c))
+	iocContext.Bind(gateway.GuardianRepositoryType).ToInstance(repository.NewGuardianRepository(db, c))
+	iocContext.Bind(gateway.PickupRepositoryType).ToInstance(repository.NewPickupRepository(db, c, dependencies.Cipher))
+	iocContext.Bind(gateway.ProvisioningRepositoryType).ToInstance(repository.NewProvisioningRepository(db, c, dependencies.Cipher))
+
+	// Register UseCase
+	//iocContext.Bind(usecase.GetConfigurationsUseCaseType).ToInstance(usecase.NewGetConfigurationsUseCase())
+	iocContext.Bind(usecase.UserUseCaseType).ToInstance(usecase.NewUserUseCase())
+	iocContext.Bind(usecase.LoginUseCaseType).ToInstance(usecase.NewLoginUseCase(dependencies.RequireEmailVerification))
+	iocContext.Bind(usecase.NotificationUseCaseType).ToInstance(usecase.NewNotificationUseCase())
+	iocContext.Bind(usecase.AccountUseCaseType).ToInstance(usecase.NewAccountUseCase(dependencies.BaseURL))
+	iocContext.Bind(usecase.AuthorizationUseCaseType).ToInstance(usecase.NewAuthorizationUseCase())
+	iocContext.Bind(usecase.PrivacyUseCaseType).ToInstance(usecase.NewPrivacyUseCase())
+	iocContext.Bind(usecase.TwoFactorUseCaseType).ToInstance(usecase.NewTwoFactorUseCase())
+	iocContext.Bind(usecase.AuditUseCaseType).ToInstance(usecase.NewAuditUseCase())
+	iocContext.Bind(usecase.SearchUseCaseType).ToInstance(usecase.NewSearchUseCase())
+	iocContext.Bind(usecase.SchoolUseCaseType).ToInstance(usecase.NewSchoolUseCase())
+	iocContext.Bind(usecase.CalendarUseCaseType).ToInstance(usecase.NewCalendarUseCase())
+	iocContext.Bind(usecase.GuardianUseCaseType).ToInstance(usecase.NewGuardianUseCase(dependencies.BaseURL))
+	iocContext.Bind(usecase.PickupUseCaseType).ToInstance(usecase.NewPickupUseCase())
+	iocContext.Bind(usecase.ProvisioningUseCaseType).ToInstance(usecase.NewProvisioningUseCase())
+
+	// Register Repositories
+	if dependencies.Metrics != nil {
+		iocContext.Bind(gateway.MetricCollectorType).ToInstance(dependencies.Metrics)
+	}
+
+	if dependencies.Jobs != nil {
+		iocContext.Bind(gateway.JobRunnerType).ToInstance(dependencies.Jobs)
+	}
+
+	// Register Services
+	//iocContext.Bind(gateway.LocaleServiceType).ToInstance(service.NewLocaleService(r.Context(), metricCollector, configurationRepository))
+	iocContext.Bind(gateway.NotificationSenderType).ToInstance(notification.NewLogSender())
+	iocContext.Bind(gateway.MailerType).ToInstance(dependencies.Mailer)
+	iocContext.Bind(gateway.TokenServiceType).ToInstance(dependencies.TokenService)
+	iocContext.Bind(gateway.FieldCipherType).ToInstance(dependencies.Cipher)
+	iocContext.Bind(gateway.TOTPServiceType).ToInstance(dependencies.TOTPService)
+	iocContext.Bind(gateway.CalendarCodecType).ToInstance(ical.NewCodec())
+	iocContext.Bind(gateway.HealthCheckerType).ToInstance(dependencies.HealthChecker)
+	iocContext.Bind(gateway.TracerType).ToInstance(tracing.NewTracer(c))
+	// Provided on each lookup, so the logs carry the fields added once the request is underway, such as the user.
+	iocContext.Bind(gateway.LoggerType).ToProvider(func() interface{} { return logging.FromContext(c) })
+
+	return ioc.NewInjector(iocContext)
+}
+
 // HTTP middleware setting a value on the request context
 /*func MyMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	ctx "github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/context"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+const bearerPrefix = "Bearer "
+
+// Authenticate requires a valid access token in the Authorization header and registers the principal
+// of its user in the service locator, so use cases can authorize their actions, and adds the user to the logs of
+// the request. It must run after Ioc.
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serviceLocator := ctx.GetServiceLocator(r.Context())
+		useCase := serviceLocator.GetInstance(usecase.AuthorizationUseCaseType).(usecase.AuthorizationUseCase)
+
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) {
+			utils.RenderError(w, web.ErrUnauthorized)
+			return
+		}
+
+		principal, err := useCase.Authenticate(strings.TrimPrefix(header, bearerPrefix), serviceLocator)
+		if err != nil {
+			logging.FromContext(r.Context()).WithError(err).Error("authentication failure")
+			utils.RenderError(w, err)
+			return
+		}
+
+		serviceLocator.Context().Bind(gateway.PrincipalType).ToInstance(principal)
+		logging.AddFields(r.Context(), log.Fields{logging.FieldUserID: principal.UserID})
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Authorize requires the principal of the request to hold every permission. It must run after Authenticate.
+func Authorize(permissions ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			serviceLocator := ctx.GetServiceLocator(r.Context())
+
+			for _, permission := range permissions {
+				if _, err := usecase.Authorize(serviceLocator, permission); err != nil {
+					utils.RenderError(w, err)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
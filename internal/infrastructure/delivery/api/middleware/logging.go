@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	mid "github.com/go-chi/chi/v5/middleware"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	requestIDHeader = "X-Request-ID"
+	// maxRequestIDLength bounds the IDs given by the clients, which end up in every log of the request.
+	maxRequestIDLength = 128
+)
+
+// RequestID identifies each request with the ID of its X-Request-ID header, such as the one set by a proxy, or a
+// new random ID when the header is missing or unusable. The ID is sent back in the same header and read with
+// chi's GetReqID.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), mid.RequestIDKey, id)))
+	})
+}
+
+// validRequestID accepts the IDs made of printable ASCII characters without spaces, up to a bounded length.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// Logger stores in the context of each request a logger with its ID and trace, and logs the request once
+// answered with its status, latency and user. It must run after RequestID and Tracing.
+func Logger(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		fields := log.Fields{logging.FieldRequestID: mid.GetReqID(r.Context())}
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+			fields[logging.FieldTraceID] = spanContext.TraceID().String()
+		}
+		ctx := logging.NewContext(r.Context(), log.WithFields(fields))
+
+		ww := mid.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		status := responseStatus(ww)
+		entry := logging.FromContext(ctx).WithFields(log.Fields{
+			logging.FieldMethod:    r.Method,
+			logging.FieldPath:      r.URL.Path,
+			logging.FieldRoute:     routePattern(r),
+			logging.FieldStatus:    status,
+			logging.FieldLatencyMS: time.Since(start).Milliseconds(),
+			logging.FieldBytes:     ww.BytesWritten(),
+			logging.FieldClientIP:  utils.ClientIP(r),
+			logging.FieldUserAgent: r.UserAgent(),
+		})
+
+		if status >= http.StatusInternalServerError {
+			entry.Error("request failed")
+			return
+		}
+		entry.Info("request handled")
+	})
+}
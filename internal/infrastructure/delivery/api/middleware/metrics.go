@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/metrics"
+	"github.com/go-chi/chi/v5"
+	mid "github.com/go-chi/chi/v5/middleware"
+)
+
+// unmatchedRoute labels the requests that matched no route, so scanners probing random paths do not create a
+// series each.
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and duration of the requests by method, route pattern and status code.
+func Metrics(collector *metrics.Collector) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := mid.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			collector.ObserveHTTPRequest(r.Method, routePattern(r), responseStatus(ww), time.Since(start))
+		})
+	}
+}
+
+// routePattern returns the route pattern r matched, which is only known once the router handled it.
+func routePattern(r *http.Request) string {
+	if routeContext := chi.RouteContext(r.Context()); routeContext != nil && routeContext.RoutePattern() != "" {
+		return routeContext.RoutePattern()
+	}
+
+	return unmatchedRoute
+}
+
+// responseStatus returns the status code written, which is 200 when the handler wrote none.
+func responseStatus(ww mid.WrapResponseWriter) int {
+	if status := ww.Status(); status != 0 {
+		return status
+	}
+
+	return http.StatusOK
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+)
+
+// Recover turns a panic while serving a request into a 500 Internal Server Error, logging the panic with its stack,
+// so the connection is answered and the server keeps running. It must run after Logger, so the panic is logged
+// with the fields of the request and the request is logged as failed.
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			// The standard way of aborting a response, which the server handles on its own.
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+
+			logging.FromContext(r.Context()).WithField("stack", string(debug.Stack())).Errorf("panic: %v", recovered)
+
+			// The connection of an upgraded request, such as a websocket, is no longer HTTP.
+			if r.Header.Get("Connection") != "Upgrade" {
+				utils.RenderError(w, fmt.Errorf("panic: %v", recovered))
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/utils"
+)
+
+// StrictTransportSecurity tells browsers to only reach the service over HTTPS for maxAge, including its
+// subdomains. The header is only sent on HTTPS responses, as browsers ignore it over plain HTTP.
+func StrictTransportSecurity(maxAge time.Duration) func(next http.Handler) http.Handler {
+	value := fmt.Sprintf("max-age=%d; includeSubDomains", int64(maxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS != nil {
+				w.Header().Set("Strict-Transport-Security", value)
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RealIP replaces the remote address of the requests coming from a trusted proxy with the client address the proxy
+// forwarded in X-Forwarded-For, so the logs and the login throttling see the client instead of the proxy. The header
+// is ignored for the requests coming from anywhere else, since clients can forge it.
+func RealIP(proxies utils.TrustedProxies) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(proxies) > 0 {
+				if client := utils.ResolveClientIP(r, proxies); client != utils.ClientIP(r) {
+					r.RemoteAddr = net.JoinHostPort(client, "0")
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
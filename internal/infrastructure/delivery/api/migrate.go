@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/config"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/migration"
+	log "github.com/sirupsen/logrus"
+)
+
+const migrateUsage = `usage: migrate [-lock-timeout duration] <command>
+
+commands:
+  up              apply every pending migration
+  down [steps]    revert the last steps migrations, 1 by default
+  status          list the migrations and whether they are applied
+  to <version>    apply or revert migrations until version is the last one applied, 0 reverts them all
+`
+
+// Migrate runs the migrate subcommand with its arguments on the configured database, and returns the exit code.
+func Migrate(cfg config.Config, args []string) int {
+	return runMigrate(args, os.Stdout, os.Stderr, func() (*migration.Migrator, error) {
+		db, err := newDBConnection(cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+
+		migrations, err := migration.Embedded()
+		if err != nil {
+			return nil, err
+		}
+
+		return migration.NewMigrator(sqlDB, migrations), nil
+	})
+}
+
+func runMigrate(args []string, stdout io.Writer, stderr io.Writer, newMigrator func() (*migration.Migrator, error)) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, migrateUsage) }
+	lockTimeout := flags.Duration("lock-timeout", time.Minute, "how long to wait for another process to finish migrating")
+
+	if err := flags.Parse(args); err != nil {
+		return ExitCodeInvalidArguments
+	}
+
+	command, operands := flags.Arg(0), flags.Args()
+	if len(operands) > 0 {
+		operands = operands[1:]
+	}
+
+	var (
+		steps   = 1
+		version uint64
+		err     error
+	)
+
+	switch {
+	case command == "up" && len(operands) == 0, command == "status" && len(operands) == 0:
+	case command == "down" && len(operands) <= 1:
+		if len(operands) == 1 {
+			if steps, err = strconv.Atoi(operands[0]); err != nil || steps < 1 {
+				fmt.Fprintf(stderr, "invalid steps %s\n", operands[0])
+				return ExitCodeInvalidArguments
+			}
+		}
+	case command == "to" && len(operands) == 1:
+		if version, err = strconv.ParseUint(operands[0], 10, 32); err != nil {
+			fmt.Fprintf(stderr, "invalid version %s\n", operands[0])
+			return ExitCodeInvalidArguments
+		}
+	default:
+		flags.Usage()
+		return ExitCodeInvalidArguments
+	}
+
+	migrator, err := newMigrator()
+	if err != nil {
+		log.Error("couldn't prepare the migrations. ", err)
+		return ExitCodeFailToCreateDBConnection
+	}
+	migrator.WithLockTimeout(*lockTimeout)
+
+	ctx := context.Background()
+
+	var done []migration.Migration
+	switch command {
+	case "up":
+		done, err = migrator.Up(ctx)
+	case "down":
+		done, err = migrator.Down(ctx, steps)
+	case "to":
+		done, err = migrator.To(ctx, uint(version))
+	case "status":
+		var statuses []migration.Status
+		if statuses, err = migrator.Status(ctx); err == nil {
+			printMigrationStatus(stdout, statuses)
+		}
+	}
+
+	for _, migrated := range done {
+		fmt.Fprintf(stdout, "%s %04d_%s\n", command, migrated.Version, migrated.Name)
+	}
+
+	if err != nil {
+		log.Error("migration failure. ", err)
+		return ExitCodeFailToMigrate
+	}
+
+	if command != "status" && len(done) == 0 {
+		fmt.Fprintln(stdout, "no change")
+	}
+
+	return ExitCodeOK
+}
+
+func printMigrationStatus(w io.Writer, statuses []migration.Status) {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		if status.Applied {
+			state, appliedAt = "applied", time.Unix(status.AppliedAt, 0).UTC().Format(time.RFC3339)
+		}
+
+		switch {
+		case status.Dirty:
+			state = "dirty"
+		case status.ChecksumMismatch:
+			state = "modified"
+		case !status.Known:
+			state = "unknown"
+		}
+
+		fmt.Fprintf(table, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+
+	_ = table.Flush()
+}
@@ -2,14 +2,15 @@ package route
 
 import (
 	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware"
-	"gorm.io/gorm"
 
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
 	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/handler"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/metrics"
 	"github.com/go-chi/chi/v5"
 )
 
-func NewRouter(db *gorm.DB) *chi.Mux { //*gin.Engine {
+func NewRouter(dependencies middleware.Dependencies) *chi.Mux { //*gin.Engine {
 	/*
 		router := gin.Default()
 		healthyCheckGroup := router.Group("/ping")
@@ -23,21 +24,103 @@ func NewRouter(db *gorm.DB) *chi.Mux { //*gin.Engine {
 
 	r := chi.NewRouter()
 	r.NotFound(web.DefaultNotFoundHandler)
+	r.MethodNotAllowed(web.DefaultMethodNotAllowedHandler)
 
-	//r.Use(mid.RequestID)
-	//r.Use(mid.RealIP)
-	//r.Use(mid.Logger)
-	//r.Use(mid.Recoverer)
-	r.Use(middleware.Ioc(db))
+	r.Use(middleware.RequestID)
+	r.Use(middleware.Tracing)
+	if dependencies.Metrics != nil {
+		r.Use(middleware.Metrics(dependencies.Metrics))
+	}
+	r.Use(middleware.RealIP(dependencies.TrustedProxies))
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recover)
+	r.Use(middleware.Ioc(dependencies))
 
 	configureRoutes(r)
 
 	return r
 }
 
+// NewMetricsRouter serves GET /metrics on the admin listener, apart from the API.
+func NewMetricsRouter(collector *metrics.Collector) *chi.Mux {
+	r := chi.NewRouter()
+	r.NotFound(web.DefaultNotFoundHandler)
+	r.MethodNotAllowed(web.DefaultMethodNotAllowedHandler)
+
+	r.Get("/metrics", collector.Handler().ServeHTTP)
+
+	return r
+}
+
 func configureRoutes(router *chi.Mux) {
 	router.Get("/ping", handler.Pong)
+	router.Get("/health/live", handler.Live)
+	router.Get("/health/ready", handler.Ready)
 	router.Route("/where/are/they/ws", func(r chi.Router) {
 		r.Post("/login", handler.Login)
+		r.Post("/login/two-factor", handler.VerifyTwoFactorLogin)
+		r.Post("/login/two-factor/enrollment", handler.StartLoginTwoFactorEnrollment)
+		r.Post("/email-verification", handler.RequestEmailVerification)
+		r.Post("/email-verification/confirm", handler.VerifyEmail)
+		r.Post("/password-reset", handler.RequestPasswordReset)
+		r.Post("/password-reset/confirm", handler.ResetPassword)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Authenticate)
+
+			r.With(middleware.Authorize(model.PermissionReadUsers)).Get("/search", handler.Search)
+			r.With(middleware.Authorize(model.PermissionReadUsers)).Get("/users", handler.ListUsers)
+			r.With(middleware.Authorize(model.PermissionReadUsers)).Get("/users/{id}", handler.GetUser)
+			r.Patch("/users/me", handler.UpdateProfile)
+			r.Post("/users/me/password", handler.ChangePassword)
+			r.With(middleware.Authorize(model.PermissionManageNotifications)).Get("/users/{id}/notification-preferences", handler.GetNotificationPreferences)
+			r.With(middleware.Authorize(model.PermissionManageNotifications)).Put("/users/{id}/notification-preferences", handler.UpdateNotificationPreferences)
+			r.Post("/users/{id}/two-factor", handler.EnrollTwoFactor)
+			r.Delete("/users/{id}/two-factor", handler.DisableTwoFactor)
+			r.Post("/users/{id}/two-factor/confirm", handler.ConfirmTwoFactor)
+			r.Post("/users/{id}/two-factor/recovery-codes", handler.RegenerateRecoveryCodes)
+			r.Get("/users/{id}/export", handler.ExportAccountData)
+			r.Get("/users/{id}/deletion", handler.GetAccountDeletion)
+			r.Post("/users/{id}/deletion", handler.RequestAccountDeletion)
+			r.Delete("/users/{id}/deletion", handler.CancelAccountDeletion)
+			r.Get("/schools", handler.ListSchools)
+			r.Get("/schools/{id}", handler.GetSchool)
+			r.Get("/schools/{id}/calendar.ics", handler.ExportSchoolCalendar)
+			r.Get("/schools/{id}/service-day", handler.GetServiceDay)
+			r.Get("/companies/{company}/calendar", handler.GetCompanyCalendar)
+			r.Get("/companies/{company}/calendar.ics", handler.ExportCompanyCalendar)
+			r.Put("/children/{id}/school", handler.SetChildSchool)
+			r.Get("/children/{id}/guardians", handler.ListGuardians)
+			r.Put("/children/{id}/guardians/{userId}", handler.UpdateGuardian)
+			r.Delete("/children/{id}/guardians/{userId}", handler.RemoveGuardian)
+			r.Get("/children/{id}/guardian-invitations", handler.ListGuardianInvitations)
+			r.Post("/children/{id}/guardian-invitations", handler.InviteGuardian)
+			r.Delete("/children/{id}/guardian-invitations/{invitationId}", handler.RevokeGuardianInvitation)
+			r.Get("/children/{id}/pickups", handler.ListPickups)
+			r.Post("/children/{id}/pickups", handler.CreatePickup)
+			r.Put("/children/{id}/pickups/{pickupId}", handler.UpdatePickup)
+			r.Delete("/children/{id}/pickups/{pickupId}", handler.DeletePickup)
+			r.Post("/children/{id}/pickup-pin", handler.GeneratePickupPIN)
+			r.Get("/children/{id}/pickup-verifications", handler.ListPickupHandovers)
+			r.Post("/children/{id}/pickup-verifications", handler.VerifyPickup)
+			r.Post("/children/{id}/notifications", handler.NotifyGuardians)
+			r.Post("/guardian-invitations/accept", handler.AcceptGuardianInvitation)
+
+			r.Route("/admin", func(r chi.Router) {
+				r.With(middleware.Authorize(model.PermissionManageUsers)).Put("/users/{id}/status", handler.SetUserStatus)
+				r.With(middleware.Authorize(model.PermissionManageRoles)).Get("/users/{id}/roles", handler.GetUserRoles)
+				r.With(middleware.Authorize(model.PermissionManageRoles)).Put("/users/{id}/roles", handler.SetUserRoles)
+				r.With(middleware.Authorize(model.PermissionManageUsers)).Get("/companies/{company}/two-factor-policy", handler.GetTwoFactorPolicy)
+				r.With(middleware.Authorize(model.PermissionManageUsers)).Put("/companies/{company}/two-factor-policy", handler.SetTwoFactorPolicy)
+				r.With(middleware.Authorize(model.PermissionReadAudit)).Get("/audit", handler.FindAuditEntries)
+				r.With(middleware.Authorize(model.PermissionReadAudit)).Get("/audit/verification", handler.VerifyAuditLog)
+				r.With(middleware.Authorize(model.PermissionManagePrivacy)).Post("/privacy/reencrypt", handler.StartReencryption)
+				r.With(middleware.Authorize(model.PermissionManageSchools)).Post("/schools", handler.CreateSchool)
+				r.With(middleware.Authorize(model.PermissionManageSchools)).Put("/schools/{id}", handler.UpdateSchool)
+				r.With(middleware.Authorize(model.PermissionManageSchools)).Post("/schools/{id}/calendar/import", handler.ImportSchoolCalendar)
+				r.With(middleware.Authorize(model.PermissionManageCalendars)).Put("/companies/{company}/calendar", handler.SetCompanyCalendar)
+				r.With(middleware.Authorize(model.PermissionManageCalendars)).Post("/companies/{company}/calendar/import", handler.ImportCompanyCalendar)
+			})
+		})
 	})
 }
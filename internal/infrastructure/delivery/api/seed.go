@@ -0,0 +1,160 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/config"
+)
+
+// The demo data of the seed command: a school, a driver and a parent following the driver, with a child
+// attending the school.
+var (
+	seedLatitude, seedLongitude = -31.648105, -60.707722
+
+	seedSchool = model.School{
+		Name:           "La Salle",
+		Street:         "San Jerónimo",
+		Number:         "2155",
+		City:           "Santa Fe",
+		State:          "Santa Fe",
+		Country:        "Argentina",
+		Latitude:       &seedLatitude,
+		Longitude:      &seedLongitude,
+		GeofenceRadius: 150,
+		BellSchedules: []model.BellSchedule{
+			{Weekday: time.Monday, Start: "08:00:00", End: "12:00:00"},
+			{Weekday: time.Tuesday, Start: "08:00:00", End: "12:00:00"},
+			{Weekday: time.Wednesday, Start: "08:00:00", End: "12:00:00"},
+			{Weekday: time.Thursday, Start: "08:00:00", End: "12:00:00"},
+			{Weekday: time.Friday, Start: "08:00:00", End: "12:00:00"},
+		},
+	}
+
+	seedDriver = model.UserProvision{
+		User: model.User{
+			Name:          "Juan",
+			LastName:      "Perez",
+			IDNumber:      "12345678",
+			Username:      "jperez",
+			Password:      "jperez1234",
+			Email:         "jperez@mail.com",
+			Type:          "observed",
+			EmailVerified: true,
+		},
+		CompanyName: "company school bus",
+		SchoolBus:   model.SchoolBus{LicensePlate: "11AAA222", Model: "Master", Brand: "Renault", SchoolBusLicense: "11222"},
+	}
+
+	seedObserver = model.UserProvision{
+		User: model.User{
+			Name:          "Maria",
+			LastName:      "Dominguez",
+			IDNumber:      "87654321",
+			Username:      "mdominguez",
+			Password:      "mdominguez1234",
+			Email:         "mdominguez@mail.com",
+			Type:          "observer",
+			EmailVerified: true,
+		},
+	}
+
+	seedChild = model.Children{Name: "Pilar", LastName: "Dominguez", SchoolStartTime: "08:00:00", SchoolEndTime: "12:00:00"}
+)
+
+// runSeed creates the demo data through the use cases. What already exists is left alone, so running it again
+// does nothing. The demo accounts have well-known passwords, so it refuses to run unless DEMO_DATA is set.
+func runSeed(cfg config.Config, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := newFlagSet("seed", "", stderr)
+	if !parseFlags(flags, args, 0) {
+		return ExitCodeInvalidArguments
+	}
+
+	if !cfg.Features.DemoData {
+		fmt.Fprintln(stderr, "seed creates accounts with public passwords, set DEMO_DATA=true to run it on a development database")
+		return ExitCodeInvalidConfig
+	}
+
+	return runAsOperator(cfg, stderr, func(locator gateway.ServiceLocator) error {
+		school, err := seedSchoolOnce(locator)
+		if err != nil {
+			return err
+		}
+
+		driver, _, err := seedUserOnce(seedDriver, stdout, locator)
+		if err != nil {
+			return err
+		}
+
+		parent, created, err := seedUserOnce(seedObserver, stdout, locator)
+		if err != nil {
+			return err
+		}
+
+		provisioning := locator.GetInstance(usecase.ProvisioningUseCaseType).(usecase.ProvisioningUseCase)
+		if err = provisioning.LinkObserver(driver.ID, parent.ID, locator); err != nil {
+			return err
+		}
+
+		// Children cannot be looked up, so they are only added along with their parent.
+		if created {
+			child := seedChild
+			child.ObserverUserID, child.SchoolID = parent.ID, school.ID
+
+			added, err := provisioning.AddChild(child, locator)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "created child %s %s with ID %d\n", added.Name, added.LastName, added.ID)
+		}
+
+		return nil
+	})
+}
+
+func seedSchoolOnce(locator gateway.ServiceLocator) (*model.School, error) {
+	useCase := locator.GetInstance(usecase.SchoolUseCaseType).(usecase.SchoolUseCase)
+
+	schools, err := useCase.List(locator)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range schools {
+		if schools[i].Name == seedSchool.Name {
+			return &schools[i], nil
+		}
+	}
+
+	return useCase.Create(seedSchool, locator)
+}
+
+// seedUserOnce creates the user unless its username is taken, and reports whether it was created.
+func seedUserOnce(provision model.UserProvision, stdout io.Writer, locator gateway.ServiceLocator) (*model.User, bool, error) {
+	users := locator.GetInstance(usecase.UserUseCaseType).(usecase.UserUseCase)
+
+	existing, err := users.GetByUsername(provision.User.Username, locator)
+	if err == nil {
+		return existing, false, nil
+	}
+
+	if !errors.Is(err, web.ErrNotFound) {
+		return nil, false, err
+	}
+
+	useCase := locator.GetInstance(usecase.ProvisioningUseCaseType).(usecase.ProvisioningUseCase)
+	user, err := useCase.CreateUser(provision, locator)
+	if err != nil {
+		return nil, false, err
+	}
+
+	fmt.Fprintf(stdout, "created %s %s with ID %d\n", user.Type, user.Username, user.ID)
+
+	return user, true, nil
+}
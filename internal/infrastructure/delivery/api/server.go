@@ -0,0 +1,245 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	stdlog "log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/config"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/route"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/certificate"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+	log "github.com/sirupsen/logrus"
+)
+
+// startServer serves until SIGINT or SIGTERM, then drains: it stops accepting connections and waits for the
+// requests in flight, stops the workers and the jobs, delivers the notification digests that are due, exports the spans left
+// and closes the database pool. It returns the exit code.
+func startServer(cfg config.Config) int {
+	dependencies, exitCode, err := newDependencies(cfg)
+	if err != nil {
+		log.Error(err)
+		return exitCode
+	}
+	defer closeDB(dependencies.DB)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracingOptions(cfg.Tracing))
+	if err != nil {
+		log.Error("couldn't set up tracing. ", err)
+		return ExitCodeFailToSetUpTracing
+	}
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	background, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	var workers sync.WaitGroup
+	dependencies.Jobs = newJobRunner(background, dependencies, &workers)
+
+	var handler http.Handler = route.NewRouter(dependencies)
+	if cfg.TLS.Enabled() && cfg.TLS.HSTSMaxAge > 0 {
+		handler = middleware.StrictTransportSecurity(cfg.TLS.HSTSMaxAge)(handler)
+	}
+
+	servers := []*http.Server{newHTTPServer(cfg.HTTP, handler)}
+
+	if cfg.TLS.Enabled() {
+		reloader, err := certificate.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			log.Error(err)
+			return ExitCodeFailToLoadCertificate
+		}
+
+		go reloader.Watch(background, cfg.TLS.ReloadInterval)
+		reloadOnHangup(background, reloader)
+		servers[0].TLSConfig = newTLSConfig(reloader)
+
+		if cfg.TLS.RedirectAddr != "" {
+			redirect := cfg.HTTP
+			redirect.Addr = cfg.TLS.RedirectAddr
+			servers = append(servers, newHTTPServer(redirect, redirectToHTTPS(cfg.HTTP.Addr)))
+		}
+	}
+
+	// The metrics are not authenticated, so they are served apart from the API, on an address only the monitoring
+	// network reaches.
+	if dependencies.Metrics != nil && cfg.HTTP.MetricsAddr != "" {
+		admin := cfg.HTTP
+		admin.Addr = cfg.HTTP.MetricsAddr
+		servers = append(servers, newHTTPServer(admin, route.NewMetricsRouter(dependencies.Metrics)))
+	}
+
+	if cfg.Features.DigestWorker {
+		startDigestWorker(background, dependencies, digestWorkerInterval, &workers)
+	}
+	if cfg.Features.ErasureWorker {
+		startErasureWorker(background, dependencies, erasureWorkerInterval, &workers)
+	}
+
+	failed := make(chan error, len(servers))
+	log.Info("server start")
+	for _, server := range servers {
+		go func(server *http.Server) {
+			log.Println("Listening on " + server.Addr)
+			failed <- listen(server)
+		}(server)
+	}
+
+	select {
+	case err = <-failed:
+		log.Error("server failed. ", err)
+		shutdown(cfg.HTTP.ShutdownTimeout, servers...)
+		exitCode = ExitCodeServerFailed
+	case <-signals.Done():
+		// From now on a second signal stops the process right away.
+		stopSignals()
+		log.Info("shutting down, waiting for the requests in flight")
+		exitCode = shutdown(cfg.HTTP.ShutdownTimeout, servers...)
+	}
+
+	stopBackground()
+	workers.Wait()
+
+	if cfg.Features.DigestWorker {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+		flushDigests(ctx, dependencies, time.Now())
+		cancel()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("spans could not be exported. ", err)
+	}
+	cancel()
+
+	log.Info("server exit")
+
+	return exitCode
+}
+
+// tracingOptions tells where the spans go.
+func tracingOptions(cfg config.Tracing) tracing.Options {
+	return tracing.Options{
+		Exporter:     cfg.Exporter,
+		File:         cfg.File,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		OTLPInsecure: cfg.OTLPInsecure,
+		SampleRatio:  cfg.SampleRatio,
+		ServiceName:  cfg.ServiceName,
+	}
+}
+
+// newHTTPServer creates the web server with the configured timeouts, so slow or idle clients cannot hold
+// connections forever.
+func newHTTPServer(cfg config.HTTP, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          stdlog.New(log.StandardLogger().WriterLevel(log.WarnLevel), "http: ", 0),
+	}
+}
+
+// listen serves HTTPS when the server has a TLS configuration, HTTP otherwise. It returns nil once the server is
+// shut down.
+func listen(server *http.Server) error {
+	var err error
+	if server.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+// shutdown stops the servers once the requests in flight finish, closing the connections left when the timeout
+// expires, and returns the exit code.
+func shutdown(timeout time.Duration, servers ...*http.Server) int {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	exitCode := ExitCodeOK
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			log.Error("requests in flight did not finish in time. ", err)
+			_ = server.Close()
+			exitCode = ExitCodeShutdownTimeout
+		}
+	}
+
+	return exitCode
+}
+
+// newTLSConfig serves the certificate of the reloader with HTTP/2, refusing the TLS versions older than 1.2.
+func newTLSConfig(reloader *certificate.Reloader) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+}
+
+// reloadOnHangup reloads the certificate on SIGHUP until the context is done.
+func reloadOnHangup(ctx context.Context, reloader *certificate.Reloader) {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hangups)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangups:
+				if err := reloader.Reload(); err != nil {
+					log.Error("certificate could not be reloaded. ", err)
+					continue
+				}
+				log.Info("certificate reloaded on SIGHUP")
+			}
+		}
+	}()
+}
+
+// redirectToHTTPS permanently redirects every request to the same URL over HTTPS, on the port of httpsAddr.
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := strings.Trim(r.Host, "[]")
+		if hostname, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = hostname
+		}
+
+		target := host
+		if strings.Contains(host, ":") {
+			target = "[" + host + "]"
+		}
+		if port != "" && port != "443" {
+			target = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(w, r, "https://"+target+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
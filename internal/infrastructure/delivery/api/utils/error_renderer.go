@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+// RenderError writes err as a web.Error with the status code mapped by GetHTTPCodeByError, along with the headers
+// of the error, such as Retry-After, when it implements web.Headerer. A web.Error is written as is. The message of
+// the server errors is not disclosed, since it may describe the internals of the service.
+func RenderError(w http.ResponseWriter, err error) {
+	var headerer web.Headerer
+	if errors.As(err, &headerer) {
+		for k, values := range headerer.Headers() {
+			for _, v := range values {
+				w.Header().Add(k, v)
+			}
+		}
+	}
+
+	var webErr *web.Error
+	if !errors.As(err, &webErr) {
+		status := GetHTTPCodeByError(err)
+		message := err.Error()
+		if status >= http.StatusInternalServerError {
+			message = strings.ToLower(http.StatusText(status))
+		}
+
+		webErr = web.NewError(status, message).(*web.Error)
+	}
+
+	_ = web.EncodeJSON(w, webErr, webErr.StatusCode())
+}
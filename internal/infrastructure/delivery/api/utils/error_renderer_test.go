@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHTTPCodeByError(t *testing.T) {
+	assert.Equal(t, http.StatusNotFound, GetHTTPCodeByError(fmt.Errorf("%w: child", web.ErrNotFound)))
+	assert.Equal(t, http.StatusGone, GetHTTPCodeByError(web.NewError(http.StatusGone, "gone")))
+	assert.Equal(t, http.StatusInternalServerError, GetHTTPCodeByError(errors.New("unexpected")))
+}
+
+func TestRenderError(t *testing.T) {
+	w := httptest.NewRecorder()
+	RenderError(w, web.NewRetryError(web.ErrTooManyAttempts, time.Minute))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"status":429,"code":"too_many_requests","message":"too many attempts, retry in 60 seconds"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	RenderError(w, fmt.Errorf("loading user: %w", web.NewError(http.StatusNotFound, "user not found")))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"status":404,"code":"not_found","message":"user not found"}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	RenderError(w, errors.New("dial tcp 10.0.0.1:3306: connection refused"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"status":500,"code":"internal_server_error","message":"internal server error"}`, w.Body.String())
+}
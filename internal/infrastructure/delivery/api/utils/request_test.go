@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.10,,2001:db8::1")
+	assert.NoError(t, err)
+	assert.Len(t, proxies, 3)
+	assert.True(t, proxies.Contains("10.1.2.3"))
+	assert.True(t, proxies.Contains("192.168.1.10"))
+	assert.False(t, proxies.Contains("192.168.1.11"))
+	assert.True(t, proxies.Contains("2001:db8::1"))
+	assert.False(t, proxies.Contains("unknown"))
+
+	_, err = ParseTrustedProxies("10.0.0.0/33")
+	assert.Error(t, err)
+}
+
+func TestResolveClientIP(t *testing.T) {
+	proxies, _ := ParseTrustedProxies("10.0.0.0/8")
+
+	request := func(remoteAddr string, forwardedFor ...string) string {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = remoteAddr
+		for _, value := range forwardedFor {
+			r.Header.Add("X-Forwarded-For", value)
+		}
+
+		return ResolveClientIP(r, proxies)
+	}
+
+	assert.Equal(t, "203.0.113.7", request("203.0.113.7:5000", "198.51.100.1"), "untrusted peers cannot forward")
+	assert.Equal(t, "198.51.100.1", request("10.0.0.2:5000", "198.51.100.1"))
+	assert.Equal(t, "198.51.100.1", request("10.0.0.2:5000", "1.1.1.1, 198.51.100.1", "10.0.0.3"), "forged entries are skipped")
+	assert.Equal(t, "10.0.0.3", request("10.0.0.2:5000", "10.0.0.3"))
+	assert.Equal(t, "10.0.0.2", request("10.0.0.2:5000"))
+	assert.Equal(t, "10.0.0.2", request("10.0.0.2:5000", "garbage"))
+}
@@ -1,13 +1,20 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
 )
 
+// GetHTTPCodeByError maps an error to the HTTP status code of its response. A web.Error keeps its own status,
+// the domain errors have their status, and anything else is an internal server error.
 func GetHTTPCodeByError(err error) int {
+	var webErr *web.Error
+	if errors.As(err, &webErr) {
+		return webErr.StatusCode()
+	}
 	if errors.Is(err, web.ErrIncorrectPassword) {
 		return http.StatusBadRequest
 	}
@@ -20,10 +27,33 @@ func GetHTTPCodeByError(err error) int {
 	if errors.Is(err, web.ErrNotFound) {
 		return http.StatusNotFound
 	}
-	if errors.Is(err, web.ErrInternalServerError) {
-		return http.StatusInternalServerError
+	if errors.Is(err, web.ErrInvalidToken) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, web.ErrEmailNotVerified) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, web.ErrInvalidCredentials) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, web.ErrAccountDisabled) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, web.ErrTooManyAttempts) {
+		return http.StatusTooManyRequests
+	}
+	if errors.Is(err, web.ErrUnauthorized) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, web.ErrForbidden) {
+		return http.StatusForbidden
+	}
+	if errors.Is(err, web.ErrInvalidTwoFactor) {
+		return http.StatusUnauthorized
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return http.StatusServiceUnavailable
 	}
 
-	// If not exist a status! This doesn't have to happen.
-	return http.StatusTeapot
+	return http.StatusInternalServerError
 }
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/usecase"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/delivery/api/middleware"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	digestWorkerInterval  = time.Minute
+	erasureWorkerInterval = time.Hour
+)
+
+// startDigestWorker periodically delivers the notification digests that are due until the context is done.
+// workers is done once the worker stops.
+func startDigestWorker(ctx context.Context, dependencies middleware.Dependencies, interval time.Duration, workers *sync.WaitGroup) {
+	ticker := time.NewTicker(interval)
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				flushDigests(ctx, dependencies, now)
+			}
+		}
+	}()
+}
+
+// flushDigests delivers the notification digests due at now.
+func flushDigests(ctx context.Context, dependencies middleware.Dependencies, now time.Time) {
+	locator := middleware.NewServiceLocator(dependencies, ctx)
+	useCase := locator.GetInstance(usecase.NotificationUseCaseType).(usecase.NotificationUseCase)
+	if err := useCase.FlushDigests(now, locator); err != nil {
+		logging.FromContext(ctx).WithError(err).Error("notification digests could not be flushed")
+	}
+}
+
+// startErasureWorker periodically erases the accounts whose deletion grace period is over until the context is done.
+// workers is done once the worker stops.
+func startErasureWorker(ctx context.Context, dependencies middleware.Dependencies, interval time.Duration, workers *sync.WaitGroup) {
+	ticker := time.NewTicker(interval)
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				locator := middleware.NewServiceLocator(dependencies, ctx)
+				useCase := locator.GetInstance(usecase.PrivacyUseCaseType).(usecase.PrivacyUseCase)
+				if err := useCase.EraseDueAccounts(now, locator); err != nil {
+					logging.FromContext(ctx).WithError(err).Error("deleted accounts could not be erased")
+				}
+			}
+		}
+	}()
+}
+
+// jobRunner runs the jobs started by the requests, such as the re-encryption, until they finish or the server shuts
+// down. The jobs get a service locator bound to the context of the server instead of the one of the request, which
+// is canceled once the response is sent.
+type jobRunner struct {
+	ctx          context.Context
+	dependencies middleware.Dependencies
+	workers      *sync.WaitGroup
+	mutex        sync.Mutex
+	running      map[string]bool
+}
+
+// newJobRunner creates the job runner of the server. workers is done once the jobs stop.
+func newJobRunner(ctx context.Context, dependencies middleware.Dependencies, workers *sync.WaitGroup) gateway.JobRunner {
+	return &jobRunner{
+		ctx:          ctx,
+		dependencies: dependencies,
+		workers:      workers,
+		running:      map[string]bool{},
+	}
+}
+
+// Start runs the job unless one of the same name is already running, and reports whether it started it.
+func (j *jobRunner) Start(name string, principal *model.Principal, job func(gateway.ServiceLocator) error) bool {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.running[name] {
+		return false
+	}
+	j.running[name] = true
+
+	ctx := logging.NewContext(j.ctx, logging.FromContext(j.ctx).WithFields(log.Fields{"job": name, "user_id": principal.UserID}))
+	locator := middleware.NewServiceLocator(j.dependencies, ctx)
+	locator.Context().Bind(gateway.PrincipalType).ToInstance(principal)
+
+	j.workers.Add(1)
+	go func() {
+		defer j.workers.Done()
+		defer j.finish(name)
+
+		logging.FromContext(ctx).Info("job started")
+		if err := job(locator); err != nil {
+			logging.FromContext(ctx).WithError(err).Error("job failed")
+			return
+		}
+		logging.FromContext(ctx).Info("job finished")
+	}()
+
+	return true
+}
+
+func (j *jobRunner) finish(name string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	delete(j.running, name)
+}
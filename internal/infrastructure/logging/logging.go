@@ -0,0 +1,81 @@
+// Package logging carries the logger of each request in its context, so the handlers, use cases and repositories
+// log with the same fields, such as the request ID and the user, and formats the logs as text or JSON.
+package logging
+
+import (
+	"context"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Formats of the logs.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Fields shared by the logs of a request.
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldUserID    = "user_id"
+	FieldMethod    = "method"
+	FieldPath      = "path"
+	FieldRoute     = "route"
+	FieldStatus    = "status"
+	FieldLatencyMS = "latency_ms"
+	FieldBytes     = "bytes"
+	FieldClientIP  = "client_ip"
+	FieldUserAgent = "user_agent"
+)
+
+type contextKey struct{}
+
+// scope holds the logger of a request. Fields added once the request is underway, such as the user after the
+// authentication, reach everything already holding the context.
+type scope struct {
+	mutex  sync.RWMutex
+	logger *log.Entry
+}
+
+// Configure sets the level and the format of the logs. The level and the format are expected to be valid.
+func Configure(level string, format string) {
+	parsed, _ := log.ParseLevel(level)
+	log.SetLevel(parsed)
+
+	if format == FormatJSON {
+		log.SetFormatter(&log.JSONFormatter{})
+	} else {
+		log.SetFormatter(&log.TextFormatter{})
+	}
+}
+
+// NewContext returns a copy of ctx carrying logger.
+func NewContext(ctx context.Context, logger *log.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, &scope{logger: logger})
+}
+
+// FromContext returns the logger carried by ctx, or the standard logger when there is none.
+func FromContext(ctx context.Context) *log.Entry {
+	if ctx != nil {
+		if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+			s.mutex.RLock()
+			defer s.mutex.RUnlock()
+
+			return s.logger
+		}
+	}
+
+	return log.NewEntry(log.StandardLogger())
+}
+
+// AddFields adds the fields to the logger carried by ctx, if any.
+func AddFields(ctx context.Context, fields log.Fields) {
+	if s, ok := ctx.Value(contextKey{}).(*scope); ok {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		s.logger = s.logger.WithFields(fields)
+	}
+}
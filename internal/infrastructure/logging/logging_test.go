@@ -0,0 +1,53 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContextWithoutLogger(t *testing.T) {
+	entry := FromContext(context.Background())
+
+	assert.Equal(t, log.StandardLogger(), entry.Logger)
+	assert.Empty(t, entry.Data)
+}
+
+func TestAddFields(t *testing.T) {
+	ctx := NewContext(context.Background(), log.WithField(FieldRequestID, "abc"))
+
+	AddFields(ctx, log.Fields{FieldUserID: uint(7)})
+
+	assert.Equal(t, log.Fields{FieldRequestID: "abc", FieldUserID: uint(7)}, FromContext(ctx).Data)
+
+	// Contexts without a logger are left alone.
+	AddFields(context.Background(), log.Fields{FieldUserID: uint(7)})
+	assert.Empty(t, FromContext(context.Background()).Data)
+}
+
+func TestConfigureJSON(t *testing.T) {
+	formatter, level, out := log.StandardLogger().Formatter, log.GetLevel(), log.StandardLogger().Out
+	defer func() {
+		log.SetFormatter(formatter)
+		log.SetLevel(level)
+		log.SetOutput(out)
+	}()
+
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	Configure("warning", FormatJSON)
+
+	ctx := NewContext(context.Background(), log.WithField(FieldRequestID, "abc"))
+	FromContext(ctx).Info("not logged")
+	FromContext(ctx).Warn("logged")
+
+	var line map[string]interface{}
+	assert.NoError(t, json.Unmarshal(output.Bytes(), &line))
+	assert.Equal(t, "logged", line["msg"])
+	assert.Equal(t, "warning", line["level"])
+	assert.Equal(t, "abc", line[FieldRequestID])
+}
@@ -0,0 +1,72 @@
+package migration
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	t.Run("sorted by version", func(t *testing.T) {
+		migrations, err := Load(fstest.MapFS{
+			"0002_add_trips.up.sql":        {Data: []byte("CREATE TABLE Trips (id INT);")},
+			"0002_add_trips.down.sql":      {Data: []byte("DROP TABLE Trips;")},
+			"0001_initial_schema.up.sql":   {Data: []byte("CREATE TABLE Users (id INT);")},
+			"0001_initial_schema.down.sql": {Data: []byte("DROP TABLE Users;")},
+			"README.md":                    {Data: []byte("ignored")},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 2)
+		assert.Equal(t, uint(1), migrations[0].Version)
+		assert.Equal(t, "initial_schema", migrations[0].Name)
+		assert.Equal(t, "add_trips", migrations[1].Name)
+		assert.Equal(t, checksum("CREATE TABLE Trips (id INT);"), migrations[1].Checksum)
+	})
+
+	t.Run("without its down script", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"0001_initial_schema.up.sql": {Data: []byte("CREATE TABLE Users (id INT);")}})
+		assert.Error(t, err)
+	})
+
+	t.Run("with a repeated version", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{
+			"0001_initial_schema.up.sql": {Data: []byte("SELECT 1;")},
+			"0001_add_trips.down.sql":    {Data: []byte("SELECT 1;")},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("with an invalid name", func(t *testing.T) {
+		_, err := Load(fstest.MapFS{"initial.sql": {Data: []byte("SELECT 1;")}})
+		assert.Error(t, err)
+	})
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Embedded()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	assert.Equal(t, uint(1), migrations[0].Version)
+
+	for _, migration := range migrations {
+		assert.NotEmpty(t, splitStatements(migration.Up))
+		assert.NotEmpty(t, splitStatements(migration.Down))
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	statements := splitStatements(`-- Table Users; with a comment
+CREATE TABLE Users (
+  type VARCHAR(45) CHECK (type = 'a;b'), /* also; here */
+  ` + "`semi;colon`" + ` INT
+);
+# another comment;
+INSERT INTO Users VALUES ('it''s; \'fine\'');
+`)
+
+	assert.Equal(t, []string{
+		"CREATE TABLE Users (\n  type VARCHAR(45) CHECK (type = 'a;b'),  \n  `semi;colon` INT\n)",
+		`INSERT INTO Users VALUES ('it''s; \'fine\'')`,
+	}, statements)
+}
@@ -0,0 +1,11 @@
+SET @OLD_FOREIGN_KEY_CHECKS=@@FOREIGN_KEY_CHECKS, FOREIGN_KEY_CHECKS=0;
+
+DROP TABLE IF EXISTS `Children`;
+DROP TABLE IF EXISTS `ObservedUsersObserverUsers`;
+DROP TABLE IF EXISTS `Addresses`;
+DROP TABLE IF EXISTS `ObserverUsers`;
+DROP TABLE IF EXISTS `ObservedUsers`;
+DROP TABLE IF EXISTS `SchoolBuses`;
+DROP TABLE IF EXISTS `Users`;
+
+SET FOREIGN_KEY_CHECKS=@OLD_FOREIGN_KEY_CHECKS;
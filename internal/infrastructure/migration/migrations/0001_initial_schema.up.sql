@@ -1,22 +1,15 @@
--- MySQL Script generated by MySQL Workbench
--- Thu Dec  8 12:42:59 2022
--- Model: New Model    Version: 1.0
--- MySQL Workbench Forward Engineering
+-- The schema as it was when the migrations were introduced, the one of the former creation_db.sql. Every
+-- statement is idempotent, so databases created by hand from creation_db.sql can run it to be brought under the
+-- migrations; the later migrations bring them up to date.
 
 SET @OLD_UNIQUE_CHECKS=@@UNIQUE_CHECKS, UNIQUE_CHECKS=0;
 SET @OLD_FOREIGN_KEY_CHECKS=@@FOREIGN_KEY_CHECKS, FOREIGN_KEY_CHECKS=0;
 SET @OLD_SQL_MODE=@@SQL_MODE, SQL_MODE='ONLY_FULL_GROUP_BY,STRICT_TRANS_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION';
 
 -- -----------------------------------------------------
--- Schema DondeEstanApp
+-- Table `Users`
 -- -----------------------------------------------------
-CREATE SCHEMA IF NOT EXISTS `DondeEstanApp` ;
-USE `DondeEstanApp` ;
-
--- -----------------------------------------------------
--- Table `DondeEstanApp`.`Users`
--- -----------------------------------------------------
-CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`Users` (
+CREATE TABLE IF NOT EXISTS `Users` (
   `id` INT NOT NULL AUTO_INCREMENT,
   `name` VARCHAR(45) NOT NULL,
   `last_name` VARCHAR(45) NOT NULL,
@@ -35,9 +28,9 @@ ENGINE = InnoDB;
 
 
 -- -----------------------------------------------------
--- Table `DondeEstanApp`.`SchoolBuses`
+-- Table `SchoolBuses`
 -- -----------------------------------------------------
-CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`SchoolBuses` (
+CREATE TABLE IF NOT EXISTS `SchoolBuses` (
   `id` INT NOT NULL AUTO_INCREMENT,
   `license_plate` VARCHAR(45) NOT NULL,
   `model` VARCHAR(45) NOT NULL,
@@ -50,9 +43,9 @@ ENGINE = InnoDB;
 
 
 -- -----------------------------------------------------
--- Table `DondeEstanApp`.`ObservedUsers`
+-- Table `ObservedUsers`
 -- -----------------------------------------------------
-CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`ObservedUsers` (
+CREATE TABLE IF NOT EXISTS `ObservedUsers` (
   `privacy_key` VARCHAR(45) NOT NULL,
   `company_name` VARCHAR(45) NOT NULL,
   `user_id` INT NOT NULL,
@@ -65,21 +58,21 @@ CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`ObservedUsers` (
   INDEX `fk_ObservedUsers_School_Buses_idx` (`school_bus_id` ASC) VISIBLE,
   CONSTRAINT `fk_ObservedUsers_Users`
     FOREIGN KEY (`user_id`)
-    REFERENCES `DondeEstanApp`.`Users` (`id`)
+    REFERENCES `Users` (`id`)
     ON DELETE NO ACTION
     ON UPDATE NO ACTION,
   CONSTRAINT `fk_ObservedUsers_School_Buses`
     FOREIGN KEY (`school_bus_id`)
-    REFERENCES `DondeEstanApp`.`SchoolBuses` (`id`)
+    REFERENCES `SchoolBuses` (`id`)
     ON DELETE NO ACTION
     ON UPDATE NO ACTION)
 ENGINE = InnoDB;
 
 
 -- -----------------------------------------------------
--- Table `DondeEstanApp`.`ObserverUsers`
+-- Table `ObserverUsers`
 -- -----------------------------------------------------
-CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`ObserverUsers` (
+CREATE TABLE IF NOT EXISTS `ObserverUsers` (
   `user_id` INT NOT NULL,
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
   `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -87,16 +80,16 @@ CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`ObserverUsers` (
   INDEX `fk_ObserverUsers_Users_idx` (`user_id` ASC) VISIBLE,
   CONSTRAINT `fk_ObserverUsers_Users1`
     FOREIGN KEY (`user_id`)
-    REFERENCES `DondeEstanApp`.`Users` (`id`)
+    REFERENCES `Users` (`id`)
     ON DELETE NO ACTION
     ON UPDATE NO ACTION)
 ENGINE = InnoDB;
 
 
 -- -----------------------------------------------------
--- Table `DondeEstanApp`.`Addresses`
+-- Table `Addresses`
 -- -----------------------------------------------------
-CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`Addresses` (
+CREATE TABLE IF NOT EXISTS `Addresses` (
   `id` INT NOT NULL AUTO_INCREMENT,
   `street` VARCHAR(45) NOT NULL,
   `number` VARCHAR(45) NOT NULL,
@@ -115,15 +108,15 @@ CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`Addresses` (
   INDEX `fk_Addresses_ObserverUsers1_idx` (`observer_user_id` ASC) VISIBLE,
   CONSTRAINT `fk_Addresses_ObserverUsers1`
     FOREIGN KEY (`observer_user_id`)
-    REFERENCES `DondeEstanApp`.`ObserverUsers` (`user_id`)
+    REFERENCES `ObserverUsers` (`user_id`)
     ON DELETE NO ACTION
     ON UPDATE NO ACTION)
 ENGINE = InnoDB;
 
 -- -----------------------------------------------------
--- Table `DondeEstanApp`.`ObservedUsers_ObserverUsers`
+-- Table `ObservedUsers_ObserverUsers`
 -- -----------------------------------------------------
-CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`ObservedUsersObserverUsers` (
+CREATE TABLE IF NOT EXISTS `ObservedUsersObserverUsers` (
   `observed_user_id` INT NOT NULL,
   `observer_user_id` INT NOT NULL,
   `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -133,20 +126,20 @@ CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`ObservedUsersObserverUsers` (
   INDEX `fk_ObservedUsersObserverUsers_ObserverUsers_idx` (`observer_user_id` ASC) VISIBLE,
   CONSTRAINT `fk_ObservedUsersObserverUsers_ObservedUsers_id`
     FOREIGN KEY (`observed_user_id`)
-    REFERENCES `DondeEstanApp`.`ObservedUsers` (`user_id`)
+    REFERENCES `ObservedUsers` (`user_id`)
     ON DELETE NO ACTION
     ON UPDATE NO ACTION,
   CONSTRAINT `fk_ObservedUsersObserverUsers_ObserverUsers_id`
     FOREIGN KEY (`observer_user_id`)
-    REFERENCES `DondeEstanApp`.`ObserverUsers` (`user_id`)
+    REFERENCES `ObserverUsers` (`user_id`)
     ON DELETE NO ACTION
     ON UPDATE NO ACTION)
 ENGINE = InnoDB;
 
 -- -----------------------------------------------------
--- Table `DondeEstanApp`.`Children`
+-- Table `Children`
 -- -----------------------------------------------------
-CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`Children` (
+CREATE TABLE IF NOT EXISTS `Children` (
   `id` INT NOT NULL AUTO_INCREMENT,
   `name` VARCHAR(45) NOT NULL,
   `last_name` VARCHAR(45) NOT NULL,
@@ -160,7 +153,7 @@ CREATE TABLE IF NOT EXISTS `DondeEstanApp`.`Children` (
   INDEX `fk_children_observer_user_idx` (`observer_user_id` ASC) VISIBLE,
   CONSTRAINT `fk_children_observer_user`
     FOREIGN KEY (`observer_user_id`)
-    REFERENCES `DondeEstanApp`.`ObserverUsers` (`user_id`)
+    REFERENCES `ObserverUsers` (`user_id`)
     ON DELETE NO ACTION
     ON UPDATE NO ACTION)
 ENGINE = InnoDB;
@@ -0,0 +1,3 @@
+DROP TABLE `NotificationDigestItems`;
+DROP TABLE `NotificationPreferences`;
+DROP TABLE `NotificationSettings`;
@@ -0,0 +1,57 @@
+-- -----------------------------------------------------
+-- Table `NotificationSettings`
+-- -----------------------------------------------------
+CREATE TABLE `NotificationSettings` (
+  `observer_user_id` INT NOT NULL,
+  `timezone` VARCHAR(64) NOT NULL DEFAULT 'America/Argentina/Buenos_Aires',
+  `quiet_hours_enabled` BOOLEAN NOT NULL DEFAULT FALSE,
+  `quiet_hours_start` TIME NOT NULL DEFAULT '00:00:00',
+  `quiet_hours_end` TIME NOT NULL DEFAULT '00:00:00',
+  `digest_enabled` BOOLEAN NOT NULL DEFAULT FALSE,
+  `digest_interval_minutes` INT NOT NULL DEFAULT 60,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`observer_user_id`),
+  CONSTRAINT `fk_NotificationSettings_ObserverUsers`
+    FOREIGN KEY (`observer_user_id`)
+    REFERENCES `ObserverUsers` (`user_id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+-- -----------------------------------------------------
+-- Table `NotificationPreferences`
+-- -----------------------------------------------------
+CREATE TABLE `NotificationPreferences` (
+  `observer_user_id` INT NOT NULL,
+  `event_type` VARCHAR(45) NOT NULL CHECK (event_type IN ('proximity', 'arrival', 'announcement', 'check_in', 'sos')),
+  `enabled` BOOLEAN NOT NULL DEFAULT TRUE,
+  `channels` VARCHAR(45) NOT NULL DEFAULT 'push',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`observer_user_id`, `event_type`),
+  CONSTRAINT `fk_NotificationPreferences_ObserverUsers`
+    FOREIGN KEY (`observer_user_id`)
+    REFERENCES `ObserverUsers` (`user_id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+-- -----------------------------------------------------
+-- Table `NotificationDigestItems`
+-- -----------------------------------------------------
+CREATE TABLE `NotificationDigestItems` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `observer_user_id` INT NOT NULL,
+  `event_type` VARCHAR(45) NOT NULL,
+  `title` VARCHAR(255) NOT NULL,
+  `body` TEXT NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `fk_NotificationDigestItems_ObserverUsers_idx` (`observer_user_id` ASC) VISIBLE,
+  CONSTRAINT `fk_NotificationDigestItems_ObserverUsers`
+    FOREIGN KEY (`observer_user_id`)
+    REFERENCES `ObserverUsers` (`user_id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
@@ -0,0 +1,3 @@
+DROP TABLE `UserTokens`;
+
+ALTER TABLE `Users` DROP COLUMN `email_verified`;
@@ -0,0 +1,24 @@
+ALTER TABLE `Users` ADD COLUMN `email_verified` BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- The users created before the email verification keep signing in.
+UPDATE `Users` SET `email_verified` = TRUE;
+
+-- -----------------------------------------------------
+-- Table `UserTokens`
+-- -----------------------------------------------------
+CREATE TABLE `UserTokens` (
+  `id` VARCHAR(64) NOT NULL,
+  `user_id` INT NOT NULL,
+  `purpose` VARCHAR(45) NOT NULL,
+  `expires_at` TIMESTAMP NOT NULL,
+  `used_at` TIMESTAMP NULL DEFAULT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  CONSTRAINT `UserTokens_purpose_CHK` CHECK (purpose IN ('verify_email', 'reset_password')),
+  INDEX `fk_UserTokens_Users_idx` (`user_id` ASC) VISIBLE,
+  CONSTRAINT `fk_UserTokens_Users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `Users` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
@@ -0,0 +1,12 @@
+-- -----------------------------------------------------
+-- Table `LoginAttempts`
+-- -----------------------------------------------------
+CREATE TABLE `LoginAttempts` (
+  `scope` VARCHAR(16) NOT NULL,
+  `attempt_key` VARCHAR(64) NOT NULL,
+  `failures` INT NOT NULL DEFAULT 0,
+  `last_failure_at` BIGINT NOT NULL DEFAULT 0,
+  `blocked_until` BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (`scope`, `attempt_key`),
+  CONSTRAINT `LoginAttempts_scope_CHK` CHECK (scope IN ('username', 'ip')))
+ENGINE = InnoDB;
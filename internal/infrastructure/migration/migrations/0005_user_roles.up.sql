@@ -0,0 +1,17 @@
+-- -----------------------------------------------------
+-- Table `UserRoles`
+-- -----------------------------------------------------
+CREATE TABLE `UserRoles` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `user_id` INT NOT NULL,
+  `role` VARCHAR(45) NOT NULL CHECK (role IN ('platform_admin', 'company_admin', 'driver', 'guardian')),
+  `company_name` VARCHAR(45) NULL DEFAULT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `user_role_company_UNIQUE` (`user_id` ASC, `role` ASC, `company_name` ASC) VISIBLE,
+  CONSTRAINT `fk_UserRoles_Users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `Users` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
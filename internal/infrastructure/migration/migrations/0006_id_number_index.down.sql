@@ -0,0 +1,3 @@
+ALTER TABLE `Users`
+  DROP INDEX `id_number_index_IDX`,
+  DROP COLUMN `id_number_index`;
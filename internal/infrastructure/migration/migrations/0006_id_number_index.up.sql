@@ -0,0 +1,4 @@
+-- The blind index of the encrypted id_number, filled by privacy-key reencrypt for the existing users.
+ALTER TABLE `Users`
+  ADD COLUMN `id_number_index` VARCHAR(64) NULL DEFAULT NULL,
+  ADD INDEX `id_number_index_IDX` (`id_number_index` ASC) VISIBLE;
@@ -0,0 +1,9 @@
+DROP TABLE `CompanyTwoFactorPolicies`;
+DROP TABLE `UserRecoveryCodes`;
+DROP TABLE `UserTwoFactor`;
+
+DELETE FROM `LoginAttempts` WHERE `scope` = 'two_factor';
+
+ALTER TABLE `LoginAttempts`
+  DROP CHECK `LoginAttempts_scope_CHK`,
+  ADD CONSTRAINT `LoginAttempts_scope_CHK` CHECK (scope IN ('username', 'ip'));
@@ -0,0 +1,47 @@
+ALTER TABLE `LoginAttempts`
+  DROP CHECK `LoginAttempts_scope_CHK`,
+  ADD CONSTRAINT `LoginAttempts_scope_CHK` CHECK (scope IN ('username', 'ip', 'two_factor'));
+
+-- -----------------------------------------------------
+-- Table `UserTwoFactor`
+-- -----------------------------------------------------
+CREATE TABLE `UserTwoFactor` (
+  `user_id` INT NOT NULL,
+  `secret` VARCHAR(255) NOT NULL,
+  `enabled` BOOLEAN NOT NULL DEFAULT FALSE,
+  `last_used_step` BIGINT NOT NULL DEFAULT 0,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`user_id`),
+  CONSTRAINT `fk_UserTwoFactor_Users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `Users` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+-- -----------------------------------------------------
+-- Table `UserRecoveryCodes`
+-- -----------------------------------------------------
+CREATE TABLE `UserRecoveryCodes` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `user_id` INT NOT NULL,
+  `code_hash` CHAR(64) NOT NULL,
+  `used_at` TIMESTAMP NULL DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `user_code_UNIQUE` (`user_id` ASC, `code_hash` ASC) VISIBLE,
+  CONSTRAINT `fk_UserRecoveryCodes_Users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `Users` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+-- -----------------------------------------------------
+-- Table `CompanyTwoFactorPolicies`
+-- -----------------------------------------------------
+CREATE TABLE `CompanyTwoFactorPolicies` (
+  `company_name` VARCHAR(45) NOT NULL,
+  `required` BOOLEAN NOT NULL DEFAULT FALSE,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`company_name`))
+ENGINE = InnoDB;
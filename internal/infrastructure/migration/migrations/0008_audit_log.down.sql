@@ -0,0 +1,4 @@
+DROP TRIGGER `AuditLog_BEFORE_DELETE`;
+DROP TRIGGER `AuditLog_BEFORE_UPDATE`;
+DROP TABLE `AuditLogHead`;
+DROP TABLE `AuditLog`;
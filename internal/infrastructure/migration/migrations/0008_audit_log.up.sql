@@ -0,0 +1,39 @@
+-- -----------------------------------------------------
+-- Table `AuditLog`
+-- -----------------------------------------------------
+CREATE TABLE `AuditLog` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT,
+  `occurred_at` BIGINT NOT NULL,
+  `action` VARCHAR(64) NOT NULL,
+  `actor_id` INT NOT NULL DEFAULT 0,
+  `actor_username` VARCHAR(45) NOT NULL DEFAULT '',
+  `target_type` VARCHAR(32) NOT NULL DEFAULT '',
+  `target_id` VARCHAR(64) NOT NULL DEFAULT '',
+  `ip` VARCHAR(45) NOT NULL DEFAULT '',
+  `request_id` VARCHAR(128) NOT NULL DEFAULT '',
+  `details` TEXT NOT NULL,
+  `prev_hash` CHAR(64) NOT NULL,
+  `hash` CHAR(64) NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `action_IDX` (`action` ASC, `occurred_at` ASC) VISIBLE,
+  INDEX `actor_IDX` (`actor_id` ASC, `occurred_at` ASC) VISIBLE,
+  INDEX `target_IDX` (`target_type` ASC, `target_id` ASC) VISIBLE)
+ENGINE = InnoDB;
+-- -----------------------------------------------------
+-- Table `AuditLogHead`
+-- Single row holding the hash of the last audit entry, locked while appending.
+-- -----------------------------------------------------
+CREATE TABLE `AuditLogHead` (
+  `id` INT NOT NULL CHECK (id = 1),
+  `last_hash` CHAR(64) NOT NULL DEFAULT '',
+  PRIMARY KEY (`id`))
+ENGINE = InnoDB;
+
+INSERT INTO `AuditLogHead` (`id`, `last_hash`) VALUES (1, '');
+
+-- The audit log is append-only.
+CREATE TRIGGER `AuditLog_BEFORE_UPDATE` BEFORE UPDATE ON `AuditLog`
+  FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'AuditLog is append-only';
+
+CREATE TRIGGER `AuditLog_BEFORE_DELETE` BEFORE DELETE ON `AuditLog`
+  FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'AuditLog is append-only';
@@ -0,0 +1,18 @@
+-- -----------------------------------------------------
+-- Table `AccountDeletions`
+-- Times are unix seconds. The Users row is anonymized, not deleted, once scheduled_at is reached.
+-- -----------------------------------------------------
+CREATE TABLE `AccountDeletions` (
+  `user_id` INT NOT NULL,
+  `status` VARCHAR(16) NOT NULL CHECK (status IN ('pending', 'cancelled', 'completed')),
+  `requested_at` BIGINT NOT NULL,
+  `scheduled_at` BIGINT NOT NULL,
+  `completed_at` BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (`user_id`),
+  INDEX `status_scheduled_at_IDX` (`status` ASC, `scheduled_at` ASC) VISIBLE,
+  CONSTRAINT `fk_AccountDeletions_Users`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `Users` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
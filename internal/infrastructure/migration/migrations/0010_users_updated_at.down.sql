@@ -0,0 +1,2 @@
+ALTER TABLE `Users`
+  MODIFY COLUMN `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
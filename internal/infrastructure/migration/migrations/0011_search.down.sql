@@ -0,0 +1,2 @@
+ALTER TABLE `Children` DROP INDEX `children_search_FT`;
+ALTER TABLE `Users` DROP INDEX `users_search_FT`;
@@ -0,0 +1,6 @@
+-- The searches are accent and case insensitive through the collation of the searched columns.
+ALTER TABLE `Users` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
+ALTER TABLE `Children` CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci;
+
+ALTER TABLE `Users` ADD FULLTEXT INDEX `users_search_FT` (`name`, `last_name`, `email`) VISIBLE;
+ALTER TABLE `Children` ADD FULLTEXT INDEX `children_search_FT` (`name`, `last_name`, `school_name`) VISIBLE;
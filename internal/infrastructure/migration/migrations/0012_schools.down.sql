@@ -0,0 +1,17 @@
+ALTER TABLE `Children`
+  ADD COLUMN `school_name` VARCHAR(45) NOT NULL DEFAULT '' AFTER `last_name`,
+  DROP INDEX `children_search_FT`;
+
+UPDATE `Children` AS c
+INNER JOIN `Schools` AS s ON s.`id` = c.`school_id`
+SET c.`school_name` = LEFT(s.`name`, 45);
+
+ALTER TABLE `Children`
+  ALTER COLUMN `school_name` DROP DEFAULT,
+  DROP FOREIGN KEY `fk_children_school`,
+  DROP INDEX `fk_children_school_idx`,
+  DROP COLUMN `school_id`,
+  ADD FULLTEXT INDEX `children_search_FT` (`name`, `last_name`, `school_name`) VISIBLE;
+
+DROP TABLE `SchoolBellSchedules`;
+DROP TABLE `Schools`;
@@ -0,0 +1,75 @@
+-- -----------------------------------------------------
+-- Table `Schools`
+-- The geofence_radius is in meters around the coordinates, which are unknown for the schools migrated from
+-- the former Children.school_name.
+-- -----------------------------------------------------
+CREATE TABLE `Schools` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `name` VARCHAR(100) NOT NULL,
+  `street` VARCHAR(45) NOT NULL DEFAULT '',
+  `number` VARCHAR(45) NOT NULL DEFAULT '',
+  `city` VARCHAR(45) NOT NULL DEFAULT '',
+  `state` VARCHAR(45) NOT NULL DEFAULT '',
+  `country` VARCHAR(45) NOT NULL DEFAULT '',
+  `latitude` DECIMAL(9,6) NULL DEFAULT NULL,
+  `longitude` DECIMAL(9,6) NULL DEFAULT NULL,
+  `geofence_radius` INT NOT NULL DEFAULT 150,
+  `timezone` VARCHAR(64) NOT NULL DEFAULT 'America/Argentina/Buenos_Aires',
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE INDEX `name_UNIQUE` (`name` ASC) VISIBLE,
+  FULLTEXT INDEX `schools_search_FT` (`name`, `city`) VISIBLE)
+ENGINE = InnoDB
+DEFAULT CHARACTER SET = utf8mb4
+COLLATE = utf8mb4_0900_ai_ci;
+
+-- -----------------------------------------------------
+-- Table `SchoolBellSchedules`
+-- The weekday goes from 0 (Sunday) to 6 (Saturday). Weekdays without a row have no classes.
+-- -----------------------------------------------------
+CREATE TABLE `SchoolBellSchedules` (
+  `school_id` INT NOT NULL,
+  `weekday` TINYINT NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+  `start_time` TIME NOT NULL,
+  `end_time` TIME NOT NULL,
+  PRIMARY KEY (`school_id`, `weekday`),
+  CONSTRAINT `fk_SchoolBellSchedules_Schools`
+    FOREIGN KEY (`school_id`)
+    REFERENCES `Schools` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+-- Moves the free text Children.school_name into the Schools table. The names are compared with the accent and
+-- case insensitive collation, so "Colegio San José" and "colegio san jose" become the same school. Each school
+-- gets a Monday to Friday bell schedule spanning the times of its children, and no coordinates, which an admin
+-- sets afterwards.
+ALTER TABLE `Children` ADD COLUMN `school_id` INT NULL AFTER `last_name`;
+
+INSERT INTO `Schools` (`name`)
+SELECT DISTINCT COALESCE(NULLIF(TRIM(`school_name`), ''), 'Unknown school') FROM `Children`
+ON DUPLICATE KEY UPDATE `name` = `Schools`.`name`;
+
+UPDATE `Children` AS c
+INNER JOIN `Schools` AS s ON s.`name` = COALESCE(NULLIF(TRIM(c.`school_name`), ''), 'Unknown school')
+SET c.`school_id` = s.`id`;
+
+INSERT IGNORE INTO `SchoolBellSchedules` (`school_id`, `weekday`, `start_time`, `end_time`)
+SELECT c.`school_id`, d.`weekday`, MIN(c.`school_start_time`), MAX(c.`school_end_time`)
+FROM `Children` AS c
+CROSS JOIN (SELECT 1 AS `weekday` UNION ALL SELECT 2 UNION ALL SELECT 3 UNION ALL SELECT 4 UNION ALL SELECT 5) AS d
+GROUP BY c.`school_id`, d.`weekday`;
+
+ALTER TABLE `Children` DROP INDEX `children_search_FT`;
+
+ALTER TABLE `Children`
+  MODIFY COLUMN `school_id` INT NOT NULL,
+  DROP COLUMN `school_name`,
+  ADD FULLTEXT INDEX `children_search_FT` (`name`, `last_name`) VISIBLE,
+  ADD INDEX `fk_children_school_idx` (`school_id` ASC) VISIBLE,
+  ADD CONSTRAINT `fk_children_school`
+    FOREIGN KEY (`school_id`)
+    REFERENCES `Schools` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION;
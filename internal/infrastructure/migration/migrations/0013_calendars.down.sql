@@ -0,0 +1,2 @@
+DROP TABLE `CompanyCalendarEntries`;
+DROP TABLE `SchoolCalendarEntries`;
@@ -0,0 +1,33 @@
+-- -----------------------------------------------------
+-- Table `SchoolCalendarEntries`
+-- Holidays and in-service days have no classes. Half days end at end_time, and special schedules replace the
+-- bell schedule of the date with start_time and end_time.
+-- -----------------------------------------------------
+CREATE TABLE `SchoolCalendarEntries` (
+  `school_id` INT NOT NULL,
+  `date` DATE NOT NULL,
+  `kind` VARCHAR(16) NOT NULL CHECK (kind IN ('holiday', 'in_service', 'half_day', 'special_schedule')),
+  `name` VARCHAR(100) NOT NULL DEFAULT '',
+  `start_time` TIME NULL DEFAULT NULL,
+  `end_time` TIME NULL DEFAULT NULL,
+  PRIMARY KEY (`school_id`, `date`),
+  CONSTRAINT `fk_SchoolCalendarEntries_Schools`
+    FOREIGN KEY (`school_id`)
+    REFERENCES `Schools` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+-- -----------------------------------------------------
+-- Table `CompanyCalendarEntries`
+-- The days of a school bus company. A holiday or in-service day of the company cancels the trips of every school.
+-- -----------------------------------------------------
+CREATE TABLE `CompanyCalendarEntries` (
+  `company_name` VARCHAR(45) NOT NULL,
+  `date` DATE NOT NULL,
+  `kind` VARCHAR(16) NOT NULL CHECK (kind IN ('holiday', 'in_service', 'half_day', 'special_schedule')),
+  `name` VARCHAR(100) NOT NULL DEFAULT '',
+  `start_time` TIME NULL DEFAULT NULL,
+  `end_time` TIME NULL DEFAULT NULL,
+  PRIMARY KEY (`company_name`, `date`))
+ENGINE = InnoDB;
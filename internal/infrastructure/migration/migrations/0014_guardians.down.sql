@@ -0,0 +1,8 @@
+DROP TABLE `GuardianInvitations`;
+DROP TABLE `ChildGuardians`;
+
+DELETE FROM `UserTokens` WHERE `purpose` = 'guardian_invitation';
+
+ALTER TABLE `UserTokens`
+  DROP CHECK `UserTokens_purpose_CHK`,
+  ADD CONSTRAINT `UserTokens_purpose_CHK` CHECK (purpose IN ('verify_email', 'reset_password'));
@@ -0,0 +1,54 @@
+ALTER TABLE `UserTokens`
+  DROP CHECK `UserTokens_purpose_CHK`,
+  ADD CONSTRAINT `UserTokens_purpose_CHK` CHECK (purpose IN ('verify_email', 'reset_password', 'guardian_invitation'));
+
+-- -----------------------------------------------------
+-- Table `ChildGuardians`
+-- The guardians of a child besides the primary one, who is Children.observer_user_id. starts_at and ends_at
+-- are unix seconds bounding the delegation, and 0 means unbounded.
+-- -----------------------------------------------------
+CREATE TABLE `ChildGuardians` (
+  `child_id` INT NOT NULL,
+  `observer_user_id` INT NOT NULL,
+  `role` VARCHAR(16) NOT NULL CHECK (role IN ('secondary', 'temporary_pickup')),
+  `starts_at` BIGINT NOT NULL DEFAULT 0,
+  `ends_at` BIGINT NOT NULL DEFAULT 0,
+  `invited_by` INT NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`child_id`, `observer_user_id`),
+  INDEX `fk_ChildGuardians_ObserverUsers_idx` (`observer_user_id` ASC) VISIBLE,
+  CONSTRAINT `fk_ChildGuardians_Children`
+    FOREIGN KEY (`child_id`)
+    REFERENCES `Children` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION,
+  CONSTRAINT `fk_ChildGuardians_ObserverUsers`
+    FOREIGN KEY (`observer_user_id`)
+    REFERENCES `ObserverUsers` (`user_id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+-- -----------------------------------------------------
+-- Table `GuardianInvitations`
+-- The id is the one of the token sent by email. Times are unix seconds.
+-- -----------------------------------------------------
+CREATE TABLE `GuardianInvitations` (
+  `id` VARCHAR(64) NOT NULL,
+  `child_id` INT NOT NULL,
+  `invited_by` INT NOT NULL,
+  `email` VARCHAR(45) NOT NULL,
+  `role` VARCHAR(16) NOT NULL CHECK (role IN ('secondary', 'temporary_pickup')),
+  `starts_at` BIGINT NOT NULL DEFAULT 0,
+  `ends_at` BIGINT NOT NULL DEFAULT 0,
+  `status` VARCHAR(16) NOT NULL CHECK (status IN ('pending', 'accepted', 'revoked')),
+  `expires_at` BIGINT NOT NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `child_status_IDX` (`child_id` ASC, `status` ASC) VISIBLE,
+  CONSTRAINT `fk_GuardianInvitations_Children`
+    FOREIGN KEY (`child_id`)
+    REFERENCES `Children` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
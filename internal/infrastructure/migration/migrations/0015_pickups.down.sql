@@ -0,0 +1,9 @@
+DROP TABLE `PickupHandovers`;
+DROP TABLE `PickupPINs`;
+DROP TABLE `AuthorizedPickups`;
+
+DELETE FROM `LoginAttempts` WHERE `scope` = 'pickup';
+
+ALTER TABLE `LoginAttempts`
+  DROP CHECK `LoginAttempts_scope_CHK`,
+  ADD CONSTRAINT `LoginAttempts_scope_CHK` CHECK (scope IN ('username', 'ip', 'two_factor'));
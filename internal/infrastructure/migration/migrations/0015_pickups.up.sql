@@ -0,0 +1,70 @@
+ALTER TABLE `LoginAttempts`
+  DROP CHECK `LoginAttempts_scope_CHK`,
+  ADD CONSTRAINT `LoginAttempts_scope_CHK` CHECK (scope IN ('username', 'ip', 'two_factor', 'pickup'));
+
+-- -----------------------------------------------------
+-- Table `AuthorizedPickups`
+-- The people the guardians of a child allow to receive it at the drop-off. id_number is encrypted.
+-- -----------------------------------------------------
+CREATE TABLE `AuthorizedPickups` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `child_id` INT NOT NULL,
+  `name` VARCHAR(45) NOT NULL,
+  `last_name` VARCHAR(45) NOT NULL,
+  `id_number` VARCHAR(255) NOT NULL,
+  `relationship` VARCHAR(16) NOT NULL,
+  `photo` MEDIUMBLOB NULL,
+  `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  INDEX `fk_AuthorizedPickups_Children_idx` (`child_id` ASC) VISIBLE,
+  CONSTRAINT `fk_AuthorizedPickups_Children`
+    FOREIGN KEY (`child_id`)
+    REFERENCES `Children` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+-- -----------------------------------------------------
+-- Table `PickupPINs`
+-- The current one-time pickup PIN of a child, hashed. expires_at is unix seconds.
+-- -----------------------------------------------------
+CREATE TABLE `PickupPINs` (
+  `child_id` INT NOT NULL,
+  `pin_hash` CHAR(64) NOT NULL,
+  `expires_at` BIGINT NOT NULL,
+  `created_by` INT NOT NULL,
+  PRIMARY KEY (`child_id`),
+  CONSTRAINT `fk_PickupPINs_Children`
+    FOREIGN KEY (`child_id`)
+    REFERENCES `Children` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
+
+-- -----------------------------------------------------
+-- Table `PickupHandovers`
+-- Who received a child from the driver. authorized_pickup_id is NULL when a PIN was shown.
+-- occurred_at is unix seconds.
+-- -----------------------------------------------------
+CREATE TABLE `PickupHandovers` (
+  `id` INT NOT NULL AUTO_INCREMENT,
+  `child_id` INT NOT NULL,
+  `driver_user_id` INT NOT NULL,
+  `method` VARCHAR(16) NOT NULL CHECK (method IN ('pin', 'id_number')),
+  `authorized_pickup_id` INT NULL DEFAULT NULL,
+  `received_by` VARCHAR(91) NOT NULL,
+  `occurred_at` BIGINT NOT NULL,
+  PRIMARY KEY (`id`),
+  INDEX `child_occurred_at_IDX` (`child_id` ASC, `occurred_at` ASC) VISIBLE,
+  CONSTRAINT `fk_PickupHandovers_Children`
+    FOREIGN KEY (`child_id`)
+    REFERENCES `Children` (`id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION,
+  CONSTRAINT `fk_PickupHandovers_ObservedUsers`
+    FOREIGN KEY (`driver_user_id`)
+    REFERENCES `ObservedUsers` (`user_id`)
+    ON DELETE NO ACTION
+    ON UPDATE NO ACTION)
+ENGINE = InnoDB;
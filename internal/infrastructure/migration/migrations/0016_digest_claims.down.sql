@@ -0,0 +1,4 @@
+ALTER TABLE `NotificationDigestItems`
+  DROP INDEX `claimed_by_IDX`,
+  DROP COLUMN `claimed_until`,
+  DROP COLUMN `claimed_by`;
@@ -0,0 +1,5 @@
+-- The replica delivering a digest claims its items first, so the others skip them until the claim expires.
+ALTER TABLE `NotificationDigestItems`
+  ADD COLUMN `claimed_by` VARCHAR(64) NULL DEFAULT NULL,
+  ADD COLUMN `claimed_until` BIGINT NOT NULL DEFAULT 0,
+  ADD INDEX `claimed_by_IDX` (`claimed_by` ASC) VISIBLE;
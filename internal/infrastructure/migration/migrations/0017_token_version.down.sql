@@ -0,0 +1,2 @@
+ALTER TABLE `Users`
+  DROP COLUMN `token_version`;
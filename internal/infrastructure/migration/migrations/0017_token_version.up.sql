@@ -0,0 +1,3 @@
+-- Access tokens carry the token version of their user when issued, so bumping it revokes them all.
+ALTER TABLE `Users`
+  ADD COLUMN `token_version` INT UNSIGNED NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- A new email waits here until its owner verifies it, so the account keeps its current email meanwhile.
+ALTER TABLE `Users`
+  ADD COLUMN `pending_email` VARCHAR(45) NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE `Schools` DROP INDEX `schools_name_FT`;
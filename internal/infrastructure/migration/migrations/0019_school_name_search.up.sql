@@ -0,0 +1,2 @@
+-- Children are also searched by the name of their school alone, which needs its own FULLTEXT index.
+ALTER TABLE `Schools` ADD FULLTEXT INDEX `schools_name_FT` (`name`) VISIBLE;
@@ -0,0 +1,375 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// lockName is the MySQL named lock held while migrating, so replicas starting together do not race.
+	lockName           = "schema_migrations"
+	defaultLockTimeout = time.Minute
+
+	statementCreateTable = "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+		"`version` INT UNSIGNED NOT NULL, " +
+		"`name` VARCHAR(100) NOT NULL, " +
+		"`checksum` CHAR(64) NOT NULL, " +
+		"`dirty` BOOLEAN NOT NULL DEFAULT FALSE, " +
+		"`applied_at` BIGINT NOT NULL, " +
+		"PRIMARY KEY (`version`)) ENGINE = InnoDB"
+	statementGetLock       = "SELECT GET_LOCK(?, ?)"
+	statementReleaseLock   = "SELECT RELEASE_LOCK(?)"
+	statementGetApplied    = "SELECT version, name, checksum, dirty, applied_at FROM schema_migrations ORDER BY version"
+	statementInsertApplied = "INSERT INTO schema_migrations (version, name, checksum, dirty, applied_at) VALUES (?, ?, ?, TRUE, ?)"
+	statementMarkClean     = "UPDATE schema_migrations SET dirty = FALSE WHERE version = ?"
+	statementMarkDirty     = "UPDATE schema_migrations SET dirty = TRUE WHERE version = ?"
+	statementDeleteApplied = "DELETE FROM schema_migrations WHERE version = ?"
+)
+
+var (
+	ErrLocked           = errors.New("another process is migrating the schema")
+	ErrDirty            = errors.New("a migration failed halfway and must be repaired by hand")
+	ErrChecksumMismatch = errors.New("an applied migration was modified")
+	ErrUnknownVersion   = errors.New("unknown migration version")
+	ErrPending          = errors.New("migrations are pending")
+)
+
+// Status tells whether a migration is applied. Known is false for the versions applied by a newer binary.
+// AppliedAt is unix seconds.
+type Status struct {
+	Version          uint
+	Name             string
+	Applied          bool
+	AppliedAt        int64
+	Dirty            bool
+	Known            bool
+	ChecksumMismatch bool
+}
+
+type applied struct {
+	version   uint
+	name      string
+	checksum  string
+	dirty     bool
+	appliedAt int64
+}
+
+// Migrator applies and reverts the migrations on a MySQL database.
+type Migrator struct {
+	db          *sql.DB
+	migrations  []Migration
+	lockTimeout time.Duration
+	now         func() time.Time
+}
+
+// NewMigrator creates a migrator for the migrations, which must be sorted by version as Load returns them.
+func NewMigrator(db *sql.DB, migrations []Migration) *Migrator {
+	return &Migrator{
+		db:          db,
+		migrations:  migrations,
+		lockTimeout: defaultLockTimeout,
+		now:         time.Now,
+	}
+}
+
+// WithLockTimeout changes how long the migrator waits for another process to finish migrating.
+func (m *Migrator) WithLockTimeout(timeout time.Duration) *Migrator {
+	m.lockTimeout = timeout
+
+	return m
+}
+
+// Status returns every known or applied migration, sorted by version.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.withLock(ctx, func(conn *sql.Conn, current map[uint]applied) error {
+		statuses = m.statuses(current)
+		return nil
+	})
+
+	return statuses, err
+}
+
+// CheckVersion fails when a known migration is not applied, or one failed halfway. It does not take the lock, so
+// it can be called often, such as by readiness checks, even while another process is migrating.
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	current, err := getApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range current {
+		if row.dirty {
+			return fmt.Errorf("%w: version %d", ErrDirty, row.version)
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := current[migration.Version]; !ok {
+			return fmt.Errorf("%w: version %d is not applied", ErrPending, migration.Version)
+		}
+	}
+
+	return nil
+}
+
+// Up applies every pending migration and returns the ones applied. The versions applied by a newer binary are
+// left alone, so replicas of the previous release can still start during a rollout.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if len(m.migrations) == 0 {
+		return nil, nil
+	}
+
+	var done []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn, current map[uint]applied) error {
+		var err error
+		done, err = m.migrateTo(ctx, conn, current, m.migrations[len(m.migrations)-1].Version, false)
+
+		return err
+	})
+
+	return done, err
+}
+
+// Down reverts the last steps applied migrations and returns the ones reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn, current map[uint]applied) error {
+		versions := appliedVersions(current)
+		if steps > len(versions) {
+			steps = len(versions)
+		}
+
+		target := uint(0)
+		if steps < len(versions) {
+			target = versions[len(versions)-steps-1]
+		}
+
+		var err error
+		done, err = m.migrateTo(ctx, conn, current, target, true)
+
+		return err
+	})
+
+	return done, err
+}
+
+// To applies or reverts migrations until version is the last one applied, and returns the ones applied or
+// reverted. Version zero reverts every migration.
+func (m *Migrator) To(ctx context.Context, version uint) ([]Migration, error) {
+	var done []Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn, current map[uint]applied) error {
+		var err error
+		done, err = m.migrateTo(ctx, conn, current, version, true)
+
+		return err
+	})
+
+	return done, err
+}
+
+// migrateTo applies the pending migrations up to target and, when revert is set, reverts the ones after it.
+func (m *Migrator) migrateTo(ctx context.Context, conn *sql.Conn, current map[uint]applied, target uint, revert bool) ([]Migration, error) {
+	if target != 0 && m.find(target) == nil {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	for _, status := range m.statuses(current) {
+		if status.Dirty {
+			return nil, fmt.Errorf("%w: version %d", ErrDirty, status.Version)
+		}
+
+		if status.ChecksumMismatch {
+			return nil, fmt.Errorf("%w: version %d", ErrChecksumMismatch, status.Version)
+		}
+	}
+
+	var done []Migration
+
+	versions := appliedVersions(current)
+	for i := len(versions) - 1; revert && i >= 0 && versions[i] > target; i-- {
+		migration := m.find(versions[i])
+		if migration == nil {
+			return done, fmt.Errorf("%w: version %d was applied by a newer binary", ErrUnknownVersion, versions[i])
+		}
+
+		if err := m.revert(ctx, conn, *migration); err != nil {
+			return done, err
+		}
+		done = append(done, *migration)
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := current[migration.Version]; ok || migration.Version > target {
+			continue
+		}
+
+		if err := m.apply(ctx, conn, migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	log.Infof("applying migration %04d_%s", migration.Version, migration.Name)
+
+	_, err := conn.ExecContext(ctx, statementInsertApplied, migration.Version, migration.Name, migration.Checksum, m.now().Unix())
+	if err != nil {
+		return err
+	}
+
+	if err = execScript(ctx, conn, migration.Up); err != nil {
+		return fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	_, err = conn.ExecContext(ctx, statementMarkClean, migration.Version)
+
+	return err
+}
+
+func (m *Migrator) revert(ctx context.Context, conn *sql.Conn, migration Migration) error {
+	log.Infof("reverting migration %04d_%s", migration.Version, migration.Name)
+
+	if _, err := conn.ExecContext(ctx, statementMarkDirty, migration.Version); err != nil {
+		return err
+	}
+
+	if err := execScript(ctx, conn, migration.Down); err != nil {
+		return fmt.Errorf("reverting migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	_, err := conn.ExecContext(ctx, statementDeleteApplied, migration.Version)
+
+	return err
+}
+
+// withLock runs fn on a single connection holding the migration lock, along with the migrations applied so far.
+// Named locks belong to the connection, so every statement has to go through it.
+func (m *Migrator) withLock(ctx context.Context, fn func(*sql.Conn, map[uint]applied) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	if err = conn.QueryRowContext(ctx, statementGetLock, lockName, int(m.lockTimeout.Seconds())).Scan(&locked); err != nil {
+		return err
+	}
+
+	if !locked.Valid || locked.Int64 != 1 {
+		return ErrLocked
+	}
+
+	defer func() {
+		if _, err := conn.ExecContext(context.Background(), statementReleaseLock, lockName); err != nil {
+			log.Error("migration lock could not be released. ", err)
+		}
+	}()
+
+	if _, err = conn.ExecContext(ctx, statementCreateTable); err != nil {
+		return err
+	}
+
+	current, err := getApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, current)
+}
+
+func (m *Migrator) statuses(current map[uint]applied) []Status {
+	statuses := make([]Status, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name, Known: true}
+		if row, ok := current[migration.Version]; ok {
+			status.Applied, status.AppliedAt, status.Dirty = true, row.appliedAt, row.dirty
+			status.ChecksumMismatch = row.checksum != migration.Checksum
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	for _, row := range current {
+		if m.find(row.version) == nil {
+			statuses = append(statuses, Status{Version: row.version, Name: row.name, Applied: true, AppliedAt: row.appliedAt, Dirty: row.dirty})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	return statuses
+}
+
+func (m *Migrator) find(version uint) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+
+	return nil
+}
+
+func getApplied(ctx context.Context, conn *sql.Conn) (map[uint]applied, error) {
+	rows, err := conn.QueryContext(ctx, statementGetApplied)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	current := map[uint]applied{}
+	for rows.Next() {
+		var row applied
+		if err = rows.Scan(&row.version, &row.name, &row.checksum, &row.dirty, &row.appliedAt); err != nil {
+			log.Error("error rows scan")
+			return nil, err
+		}
+
+		current[row.version] = row
+	}
+
+	return current, rows.Err()
+}
+
+func appliedVersions(current map[uint]applied) []uint {
+	versions := make([]uint, 0, len(current))
+	for version := range current {
+		versions = append(versions, version)
+	}
+
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+
+	return versions
+}
+
+func execScript(ctx context.Context, conn *sql.Conn, script string) error {
+	for _, statement := range splitStatements(script) {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
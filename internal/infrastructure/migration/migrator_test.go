@@ -0,0 +1,170 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var appliedColumns = []string{"version", "name", "checksum", "dirty", "applied_at"}
+
+func newTestMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	migrations := []Migration{
+		{Version: 1, Name: "initial_schema", Up: "CREATE TABLE Users (id INT);", Down: "DROP TABLE Users;"},
+		{Version: 2, Name: "add_trips", Up: "CREATE TABLE Trips (id INT);\nCREATE INDEX trips_idx ON Trips (id);", Down: "DROP TABLE Trips;"},
+	}
+	for i := range migrations {
+		migrations[i].Checksum = checksum(migrations[i].Up)
+	}
+
+	migrator := NewMigrator(db, migrations).WithLockTimeout(5 * time.Second)
+	migrator.now = func() time.Time { return time.Unix(1689000000, 0) }
+
+	return migrator, mock
+}
+
+func expectLock(mock sqlmock.Sqlmock, rows *sqlmock.Rows) {
+	mock.ExpectQuery(statementGetLock).WithArgs(lockName, 5).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(1))
+	mock.ExpectExec(statementCreateTable).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(statementGetApplied).WillReturnRows(rows)
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Up applies the pending migrations", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, sqlmock.NewRows(appliedColumns).AddRow(1, "initial_schema", migrator.migrations[0].Checksum, false, 1688000000))
+		mock.ExpectExec(statementInsertApplied).WithArgs(2, "add_trips", migrator.migrations[1].Checksum, 1689000000).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("CREATE TABLE Trips (id INT)").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE INDEX trips_idx ON Trips (id)").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(statementMarkClean).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(statementReleaseLock).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		done, err := migrator.Up(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, done, 1)
+		assert.Equal(t, uint(2), done[0].Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Up leaves the versions of a newer binary alone", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, sqlmock.NewRows(appliedColumns).
+			AddRow(1, "initial_schema", migrator.migrations[0].Checksum, false, 1688000000).
+			AddRow(2, "add_trips", migrator.migrations[1].Checksum, false, 1688000000).
+			AddRow(3, "add_routes", "abc", false, 1688500000))
+		mock.ExpectExec(statementReleaseLock).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		done, err := migrator.Up(ctx)
+		assert.NoError(t, err)
+		assert.Empty(t, done)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Up refuses a modified migration", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, sqlmock.NewRows(appliedColumns).AddRow(1, "initial_schema", "modified", false, 1688000000))
+		mock.ExpectExec(statementReleaseLock).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := migrator.Up(ctx)
+		assert.True(t, errors.Is(err, ErrChecksumMismatch))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Up refuses a dirty migration", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, sqlmock.NewRows(appliedColumns).AddRow(1, "initial_schema", migrator.migrations[0].Checksum, true, 1688000000))
+		mock.ExpectExec(statementReleaseLock).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := migrator.Up(ctx)
+		assert.True(t, errors.Is(err, ErrDirty))
+	})
+
+	t.Run("Up while another process holds the lock", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		mock.ExpectQuery(statementGetLock).WithArgs(lockName, 5).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(0))
+
+		_, err := migrator.Up(ctx)
+		assert.Equal(t, ErrLocked, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("A failed migration is left dirty", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, sqlmock.NewRows(appliedColumns).AddRow(1, "initial_schema", migrator.migrations[0].Checksum, false, 1688000000))
+		mock.ExpectExec(statementInsertApplied).WithArgs(2, "add_trips", migrator.migrations[1].Checksum, 1689000000).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("CREATE TABLE Trips (id INT)").WillReturnError(errors.New("table exists"))
+		mock.ExpectExec(statementReleaseLock).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := migrator.Up(ctx)
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Down reverts the last migration", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, sqlmock.NewRows(appliedColumns).
+			AddRow(1, "initial_schema", migrator.migrations[0].Checksum, false, 1688000000).
+			AddRow(2, "add_trips", migrator.migrations[1].Checksum, false, 1688000000))
+		mock.ExpectExec(statementMarkDirty).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DROP TABLE Trips").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(statementDeleteApplied).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(statementReleaseLock).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		done, err := migrator.Down(ctx, 1)
+		assert.NoError(t, err)
+		assert.Len(t, done, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("To an unknown version", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, sqlmock.NewRows(appliedColumns))
+		mock.ExpectExec(statementReleaseLock).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		_, err := migrator.To(ctx, 7)
+		assert.True(t, errors.Is(err, ErrUnknownVersion))
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		expectLock(mock, sqlmock.NewRows(appliedColumns).AddRow(1, "initial_schema", migrator.migrations[0].Checksum, false, 1688000000))
+		mock.ExpectExec(statementReleaseLock).WithArgs(lockName).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		statuses, err := migrator.Status(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []Status{
+			{Version: 1, Name: "initial_schema", Applied: true, AppliedAt: 1688000000, Known: true},
+			{Version: 2, Name: "add_trips", Known: true},
+		}, statuses)
+	})
+
+	t.Run("CheckVersion", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+		checksum := migrator.migrations[0].Checksum
+		mock.ExpectQuery(statementGetApplied).WillReturnRows(sqlmock.NewRows(appliedColumns).AddRow(1, "initial_schema", checksum, false, 1688000000))
+		mock.ExpectQuery(statementGetApplied).WillReturnRows(sqlmock.NewRows(appliedColumns).
+			AddRow(1, "initial_schema", checksum, false, 1688000000).
+			AddRow(2, "add_trips", migrator.migrations[1].Checksum, true, 1688000000))
+		mock.ExpectQuery(statementGetApplied).WillReturnRows(sqlmock.NewRows(appliedColumns).
+			AddRow(1, "initial_schema", checksum, false, 1688000000).
+			AddRow(2, "add_trips", migrator.migrations[1].Checksum, false, 1688000000).
+			AddRow(3, "add_routes", "abc", false, 1688500000))
+
+		assert.True(t, errors.Is(migrator.CheckVersion(ctx), ErrPending))
+		assert.True(t, errors.Is(migrator.CheckVersion(ctx), ErrDirty))
+		assert.NoError(t, migrator.CheckVersion(ctx))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
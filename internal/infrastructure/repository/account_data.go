@@ -0,0 +1,365 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	statementGetAccountChildren      = "SELECT c.id, c.name, c.last_name, c.school_id, s.name, c.school_start_time, c.school_end_time, c.observer_user_id, c.created_at, c.updated_at FROM Children AS c INNER JOIN Schools AS s ON s.id = c.school_id WHERE c.observer_user_id = @user_id ORDER BY c.id"
+	statementGetAccountAddresses     = "SELECT id, street, number, floor, apartament, zipCode, city, state, country, latitude, longitude, observer_user_id, created_at, updated_at FROM Addresses WHERE observer_user_id = @user_id ORDER BY id"
+	statementGetAccountLinks         = "SELECT observed_user_id, observer_user_id, created_at, updated_at FROM ObservedUsersObserverUsers WHERE observer_user_id = @user_id OR observed_user_id = @user_id"
+	statementGetAccountDeletion      = "SELECT user_id, status, requested_at, scheduled_at, completed_at FROM AccountDeletions WHERE user_id = @user_id"
+	statementSaveAccountDeletion     = "INSERT INTO AccountDeletions (user_id, status, requested_at, scheduled_at, completed_at) VALUES (@user_id, @status, @requested_at, @scheduled_at, @completed_at) ON DUPLICATE KEY UPDATE status = VALUES(status), requested_at = VALUES(requested_at), scheduled_at = VALUES(scheduled_at), completed_at = VALUES(completed_at)"
+	statementGetDueAccountDeletions  = "SELECT user_id, status, requested_at, scheduled_at, completed_at FROM AccountDeletions WHERE status = 'pending' AND scheduled_at <= @now ORDER BY scheduled_at"
+	statementCompleteAccountDeletion = "UPDATE AccountDeletions SET status = 'completed', completed_at = @completed_at WHERE user_id = @user_id"
+	statementGetAccountGuardianships = "SELECT g.child_id, g.observer_user_id, g.role, g.starts_at, g.ends_at, g.invited_by, g.created_at FROM ChildGuardians AS g INNER JOIN Children AS c ON c.id = g.child_id WHERE g.observer_user_id = @user_id OR c.observer_user_id = @user_id ORDER BY g.child_id, g.observer_user_id"
+	statementGetAccountHandovers     = "SELECT h.id, h.child_id, h.driver_user_id, h.method, COALESCE(h.authorized_pickup_id, 0), h.received_by, h.occurred_at FROM PickupHandovers AS h INNER JOIN Children AS c ON c.id = h.child_id WHERE h.driver_user_id = @user_id OR c.observer_user_id = @user_id ORDER BY h.occurred_at, h.id"
+	// statementGetChildHeirs obtains, for each child of a user, the secondary guardians still delegated who can
+	// become its primary guardian, the longest-standing first.
+	statementGetChildHeirs = "SELECT c.id, g.observer_user_id FROM Children AS c INNER JOIN ChildGuardians AS g ON g.child_id = c.id WHERE c.observer_user_id = @user_id AND g.observer_user_id <> @user_id AND g.role = 'secondary' AND (g.ends_at = 0 OR g.ends_at > @now) ORDER BY c.id, g.created_at, g.observer_user_id"
+	statementHandOverChild = "UPDATE Children SET observer_user_id = @heir_user_id, updated_at = CURRENT_TIMESTAMP WHERE id = @child_id"
+	statementDeleteHeir    = "DELETE FROM ChildGuardians WHERE child_id = @child_id AND observer_user_id = @heir_user_id"
+	// statementCopyHeirLinks links the heir to the drivers of the user, so the child stays on its bus.
+	statementCopyHeirLinks = "INSERT IGNORE INTO ObservedUsersObserverUsers (observed_user_id, observer_user_id) SELECT observed_user_id, @heir_user_id FROM ObservedUsersObserverUsers WHERE observer_user_id = @user_id"
+	statementAnonymizeUser = "UPDATE Users SET name = '', last_name = '', id_number = '', id_number_index = NULL, username = CONCAT('deleted-', id), password = '', email = CONCAT('deleted-', id, '@deleted.invalid'), enabled = FALSE, email_verified = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = @user_id"
+)
+
+// eraseStatements delete every row holding personal data of a user, children before parents so the foreign keys hold.
+// The Users row itself is anonymized instead, so the audit log and the links to it keep pointing somewhere. The
+// children handed over to another guardian are no longer the user's by then, so they are left alone.
+var eraseStatements = []string{
+	"DELETE FROM ObservedUsersObserverUsers WHERE observer_user_id = @user_id OR observed_user_id = @user_id",
+	"DELETE FROM GuardianInvitations WHERE invited_by = @user_id",
+	"DELETE FROM ChildGuardians WHERE observer_user_id = @user_id",
+	"DELETE g FROM ChildGuardians AS g INNER JOIN Children AS c ON c.id = g.child_id WHERE c.observer_user_id = @user_id",
+	"DELETE FROM PickupHandovers WHERE driver_user_id = @user_id",
+	"DELETE h FROM PickupHandovers AS h INNER JOIN Children AS c ON c.id = h.child_id WHERE c.observer_user_id = @user_id",
+	"DELETE FROM PickupPINs WHERE created_by = @user_id",
+	"DELETE p FROM PickupPINs AS p INNER JOIN Children AS c ON c.id = p.child_id WHERE c.observer_user_id = @user_id",
+	"DELETE a FROM AuthorizedPickups AS a INNER JOIN Children AS c ON c.id = a.child_id WHERE c.observer_user_id = @user_id",
+	"DELETE FROM Children WHERE observer_user_id = @user_id",
+	"DELETE FROM Addresses WHERE observer_user_id = @user_id",
+	"DELETE FROM NotificationDigestItems WHERE observer_user_id = @user_id",
+	"DELETE FROM NotificationPreferences WHERE observer_user_id = @user_id",
+	"DELETE FROM NotificationSettings WHERE observer_user_id = @user_id",
+	"DELETE FROM ObserverUsers WHERE user_id = @user_id",
+	"DELETE FROM ObservedUsers WHERE user_id = @user_id",
+	"DELETE FROM UserTokens WHERE user_id = @user_id",
+	"DELETE FROM UserRecoveryCodes WHERE user_id = @user_id",
+	"DELETE FROM UserTwoFactor WHERE user_id = @user_id",
+	"DELETE FROM UserRoles WHERE user_id = @user_id",
+	statementAnonymizeUser,
+}
+
+func NewAccountDataRepository(db *gorm.DB, ctx context.Context) gateway.AccountDataRepository {
+	return &AccountDataRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// AccountDataRepository represents the repository for export and erase the personal data of the users.
+type AccountDataRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// GetChildren obtains the children of an observer user.
+func (r AccountDataRepository) GetChildren(userID uint) (_ []model.Children, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AccountDataRepository.GetChildren")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetAccountChildren, sql.Named("user_id", userID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	children := []model.Children{}
+	for rows.Next() {
+		var child model.Children
+
+		err = rows.Scan(&child.ID, &child.Name, &child.LastName, &child.SchoolID, &child.SchoolName, &child.SchoolStartTime,
+			&child.SchoolEndTime, &child.ObserverUserID, &child.CreatedAt, &child.UpdatedAt)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		children = append(children, child)
+	}
+
+	return children, nil
+}
+
+// GetAddresses obtains the addresses of an observer user.
+func (r AccountDataRepository) GetAddresses(userID uint) (_ []model.Address, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AccountDataRepository.GetAddresses")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetAccountAddresses, sql.Named("user_id", userID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	addresses := []model.Address{}
+	for rows.Next() {
+		var (
+			address          model.Address
+			floor, apartment sql.NullString
+		)
+
+		err = rows.Scan(&address.ID, &address.Street, &address.Number, &floor, &apartment, &address.ZipCode,
+			&address.City, &address.State, &address.Country, &address.Latitude, &address.Longitude,
+			&address.ObserverUserID, &address.CreatedAt, &address.UpdatedAt)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		address.Floor = floor.String
+		address.Apartment = apartment.String
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// GetLinks obtains the links between observers and observed users where the user takes part, on either side.
+func (r AccountDataRepository) GetLinks(userID uint) (_ []model.ObservedUserObserverUser, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AccountDataRepository.GetLinks")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetAccountLinks, sql.Named("user_id", userID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []model.ObservedUserObserverUser{}
+	for rows.Next() {
+		var link model.ObservedUserObserverUser
+
+		if err = rows.Scan(&link.ObservedUserID, &link.ObserverUserID, &link.CreatedAt, &link.UpdatedAt); err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		links = append(links, link)
+	}
+
+	return links, nil
+}
+
+// GetGuardianships obtains the guardians of the children of a user, and the children the user is a guardian of.
+func (r AccountDataRepository) GetGuardianships(userID uint) (_ []model.Guardian, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AccountDataRepository.GetGuardianships")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetAccountGuardianships, sql.Named("user_id", userID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guardians := []model.Guardian{}
+	for rows.Next() {
+		var guardian model.Guardian
+
+		err = rows.Scan(&guardian.ChildID, &guardian.ObserverUserID, &guardian.Role, &guardian.StartsAt, &guardian.EndsAt,
+			&guardian.InvitedBy, &guardian.CreatedAt)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		guardians = append(guardians, guardian)
+	}
+
+	return guardians, nil
+}
+
+// GetHandovers obtains the handovers of the children of a user, and the ones recorded by the user as a driver.
+func (r AccountDataRepository) GetHandovers(userID uint) (_ []model.PickupHandover, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AccountDataRepository.GetHandovers")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetAccountHandovers, sql.Named("user_id", userID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	handovers := []model.PickupHandover{}
+	for rows.Next() {
+		var handover model.PickupHandover
+
+		err = rows.Scan(&handover.ID, &handover.ChildID, &handover.DriverUserID, &handover.Method,
+			&handover.AuthorizedPickupID, &handover.ReceivedBy, &handover.OccurredAt)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		handovers = append(handovers, handover)
+	}
+
+	return handovers, nil
+}
+
+// GetDeletion obtains the deletion request of a user. It returns nil when the user never asked for it.
+func (r AccountDataRepository) GetDeletion(userID uint) (_ *model.AccountDeletion, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AccountDataRepository.GetDeletion")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	deletion, err := scanAccountDeletion(r.DB.Raw(statementGetAccountDeletion, sql.Named("user_id", userID)).Row())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return nil, err
+	}
+
+	return deletion, nil
+}
+
+// SaveDeletion persists the deletion request of a user, replacing the previous one.
+func (r AccountDataRepository) SaveDeletion(deletion model.AccountDeletion) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "AccountDataRepository.SaveDeletion")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementSaveAccountDeletion,
+		sql.Named("user_id", deletion.UserID),
+		sql.Named("status", deletion.Status),
+		sql.Named("requested_at", deletion.RequestedAt),
+		sql.Named("scheduled_at", deletion.ScheduledAt),
+		sql.Named("completed_at", deletion.CompletedAt),
+	).Error
+}
+
+// GetDueDeletions obtains the pending deletion requests whose grace period ended at the given unix time.
+func (r AccountDataRepository) GetDueDeletions(now int64) (_ []model.AccountDeletion, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AccountDataRepository.GetDueDeletions")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetDueAccountDeletions, sql.Named("now", now)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deletions []model.AccountDeletion
+	for rows.Next() {
+		deletion, err := scanAccountDeletion(rows)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		deletions = append(deletions, *deletion)
+	}
+
+	return deletions, nil
+}
+
+// Erase deletes the personal data of a user, anonymizes its Users row and marks its deletion request as completed,
+// all in one transaction. The children the user is the primary guardian of are handed over to their longest-standing
+// secondary guardian, along with their pickups and handovers; only the ones nobody else looks after are deleted.
+// Temporary pickups act on behalf of the primary guardian, so their delegations end with it.
+func (r AccountDataRepository) Erase(userID uint, completedAt int64) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "AccountDataRepository.Erase")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		heirs, err := getChildHeirs(tx, userID, completedAt)
+		if err != nil {
+			return err
+		}
+
+		for _, heir := range heirs {
+			args := []interface{}{sql.Named("child_id", heir.childID), sql.Named("heir_user_id", heir.userID), sql.Named("user_id", userID)}
+			for _, statement := range []string{statementHandOverChild, statementDeleteHeir, statementCopyHeirLinks} {
+				if err = tx.Exec(statement, args...).Error; err != nil {
+					return err
+				}
+			}
+
+			logging.FromContext(r.context).WithField("child_id", heir.childID).WithField("heir_user_id", heir.userID).
+				Info("child handed over to another guardian")
+		}
+
+		for _, statement := range eraseStatements {
+			if err := tx.Exec(statement, sql.Named("user_id", userID)).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec(
+			statementCompleteAccountDeletion,
+			sql.Named("completed_at", completedAt),
+			sql.Named("user_id", userID),
+		).Error
+	})
+}
+
+// childHeir is the guardian a child is handed over to when its primary guardian is erased.
+type childHeir struct {
+	childID uint
+	userID  uint
+}
+
+// getChildHeirs returns the guardian each child of the user is handed over to, by ascending child ID.
+func getChildHeirs(tx *gorm.DB, userID uint, now int64) ([]childHeir, error) {
+	rows, err := tx.Raw(statementGetChildHeirs, sql.Named("user_id", userID), sql.Named("now", now)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var heirs []childHeir
+	for rows.Next() {
+		var heir childHeir
+		if err = rows.Scan(&heir.childID, &heir.userID); err != nil {
+			return nil, err
+		}
+
+		if len(heirs) == 0 || heirs[len(heirs)-1].childID != heir.childID {
+			heirs = append(heirs, heir)
+		}
+	}
+
+	return heirs, rows.Err()
+}
+
+func scanAccountDeletion(row interface{ Scan(...interface{}) error }) (*model.AccountDeletion, error) {
+	var deletion model.AccountDeletion
+
+	err := row.Scan(&deletion.UserID, &deletion.Status, &deletion.RequestedAt, &deletion.ScheduledAt, &deletion.CompletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &deletion, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestAccountDataRepository(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	ar := NewAccountDataRepository(gdb, context.Background())
+
+	t.Run("GetAddresses with optional columns", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetAccountAddresses)).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "street", "number", "floor", "apartament", "zipCode", "city", "state", "country", "latitude", "longitude", "observer_user_id", "created_at", "updated_at"}).
+				AddRow(1, "Av. Rivadavia", "1234", nil, "B", "1406", "CABA", "Buenos Aires", "Argentina", "-34.6", "-58.4", 2, "2022-12-10", "2022-12-10"))
+
+		addresses, err := ar.GetAddresses(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Address{{
+			ID: 1, Street: "Av. Rivadavia", Number: "1234", Apartment: "B", ZipCode: "1406", City: "CABA", State: "Buenos Aires",
+			Country: "Argentina", Latitude: "-34.6", Longitude: "-58.4", ObserverUserID: 2, CreatedAt: "2022-12-10", UpdatedAt: "2022-12-10",
+		}}, addresses)
+	})
+
+	t.Run("GetLinks on either side", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetAccountLinks)).WithArgs(2, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"observed_user_id", "observer_user_id", "created_at", "updated_at"}).AddRow(1, 2, "2022-12-10", "2022-12-10"))
+
+		links, err := ar.GetLinks(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.ObservedUserObserverUser{{ObservedUserID: 1, ObserverUserID: 2, CreatedAt: "2022-12-10", UpdatedAt: "2022-12-10"}}, links)
+	})
+
+	t.Run("GetDeletion not requested", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetAccountDeletion)).WithArgs(2).WillReturnError(sql.ErrNoRows)
+
+		deletion, err := ar.GetDeletion(2)
+		assert.NoError(t, err)
+		assert.Nil(t, deletion)
+	})
+
+	t.Run("GetDueDeletions", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetDueAccountDeletions)).WithArgs(1000).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "status", "requested_at", "scheduled_at", "completed_at"}).AddRow(2, "pending", 100, 900, 0))
+
+		deletions, err := ar.GetDueDeletions(1000)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.AccountDeletion{{UserID: 2, Status: model.AccountDeletionPending, RequestedAt: 100, ScheduledAt: 900}}, deletions)
+	})
+
+	t.Run("GetGuardianships", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetAccountGuardianships)).WithArgs(2, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"child_id", "observer_user_id", "role", "starts_at", "ends_at", "invited_by", "created_at"}).
+				AddRow(1, 4, "secondary", 0, 0, 2, "2023-07-03"))
+
+		guardians, err := ar.GetGuardianships(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Guardian{{ChildID: 1, ObserverUserID: 4, Role: model.GuardianRoleSecondary, InvitedBy: 2, CreatedAt: "2023-07-03"}}, guardians)
+	})
+
+	t.Run("Erase cascades and anonymizes in one transaction", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(positional(statementGetChildHeirs)).WithArgs(2, 2, 1000).WillReturnRows(sqlmock.NewRows([]string{"id", "observer_user_id"}))
+		mock.ExpectExec(positional(eraseStatements[0])).WithArgs(2, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		for _, statement := range eraseStatements[1 : len(eraseStatements)-1] {
+			mock.ExpectExec(positional(statement)).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		// the anonymized email holds an @ that is not a parameter
+		mock.ExpectExec(regexp.QuoteMeta(strings.Replace(statementAnonymizeUser, "@user_id", "?", 1))).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(positional(statementCompleteAccountDeletion)).WithArgs(1000, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		assert.NoError(t, ar.Erase(2, 1000))
+	})
+
+	t.Run("Erase hands the children over to their secondary guardians", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(positional(statementGetChildHeirs)).WithArgs(2, 2, 1000).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "observer_user_id"}).AddRow(1, 4).AddRow(1, 6).AddRow(3, 6))
+		for _, heir := range [][]driver.Value{{1, 4}, {3, 6}} {
+			mock.ExpectExec(positional(statementHandOverChild)).WithArgs(heir[1], heir[0]).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(positional(statementDeleteHeir)).WithArgs(heir[0], heir[1]).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(positional(statementCopyHeirLinks)).WithArgs(heir[1], 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectExec(positional(eraseStatements[0])).WithArgs(2, 2).WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		assert.Error(t, ar.Erase(2, 1000))
+	})
+
+	t.Run("Erase rolls back on failure", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(positional(statementGetChildHeirs)).WithArgs(2, 2, 1000).WillReturnRows(sqlmock.NewRows([]string{"id", "observer_user_id"}))
+		mock.ExpectExec(positional(eraseStatements[0])).WithArgs(2, 2).WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		assert.Error(t, ar.Erase(2, 1000))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
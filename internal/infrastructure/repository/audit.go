@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	auditColumns             = "id, occurred_at, action, actor_id, actor_username, target_type, target_id, ip, request_id, details, prev_hash, hash"
+	statementGetAuditHead    = "SELECT last_hash FROM AuditLogHead WHERE id = 1"
+	statementLockAuditHead   = "SELECT last_hash FROM AuditLogHead WHERE id = 1 FOR UPDATE"
+	statementInsertAudit     = "INSERT INTO AuditLog (occurred_at, action, actor_id, actor_username, target_type, target_id, ip, request_id, details, prev_hash, hash) VALUES (@occurred_at, @action, @actor_id, @actor_username, @target_type, @target_id, @ip, @request_id, @details, @prev_hash, @hash)"
+	statementUpdateAuditHead = "UPDATE AuditLogHead SET last_hash = @hash WHERE id = 1"
+	statementFindAudit       = "SELECT " + auditColumns + " FROM AuditLog WHERE id > @after_id"
+)
+
+// auditFilters are the conditions applied by Find for each filter of the query.
+var auditFilters = map[string]string{
+	"action":      "action = @action",
+	"actor_id":    "actor_id = @actor_id",
+	"target_type": "target_type = @target_type",
+	"target_id":   "target_id = @target_id",
+	"from":        "occurred_at >= @from",
+	"to":          "occurred_at < @to",
+}
+
+func NewAuditRepository(db *gorm.DB, ctx context.Context) gateway.AuditRepository {
+	return &AuditRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// AuditRepository represents the repository for the append-only audit log.
+type AuditRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// Append chains the entry to the last one and persists it. The head of the chain is locked while appending,
+// so concurrent entries are chained one after the other.
+func (r AuditRepository) Append(entry model.AuditEntry) (_ *model.AuditEntry, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AuditRepository.Append")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	details, err := json.Marshal(entry.Details)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(statementLockAuditHead).Row().Scan(&entry.PrevHash); err != nil {
+			return err
+		}
+
+		entry.Hash = entry.ComputeHash()
+
+		result := tx.Exec(
+			statementInsertAudit,
+			sql.Named("occurred_at", entry.OccurredAt),
+			sql.Named("action", entry.Action),
+			sql.Named("actor_id", entry.ActorID),
+			sql.Named("actor_username", entry.ActorUsername),
+			sql.Named("target_type", entry.TargetType),
+			sql.Named("target_id", entry.TargetID),
+			sql.Named("ip", entry.IP),
+			sql.Named("request_id", entry.RequestID),
+			sql.Named("details", string(details)),
+			sql.Named("prev_hash", entry.PrevHash),
+			sql.Named("hash", entry.Hash),
+		)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		return tx.Exec(statementUpdateAuditHead, sql.Named("hash", entry.Hash)).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// GetHead obtains the hash of the last entry appended, empty when none was.
+func (r AuditRepository) GetHead() (_ string, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AuditRepository.GetHead")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var head string
+	if err := r.DB.Raw(statementGetAuditHead).Row().Scan(&head); err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return "", err
+	}
+
+	return head, nil
+}
+
+// Find obtains the entries matching the query by ascending ID.
+func (r AuditRepository) Find(query model.AuditQuery) (_ []model.AuditEntry, err error) {
+	ctx, span := tracing.StartSpan(r.context, "AuditRepository.Find")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	statement := strings.Builder{}
+	statement.WriteString(statementFindAudit)
+	args := []interface{}{sql.Named("after_id", query.AfterID)}
+
+	filters := []struct {
+		name  string
+		value interface{}
+		set   bool
+	}{
+		{"action", query.Action, query.Action != ""},
+		{"actor_id", query.ActorID, query.ActorID != 0},
+		{"target_type", query.TargetType, query.TargetType != ""},
+		{"target_id", query.TargetID, query.TargetID != ""},
+		{"from", query.From, query.From != 0},
+		{"to", query.To, query.To != 0},
+	}
+
+	for _, filter := range filters {
+		if filter.set {
+			statement.WriteString(" AND " + auditFilters[filter.name])
+			args = append(args, sql.Named(filter.name, filter.value))
+		}
+	}
+
+	statement.WriteString(" ORDER BY id LIMIT @limit")
+	args = append(args, sql.Named("limit", query.Limit))
+
+	rows, err := r.DB.Raw(statement.String(), args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.AuditEntry{}
+	for rows.Next() {
+		var (
+			entry   model.AuditEntry
+			details string
+		)
+
+		err = rows.Scan(&entry.ID, &entry.OccurredAt, &entry.Action, &entry.ActorID, &entry.ActorUsername, &entry.TargetType,
+			&entry.TargetID, &entry.IP, &entry.RequestID, &details, &entry.PrevHash, &entry.Hash)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		if err = json.Unmarshal([]byte(details), &entry.Details); err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error unmarshalling audit details")
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestAuditRepository(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	ar := NewAuditRepository(gdb, context.Background())
+
+	entry := model.AuditEntry{
+		OccurredAt: 1670694570000,
+		Action:     model.AuditActionLogin,
+		ActorID:    2,
+		TargetType: model.AuditTargetUser,
+		TargetID:   "2",
+		IP:         "10.0.0.1",
+		RequestID:  "req-1",
+	}
+
+	t.Run("Append chains the entry to the head", func(t *testing.T) {
+		chained := entry
+		chained.PrevHash = "previous"
+		hash := chained.ComputeHash()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(positional(statementLockAuditHead)).WillReturnRows(sqlmock.NewRows([]string{"last_hash"}).AddRow("previous"))
+		mock.ExpectExec(positional(statementInsertAudit)).
+			WithArgs(entry.OccurredAt, entry.Action, entry.ActorID, "", entry.TargetType, entry.TargetID, entry.IP, entry.RequestID, "null", "previous", hash).
+			WillReturnResult(sqlmock.NewResult(7, 1))
+		mock.ExpectExec(positional(statementUpdateAuditHead)).WithArgs(hash).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		appended, err := ar.Append(entry)
+		assert.NoError(t, err)
+		assert.Equal(t, "previous", appended.PrevHash)
+		assert.Equal(t, hash, appended.Hash)
+	})
+
+	t.Run("GetHead", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetAuditHead)).WillReturnRows(sqlmock.NewRows([]string{"last_hash"}).AddRow("previous"))
+
+		head, err := ar.GetHead()
+		assert.NoError(t, err)
+		assert.Equal(t, "previous", head)
+	})
+
+	t.Run("Find with filters", func(t *testing.T) {
+		statement := statementFindAudit + " AND " + auditFilters["action"] + " AND " + auditFilters["actor_id"] + " AND " + auditFilters["from"] + " ORDER BY id LIMIT @limit"
+		columns := []string{"id", "occurred_at", "action", "actor_id", "actor_username", "target_type", "target_id", "ip", "request_id", "details", "prev_hash", "hash"}
+
+		mock.ExpectQuery(positional(statement)).
+			WithArgs(3, model.AuditActionLoginFailed, 2, 1670694570000, 10).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(4, 1670694570001, model.AuditActionLoginFailed, 2, "jperez", "user", "2", "10.0.0.1", "req-2", `{"reason":"invalid_credentials"}`, "a", "b"))
+
+		entries, err := ar.Find(model.AuditQuery{AfterID: 3, Action: model.AuditActionLoginFailed, ActorID: 2, From: 1670694570000, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, map[string]string{"reason": "invalid_credentials"}, entries[0].Details)
+		assert.Equal(t, "jperez", entries[0].ActorUsername)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
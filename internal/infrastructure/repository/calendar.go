@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	statementGetCompanyCalendar    = "SELECT company_name, date, kind, name, start_time, end_time FROM CompanyCalendarEntries WHERE company_name = @company_name ORDER BY date"
+	statementDeleteCompanyCalendar = "DELETE FROM CompanyCalendarEntries WHERE company_name = @company_name"
+	statementInsertCompanyCalendar = "INSERT INTO CompanyCalendarEntries (company_name, date, kind, name, start_time, end_time) VALUES (@company_name, @date, @kind, @name, @start_time, @end_time)"
+	statementGetFamilySchools      = "SELECT DISTINCT c.school_id FROM Children AS c LEFT JOIN ChildGuardians AS g ON g.child_id = c.id AND g.observer_user_id = @observer_user_id WHERE c.observer_user_id = @observer_user_id OR g.child_id IS NOT NULL ORDER BY c.school_id"
+	statementGetFamilyCompanies    = "SELECT DISTINCT odu.company_name FROM ObservedUsersObserverUsers AS oduoru INNER JOIN ObservedUsers AS odu ON odu.user_id = oduoru.observed_user_id WHERE oduoru.observer_user_id = @observer_user_id ORDER BY odu.company_name"
+)
+
+func NewCalendarRepository(db *gorm.DB, ctx context.Context) gateway.CalendarRepository {
+	return &CalendarRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// CalendarRepository represents the repository for manage the company calendars.
+type CalendarRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// GetCompanyCalendar obtains the calendar entries of a company sorted by date.
+func (r CalendarRepository) GetCompanyCalendar(companyName string) (_ []model.CalendarEntry, err error) {
+	ctx, span := tracing.StartSpan(r.context, "CalendarRepository.GetCompanyCalendar")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetCompanyCalendar, sql.Named("company_name", companyName)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.CalendarEntry{}
+	for rows.Next() {
+		var company string
+
+		entry, err := scanCalendarEntry(r.context, rows, &company)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// SaveCompanyCalendar replaces the calendar entries of a company.
+func (r CalendarRepository) SaveCompanyCalendar(companyName string, entries []model.CalendarEntry) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "CalendarRepository.SaveCompanyCalendar")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		company := sql.Named("company_name", companyName)
+		if err := tx.Exec(statementDeleteCompanyCalendar, company).Error; err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := tx.Exec(statementInsertCompanyCalendar, append(calendarEntryArgs(entry), company)...).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// GetFamilySchools obtains the schools the children an observer user is a guardian of attend.
+func (r CalendarRepository) GetFamilySchools(observerUserID uint) (_ []uint, err error) {
+	ctx, span := tracing.StartSpan(r.context, "CalendarRepository.GetFamilySchools")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var schools []uint
+
+	err = r.DB.Raw(statementGetFamilySchools, sql.Named("observer_user_id", observerUserID)).Scan(&schools).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return schools, nil
+}
+
+// GetFamilyCompanies obtains the companies of the drivers linked to an observer user.
+func (r CalendarRepository) GetFamilyCompanies(observerUserID uint) (_ []string, err error) {
+	ctx, span := tracing.StartSpan(r.context, "CalendarRepository.GetFamilyCompanies")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var companies []string
+
+	err = r.DB.Raw(statementGetFamilyCompanies, sql.Named("observer_user_id", observerUserID)).Scan(&companies).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return companies, nil
+}
+
+// calendarEntryArgs returns the named arguments of a calendar entry. Missing times are stored as NULL.
+func calendarEntryArgs(entry model.CalendarEntry) []interface{} {
+	return []interface{}{
+		sql.Named("date", entry.Date),
+		sql.Named("kind", entry.Kind),
+		sql.Named("name", entry.Name),
+		sql.Named("start_time", sql.NullString{String: entry.Start, Valid: entry.Start != ""}),
+		sql.Named("end_time", sql.NullString{String: entry.End, Valid: entry.End != ""}),
+	}
+}
+
+// scanCalendarEntry scans a row of the owner of the entry, then the date, kind, name, start and end times.
+func scanCalendarEntry(ctx context.Context, rows *sql.Rows, owner interface{}) (model.CalendarEntry, error) {
+	var (
+		entry model.CalendarEntry
+		start sql.NullString
+		end   sql.NullString
+	)
+
+	if err := rows.Scan(owner, &entry.Date, &entry.Kind, &entry.Name, &start, &end); err != nil {
+		logging.FromContext(ctx).WithError(err).Error("error rows scan")
+		return entry, err
+	}
+
+	entry.Start, entry.End = start.String, end.String
+
+	return entry, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var calendarRowColumns = []string{"owner", "date", "kind", "name", "start_time", "end_time"}
+
+func TestCalendarRepository(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	cr := NewCalendarRepository(gdb, context.Background())
+
+	t.Run("GetCompanyCalendar", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetCompanyCalendar)).WithArgs("Transportes Santa Fe").
+			WillReturnRows(sqlmock.NewRows(calendarRowColumns).
+				AddRow("Transportes Santa Fe", "2023-07-10", model.CalendarEntryInService, "Fleet maintenance", nil, nil).
+				AddRow("Transportes Santa Fe", "2023-07-11", model.CalendarEntrySpecialSchedule, "", "10:00:00", "15:00:00"))
+
+		entries, err := cr.GetCompanyCalendar("Transportes Santa Fe")
+		assert.NoError(t, err)
+		assert.Equal(t, []model.CalendarEntry{
+			{Date: "2023-07-10", Kind: model.CalendarEntryInService, Name: "Fleet maintenance"},
+			{Date: "2023-07-11", Kind: model.CalendarEntrySpecialSchedule, Start: "10:00:00", End: "15:00:00"},
+		}, entries)
+	})
+
+	t.Run("SaveCompanyCalendar replaces the entries", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(positional(statementDeleteCompanyCalendar)).WithArgs("Transportes Santa Fe").WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(positional(statementInsertCompanyCalendar)).
+			WithArgs("Transportes Santa Fe", "2023-07-12", model.CalendarEntryHalfDay, "", nil, "12:00").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := cr.SaveCompanyCalendar("Transportes Santa Fe", []model.CalendarEntry{{Date: "2023-07-12", Kind: model.CalendarEntryHalfDay, End: "12:00"}})
+		assert.NoError(t, err)
+	})
+
+	t.Run("GetFamilySchools and GetFamilyCompanies", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetFamilySchools)).WithArgs(2, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"school_id"}).AddRow(1).AddRow(3))
+		mock.ExpectQuery(positional(statementGetFamilyCompanies)).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"company_name"}).AddRow("Transportes Santa Fe"))
+
+		schools, err := cr.GetFamilySchools(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{1, 3}, schools)
+
+		companies, err := cr.GetFamilyCompanies(2)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Transportes Santa Fe"}, companies)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
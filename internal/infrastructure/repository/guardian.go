@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	// statementGetGuardians reads the primary guardian from Children, along with the guardians granted afterwards.
+	statementGetGuardians = "SELECT c.id, u.id, u.name, u.last_name, 'primary', 0, 0, 0, c.created_at FROM Children AS c INNER JOIN Users AS u ON u.id = c.observer_user_id WHERE c.id = @child_id " +
+		"UNION ALL SELECT g.child_id, u.id, u.name, u.last_name, g.role, g.starts_at, g.ends_at, g.invited_by, g.created_at FROM ChildGuardians AS g INNER JOIN Users AS u ON u.id = g.observer_user_id WHERE g.child_id = @child_id"
+	statementSaveGuardian         = "INSERT INTO ChildGuardians (child_id, observer_user_id, role, starts_at, ends_at, invited_by) VALUES (@child_id, @observer_user_id, @role, @starts_at, @ends_at, @invited_by) ON DUPLICATE KEY UPDATE role = VALUES(role), starts_at = VALUES(starts_at), ends_at = VALUES(ends_at)"
+	statementDeleteGuardian       = "DELETE FROM ChildGuardians WHERE child_id = @child_id AND observer_user_id = @observer_user_id"
+	invitationColumns             = "id, child_id, invited_by, email, role, starts_at, ends_at, status, expires_at, created_at"
+	statementGetInvitation        = "SELECT " + invitationColumns + " FROM GuardianInvitations WHERE id = @id"
+	statementGetPendingInvitation = "SELECT " + invitationColumns + " FROM GuardianInvitations WHERE child_id = @child_id AND status = 'pending' AND expires_at > UNIX_TIMESTAMP() ORDER BY created_at"
+	statementSaveInvitation       = "INSERT INTO GuardianInvitations (id, child_id, invited_by, email, role, starts_at, ends_at, status, expires_at) VALUES (@id, @child_id, @invited_by, @email, @role, @starts_at, @ends_at, @status, @expires_at) ON DUPLICATE KEY UPDATE status = VALUES(status)"
+)
+
+func NewGuardianRepository(db *gorm.DB, ctx context.Context) gateway.GuardianRepository {
+	return &GuardianRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// GuardianRepository represents the repository for manage the guardians of the children and their invitations.
+type GuardianRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// GetGuardians obtains every guardian of a child, starting with the primary one. It returns none when the child
+// does not exist.
+func (r GuardianRepository) GetGuardians(childID uint) (_ []model.Guardian, err error) {
+	ctx, span := tracing.StartSpan(r.context, "GuardianRepository.GetGuardians")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetGuardians, sql.Named("child_id", childID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	guardians := []model.Guardian{}
+	for rows.Next() {
+		var guardian model.Guardian
+
+		err = rows.Scan(
+			&guardian.ChildID,
+			&guardian.ObserverUserID,
+			&guardian.Name,
+			&guardian.LastName,
+			&guardian.Role,
+			&guardian.StartsAt,
+			&guardian.EndsAt,
+			&guardian.InvitedBy,
+			&guardian.CreatedAt,
+		)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		guardians = append(guardians, guardian)
+	}
+
+	return guardians, nil
+}
+
+// SaveGuardian grants the guardian role, or replaces the role and delegation of an existing guardian.
+func (r GuardianRepository) SaveGuardian(guardian model.Guardian) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "GuardianRepository.SaveGuardian")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementSaveGuardian,
+		sql.Named("child_id", guardian.ChildID),
+		sql.Named("observer_user_id", guardian.ObserverUserID),
+		sql.Named("role", guardian.Role),
+		sql.Named("starts_at", guardian.StartsAt),
+		sql.Named("ends_at", guardian.EndsAt),
+		sql.Named("invited_by", guardian.InvitedBy),
+	).Error
+}
+
+// DeleteGuardian removes a guardian from a child.
+func (r GuardianRepository) DeleteGuardian(childID uint, observerUserID uint) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "GuardianRepository.DeleteGuardian")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementDeleteGuardian, sql.Named("child_id", childID), sql.Named("observer_user_id", observerUserID)).Error
+}
+
+// GetInvitation obtains an invitation by its ID. It returns nil when the invitation does not exist.
+func (r GuardianRepository) GetInvitation(id string) (_ *model.GuardianInvitation, err error) {
+	ctx, span := tracing.StartSpan(r.context, "GuardianRepository.GetInvitation")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetInvitation, sql.Named("id", id)).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	invitations, err := scanInvitations(r.context, rows)
+	if err != nil || len(invitations) == 0 {
+		return nil, err
+	}
+
+	return &invitations[0], nil
+}
+
+// GetPendingInvitations obtains the invitations of a child that were neither answered nor expired.
+func (r GuardianRepository) GetPendingInvitations(childID uint) (_ []model.GuardianInvitation, err error) {
+	ctx, span := tracing.StartSpan(r.context, "GuardianRepository.GetPendingInvitations")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetPendingInvitation, sql.Named("child_id", childID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	return scanInvitations(r.context, rows)
+}
+
+// SaveInvitation inserts an invitation, or updates the status of an existing one.
+func (r GuardianRepository) SaveInvitation(invitation model.GuardianInvitation) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "GuardianRepository.SaveInvitation")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementSaveInvitation,
+		sql.Named("id", invitation.ID),
+		sql.Named("child_id", invitation.ChildID),
+		sql.Named("invited_by", invitation.InvitedBy),
+		sql.Named("email", invitation.Email),
+		sql.Named("role", invitation.Role),
+		sql.Named("starts_at", invitation.StartsAt),
+		sql.Named("ends_at", invitation.EndsAt),
+		sql.Named("status", invitation.Status),
+		sql.Named("expires_at", invitation.ExpiresAt),
+	).Error
+}
+
+func scanInvitations(ctx context.Context, rows *sql.Rows) ([]model.GuardianInvitation, error) {
+	defer rows.Close()
+
+	invitations := []model.GuardianInvitation{}
+	for rows.Next() {
+		var invitation model.GuardianInvitation
+
+		err := rows.Scan(
+			&invitation.ID,
+			&invitation.ChildID,
+			&invitation.InvitedBy,
+			&invitation.Email,
+			&invitation.Role,
+			&invitation.StartsAt,
+			&invitation.EndsAt,
+			&invitation.Status,
+			&invitation.ExpiresAt,
+			&invitation.CreatedAt,
+		)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, nil
+}
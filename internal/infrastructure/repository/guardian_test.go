@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var invitationRowColumns = []string{"id", "child_id", "invited_by", "email", "role", "starts_at", "ends_at", "status", "expires_at", "created_at"}
+
+func TestGuardianRepository(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	gr := NewGuardianRepository(gdb, context.Background())
+	invitation := model.GuardianInvitation{
+		ID: "4f1c2a", ChildID: 1, InvitedBy: 2, Email: "mgomez@mail.com", Role: model.GuardianRoleTemporaryPickup,
+		EndsAt: 1689000000, Status: model.GuardianInvitationPending, ExpiresAt: 1688990000, CreatedAt: "2023-07-03",
+	}
+
+	t.Run("GetGuardians with the primary one first", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetGuardians)).WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"child_id", "observer_user_id", "name", "last_name", "role", "starts_at", "ends_at", "invited_by", "created_at"}).
+				AddRow(1, 2, "Juan", "Pérez", model.GuardianRolePrimary, 0, 0, 0, "2023-01-10").
+				AddRow(1, 5, "María", "Gómez", model.GuardianRoleTemporaryPickup, 0, 1689000000, 2, "2023-07-03"))
+
+		guardians, err := gr.GetGuardians(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.Guardian{
+			{ChildID: 1, ObserverUserID: 2, Name: "Juan", LastName: "Pérez", Role: model.GuardianRolePrimary, CreatedAt: "2023-01-10"},
+			{ChildID: 1, ObserverUserID: 5, Name: "María", LastName: "Gómez", Role: model.GuardianRoleTemporaryPickup, EndsAt: 1689000000, InvitedBy: 2, CreatedAt: "2023-07-03"},
+		}, guardians)
+	})
+
+	t.Run("SaveGuardian and DeleteGuardian", func(t *testing.T) {
+		mock.ExpectExec(positional(statementSaveGuardian)).WithArgs(1, 5, model.GuardianRoleSecondary, 0, 0, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(positional(statementDeleteGuardian)).WithArgs(1, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, gr.SaveGuardian(model.Guardian{ChildID: 1, ObserverUserID: 5, Role: model.GuardianRoleSecondary, InvitedBy: 2}))
+		assert.NoError(t, gr.DeleteGuardian(1, 5))
+	})
+
+	t.Run("GetInvitation", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetInvitation)).WithArgs("4f1c2a").
+			WillReturnRows(sqlmock.NewRows(invitationRowColumns).
+				AddRow("4f1c2a", 1, 2, "mgomez@mail.com", model.GuardianRoleTemporaryPickup, 0, 1689000000, model.GuardianInvitationPending, 1688990000, "2023-07-03"))
+
+		found, err := gr.GetInvitation("4f1c2a")
+		assert.NoError(t, err)
+		assert.Equal(t, &invitation, found)
+	})
+
+	t.Run("GetInvitation not found", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetInvitation)).WithArgs("missing").WillReturnRows(sqlmock.NewRows(invitationRowColumns))
+
+		found, err := gr.GetInvitation("missing")
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("SaveInvitation", func(t *testing.T) {
+		mock.ExpectExec(positional(statementSaveInvitation)).
+			WithArgs("4f1c2a", 1, 2, "mgomez@mail.com", model.GuardianRoleTemporaryPickup, 0, 1689000000, model.GuardianInvitationPending, 1688990000).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, gr.SaveInvitation(invitation))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
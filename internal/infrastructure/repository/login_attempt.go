@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	statementGetLoginAttempt      = "SELECT scope, attempt_key, failures, last_failure_at, blocked_until FROM LoginAttempts WHERE scope = @scope AND attempt_key = @attempt_key"
+	statementRegisterLoginFailure = "INSERT INTO LoginAttempts (scope, attempt_key, failures, last_failure_at, blocked_until) VALUES (@scope, @attempt_key, 1, @last_failure_at, 0) ON DUPLICATE KEY UPDATE failures = IF(last_failure_at < @window_start, 1, failures + 1), last_failure_at = VALUES(last_failure_at)"
+	statementBlockLoginAttempt    = "UPDATE LoginAttempts SET blocked_until = GREATEST(blocked_until, @blocked_until) WHERE scope = @scope AND attempt_key = @attempt_key"
+	statementDeleteLoginAttempt   = "DELETE FROM LoginAttempts WHERE scope = @scope AND attempt_key = @attempt_key"
+)
+
+func NewLoginAttemptRepository(db *gorm.DB, ctx context.Context) gateway.LoginAttemptRepository {
+	return &LoginAttemptRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// LoginAttemptRepository represents the repository for manage the failed login counters.
+type LoginAttemptRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// Get obtains the failed login counter of a username or IP. An empty counter is returned when there is none.
+func (r LoginAttemptRepository) Get(scope string, key string) (_ *model.LoginAttempt, err error) {
+	ctx, span := tracing.StartSpan(r.context, "LoginAttemptRepository.Get")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	attempt := model.LoginAttempt{Scope: scope, Key: key}
+
+	err = r.DB.
+		Raw(statementGetLoginAttempt, sql.Named("scope", scope), sql.Named("attempt_key", key)).
+		Row().
+		Scan(&attempt.Scope, &attempt.Key, &attempt.Failures, &attempt.LastFailureAt, &attempt.BlockedUntil)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// RegisterFailure counts a failure at the given time and returns the updated counter. The database increments the
+// counter, so the failures made at once on several replicas are all counted. The counter starts over when the
+// previous failure is older than window.
+func (r LoginAttemptRepository) RegisterFailure(scope string, key string, at time.Time, window time.Duration) (_ *model.LoginAttempt, err error) {
+	ctx, span := tracing.StartSpan(r.context, "LoginAttemptRepository.RegisterFailure")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	attempt := model.LoginAttempt{Scope: scope, Key: key}
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			statementRegisterLoginFailure,
+			sql.Named("scope", scope),
+			sql.Named("attempt_key", key),
+			sql.Named("last_failure_at", at.Unix()),
+			sql.Named("window_start", at.Add(-window).Unix()),
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.
+			Raw(statementGetLoginAttempt, sql.Named("scope", scope), sql.Named("attempt_key", key)).
+			Row().
+			Scan(&attempt.Scope, &attempt.Key, &attempt.Failures, &attempt.LastFailureAt, &attempt.BlockedUntil)
+	})
+
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error registering login failure")
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+// Block keeps the username, IP, user or child from trying again until the given unix time, unless it is already
+// blocked for longer.
+func (r LoginAttemptRepository) Block(scope string, key string, until int64) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "LoginAttemptRepository.Block")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementBlockLoginAttempt,
+		sql.Named("blocked_until", until),
+		sql.Named("scope", scope),
+		sql.Named("attempt_key", key),
+	).Error
+}
+
+// Delete resets the failed login counter.
+func (r LoginAttemptRepository) Delete(scope string, key string) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "LoginAttemptRepository.Delete")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementDeleteLoginAttempt, sql.Named("scope", scope), sql.Named("attempt_key", key)).Error
+}
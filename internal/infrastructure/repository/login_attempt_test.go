@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestRegisterFailure(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	lr := NewLoginAttemptRepository(gdb, context.Background())
+	now := time.Date(2022, 12, 10, 15, 0, 0, 0, time.UTC)
+
+	t.Run("RegisterFailure successful", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(positional(statementRegisterLoginFailure)).
+			WithArgs(model.LoginAttemptScopeUsername, "jperez", now.Unix(), now.Add(-15*time.Minute).Unix()).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(positional(statementGetLoginAttempt)).WithArgs(model.LoginAttemptScopeUsername, "jperez").
+			WillReturnRows(sqlmock.NewRows([]string{"scope", "attempt_key", "failures", "last_failure_at", "blocked_until"}).
+				AddRow(model.LoginAttemptScopeUsername, "jperez", 4, now.Unix(), 0))
+		mock.ExpectCommit()
+
+		attempt, err := lr.RegisterFailure(model.LoginAttemptScopeUsername, "jperez", now, 15*time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, attempt.Failures)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("RegisterFailure error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(positional(statementRegisterLoginFailure)).WillReturnError(web.ErrInternalServerError)
+		mock.ExpectRollback()
+
+		attempt, err := lr.RegisterFailure(model.LoginAttemptScopeUsername, "jperez", now, 15*time.Minute)
+		assert.Nil(t, attempt)
+		assert.Error(t, err)
+	})
+}
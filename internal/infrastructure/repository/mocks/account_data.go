@@ -0,0 +1,168 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: account_data_repository.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	reflect "reflect"
+
+	model "github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAccountDataRepository is a mock of AccountDataRepository interface.
+type MockAccountDataRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountDataRepositoryMockRecorder
+}
+
+// MockAccountDataRepositoryMockRecorder is the mock recorder for MockAccountDataRepository.
+type MockAccountDataRepositoryMockRecorder struct {
+	mock *MockAccountDataRepository
+}
+
+// NewMockAccountDataRepository creates a new mock instance.
+func NewMockAccountDataRepository(ctrl *gomock.Controller) *MockAccountDataRepository {
+	mock := &MockAccountDataRepository{ctrl: ctrl}
+	mock.recorder = &MockAccountDataRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountDataRepository) EXPECT() *MockAccountDataRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Erase mocks base method.
+func (m *MockAccountDataRepository) Erase(arg0 uint, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Erase", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Erase indicates an expected call of Erase.
+func (mr *MockAccountDataRepositoryMockRecorder) Erase(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Erase", reflect.TypeOf((*MockAccountDataRepository)(nil).Erase), arg0, arg1)
+}
+
+// GetAddresses mocks base method.
+func (m *MockAccountDataRepository) GetAddresses(arg0 uint) ([]model.Address, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAddresses", arg0)
+	ret0, _ := ret[0].([]model.Address)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAddresses indicates an expected call of GetAddresses.
+func (mr *MockAccountDataRepositoryMockRecorder) GetAddresses(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAddresses", reflect.TypeOf((*MockAccountDataRepository)(nil).GetAddresses), arg0)
+}
+
+// GetChildren mocks base method.
+func (m *MockAccountDataRepository) GetChildren(arg0 uint) ([]model.Children, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChildren", arg0)
+	ret0, _ := ret[0].([]model.Children)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChildren indicates an expected call of GetChildren.
+func (mr *MockAccountDataRepositoryMockRecorder) GetChildren(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChildren", reflect.TypeOf((*MockAccountDataRepository)(nil).GetChildren), arg0)
+}
+
+// GetDeletion mocks base method.
+func (m *MockAccountDataRepository) GetDeletion(arg0 uint) (*model.AccountDeletion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDeletion", arg0)
+	ret0, _ := ret[0].(*model.AccountDeletion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDeletion indicates an expected call of GetDeletion.
+func (mr *MockAccountDataRepositoryMockRecorder) GetDeletion(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDeletion", reflect.TypeOf((*MockAccountDataRepository)(nil).GetDeletion), arg0)
+}
+
+// GetDueDeletions mocks base method.
+func (m *MockAccountDataRepository) GetDueDeletions(arg0 int64) ([]model.AccountDeletion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueDeletions", arg0)
+	ret0, _ := ret[0].([]model.AccountDeletion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueDeletions indicates an expected call of GetDueDeletions.
+func (mr *MockAccountDataRepositoryMockRecorder) GetDueDeletions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueDeletions", reflect.TypeOf((*MockAccountDataRepository)(nil).GetDueDeletions), arg0)
+}
+
+// GetGuardianships mocks base method.
+func (m *MockAccountDataRepository) GetGuardianships(arg0 uint) ([]model.Guardian, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGuardianships", arg0)
+	ret0, _ := ret[0].([]model.Guardian)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGuardianships indicates an expected call of GetGuardianships.
+func (mr *MockAccountDataRepositoryMockRecorder) GetGuardianships(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGuardianships", reflect.TypeOf((*MockAccountDataRepository)(nil).GetGuardianships), arg0)
+}
+
+// GetHandovers mocks base method.
+func (m *MockAccountDataRepository) GetHandovers(arg0 uint) ([]model.PickupHandover, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHandovers", arg0)
+	ret0, _ := ret[0].([]model.PickupHandover)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHandovers indicates an expected call of GetHandovers.
+func (mr *MockAccountDataRepositoryMockRecorder) GetHandovers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHandovers", reflect.TypeOf((*MockAccountDataRepository)(nil).GetHandovers), arg0)
+}
+
+// GetLinks mocks base method.
+func (m *MockAccountDataRepository) GetLinks(arg0 uint) ([]model.ObservedUserObserverUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLinks", arg0)
+	ret0, _ := ret[0].([]model.ObservedUserObserverUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLinks indicates an expected call of GetLinks.
+func (mr *MockAccountDataRepositoryMockRecorder) GetLinks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLinks", reflect.TypeOf((*MockAccountDataRepository)(nil).GetLinks), arg0)
+}
+
+// SaveDeletion mocks base method.
+func (m *MockAccountDataRepository) SaveDeletion(arg0 model.AccountDeletion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDeletion", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDeletion indicates an expected call of SaveDeletion.
+func (mr *MockAccountDataRepositoryMockRecorder) SaveDeletion(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDeletion", reflect.TypeOf((*MockAccountDataRepository)(nil).SaveDeletion), arg0)
+}
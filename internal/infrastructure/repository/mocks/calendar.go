@@ -0,0 +1,148 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: calendar.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	io "io"
+	reflect "reflect"
+
+	model "github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCalendarRepository is a mock of CalendarRepository interface.
+type MockCalendarRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCalendarRepositoryMockRecorder
+}
+
+// MockCalendarRepositoryMockRecorder is the mock recorder for MockCalendarRepository.
+type MockCalendarRepositoryMockRecorder struct {
+	mock *MockCalendarRepository
+}
+
+// NewMockCalendarRepository creates a new mock instance.
+func NewMockCalendarRepository(ctrl *gomock.Controller) *MockCalendarRepository {
+	mock := &MockCalendarRepository{ctrl: ctrl}
+	mock.recorder = &MockCalendarRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCalendarRepository) EXPECT() *MockCalendarRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetCompanyCalendar mocks base method.
+func (m *MockCalendarRepository) GetCompanyCalendar(arg0 string) ([]model.CalendarEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCompanyCalendar", arg0)
+	ret0, _ := ret[0].([]model.CalendarEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCompanyCalendar indicates an expected call of GetCompanyCalendar.
+func (mr *MockCalendarRepositoryMockRecorder) GetCompanyCalendar(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCompanyCalendar", reflect.TypeOf((*MockCalendarRepository)(nil).GetCompanyCalendar), arg0)
+}
+
+// GetFamilyCompanies mocks base method.
+func (m *MockCalendarRepository) GetFamilyCompanies(arg0 uint) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFamilyCompanies", arg0)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFamilyCompanies indicates an expected call of GetFamilyCompanies.
+func (mr *MockCalendarRepositoryMockRecorder) GetFamilyCompanies(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFamilyCompanies", reflect.TypeOf((*MockCalendarRepository)(nil).GetFamilyCompanies), arg0)
+}
+
+// GetFamilySchools mocks base method.
+func (m *MockCalendarRepository) GetFamilySchools(arg0 uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFamilySchools", arg0)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFamilySchools indicates an expected call of GetFamilySchools.
+func (mr *MockCalendarRepositoryMockRecorder) GetFamilySchools(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFamilySchools", reflect.TypeOf((*MockCalendarRepository)(nil).GetFamilySchools), arg0)
+}
+
+// SaveCompanyCalendar mocks base method.
+func (m *MockCalendarRepository) SaveCompanyCalendar(arg0 string, arg1 []model.CalendarEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCompanyCalendar", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCompanyCalendar indicates an expected call of SaveCompanyCalendar.
+func (mr *MockCalendarRepositoryMockRecorder) SaveCompanyCalendar(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCompanyCalendar", reflect.TypeOf((*MockCalendarRepository)(nil).SaveCompanyCalendar), arg0, arg1)
+}
+
+// MockCalendarCodec is a mock of CalendarCodec interface.
+type MockCalendarCodec struct {
+	ctrl     *gomock.Controller
+	recorder *MockCalendarCodecMockRecorder
+}
+
+// MockCalendarCodecMockRecorder is the mock recorder for MockCalendarCodec.
+type MockCalendarCodecMockRecorder struct {
+	mock *MockCalendarCodec
+}
+
+// NewMockCalendarCodec creates a new mock instance.
+func NewMockCalendarCodec(ctrl *gomock.Controller) *MockCalendarCodec {
+	mock := &MockCalendarCodec{ctrl: ctrl}
+	mock.recorder = &MockCalendarCodecMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCalendarCodec) EXPECT() *MockCalendarCodecMockRecorder {
+	return m.recorder
+}
+
+// Decode mocks base method.
+func (m *MockCalendarCodec) Decode(arg0 io.Reader, arg1 string) ([]model.CalendarEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decode", arg0, arg1)
+	ret0, _ := ret[0].([]model.CalendarEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decode indicates an expected call of Decode.
+func (mr *MockCalendarCodecMockRecorder) Decode(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decode", reflect.TypeOf((*MockCalendarCodec)(nil).Decode), arg0, arg1)
+}
+
+// Encode mocks base method.
+func (m *MockCalendarCodec) Encode(arg0 model.Calendar) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Encode", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Encode indicates an expected call of Encode.
+func (mr *MockCalendarCodecMockRecorder) Encode(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Encode", reflect.TypeOf((*MockCalendarCodec)(nil).Encode), arg0)
+}
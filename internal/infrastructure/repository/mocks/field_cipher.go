@@ -0,0 +1,159 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: field_cipher.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockKeyProvider is a mock of KeyProvider interface.
+type MockKeyProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyProviderMockRecorder
+}
+
+// MockKeyProviderMockRecorder is the mock recorder for MockKeyProvider.
+type MockKeyProviderMockRecorder struct {
+	mock *MockKeyProvider
+}
+
+// NewMockKeyProvider creates a new mock instance.
+func NewMockKeyProvider(ctrl *gomock.Controller) *MockKeyProvider {
+	mock := &MockKeyProvider{ctrl: ctrl}
+	mock.recorder = &MockKeyProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyProvider) EXPECT() *MockKeyProviderMockRecorder {
+	return m.recorder
+}
+
+// CurrentKey mocks base method.
+func (m *MockKeyProvider) CurrentKey() (string, []byte) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CurrentKey")
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].([]byte)
+	return ret0, ret1
+}
+
+// CurrentKey indicates an expected call of CurrentKey.
+func (mr *MockKeyProviderMockRecorder) CurrentKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CurrentKey", reflect.TypeOf((*MockKeyProvider)(nil).CurrentKey))
+}
+
+// IndexKey mocks base method.
+func (m *MockKeyProvider) IndexKey() []byte {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IndexKey")
+	ret0, _ := ret[0].([]byte)
+	return ret0
+}
+
+// IndexKey indicates an expected call of IndexKey.
+func (mr *MockKeyProviderMockRecorder) IndexKey() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IndexKey", reflect.TypeOf((*MockKeyProvider)(nil).IndexKey))
+}
+
+// Key mocks base method.
+func (m *MockKeyProvider) Key(arg0 string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Key", arg0)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Key indicates an expected call of Key.
+func (mr *MockKeyProviderMockRecorder) Key(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Key", reflect.TypeOf((*MockKeyProvider)(nil).Key), arg0)
+}
+
+// MockFieldCipher is a mock of FieldCipher interface.
+type MockFieldCipher struct {
+	ctrl     *gomock.Controller
+	recorder *MockFieldCipherMockRecorder
+}
+
+// MockFieldCipherMockRecorder is the mock recorder for MockFieldCipher.
+type MockFieldCipherMockRecorder struct {
+	mock *MockFieldCipher
+}
+
+// NewMockFieldCipher creates a new mock instance.
+func NewMockFieldCipher(ctrl *gomock.Controller) *MockFieldCipher {
+	mock := &MockFieldCipher{ctrl: ctrl}
+	mock.recorder = &MockFieldCipherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFieldCipher) EXPECT() *MockFieldCipherMockRecorder {
+	return m.recorder
+}
+
+// BlindIndex mocks base method.
+func (m *MockFieldCipher) BlindIndex(arg0 string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlindIndex", arg0)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// BlindIndex indicates an expected call of BlindIndex.
+func (mr *MockFieldCipherMockRecorder) BlindIndex(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlindIndex", reflect.TypeOf((*MockFieldCipher)(nil).BlindIndex), arg0)
+}
+
+// Decrypt mocks base method.
+func (m *MockFieldCipher) Decrypt(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decrypt", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decrypt indicates an expected call of Decrypt.
+func (mr *MockFieldCipherMockRecorder) Decrypt(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decrypt", reflect.TypeOf((*MockFieldCipher)(nil).Decrypt), arg0)
+}
+
+// Encrypt mocks base method.
+func (m *MockFieldCipher) Encrypt(arg0 string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Encrypt", arg0)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Encrypt indicates an expected call of Encrypt.
+func (mr *MockFieldCipherMockRecorder) Encrypt(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Encrypt", reflect.TypeOf((*MockFieldCipher)(nil).Encrypt), arg0)
+}
+
+// NeedsRotation mocks base method.
+func (m *MockFieldCipher) NeedsRotation(arg0 string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NeedsRotation", arg0)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// NeedsRotation indicates an expected call of NeedsRotation.
+func (mr *MockFieldCipherMockRecorder) NeedsRotation(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NeedsRotation", reflect.TypeOf((*MockFieldCipher)(nil).NeedsRotation), arg0)
+}
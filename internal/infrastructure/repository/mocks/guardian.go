@@ -0,0 +1,122 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: guardian_repository.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	reflect "reflect"
+
+	model "github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockGuardianRepository is a mock of GuardianRepository interface.
+type MockGuardianRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockGuardianRepositoryMockRecorder
+}
+
+// MockGuardianRepositoryMockRecorder is the mock recorder for MockGuardianRepository.
+type MockGuardianRepositoryMockRecorder struct {
+	mock *MockGuardianRepository
+}
+
+// NewMockGuardianRepository creates a new mock instance.
+func NewMockGuardianRepository(ctrl *gomock.Controller) *MockGuardianRepository {
+	mock := &MockGuardianRepository{ctrl: ctrl}
+	mock.recorder = &MockGuardianRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGuardianRepository) EXPECT() *MockGuardianRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteGuardian mocks base method.
+func (m *MockGuardianRepository) DeleteGuardian(arg0, arg1 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteGuardian", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteGuardian indicates an expected call of DeleteGuardian.
+func (mr *MockGuardianRepositoryMockRecorder) DeleteGuardian(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteGuardian", reflect.TypeOf((*MockGuardianRepository)(nil).DeleteGuardian), arg0, arg1)
+}
+
+// GetGuardians mocks base method.
+func (m *MockGuardianRepository) GetGuardians(arg0 uint) ([]model.Guardian, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGuardians", arg0)
+	ret0, _ := ret[0].([]model.Guardian)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGuardians indicates an expected call of GetGuardians.
+func (mr *MockGuardianRepositoryMockRecorder) GetGuardians(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGuardians", reflect.TypeOf((*MockGuardianRepository)(nil).GetGuardians), arg0)
+}
+
+// GetInvitation mocks base method.
+func (m *MockGuardianRepository) GetInvitation(arg0 string) (*model.GuardianInvitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvitation", arg0)
+	ret0, _ := ret[0].(*model.GuardianInvitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvitation indicates an expected call of GetInvitation.
+func (mr *MockGuardianRepositoryMockRecorder) GetInvitation(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvitation", reflect.TypeOf((*MockGuardianRepository)(nil).GetInvitation), arg0)
+}
+
+// GetPendingInvitations mocks base method.
+func (m *MockGuardianRepository) GetPendingInvitations(arg0 uint) ([]model.GuardianInvitation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingInvitations", arg0)
+	ret0, _ := ret[0].([]model.GuardianInvitation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingInvitations indicates an expected call of GetPendingInvitations.
+func (mr *MockGuardianRepositoryMockRecorder) GetPendingInvitations(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingInvitations", reflect.TypeOf((*MockGuardianRepository)(nil).GetPendingInvitations), arg0)
+}
+
+// SaveGuardian mocks base method.
+func (m *MockGuardianRepository) SaveGuardian(arg0 model.Guardian) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveGuardian", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveGuardian indicates an expected call of SaveGuardian.
+func (mr *MockGuardianRepositoryMockRecorder) SaveGuardian(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveGuardian", reflect.TypeOf((*MockGuardianRepository)(nil).SaveGuardian), arg0)
+}
+
+// SaveInvitation mocks base method.
+func (m *MockGuardianRepository) SaveInvitation(arg0 model.GuardianInvitation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveInvitation", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveInvitation indicates an expected call of SaveInvitation.
+func (mr *MockGuardianRepositoryMockRecorder) SaveInvitation(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveInvitation", reflect.TypeOf((*MockGuardianRepository)(nil).SaveInvitation), arg0)
+}
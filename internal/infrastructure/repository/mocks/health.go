@@ -0,0 +1,114 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: health.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gateway "github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	model "github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockHealthCheck is a mock of HealthCheck interface.
+type MockHealthCheck struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthCheckMockRecorder
+}
+
+// MockHealthCheckMockRecorder is the mock recorder for MockHealthCheck.
+type MockHealthCheckMockRecorder struct {
+	mock *MockHealthCheck
+}
+
+// NewMockHealthCheck creates a new mock instance.
+func NewMockHealthCheck(ctrl *gomock.Controller) *MockHealthCheck {
+	mock := &MockHealthCheck{ctrl: ctrl}
+	mock.recorder = &MockHealthCheckMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealthCheck) EXPECT() *MockHealthCheckMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockHealthCheck) Check(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockHealthCheckMockRecorder) Check(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockHealthCheck)(nil).Check), arg0)
+}
+
+// Name mocks base method.
+func (m *MockHealthCheck) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockHealthCheckMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockHealthCheck)(nil).Name))
+}
+
+// MockHealthChecker is a mock of HealthChecker interface.
+type MockHealthChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthCheckerMockRecorder
+}
+
+// MockHealthCheckerMockRecorder is the mock recorder for MockHealthChecker.
+type MockHealthCheckerMockRecorder struct {
+	mock *MockHealthChecker
+}
+
+// NewMockHealthChecker creates a new mock instance.
+func NewMockHealthChecker(ctrl *gomock.Controller) *MockHealthChecker {
+	mock := &MockHealthChecker{ctrl: ctrl}
+	mock.recorder = &MockHealthCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealthChecker) EXPECT() *MockHealthCheckerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockHealthChecker) Check(arg0 context.Context) model.HealthReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", arg0)
+	ret0, _ := ret[0].(model.HealthReport)
+	return ret0
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockHealthCheckerMockRecorder) Check(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockHealthChecker)(nil).Check), arg0)
+}
+
+// Register mocks base method.
+func (m *MockHealthChecker) Register(arg0 gateway.HealthCheck) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Register", arg0)
+}
+
+// Register indicates an expected call of Register.
+func (mr *MockHealthCheckerMockRecorder) Register(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockHealthChecker)(nil).Register), arg0)
+}
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: job_runner.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	reflect "reflect"
+
+	gateway "github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	model "github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockJobRunner is a mock of JobRunner interface.
+type MockJobRunner struct {
+	ctrl     *gomock.Controller
+	recorder *MockJobRunnerMockRecorder
+}
+
+// MockJobRunnerMockRecorder is the mock recorder for MockJobRunner.
+type MockJobRunnerMockRecorder struct {
+	mock *MockJobRunner
+}
+
+// NewMockJobRunner creates a new mock instance.
+func NewMockJobRunner(ctrl *gomock.Controller) *MockJobRunner {
+	mock := &MockJobRunner{ctrl: ctrl}
+	mock.recorder = &MockJobRunnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJobRunner) EXPECT() *MockJobRunnerMockRecorder {
+	return m.recorder
+}
+
+// Start mocks base method.
+func (m *MockJobRunner) Start(name string, principal *model.Principal, job func(gateway.ServiceLocator) error) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Start", name, principal, job)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Start indicates an expected call of Start.
+func (mr *MockJobRunnerMockRecorder) Start(name, principal, job interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockJobRunner)(nil).Start), name, principal, job)
+}
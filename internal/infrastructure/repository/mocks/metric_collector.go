@@ -0,0 +1,58 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: metric_collector.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMetricCollector is a mock of MetricCollector interface.
+type MockMetricCollector struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetricCollectorMockRecorder
+}
+
+// MockMetricCollectorMockRecorder is the mock recorder for MockMetricCollector.
+type MockMetricCollectorMockRecorder struct {
+	mock *MockMetricCollector
+}
+
+// NewMockMetricCollector creates a new mock instance.
+func NewMockMetricCollector(ctrl *gomock.Controller) *MockMetricCollector {
+	mock := &MockMetricCollector{ctrl: ctrl}
+	mock.recorder = &MockMetricCollectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetricCollector) EXPECT() *MockMetricCollectorMockRecorder {
+	return m.recorder
+}
+
+// CountLoginFailure mocks base method.
+func (m *MockMetricCollector) CountLoginFailure(reason string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CountLoginFailure", reason)
+}
+
+// CountLoginFailure indicates an expected call of CountLoginFailure.
+func (mr *MockMetricCollectorMockRecorder) CountLoginFailure(reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountLoginFailure", reflect.TypeOf((*MockMetricCollector)(nil).CountLoginFailure), reason)
+}
+
+// CountNotification mocks base method.
+func (m *MockMetricCollector) CountNotification(channel, outcome string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "CountNotification", channel, outcome)
+}
+
+// CountNotification indicates an expected call of CountNotification.
+func (mr *MockMetricCollectorMockRecorder) CountNotification(channel, outcome interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountNotification", reflect.TypeOf((*MockMetricCollector)(nil).CountNotification), channel, outcome)
+}
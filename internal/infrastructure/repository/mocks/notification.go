@@ -0,0 +1,153 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: notification_repository.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	reflect "reflect"
+	time "time"
+
+	model "github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockNotificationRepository is a mock of NotificationRepository interface.
+type MockNotificationRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationRepositoryMockRecorder
+}
+
+// MockNotificationRepositoryMockRecorder is the mock recorder for MockNotificationRepository.
+type MockNotificationRepositoryMockRecorder struct {
+	mock *MockNotificationRepository
+}
+
+// NewMockNotificationRepository creates a new mock instance.
+func NewMockNotificationRepository(ctrl *gomock.Controller) *MockNotificationRepository {
+	mock := &MockNotificationRepository{ctrl: ctrl}
+	mock.recorder = &MockNotificationRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationRepository) EXPECT() *MockNotificationRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimDigestItems mocks base method.
+func (m *MockNotificationRepository) ClaimDigestItems(arg0 uint, arg1 string, arg2 time.Duration) ([]model.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimDigestItems", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]model.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimDigestItems indicates an expected call of ClaimDigestItems.
+func (mr *MockNotificationRepositoryMockRecorder) ClaimDigestItems(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimDigestItems", reflect.TypeOf((*MockNotificationRepository)(nil).ClaimDigestItems), arg0, arg1, arg2)
+}
+
+// DeleteDigestItems mocks base method.
+func (m *MockNotificationRepository) DeleteDigestItems(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDigestItems", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDigestItems indicates an expected call of DeleteDigestItems.
+func (mr *MockNotificationRepositoryMockRecorder) DeleteDigestItems(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDigestItems", reflect.TypeOf((*MockNotificationRepository)(nil).DeleteDigestItems), arg0)
+}
+
+// GetDigestItems mocks base method.
+func (m *MockNotificationRepository) GetDigestItems(arg0 uint) ([]model.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDigestItems", arg0)
+	ret0, _ := ret[0].([]model.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDigestItems indicates an expected call of GetDigestItems.
+func (mr *MockNotificationRepositoryMockRecorder) GetDigestItems(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDigestItems", reflect.TypeOf((*MockNotificationRepository)(nil).GetDigestItems), arg0)
+}
+
+// GetObserversWithDueDigest mocks base method.
+func (m *MockNotificationRepository) GetObserversWithDueDigest() ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetObserversWithDueDigest")
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetObserversWithDueDigest indicates an expected call of GetObserversWithDueDigest.
+func (mr *MockNotificationRepositoryMockRecorder) GetObserversWithDueDigest() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetObserversWithDueDigest", reflect.TypeOf((*MockNotificationRepository)(nil).GetObserversWithDueDigest))
+}
+
+// GetSettings mocks base method.
+func (m *MockNotificationRepository) GetSettings(arg0 uint) (*model.NotificationSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettings", arg0)
+	ret0, _ := ret[0].(*model.NotificationSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettings indicates an expected call of GetSettings.
+func (mr *MockNotificationRepositoryMockRecorder) GetSettings(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettings", reflect.TypeOf((*MockNotificationRepository)(nil).GetSettings), arg0)
+}
+
+// ReleaseDigestItems mocks base method.
+func (m *MockNotificationRepository) ReleaseDigestItems(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseDigestItems", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseDigestItems indicates an expected call of ReleaseDigestItems.
+func (mr *MockNotificationRepositoryMockRecorder) ReleaseDigestItems(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseDigestItems", reflect.TypeOf((*MockNotificationRepository)(nil).ReleaseDigestItems), arg0)
+}
+
+// SaveDigestItem mocks base method.
+func (m *MockNotificationRepository) SaveDigestItem(arg0 model.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDigestItem", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDigestItem indicates an expected call of SaveDigestItem.
+func (mr *MockNotificationRepositoryMockRecorder) SaveDigestItem(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDigestItem", reflect.TypeOf((*MockNotificationRepository)(nil).SaveDigestItem), arg0)
+}
+
+// SaveSettings mocks base method.
+func (m *MockNotificationRepository) SaveSettings(arg0 model.NotificationSettings) (*model.NotificationSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSettings", arg0)
+	ret0, _ := ret[0].(*model.NotificationSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveSettings indicates an expected call of SaveSettings.
+func (mr *MockNotificationRepositoryMockRecorder) SaveSettings(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSettings", reflect.TypeOf((*MockNotificationRepository)(nil).SaveSettings), arg0)
+}
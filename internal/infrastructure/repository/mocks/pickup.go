@@ -0,0 +1,211 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: pickup_repository.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	reflect "reflect"
+
+	model "github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPickupRepository is a mock of PickupRepository interface.
+type MockPickupRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockPickupRepositoryMockRecorder
+}
+
+// MockPickupRepositoryMockRecorder is the mock recorder for MockPickupRepository.
+type MockPickupRepositoryMockRecorder struct {
+	mock *MockPickupRepository
+}
+
+// NewMockPickupRepository creates a new mock instance.
+func NewMockPickupRepository(ctrl *gomock.Controller) *MockPickupRepository {
+	mock := &MockPickupRepository{ctrl: ctrl}
+	mock.recorder = &MockPickupRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPickupRepository) EXPECT() *MockPickupRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeletePickup mocks base method.
+func (m *MockPickupRepository) DeletePickup(arg0, arg1 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePickup", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePickup indicates an expected call of DeletePickup.
+func (mr *MockPickupRepositoryMockRecorder) DeletePickup(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePickup", reflect.TypeOf((*MockPickupRepository)(nil).DeletePickup), arg0, arg1)
+}
+
+// GetEncryptedIDNumbers mocks base method.
+func (m *MockPickupRepository) GetEncryptedIDNumbers(arg0 uint, arg1 int) ([]model.EncryptedValue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEncryptedIDNumbers", arg0, arg1)
+	ret0, _ := ret[0].([]model.EncryptedValue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEncryptedIDNumbers indicates an expected call of GetEncryptedIDNumbers.
+func (mr *MockPickupRepositoryMockRecorder) GetEncryptedIDNumbers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEncryptedIDNumbers", reflect.TypeOf((*MockPickupRepository)(nil).GetEncryptedIDNumbers), arg0, arg1)
+}
+
+// GetHandovers mocks base method.
+func (m *MockPickupRepository) GetHandovers(arg0 uint) ([]model.PickupHandover, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHandovers", arg0)
+	ret0, _ := ret[0].([]model.PickupHandover)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHandovers indicates an expected call of GetHandovers.
+func (mr *MockPickupRepositoryMockRecorder) GetHandovers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHandovers", reflect.TypeOf((*MockPickupRepository)(nil).GetHandovers), arg0)
+}
+
+// GetPIN mocks base method.
+func (m *MockPickupRepository) GetPIN(arg0 uint) (*model.PickupPIN, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPIN", arg0)
+	ret0, _ := ret[0].(*model.PickupPIN)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPIN indicates an expected call of GetPIN.
+func (mr *MockPickupRepositoryMockRecorder) GetPIN(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPIN", reflect.TypeOf((*MockPickupRepository)(nil).GetPIN), arg0)
+}
+
+// GetPickup mocks base method.
+func (m *MockPickupRepository) GetPickup(arg0, arg1 uint) (*model.AuthorizedPickup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPickup", arg0, arg1)
+	ret0, _ := ret[0].(*model.AuthorizedPickup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPickup indicates an expected call of GetPickup.
+func (mr *MockPickupRepositoryMockRecorder) GetPickup(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPickup", reflect.TypeOf((*MockPickupRepository)(nil).GetPickup), arg0, arg1)
+}
+
+// GetPickups mocks base method.
+func (m *MockPickupRepository) GetPickups(arg0 uint) ([]model.AuthorizedPickup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPickups", arg0)
+	ret0, _ := ret[0].([]model.AuthorizedPickup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPickups indicates an expected call of GetPickups.
+func (mr *MockPickupRepositoryMockRecorder) GetPickups(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPickups", reflect.TypeOf((*MockPickupRepository)(nil).GetPickups), arg0)
+}
+
+// IsDriverOf mocks base method.
+func (m *MockPickupRepository) IsDriverOf(arg0, arg1 uint) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDriverOf", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsDriverOf indicates an expected call of IsDriverOf.
+func (mr *MockPickupRepositoryMockRecorder) IsDriverOf(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDriverOf", reflect.TypeOf((*MockPickupRepository)(nil).IsDriverOf), arg0, arg1)
+}
+
+// RedeemPIN mocks base method.
+func (m *MockPickupRepository) RedeemPIN(arg0 uint, arg1 string, arg2 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RedeemPIN", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RedeemPIN indicates an expected call of RedeemPIN.
+func (mr *MockPickupRepositoryMockRecorder) RedeemPIN(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemPIN", reflect.TypeOf((*MockPickupRepository)(nil).RedeemPIN), arg0, arg1, arg2)
+}
+
+// SaveHandover mocks base method.
+func (m *MockPickupRepository) SaveHandover(arg0 model.PickupHandover) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveHandover", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveHandover indicates an expected call of SaveHandover.
+func (mr *MockPickupRepositoryMockRecorder) SaveHandover(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveHandover", reflect.TypeOf((*MockPickupRepository)(nil).SaveHandover), arg0)
+}
+
+// SavePIN mocks base method.
+func (m *MockPickupRepository) SavePIN(arg0 model.PickupPIN) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePIN", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SavePIN indicates an expected call of SavePIN.
+func (mr *MockPickupRepositoryMockRecorder) SavePIN(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePIN", reflect.TypeOf((*MockPickupRepository)(nil).SavePIN), arg0)
+}
+
+// SavePickup mocks base method.
+func (m *MockPickupRepository) SavePickup(arg0 model.AuthorizedPickup) (*model.AuthorizedPickup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePickup", arg0)
+	ret0, _ := ret[0].(*model.AuthorizedPickup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SavePickup indicates an expected call of SavePickup.
+func (mr *MockPickupRepositoryMockRecorder) SavePickup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePickup", reflect.TypeOf((*MockPickupRepository)(nil).SavePickup), arg0)
+}
+
+// UpdateEncryptedIDNumber mocks base method.
+func (m *MockPickupRepository) UpdateEncryptedIDNumber(arg0 model.EncryptedValue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEncryptedIDNumber", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEncryptedIDNumber indicates an expected call of UpdateEncryptedIDNumber.
+func (mr *MockPickupRepositoryMockRecorder) UpdateEncryptedIDNumber(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEncryptedIDNumber", reflect.TypeOf((*MockPickupRepository)(nil).UpdateEncryptedIDNumber), arg0)
+}
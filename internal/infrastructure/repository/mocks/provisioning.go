@@ -0,0 +1,138 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: provisioning_repository.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	reflect "reflect"
+
+	model "github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockProvisioningRepository is a mock of ProvisioningRepository interface.
+type MockProvisioningRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockProvisioningRepositoryMockRecorder
+}
+
+// MockProvisioningRepositoryMockRecorder is the mock recorder for MockProvisioningRepository.
+type MockProvisioningRepositoryMockRecorder struct {
+	mock *MockProvisioningRepository
+}
+
+// NewMockProvisioningRepository creates a new mock instance.
+func NewMockProvisioningRepository(ctrl *gomock.Controller) *MockProvisioningRepository {
+	mock := &MockProvisioningRepository{ctrl: ctrl}
+	mock.recorder = &MockProvisioningRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProvisioningRepository) EXPECT() *MockProvisioningRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AssignSchoolBus mocks base method.
+func (m *MockProvisioningRepository) AssignSchoolBus(arg0, arg1 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignSchoolBus", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignSchoolBus indicates an expected call of AssignSchoolBus.
+func (mr *MockProvisioningRepositoryMockRecorder) AssignSchoolBus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignSchoolBus", reflect.TypeOf((*MockProvisioningRepository)(nil).AssignSchoolBus), arg0, arg1)
+}
+
+// CreateDriver mocks base method.
+func (m *MockProvisioningRepository) CreateDriver(arg0 model.ObservedUser) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDriver", arg0)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDriver indicates an expected call of CreateDriver.
+func (mr *MockProvisioningRepositoryMockRecorder) CreateDriver(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDriver", reflect.TypeOf((*MockProvisioningRepository)(nil).CreateDriver), arg0)
+}
+
+// CreateObserver mocks base method.
+func (m *MockProvisioningRepository) CreateObserver(arg0 model.User) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateObserver", arg0)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateObserver indicates an expected call of CreateObserver.
+func (mr *MockProvisioningRepositoryMockRecorder) CreateObserver(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateObserver", reflect.TypeOf((*MockProvisioningRepository)(nil).CreateObserver), arg0)
+}
+
+// FindSchoolBus mocks base method.
+func (m *MockProvisioningRepository) FindSchoolBus(arg0 string) (*model.SchoolBus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindSchoolBus", arg0)
+	ret0, _ := ret[0].(*model.SchoolBus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindSchoolBus indicates an expected call of FindSchoolBus.
+func (mr *MockProvisioningRepositoryMockRecorder) FindSchoolBus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindSchoolBus", reflect.TypeOf((*MockProvisioningRepository)(nil).FindSchoolBus), arg0)
+}
+
+// LinkObserver mocks base method.
+func (m *MockProvisioningRepository) LinkObserver(arg0, arg1 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkObserver", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkObserver indicates an expected call of LinkObserver.
+func (mr *MockProvisioningRepositoryMockRecorder) LinkObserver(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkObserver", reflect.TypeOf((*MockProvisioningRepository)(nil).LinkObserver), arg0, arg1)
+}
+
+// SaveChild mocks base method.
+func (m *MockProvisioningRepository) SaveChild(arg0 model.Children) (*model.Children, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveChild", arg0)
+	ret0, _ := ret[0].(*model.Children)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveChild indicates an expected call of SaveChild.
+func (mr *MockProvisioningRepositoryMockRecorder) SaveChild(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveChild", reflect.TypeOf((*MockProvisioningRepository)(nil).SaveChild), arg0)
+}
+
+// SaveSchoolBus mocks base method.
+func (m *MockProvisioningRepository) SaveSchoolBus(arg0 model.SchoolBus) (*model.SchoolBus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSchoolBus", arg0)
+	ret0, _ := ret[0].(*model.SchoolBus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveSchoolBus indicates an expected call of SaveSchoolBus.
+func (mr *MockProvisioningRepositoryMockRecorder) SaveSchoolBus(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSchoolBus", reflect.TypeOf((*MockProvisioningRepository)(nil).SaveSchoolBus), arg0)
+}
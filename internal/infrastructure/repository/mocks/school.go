@@ -0,0 +1,109 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: school_repository.go
+
+// Package mock_gateway is a generated GoMock package.
+package mock_gateway
+
+import (
+	reflect "reflect"
+
+	model "github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockSchoolRepository is a mock of SchoolRepository interface.
+type MockSchoolRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSchoolRepositoryMockRecorder
+}
+
+// MockSchoolRepositoryMockRecorder is the mock recorder for MockSchoolRepository.
+type MockSchoolRepositoryMockRecorder struct {
+	mock *MockSchoolRepository
+}
+
+// NewMockSchoolRepository creates a new mock instance.
+func NewMockSchoolRepository(ctrl *gomock.Controller) *MockSchoolRepository {
+	mock := &MockSchoolRepository{ctrl: ctrl}
+	mock.recorder = &MockSchoolRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSchoolRepository) EXPECT() *MockSchoolRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetChild mocks base method.
+func (m *MockSchoolRepository) GetChild(arg0 uint) (*model.Children, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChild", arg0)
+	ret0, _ := ret[0].(*model.Children)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChild indicates an expected call of GetChild.
+func (mr *MockSchoolRepositoryMockRecorder) GetChild(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChild", reflect.TypeOf((*MockSchoolRepository)(nil).GetChild), arg0)
+}
+
+// GetSchool mocks base method.
+func (m *MockSchoolRepository) GetSchool(arg0 uint) (*model.School, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchool", arg0)
+	ret0, _ := ret[0].(*model.School)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchool indicates an expected call of GetSchool.
+func (mr *MockSchoolRepositoryMockRecorder) GetSchool(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchool", reflect.TypeOf((*MockSchoolRepository)(nil).GetSchool), arg0)
+}
+
+// GetSchools mocks base method.
+func (m *MockSchoolRepository) GetSchools() ([]model.School, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchools")
+	ret0, _ := ret[0].([]model.School)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSchools indicates an expected call of GetSchools.
+func (mr *MockSchoolRepositoryMockRecorder) GetSchools() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchools", reflect.TypeOf((*MockSchoolRepository)(nil).GetSchools))
+}
+
+// SaveSchool mocks base method.
+func (m *MockSchoolRepository) SaveSchool(arg0 model.School) (*model.School, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveSchool", arg0)
+	ret0, _ := ret[0].(*model.School)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveSchool indicates an expected call of SaveSchool.
+func (mr *MockSchoolRepositoryMockRecorder) SaveSchool(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSchool", reflect.TypeOf((*MockSchoolRepository)(nil).SaveSchool), arg0)
+}
+
+// SetChildSchool mocks base method.
+func (m *MockSchoolRepository) SetChildSchool(arg0, arg1 uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChildSchool", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetChildSchool indicates an expected call of SetChildSchool.
+func (mr *MockSchoolRepositoryMockRecorder) SetChildSchool(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChildSchool", reflect.TypeOf((*MockSchoolRepository)(nil).SetChildSchool), arg0, arg1)
+}
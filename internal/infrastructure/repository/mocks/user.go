@@ -34,6 +34,65 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ConfirmPendingEmail mocks base method.
+func (m *MockUserRepository) ConfirmPendingEmail(arg0 uint, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmPendingEmail", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmPendingEmail indicates an expected call of ConfirmPendingEmail.
+func (mr *MockUserRepositoryMockRecorder) ConfirmPendingEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmPendingEmail", reflect.TypeOf((*MockUserRepository)(nil).ConfirmPendingEmail), arg0, arg1)
+}
+
+// CountUsers mocks base method.
+func (m *MockUserRepository) CountUsers(arg0 model.UserQuery) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsers", arg0)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUsers indicates an expected call of CountUsers.
+func (mr *MockUserRepositoryMockRecorder) CountUsers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsers", reflect.TypeOf((*MockUserRepository)(nil).CountUsers), arg0)
+}
+
+// FindByEmail mocks base method.
+func (m *MockUserRepository) FindByEmail(arg0 string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByEmail", arg0)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByEmail indicates an expected call of FindByEmail.
+func (mr *MockUserRepositoryMockRecorder) FindByEmail(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByEmail", reflect.TypeOf((*MockUserRepository)(nil).FindByEmail), arg0)
+}
+
+// FindByIDNumber mocks base method.
+func (m *MockUserRepository) FindByIDNumber(arg0 string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByIDNumber", arg0)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByIDNumber indicates an expected call of FindByIDNumber.
+func (mr *MockUserRepositoryMockRecorder) FindByIDNumber(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByIDNumber", reflect.TypeOf((*MockUserRepository)(nil).FindByIDNumber), arg0)
+}
+
 // FindByUsername mocks base method.
 func (m *MockUserRepository) FindByUsername(arg0 string) (*model.User, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +123,21 @@ func (mr *MockUserRepositoryMockRecorder) Get(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUserRepository)(nil).Get), arg0)
 }
 
+// GetEncryptedIDNumbers mocks base method.
+func (m *MockUserRepository) GetEncryptedIDNumbers(arg0 uint, arg1 int) ([]model.EncryptedValue, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEncryptedIDNumbers", arg0, arg1)
+	ret0, _ := ret[0].([]model.EncryptedValue)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEncryptedIDNumbers indicates an expected call of GetEncryptedIDNumbers.
+func (mr *MockUserRepositoryMockRecorder) GetEncryptedIDNumbers(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEncryptedIDNumbers", reflect.TypeOf((*MockUserRepository)(nil).GetEncryptedIDNumbers), arg0, arg1)
+}
+
 // GetObservedUser mocks base method.
 func (m *MockUserRepository) GetObservedUser(arg0 *model.ObservedUser) (*model.IUser, error) {
 	m.ctrl.T.Helper()
@@ -95,18 +169,18 @@ func (mr *MockUserRepositoryMockRecorder) GetObserverUser(arg0 interface{}) *gom
 }
 
 // GetUsers mocks base method.
-func (m *MockUserRepository) GetUsers(arg0, arg1 string) (*[]model.User, error) {
+func (m *MockUserRepository) GetUsers(arg0 model.UserQuery) ([]model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", arg0, arg1)
-	ret0, _ := ret[0].(*[]model.User)
+	ret := m.ctrl.Call(m, "GetUsers", arg0)
+	ret0, _ := ret[0].([]model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockUserRepositoryMockRecorder) GetUsers(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) GetUsers(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserRepository)(nil).GetUsers), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockUserRepository)(nil).GetUsers), arg0)
 }
 
 // Save mocks base method.
@@ -123,3 +197,87 @@ func (mr *MockUserRepositoryMockRecorder) Save(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockUserRepository)(nil).Save), arg0)
 }
+
+// SetEmailVerified mocks base method.
+func (m *MockUserRepository) SetEmailVerified(arg0 uint, arg1 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmailVerified", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEmailVerified indicates an expected call of SetEmailVerified.
+func (mr *MockUserRepositoryMockRecorder) SetEmailVerified(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmailVerified", reflect.TypeOf((*MockUserRepository)(nil).SetEmailVerified), arg0, arg1)
+}
+
+// SetEnabled mocks base method.
+func (m *MockUserRepository) SetEnabled(arg0 uint, arg1 bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEnabled", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetEnabled indicates an expected call of SetEnabled.
+func (mr *MockUserRepositoryMockRecorder) SetEnabled(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEnabled", reflect.TypeOf((*MockUserRepository)(nil).SetEnabled), arg0, arg1)
+}
+
+// SetPendingEmail mocks base method.
+func (m *MockUserRepository) SetPendingEmail(arg0 uint, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPendingEmail", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPendingEmail indicates an expected call of SetPendingEmail.
+func (mr *MockUserRepositoryMockRecorder) SetPendingEmail(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPendingEmail", reflect.TypeOf((*MockUserRepository)(nil).SetPendingEmail), arg0, arg1)
+}
+
+// UpdateEncryptedIDNumber mocks base method.
+func (m *MockUserRepository) UpdateEncryptedIDNumber(arg0 model.EncryptedValue) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEncryptedIDNumber", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateEncryptedIDNumber indicates an expected call of UpdateEncryptedIDNumber.
+func (mr *MockUserRepositoryMockRecorder) UpdateEncryptedIDNumber(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEncryptedIDNumber", reflect.TypeOf((*MockUserRepository)(nil).UpdateEncryptedIDNumber), arg0)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockUserRepository) UpdatePassword(arg0 uint, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockUserRepositoryMockRecorder) UpdatePassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockUserRepository)(nil).UpdatePassword), arg0, arg1)
+}
+
+// UpdateProfile mocks base method.
+func (m *MockUserRepository) UpdateProfile(arg0 model.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProfile", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProfile indicates an expected call of UpdateProfile.
+func (mr *MockUserRepositoryMockRecorder) UpdateProfile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockUserRepository)(nil).UpdateProfile), arg0)
+}
@@ -0,0 +1,301 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	statementGetNotificationSettings    = "SELECT observer_user_id, timezone, quiet_hours_enabled, quiet_hours_start, quiet_hours_end, digest_enabled, digest_interval_minutes, created_at, updated_at FROM NotificationSettings WHERE observer_user_id = @observer_user_id"
+	statementGetNotificationPreferences = "SELECT event_type, enabled, channels FROM NotificationPreferences WHERE observer_user_id = @observer_user_id"
+	statementUpsertNotificationSettings = "INSERT INTO NotificationSettings (observer_user_id, timezone, quiet_hours_enabled, quiet_hours_start, quiet_hours_end, digest_enabled, digest_interval_minutes) VALUES (@observer_user_id, @timezone, @quiet_hours_enabled, @quiet_hours_start, @quiet_hours_end, @digest_enabled, @digest_interval_minutes) ON DUPLICATE KEY UPDATE timezone = VALUES(timezone), quiet_hours_enabled = VALUES(quiet_hours_enabled), quiet_hours_start = VALUES(quiet_hours_start), quiet_hours_end = VALUES(quiet_hours_end), digest_enabled = VALUES(digest_enabled), digest_interval_minutes = VALUES(digest_interval_minutes), updated_at = CURRENT_TIMESTAMP"
+	statementDeleteNotificationPrefs    = "DELETE FROM NotificationPreferences WHERE observer_user_id = @observer_user_id"
+	statementInsertNotificationPref     = "INSERT INTO NotificationPreferences (observer_user_id, event_type, enabled, channels) VALUES (@observer_user_id, @event_type, @enabled, @channels)"
+	statementInsertDigestItem           = "INSERT INTO NotificationDigestItems (observer_user_id, event_type, title, body) VALUES (@observer_user_id, @event_type, @title, @body)"
+	statementGetDigestItems             = "SELECT id, observer_user_id, event_type, title, body, created_at FROM NotificationDigestItems WHERE observer_user_id = @observer_user_id ORDER BY id"
+	statementClaimDigestItems           = "UPDATE NotificationDigestItems SET claimed_by = @claimed_by, claimed_until = UNIX_TIMESTAMP() + @ttl WHERE observer_user_id = @observer_user_id AND claimed_until < UNIX_TIMESTAMP()"
+	statementGetClaimedDigestItems      = "SELECT id, observer_user_id, event_type, title, body, created_at FROM NotificationDigestItems WHERE claimed_by = @claimed_by ORDER BY id"
+	statementReleaseDigestItems         = "UPDATE NotificationDigestItems SET claimed_by = NULL, claimed_until = 0 WHERE claimed_by = @claimed_by"
+	statementDeleteDigestItems          = "DELETE FROM NotificationDigestItems WHERE claimed_by = @claimed_by"
+	statementGetDueDigestObservers      = "SELECT d.observer_user_id FROM NotificationDigestItems AS d LEFT JOIN NotificationSettings AS s ON s.observer_user_id = d.observer_user_id GROUP BY d.observer_user_id, s.digest_enabled, s.digest_interval_minutes HAVING COALESCE(s.digest_enabled, FALSE) = FALSE OR MIN(d.created_at) <= NOW() - INTERVAL COALESCE(s.digest_interval_minutes, 60) MINUTE"
+	channelSeparator                    = ","
+	midnight                            = "00:00:00"
+)
+
+func NewNotificationRepository(db *gorm.DB, ctx context.Context) gateway.NotificationRepository {
+	return &NotificationRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// NotificationRepository represents the repository for manage notification preferences and digests.
+type NotificationRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// GetSettings obtains the notification settings of an observer user. Defaults are returned when nothing was saved.
+func (r NotificationRepository) GetSettings(observerUserID uint) (_ *model.NotificationSettings, err error) {
+	ctx, span := tracing.StartSpan(r.context, "NotificationRepository.GetSettings")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	settings := model.NewNotificationSettings(observerUserID)
+
+	err = r.DB.
+		Raw(statementGetNotificationSettings, sql.Named("observer_user_id", observerUserID)).
+		Row().
+		Scan(
+			&settings.ObserverUserID,
+			&settings.Timezone,
+			&settings.QuietHours.Enabled,
+			&settings.QuietHours.Start,
+			&settings.QuietHours.End,
+			&settings.DigestEnabled,
+			&settings.DigestIntervalMinutes,
+			&settings.CreatedAt,
+			&settings.UpdatedAt,
+		)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return nil, err
+	}
+
+	rows, err := r.DB.Raw(statementGetNotificationPreferences, sql.Named("observer_user_id", observerUserID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			preference model.NotificationPreference
+			channels   string
+		)
+
+		if err = rows.Scan(&preference.EventType, &preference.Enabled, &channels); err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		preference.Channels = splitChannels(channels)
+		settings.Preferences = replacePreference(settings.Preferences, preference)
+	}
+
+	return &settings, nil
+}
+
+// SaveSettings persists the notification settings of an observer user, replacing the previous preferences.
+func (r NotificationRepository) SaveSettings(settings model.NotificationSettings) (_ *model.NotificationSettings, err error) {
+	ctx, span := tracing.StartSpan(r.context, "NotificationRepository.SaveSettings")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			statementUpsertNotificationSettings,
+			sql.Named("observer_user_id", settings.ObserverUserID),
+			sql.Named("timezone", settings.Timezone),
+			sql.Named("quiet_hours_enabled", settings.QuietHours.Enabled),
+			sql.Named("quiet_hours_start", clockOrMidnight(settings.QuietHours.Start)),
+			sql.Named("quiet_hours_end", clockOrMidnight(settings.QuietHours.End)),
+			sql.Named("digest_enabled", settings.DigestEnabled),
+			sql.Named("digest_interval_minutes", settings.DigestIntervalMinutes),
+		).Error
+		if err != nil {
+			return err
+		}
+
+		if err = tx.Exec(statementDeleteNotificationPrefs, sql.Named("observer_user_id", settings.ObserverUserID)).Error; err != nil {
+			return err
+		}
+
+		for _, preference := range settings.Preferences {
+			err = tx.Exec(
+				statementInsertNotificationPref,
+				sql.Named("observer_user_id", settings.ObserverUserID),
+				sql.Named("event_type", preference.EventType),
+				sql.Named("enabled", preference.Enabled),
+				sql.Named("channels", strings.Join(preference.Channels, channelSeparator)),
+			).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error saving notification settings")
+		return nil, err
+	}
+
+	return r.GetSettings(settings.ObserverUserID)
+}
+
+// SaveDigestItem queues a notification to be delivered in the next digest of the observer user.
+func (r NotificationRepository) SaveDigestItem(notification model.Notification) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "NotificationRepository.SaveDigestItem")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementInsertDigestItem,
+		sql.Named("observer_user_id", notification.ObserverUserID),
+		sql.Named("event_type", notification.EventType),
+		sql.Named("title", notification.Title),
+		sql.Named("body", notification.Body),
+	).Error
+}
+
+// GetDigestItems obtains the queued notifications of an observer user, oldest first.
+func (r NotificationRepository) GetDigestItems(observerUserID uint) (_ []model.Notification, err error) {
+	ctx, span := tracing.StartSpan(r.context, "NotificationRepository.GetDigestItems")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetDigestItems, sql.Named("observer_user_id", observerUserID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanDigestItems(rows)
+}
+
+// ClaimDigestItems claims the queued notifications of an observer user for ttl, so no other replica
+// delivers them meanwhile, and returns the ones claimed, oldest first. The items claimed by another replica are left
+// out until its claim expires.
+func (r NotificationRepository) ClaimDigestItems(observerUserID uint, claim string, ttl time.Duration) (_ []model.Notification, err error) {
+	ctx, span := tracing.StartSpan(r.context, "NotificationRepository.ClaimDigestItems")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	err = r.DB.Exec(
+		statementClaimDigestItems,
+		sql.Named("observer_user_id", observerUserID),
+		sql.Named("claimed_by", claim),
+		sql.Named("ttl", int64(ttl.Seconds())),
+	).Error
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.DB.Raw(statementGetClaimedDigestItems, sql.Named("claimed_by", claim)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanDigestItems(rows)
+}
+
+// ReleaseDigestItems gives up a claim, so the items are delivered in a later digest.
+func (r NotificationRepository) ReleaseDigestItems(claim string) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "NotificationRepository.ReleaseDigestItems")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementReleaseDigestItems, sql.Named("claimed_by", claim)).Error
+}
+
+// DeleteDigestItems removes the queued notifications held by a claim once delivered.
+func (r NotificationRepository) DeleteDigestItems(claim string) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "NotificationRepository.DeleteDigestItems")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementDeleteDigestItems, sql.Named("claimed_by", claim)).Error
+}
+
+func (r NotificationRepository) scanDigestItems(rows *sql.Rows) ([]model.Notification, error) {
+	var notifications []model.Notification
+
+	for rows.Next() {
+		var notification model.Notification
+
+		err := rows.Scan(
+			&notification.ID,
+			&notification.ObserverUserID,
+			&notification.EventType,
+			&notification.Title,
+			&notification.Body,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// GetObserversWithDueDigest obtains the observer users whose queued notifications must be delivered now.
+func (r NotificationRepository) GetObserversWithDueDigest() (_ []uint, err error) {
+	ctx, span := tracing.StartSpan(r.context, "NotificationRepository.GetObserversWithDueDigest")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var observerUserIDs []uint
+
+	rows, err := r.DB.Raw(statementGetDueDigestObservers).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var observerUserID uint
+		if err = rows.Scan(&observerUserID); err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		observerUserIDs = append(observerUserIDs, observerUserID)
+	}
+
+	return observerUserIDs, nil
+}
+
+func replacePreference(preferences []model.NotificationPreference, preference model.NotificationPreference) []model.NotificationPreference {
+	for i := range preferences {
+		if preferences[i].EventType == preference.EventType {
+			preferences[i] = preference
+			return preferences
+		}
+	}
+
+	return append(preferences, preference)
+}
+
+func splitChannels(channels string) []string {
+	if channels == "" {
+		return []string{}
+	}
+
+	return strings.Split(channels, channelSeparator)
+}
+
+func clockOrMidnight(clock string) string {
+	if clock == "" {
+		return midnight
+	}
+
+	return clock
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestGetNotificationSettings(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	nr := NewNotificationRepository(gdb, context.Background())
+
+	settingsColumns := []string{"observer_user_id", "timezone", "quiet_hours_enabled", "quiet_hours_start", "quiet_hours_end", "digest_enabled", "digest_interval_minutes", "created_at", "updated_at"}
+	preferencesColumns := []string{"event_type", "enabled", "channels"}
+
+	t.Run("GetSettings defaults", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetNotificationSettings)).WithArgs(2).WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(positional(statementGetNotificationPreferences)).WithArgs(2).WillReturnRows(sqlmock.NewRows(preferencesColumns))
+
+		settings, err := nr.GetSettings(2)
+		assert.NoError(t, err)
+		assert.Equal(t, model.NewNotificationSettings(2), *settings)
+	})
+
+	t.Run("GetSettings successful", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetNotificationSettings)).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(settingsColumns).AddRow(2, "UTC", true, "22:00:00", "07:00:00", true, 30, "2022-12-10 17:49:30", "2022-12-10 17:49:30"))
+		mock.ExpectQuery(positional(statementGetNotificationPreferences)).WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(preferencesColumns).AddRow(model.NotificationEventProximity, false, "").AddRow(model.NotificationEventSOS, true, "push,sms"))
+
+		settings, err := nr.GetSettings(2)
+		assert.NoError(t, err)
+		assert.Equal(t, "UTC", settings.Timezone)
+		assert.Equal(t, model.QuietHours{Enabled: true, Start: "22:00:00", End: "07:00:00"}, settings.QuietHours)
+		assert.Equal(t, 30, settings.DigestIntervalMinutes)
+		assert.False(t, settings.Preference(model.NotificationEventProximity).Enabled)
+		assert.Equal(t, []string{"push", "sms"}, settings.Preference(model.NotificationEventSOS).Channels)
+		assert.Equal(t, len(model.NotificationEvents), len(settings.Preferences))
+	})
+
+	t.Run("GetSettings scan error", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetNotificationSettings)).WithArgs(2).WillReturnError(web.ErrInternalServerError)
+
+		settings, err := nr.GetSettings(2)
+		assert.Nil(t, settings)
+		assert.Error(t, err)
+	})
+}
+
+func TestGetObserversWithDueDigest(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	nr := NewNotificationRepository(gdb, context.Background())
+
+	t.Run("GetObserversWithDueDigest successful", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetDueDigestObservers)).
+			WillReturnRows(sqlmock.NewRows([]string{"observer_user_id"}).AddRow(2).AddRow(5))
+
+		observerUserIDs, err := nr.GetObserversWithDueDigest()
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{2, 5}, observerUserIDs)
+	})
+
+	t.Run("GetObserversWithDueDigest error", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetDueDigestObservers)).WillReturnError(web.ErrInternalServerError)
+
+		observerUserIDs, err := nr.GetObserversWithDueDigest()
+		assert.Nil(t, observerUserIDs)
+		assert.Error(t, err)
+	})
+}
+
+func TestClaimDigestItems(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	nr := NewNotificationRepository(gdb, context.Background())
+
+	t.Run("ClaimDigestItems successful", func(t *testing.T) {
+		mock.ExpectExec(positional(statementClaimDigestItems)).WithArgs("claim", 300, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(positional(statementGetClaimedDigestItems)).WithArgs("claim").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "observer_user_id", "event_type", "title", "body", "created_at"}).
+				AddRow(7, 2, model.NotificationEventAnnouncement, "announcement", "", "2022-12-10 17:49:30"))
+
+		items, err := nr.ClaimDigestItems(2, "claim", 5*time.Minute)
+		assert.NoError(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, uint(7), items[0].ID)
+	})
+
+	t.Run("ClaimDigestItems error", func(t *testing.T) {
+		mock.ExpectExec(positional(statementClaimDigestItems)).WithArgs("claim", 300, 2).WillReturnError(web.ErrInternalServerError)
+
+		items, err := nr.ClaimDigestItems(2, "claim", 5*time.Minute)
+		assert.Nil(t, items)
+		assert.Error(t, err)
+	})
+}
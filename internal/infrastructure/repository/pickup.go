@@ -0,0 +1,319 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	pickupColumns            = "id, child_id, name, last_name, id_number, relationship, photo, created_at, updated_at"
+	statementGetPickups      = "SELECT " + pickupColumns + " FROM AuthorizedPickups WHERE child_id = @child_id ORDER BY id"
+	statementGetPickup       = "SELECT " + pickupColumns + " FROM AuthorizedPickups WHERE child_id = @child_id AND id = @id"
+	statementInsertPickup    = "INSERT INTO AuthorizedPickups (child_id, name, last_name, id_number, relationship, photo) VALUES (@child_id, @name, @last_name, @id_number, @relationship, @photo)"
+	statementUpdatePickup    = "UPDATE AuthorizedPickups SET name = @name, last_name = @last_name, id_number = @id_number, relationship = @relationship, photo = @photo, updated_at = CURRENT_TIMESTAMP WHERE child_id = @child_id AND id = @id"
+	statementDeletePickup    = "DELETE FROM AuthorizedPickups WHERE child_id = @child_id AND id = @id"
+	statementGetPickupPIN    = "SELECT child_id, pin_hash, expires_at, created_by FROM PickupPINs WHERE child_id = @child_id"
+	statementSavePickupPIN   = "INSERT INTO PickupPINs (child_id, pin_hash, expires_at, created_by) VALUES (@child_id, @pin_hash, @expires_at, @created_by) ON DUPLICATE KEY UPDATE pin_hash = VALUES(pin_hash), expires_at = VALUES(expires_at), created_by = VALUES(created_by)"
+	statementRedeemPickupPIN = "DELETE FROM PickupPINs WHERE child_id = @child_id AND pin_hash = @pin_hash AND expires_at > @now"
+	// statementIsDriverOf checks whether the observed user is linked to the observer user the child belongs to.
+	statementIsDriverOf   = "SELECT COUNT(*) FROM Children AS c INNER JOIN ObservedUsersObserverUsers AS oduoru ON oduoru.observer_user_id = c.observer_user_id WHERE c.id = @child_id AND oduoru.observed_user_id = @driver_user_id"
+	statementSaveHandover = "INSERT INTO PickupHandovers (child_id, driver_user_id, method, authorized_pickup_id, received_by, occurred_at) VALUES (@child_id, @driver_user_id, @method, @authorized_pickup_id, @received_by, @occurred_at)"
+	statementGetHandovers = "SELECT id, child_id, driver_user_id, method, COALESCE(authorized_pickup_id, 0), received_by, occurred_at FROM PickupHandovers WHERE child_id = @child_id ORDER BY occurred_at DESC LIMIT 100"
+	// The re-encryption job walks the ID numbers in batches, without decrypting them.
+	statementGetPickupIDNumbers   = "SELECT id, id_number FROM AuthorizedPickups WHERE id > @id ORDER BY id LIMIT @limit"
+	statementUpdatePickupIDNumber = "UPDATE AuthorizedPickups SET id_number = @id_number WHERE id = @id"
+)
+
+// NewPickupRepository creates the pickup repository. The ID numbers of the authorized pickups are encrypted with
+// cipher before being stored.
+func NewPickupRepository(db *gorm.DB, ctx context.Context, cipher gateway.FieldCipher) gateway.PickupRepository {
+	return &PickupRepository{
+		DB:      db,
+		context: ctx,
+		cipher:  cipher,
+	}
+}
+
+// PickupRepository represents the repository for manage who can receive the children at the drop-off.
+type PickupRepository struct {
+	DB      *gorm.DB
+	context context.Context
+	cipher  gateway.FieldCipher
+}
+
+// GetPickups obtains the people authorized to receive a child.
+func (r PickupRepository) GetPickups(childID uint) (_ []model.AuthorizedPickup, err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.GetPickups")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetPickups, sql.Named("child_id", childID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.scanPickups(rows)
+}
+
+// GetPickup obtains an authorized pickup of a child. It returns nil when it does not exist.
+func (r PickupRepository) GetPickup(childID uint, id uint) (_ *model.AuthorizedPickup, err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.GetPickup")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetPickup, sql.Named("child_id", childID), sql.Named("id", id)).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	pickups, err := r.scanPickups(rows)
+	if err != nil || len(pickups) == 0 {
+		return nil, err
+	}
+
+	return &pickups[0], nil
+}
+
+// SavePickup inserts an authorized pickup when it has no ID, or replaces the existing one.
+func (r PickupRepository) SavePickup(pickup model.AuthorizedPickup) (_ *model.AuthorizedPickup, err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.SavePickup")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	idNumber, err := r.cipher.Encrypt(pickup.IDNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	args := []interface{}{
+		sql.Named("child_id", pickup.ChildID),
+		sql.Named("name", pickup.Name),
+		sql.Named("last_name", pickup.LastName),
+		sql.Named("id_number", idNumber),
+		sql.Named("relationship", pickup.Relationship),
+		sql.Named("photo", pickup.Photo),
+	}
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if pickup.ID != 0 {
+			return tx.Exec(statementUpdatePickup, append(args, sql.Named("id", pickup.ID))...).Error
+		}
+
+		if err := tx.Exec(statementInsertPickup, args...).Error; err != nil {
+			return err
+		}
+
+		return tx.Raw(statementLastInsertID).Row().Scan(&pickup.ID)
+	})
+
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error saving authorized pickup")
+		return nil, err
+	}
+
+	return r.GetPickup(pickup.ChildID, pickup.ID)
+}
+
+// GetEncryptedIDNumbers obtains the stored ID numbers of the authorized pickups with an ID greater than afterID,
+// without decrypting them.
+func (r PickupRepository) GetEncryptedIDNumbers(afterID uint, limit int) (_ []model.EncryptedValue, err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.GetEncryptedIDNumbers")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetPickupIDNumbers, sql.Named("id", afterID), sql.Named("limit", limit)).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEncryptedValues(r.context, rows)
+}
+
+// UpdateEncryptedIDNumber replaces the stored ID number of an authorized pickup.
+func (r PickupRepository) UpdateEncryptedIDNumber(value model.EncryptedValue) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.UpdateEncryptedIDNumber")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementUpdatePickupIDNumber, sql.Named("id_number", value.Ciphertext), sql.Named("id", value.ID)).Error
+}
+
+// DeletePickup removes an authorized pickup of a child.
+func (r PickupRepository) DeletePickup(childID uint, id uint) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.DeletePickup")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementDeletePickup, sql.Named("child_id", childID), sql.Named("id", id)).Error
+}
+
+// GetPIN obtains the current pickup PIN of a child, without the PIN itself. It returns nil when there is none.
+func (r PickupRepository) GetPIN(childID uint) (_ *model.PickupPIN, err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.GetPIN")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var pin model.PickupPIN
+
+	err = r.DB.
+		Raw(statementGetPickupPIN, sql.Named("child_id", childID)).
+		Row().
+		Scan(&pin.ChildID, &pin.Hash, &pin.ExpiresAt, &pin.CreatedBy)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return nil, err
+	}
+
+	return &pin, nil
+}
+
+// SavePIN stores the hash of the pickup PIN of a child, replacing the previous one.
+func (r PickupRepository) SavePIN(pin model.PickupPIN) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.SavePIN")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementSavePickupPIN,
+		sql.Named("child_id", pin.ChildID),
+		sql.Named("pin_hash", pin.Hash),
+		sql.Named("expires_at", pin.ExpiresAt),
+		sql.Named("created_by", pin.CreatedBy),
+	).Error
+}
+
+// RedeemPIN deletes the pickup PIN of a child when its hash matches and it has not expired at now, in unix
+// seconds. It returns false when there was no such PIN, so each PIN is accepted only once.
+func (r PickupRepository) RedeemPIN(childID uint, hash string, now int64) (_ bool, err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.RedeemPIN")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	result := r.DB.Exec(statementRedeemPickupPIN, sql.Named("child_id", childID), sql.Named("pin_hash", hash), sql.Named("now", now))
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// IsDriverOf reports whether the observed user drives the bus of the child.
+func (r PickupRepository) IsDriverOf(driverUserID uint, childID uint) (_ bool, err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.IsDriverOf")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var count int
+
+	err = r.DB.
+		Raw(statementIsDriverOf, sql.Named("child_id", childID), sql.Named("driver_user_id", driverUserID)).
+		Row().
+		Scan(&count)
+
+	return count > 0, err
+}
+
+// SaveHandover records who received a child from the driver.
+func (r PickupRepository) SaveHandover(handover model.PickupHandover) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.SaveHandover")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var authorizedPickupID interface{}
+	if handover.AuthorizedPickupID != 0 {
+		authorizedPickupID = handover.AuthorizedPickupID
+	}
+
+	return r.DB.Exec(
+		statementSaveHandover,
+		sql.Named("child_id", handover.ChildID),
+		sql.Named("driver_user_id", handover.DriverUserID),
+		sql.Named("method", handover.Method),
+		sql.Named("authorized_pickup_id", authorizedPickupID),
+		sql.Named("received_by", handover.ReceivedBy),
+		sql.Named("occurred_at", handover.OccurredAt),
+	).Error
+}
+
+// GetHandovers obtains the latest handovers of a child, the most recent first.
+func (r PickupRepository) GetHandovers(childID uint) (_ []model.PickupHandover, err error) {
+	ctx, span := tracing.StartSpan(r.context, "PickupRepository.GetHandovers")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetHandovers, sql.Named("child_id", childID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	handovers := []model.PickupHandover{}
+	for rows.Next() {
+		var handover model.PickupHandover
+
+		err = rows.Scan(
+			&handover.ID,
+			&handover.ChildID,
+			&handover.DriverUserID,
+			&handover.Method,
+			&handover.AuthorizedPickupID,
+			&handover.ReceivedBy,
+			&handover.OccurredAt,
+		)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		handovers = append(handovers, handover)
+	}
+
+	return handovers, nil
+}
+
+func (r PickupRepository) scanPickups(rows *sql.Rows) ([]model.AuthorizedPickup, error) {
+	defer rows.Close()
+
+	pickups := []model.AuthorizedPickup{}
+	for rows.Next() {
+		var pickup model.AuthorizedPickup
+
+		err := rows.Scan(
+			&pickup.ID,
+			&pickup.ChildID,
+			&pickup.Name,
+			&pickup.LastName,
+			&pickup.IDNumber,
+			&pickup.Relationship,
+			&pickup.Photo,
+			&pickup.CreatedAt,
+			&pickup.UpdatedAt,
+		)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		if pickup.IDNumber, err = r.cipher.Decrypt(pickup.IDNumber); err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error decrypting ID number")
+			return nil, err
+		}
+
+		pickups = append(pickups, pickup)
+	}
+
+	return pickups, nil
+}
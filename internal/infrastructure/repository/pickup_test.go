@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var pickupRowColumns = []string{"id", "child_id", "name", "last_name", "id_number", "relationship", "photo", "created_at", "updated_at"}
+
+func TestPickupRepository(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	pr := NewPickupRepository(gdb, context.Background(), testCipher)
+	encrypted, _ := testCipher.Encrypt("30111222")
+	pickup := model.AuthorizedPickup{
+		ID: 3, ChildID: 1, Name: "Rosa", LastName: "Pérez", IDNumber: "30111222", Relationship: "grandparent",
+		Photo: []byte{0xFF, 0xD8, 0xFF}, CreatedAt: "2023-07-03", UpdatedAt: "2023-07-03",
+	}
+
+	t.Run("GetPickups decrypts the ID numbers", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetPickups)).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(pickupRowColumns).
+				AddRow(3, 1, "Rosa", "Pérez", encrypted, "grandparent", []byte{0xFF, 0xD8, 0xFF}, "2023-07-03", "2023-07-03"))
+
+		pickups, err := pr.GetPickups(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.AuthorizedPickup{pickup}, pickups)
+	})
+
+	t.Run("SavePickup inserts and encrypts the ID number", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(positional(statementInsertPickup)).
+			WithArgs(1, "Rosa", "Pérez", sqlmock.AnyArg(), "grandparent", []byte{0xFF, 0xD8, 0xFF}).
+			WillReturnResult(sqlmock.NewResult(3, 1))
+		mock.ExpectQuery(positional(statementLastInsertID)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectCommit()
+		mock.ExpectQuery(positional(statementGetPickup)).WithArgs(1, 3).
+			WillReturnRows(sqlmock.NewRows(pickupRowColumns).
+				AddRow(3, 1, "Rosa", "Pérez", encrypted, "grandparent", []byte{0xFF, 0xD8, 0xFF}, "2023-07-03", "2023-07-03"))
+
+		created := pickup
+		created.ID = 0
+		saved, err := pr.SavePickup(created)
+		assert.NoError(t, err)
+		assert.Equal(t, &pickup, saved)
+	})
+
+	t.Run("GetEncryptedIDNumbers leaves the ID numbers encrypted", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetPickupIDNumbers)).WithArgs(0, 100).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "id_number"}).AddRow(3, encrypted))
+
+		values, err := pr.GetEncryptedIDNumbers(0, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.EncryptedValue{{ID: 3, Ciphertext: encrypted}}, values)
+	})
+
+	t.Run("GetPickup not found", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetPickup)).WithArgs(1, 9).WillReturnRows(sqlmock.NewRows(pickupRowColumns))
+
+		found, err := pr.GetPickup(1, 9)
+		assert.NoError(t, err)
+		assert.Nil(t, found)
+	})
+
+	t.Run("GetPIN none", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetPickupPIN)).WithArgs(1).WillReturnError(sql.ErrNoRows)
+
+		pin, err := pr.GetPIN(1)
+		assert.NoError(t, err)
+		assert.Nil(t, pin)
+	})
+
+	t.Run("RedeemPIN", func(t *testing.T) {
+		mock.ExpectExec(positional(statementRedeemPickupPIN)).WithArgs(1, "hash", 1689000000).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		redeemed, err := pr.RedeemPIN(1, "hash", 1689000000)
+		assert.NoError(t, err)
+		assert.True(t, redeemed)
+	})
+
+	t.Run("RedeemPIN already used or expired", func(t *testing.T) {
+		mock.ExpectExec(positional(statementRedeemPickupPIN)).WithArgs(1, "hash", 1689000000).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		redeemed, err := pr.RedeemPIN(1, "hash", 1689000000)
+		assert.NoError(t, err)
+		assert.False(t, redeemed)
+	})
+
+	t.Run("IsDriverOf", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementIsDriverOf)).WithArgs(1, 7).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		driver, err := pr.IsDriverOf(7, 1)
+		assert.NoError(t, err)
+		assert.True(t, driver)
+	})
+
+	t.Run("SaveHandover with a PIN leaves the authorized pickup out", func(t *testing.T) {
+		mock.ExpectExec(positional(statementSaveHandover)).
+			WithArgs(1, 7, model.PickupMethodPIN, nil, "Tía Carla", 1689000000).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		assert.NoError(t, pr.SaveHandover(model.PickupHandover{
+			ChildID: 1, DriverUserID: 7, Method: model.PickupMethodPIN, ReceivedBy: "Tía Carla", OccurredAt: 1689000000,
+		}))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	statementInsertUser      = "INSERT INTO Users (name, last_name, id_number, id_number_index, username, password, email, type, email_verified) VALUES (@name, @last_name, @id_number, @id_number_index, @username, @password, @email, @type, @email_verified)"
+	statementInsertObserver  = "INSERT INTO ObserverUsers (user_id) VALUES (@user_id)"
+	statementInsertDriver    = "INSERT INTO ObservedUsers (user_id, privacy_key, company_name, school_bus_id) VALUES (@user_id, @privacy_key, @company_name, @school_bus_id)"
+	statementFindSchoolBus   = "SELECT id, license_plate, model, brand, school_bus_license, created_at, updated_at FROM SchoolBuses WHERE license_plate = @license_plate"
+	statementInsertSchoolBus = "INSERT INTO SchoolBuses (license_plate, model, brand, school_bus_license) VALUES (@license_plate, @model, @brand, @school_bus_license)"
+	statementAssignSchoolBus = "UPDATE ObservedUsers SET school_bus_id = @school_bus_id, updated_at = CURRENT_TIMESTAMP WHERE user_id = @user_id"
+	statementLinkObserver    = "INSERT IGNORE INTO ObservedUsersObserverUsers (observed_user_id, observer_user_id) VALUES (@observed_user_id, @observer_user_id)"
+	statementInsertChild     = "INSERT INTO Children (name, last_name, school_id, school_start_time, school_end_time, observer_user_id) VALUES (@name, @last_name, @school_id, @school_start_time, @school_end_time, @observer_user_id)"
+)
+
+// NewProvisioningRepository creates the provisioning repository. The ID numbers are encrypted with cipher before
+// being stored, as the user repository does.
+func NewProvisioningRepository(db *gorm.DB, ctx context.Context, cipher gateway.FieldCipher) gateway.ProvisioningRepository {
+	return &ProvisioningRepository{
+		DB:      db,
+		context: ctx,
+		cipher:  cipher,
+	}
+}
+
+// ProvisioningRepository represents the repository for create the accounts, school buses and children.
+type ProvisioningRepository struct {
+	DB      *gorm.DB
+	context context.Context
+	cipher  gateway.FieldCipher
+}
+
+// CreateObserver inserts an observer user along with its profile.
+func (r ProvisioningRepository) CreateObserver(user model.User) (_ *model.User, err error) {
+	ctx, span := tracing.StartSpan(r.context, "ProvisioningRepository.CreateObserver")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := r.insertUser(tx, &user); err != nil {
+			return err
+		}
+
+		return tx.Exec(statementInsertObserver, sql.Named("user_id", user.ID)).Error
+	})
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error creating observer user")
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// CreateDriver inserts an observed user along with its profile, driving the school bus of the driver.
+func (r ProvisioningRepository) CreateDriver(driver model.ObservedUser) (_ *model.User, err error) {
+	ctx, span := tracing.StartSpan(r.context, "ProvisioningRepository.CreateDriver")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	user := driver.User
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := r.insertUser(tx, &user); err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			statementInsertDriver,
+			sql.Named("user_id", user.ID),
+			sql.Named("privacy_key", driver.PrivacyKey),
+			sql.Named("company_name", driver.CompanyName),
+			sql.Named("school_bus_id", driver.SchoolBus.ID),
+		).Error
+	})
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error creating observed user")
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// FindSchoolBus obtains a school bus by its license plate. It returns nil when no bus has the license plate.
+func (r ProvisioningRepository) FindSchoolBus(licensePlate string) (_ *model.SchoolBus, err error) {
+	ctx, span := tracing.StartSpan(r.context, "ProvisioningRepository.FindSchoolBus")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var bus model.SchoolBus
+
+	err = r.DB.
+		Raw(statementFindSchoolBus, sql.Named("license_plate", licensePlate)).
+		Row().
+		Scan(&bus.ID, &bus.LicensePlate, &bus.Model, &bus.Brand, &bus.SchoolBusLicense, &bus.CreatedAt, &bus.UpdatedAt)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return nil, err
+	}
+
+	return &bus, nil
+}
+
+// SaveSchoolBus inserts a school bus.
+func (r ProvisioningRepository) SaveSchoolBus(bus model.SchoolBus) (_ *model.SchoolBus, err error) {
+	ctx, span := tracing.StartSpan(r.context, "ProvisioningRepository.SaveSchoolBus")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			statementInsertSchoolBus,
+			sql.Named("license_plate", bus.LicensePlate),
+			sql.Named("model", bus.Model),
+			sql.Named("brand", bus.Brand),
+			sql.Named("school_bus_license", bus.SchoolBusLicense),
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Raw(statementLastInsertID).Row().Scan(&bus.ID)
+	})
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error saving school bus")
+		return nil, err
+	}
+
+	return &bus, nil
+}
+
+// AssignSchoolBus sets the school bus an observed user drives.
+func (r ProvisioningRepository) AssignSchoolBus(userID uint, busID uint) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "ProvisioningRepository.AssignSchoolBus")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementAssignSchoolBus, sql.Named("school_bus_id", busID), sql.Named("user_id", userID)).Error
+}
+
+// LinkObserver lets an observer user follow an observed user. Linking them again does nothing.
+func (r ProvisioningRepository) LinkObserver(observedUserID uint, observerUserID uint) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "ProvisioningRepository.LinkObserver")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementLinkObserver,
+		sql.Named("observed_user_id", observedUserID),
+		sql.Named("observer_user_id", observerUserID),
+	).Error
+}
+
+// SaveChild inserts a child of an observer user.
+func (r ProvisioningRepository) SaveChild(child model.Children) (_ *model.Children, err error) {
+	ctx, span := tracing.StartSpan(r.context, "ProvisioningRepository.SaveChild")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			statementInsertChild,
+			sql.Named("name", child.Name),
+			sql.Named("last_name", child.LastName),
+			sql.Named("school_id", child.SchoolID),
+			sql.Named("school_start_time", child.SchoolStartTime),
+			sql.Named("school_end_time", child.SchoolEndTime),
+			sql.Named("observer_user_id", child.ObserverUserID),
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Raw(statementLastInsertID).Row().Scan(&child.ID)
+	})
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error saving child")
+		return nil, err
+	}
+
+	return &child, nil
+}
+
+// insertUser stores the user with its ID number encrypted, along with its blind index, and sets its ID.
+func (r ProvisioningRepository) insertUser(tx *gorm.DB, user *model.User) error {
+	encrypted, err := r.cipher.Encrypt(user.IDNumber)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Exec(
+		statementInsertUser,
+		sql.Named("name", user.Name),
+		sql.Named("last_name", user.LastName),
+		sql.Named("id_number", encrypted),
+		sql.Named("id_number_index", r.cipher.BlindIndex(user.IDNumber)),
+		sql.Named("username", user.Username),
+		sql.Named("password", user.Password),
+		sql.Named("email", user.Email),
+		sql.Named("type", user.Type),
+		sql.Named("email_verified", user.EmailVerified),
+	).Error
+	if err != nil {
+		return err
+	}
+
+	user.Enabled = true
+
+	return tx.Raw(statementLastInsertID).Row().Scan(&user.ID)
+}
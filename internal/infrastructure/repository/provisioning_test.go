@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestProvisioningRepository(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	pr := NewProvisioningRepository(gdb, context.Background(), testCipher)
+	user := model.User{
+		Name: "Juan", LastName: "Perez", IDNumber: "12345678", Username: "jperez", Password: "jperez1234",
+		Email: "jperez@mail.com", Type: "observed", EmailVerified: true,
+	}
+
+	t.Run("CreateDriver inserts the user and its profile", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(positional(statementInsertUser)).
+			WithArgs("Juan", "Perez", encryptedWith("12345678"), testCipher.BlindIndex("12345678"), "jperez", "jperez1234", "jperez@mail.com", "observed", true).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(positional(statementLastInsertID)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(positional(statementInsertDriver)).
+			WithArgs(1, "key", "company school bus", 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		created, err := pr.CreateDriver(model.ObservedUser{User: user, PrivacyKey: "key", CompanyName: "company school bus", SchoolBus: model.SchoolBus{ID: 2}})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), created.ID)
+		assert.Equal(t, "12345678", created.IDNumber)
+		assert.True(t, created.Enabled)
+	})
+
+	t.Run("CreateObserver rolls back when the profile fails", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(positional(statementInsertUser)).WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectQuery(positional(statementLastInsertID)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectExec(positional(statementInsertObserver)).WithArgs(2).WillReturnError(errors.New("duplicate"))
+		mock.ExpectRollback()
+
+		created, err := pr.CreateObserver(user)
+		assert.Error(t, err)
+		assert.Nil(t, created)
+	})
+
+	t.Run("FindSchoolBus not found", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementFindSchoolBus)).WithArgs("11AAA222").WillReturnError(sql.ErrNoRows)
+
+		bus, err := pr.FindSchoolBus("11AAA222")
+		assert.NoError(t, err)
+		assert.Nil(t, bus)
+	})
+
+	t.Run("SaveSchoolBus", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(positional(statementInsertSchoolBus)).
+			WithArgs("11AAA222", "Master", "Renault", "11222").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectQuery(positional(statementLastInsertID)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
+		mock.ExpectCommit()
+
+		bus, err := pr.SaveSchoolBus(model.SchoolBus{LicensePlate: "11AAA222", Model: "Master", Brand: "Renault", SchoolBusLicense: "11222"})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), bus.ID)
+	})
+
+	t.Run("LinkObserver", func(t *testing.T) {
+		mock.ExpectExec(positional(statementLinkObserver)).WithArgs(1, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, pr.LinkObserver(1, 2))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	statementGetRoles    = "SELECT role, company_name FROM UserRoles WHERE user_id = @user_id ORDER BY role"
+	statementDeleteRoles = "DELETE FROM UserRoles WHERE user_id = @user_id"
+	statementInsertRole  = "INSERT INTO UserRoles (user_id, role, company_name) VALUES (@user_id, @role, @company_name)"
+)
+
+func NewRoleRepository(db *gorm.DB, ctx context.Context) gateway.RoleRepository {
+	return &RoleRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// RoleRepository represents the repository for manage the roles granted to users.
+type RoleRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// GetRoles obtains the roles explicitly granted to a user.
+func (r RoleRepository) GetRoles(userID uint) (_ []model.RoleAssignment, err error) {
+	ctx, span := tracing.StartSpan(r.context, "RoleRepository.GetRoles")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var roles []model.RoleAssignment
+
+	rows, err := r.DB.Raw(statementGetRoles, sql.Named("user_id", userID)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			role        model.RoleAssignment
+			companyName sql.NullString
+		)
+
+		if err = rows.Scan(&role.Role, &companyName); err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		role.CompanyName = companyName.String
+		roles = append(roles, role)
+	}
+
+	return roles, nil
+}
+
+// SetRoles replaces the roles granted to a user.
+func (r RoleRepository) SetRoles(userID uint, roles []model.RoleAssignment) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "RoleRepository.SetRoles")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(statementDeleteRoles, sql.Named("user_id", userID)).Error; err != nil {
+			return err
+		}
+
+		for _, role := range roles {
+			companyName := sql.NullString{String: role.CompanyName, Valid: role.CompanyName != ""}
+			err := tx.Exec(
+				statementInsertRole,
+				sql.Named("user_id", userID),
+				sql.Named("role", role.Role),
+				sql.Named("company_name", companyName),
+			).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
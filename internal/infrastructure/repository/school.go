@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	schoolColumns                 = "id, name, street, number, city, state, country, latitude, longitude, geofence_radius, timezone, created_at, updated_at"
+	statementGetSchool            = "SELECT " + schoolColumns + " FROM Schools WHERE id = @id"
+	statementGetSchools           = "SELECT " + schoolColumns + " FROM Schools ORDER BY name, id"
+	statementInsertSchool         = "INSERT INTO Schools (name, street, number, city, state, country, latitude, longitude, geofence_radius, timezone) VALUES (@name, @street, @number, @city, @state, @country, @latitude, @longitude, @geofence_radius, @timezone)"
+	statementLastInsertID         = "SELECT LAST_INSERT_ID()"
+	statementUpdateSchool         = "UPDATE Schools SET name = @name, street = @street, number = @number, city = @city, state = @state, country = @country, latitude = @latitude, longitude = @longitude, geofence_radius = @geofence_radius, timezone = @timezone WHERE id = @id"
+	statementGetBellSchedules     = "SELECT school_id, weekday, start_time, end_time FROM SchoolBellSchedules ORDER BY school_id, weekday"
+	statementGetSchoolBells       = "SELECT school_id, weekday, start_time, end_time FROM SchoolBellSchedules WHERE school_id = @school_id ORDER BY weekday"
+	statementDeleteBellSchedules  = "DELETE FROM SchoolBellSchedules WHERE school_id = @school_id"
+	statementInsertBellSchedule   = "INSERT INTO SchoolBellSchedules (school_id, weekday, start_time, end_time) VALUES (@school_id, @weekday, @start_time, @end_time)"
+	statementGetCalendarEntries   = "SELECT school_id, date, kind, name, start_time, end_time FROM SchoolCalendarEntries ORDER BY school_id, date"
+	statementGetSchoolCalendar    = "SELECT school_id, date, kind, name, start_time, end_time FROM SchoolCalendarEntries WHERE school_id = @school_id ORDER BY date"
+	statementDeleteSchoolCalendar = "DELETE FROM SchoolCalendarEntries WHERE school_id = @school_id"
+	statementInsertSchoolCalendar = "INSERT INTO SchoolCalendarEntries (school_id, date, kind, name, start_time, end_time) VALUES (@school_id, @date, @kind, @name, @start_time, @end_time)"
+	statementGetChild             = "SELECT c.id, c.name, c.last_name, c.school_id, s.name, c.school_start_time, c.school_end_time, c.observer_user_id, c.created_at, c.updated_at FROM Children AS c INNER JOIN Schools AS s ON s.id = c.school_id WHERE c.id = @id"
+	statementSetChildSchool       = "UPDATE Children SET school_id = @school_id, updated_at = CURRENT_TIMESTAMP WHERE id = @id"
+)
+
+func NewSchoolRepository(db *gorm.DB, ctx context.Context) gateway.SchoolRepository {
+	return &SchoolRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// SchoolRepository represents the repository for manage the schools, their calendars and the children attending them.
+type SchoolRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// GetSchool obtains a school with its bell schedules and calendar. It returns nil when the school does not exist.
+func (r SchoolRepository) GetSchool(id uint) (_ *model.School, err error) {
+	ctx, span := tracing.StartSpan(r.context, "SchoolRepository.GetSchool")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetSchool, sql.Named("id", id)).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	schools, err := scanSchools(r.context, rows)
+	if err != nil || len(schools) == 0 {
+		return nil, err
+	}
+
+	if err = r.loadCalendars(schools, statementGetSchoolBells, statementGetSchoolCalendar, sql.Named("school_id", id)); err != nil {
+		return nil, err
+	}
+
+	return &schools[0], nil
+}
+
+// GetSchools obtains every school with its bell schedules and calendar, sorted by name.
+func (r SchoolRepository) GetSchools() (_ []model.School, err error) {
+	ctx, span := tracing.StartSpan(r.context, "SchoolRepository.GetSchools")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetSchools).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	schools, err := scanSchools(r.context, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.loadCalendars(schools, statementGetBellSchedules, statementGetCalendarEntries); err != nil {
+		return nil, err
+	}
+
+	return schools, nil
+}
+
+// SaveSchool inserts the school when it has no ID, or updates it otherwise, replacing its bell schedules and calendar.
+func (r SchoolRepository) SaveSchool(school model.School) (_ *model.School, err error) {
+	ctx, span := tracing.StartSpan(r.context, "SchoolRepository.SaveSchool")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		args := []interface{}{
+			sql.Named("name", school.Name),
+			sql.Named("street", school.Street),
+			sql.Named("number", school.Number),
+			sql.Named("city", school.City),
+			sql.Named("state", school.State),
+			sql.Named("country", school.Country),
+			sql.Named("latitude", school.Latitude),
+			sql.Named("longitude", school.Longitude),
+			sql.Named("geofence_radius", school.GeofenceRadius),
+			sql.Named("timezone", school.Timezone),
+		}
+
+		if school.ID == 0 {
+			if err := tx.Exec(statementInsertSchool, args...).Error; err != nil {
+				return err
+			}
+
+			if err := tx.Raw(statementLastInsertID).Row().Scan(&school.ID); err != nil {
+				return err
+			}
+		} else if err := tx.Exec(statementUpdateSchool, append(args, sql.Named("id", school.ID))...).Error; err != nil {
+			return err
+		}
+
+		schoolID := sql.Named("school_id", school.ID)
+		if err := tx.Exec(statementDeleteBellSchedules, schoolID).Error; err != nil {
+			return err
+		}
+
+		for _, schedule := range school.BellSchedules {
+			err := tx.Exec(
+				statementInsertBellSchedule,
+				schoolID,
+				sql.Named("weekday", int(schedule.Weekday)),
+				sql.Named("start_time", schedule.Start),
+				sql.Named("end_time", schedule.End),
+			).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Exec(statementDeleteSchoolCalendar, schoolID).Error; err != nil {
+			return err
+		}
+
+		for _, entry := range school.Calendar {
+			if err := tx.Exec(statementInsertSchoolCalendar, append(calendarEntryArgs(entry), schoolID)...).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error saving school")
+		return nil, err
+	}
+
+	return r.GetSchool(school.ID)
+}
+
+// GetChild obtains a child along with the name of its school. It returns nil when the child does not exist.
+func (r SchoolRepository) GetChild(id uint) (_ *model.Children, err error) {
+	ctx, span := tracing.StartSpan(r.context, "SchoolRepository.GetChild")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var child model.Children
+
+	err = r.DB.
+		Raw(statementGetChild, sql.Named("id", id)).
+		Row().
+		Scan(
+			&child.ID,
+			&child.Name,
+			&child.LastName,
+			&child.SchoolID,
+			&child.SchoolName,
+			&child.SchoolStartTime,
+			&child.SchoolEndTime,
+			&child.ObserverUserID,
+			&child.CreatedAt,
+			&child.UpdatedAt,
+		)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return nil, err
+	}
+
+	return &child, nil
+}
+
+// SetChildSchool links a child to the school it attends.
+func (r SchoolRepository) SetChildSchool(childID uint, schoolID uint) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "SchoolRepository.SetChildSchool")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementSetChildSchool, sql.Named("school_id", schoolID), sql.Named("id", childID)).Error
+}
+
+// loadCalendars fills the bell schedules and calendar entries of the schools, read with the given statements.
+func (r SchoolRepository) loadCalendars(schools []model.School, statementBells string, statementCalendar string, args ...interface{}) error {
+	bySchool := make(map[uint]*model.School, len(schools))
+	for i := range schools {
+		schools[i].BellSchedules = []model.BellSchedule{}
+		schools[i].Calendar = []model.CalendarEntry{}
+		bySchool[schools[i].ID] = &schools[i]
+	}
+
+	rows, err := r.DB.Raw(statementBells, args...).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			schoolID uint
+			schedule model.BellSchedule
+		)
+
+		if err = rows.Scan(&schoolID, &schedule.Weekday, &schedule.Start, &schedule.End); err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return err
+		}
+
+		if school, ok := bySchool[schoolID]; ok {
+			school.BellSchedules = append(school.BellSchedules, schedule)
+		}
+	}
+
+	rows, err = r.DB.Raw(statementCalendar, args...).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var schoolID uint
+
+		entry, err := scanCalendarEntry(r.context, rows, &schoolID)
+		if err != nil {
+			return err
+		}
+
+		if school, ok := bySchool[schoolID]; ok {
+			school.Calendar = append(school.Calendar, entry)
+		}
+	}
+
+	return nil
+}
+
+func scanSchools(ctx context.Context, rows *sql.Rows) ([]model.School, error) {
+	defer rows.Close()
+
+	schools := []model.School{}
+	for rows.Next() {
+		var (
+			school    model.School
+			latitude  sql.NullFloat64
+			longitude sql.NullFloat64
+		)
+
+		err := rows.Scan(
+			&school.ID,
+			&school.Name,
+			&school.Street,
+			&school.Number,
+			&school.City,
+			&school.State,
+			&school.Country,
+			&latitude,
+			&longitude,
+			&school.GeofenceRadius,
+			&school.Timezone,
+			&school.CreatedAt,
+			&school.UpdatedAt,
+		)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		if latitude.Valid && longitude.Valid {
+			school.Latitude = &latitude.Float64
+			school.Longitude = &longitude.Float64
+		}
+
+		schools = append(schools, school)
+	}
+
+	return schools, nil
+}
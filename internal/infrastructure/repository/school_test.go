@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var schoolRowColumns = []string{"id", "name", "street", "number", "city", "state", "country", "latitude", "longitude", "geofence_radius", "timezone", "created_at", "updated_at"}
+
+func TestSchoolRepository(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	sr := NewSchoolRepository(gdb, context.Background())
+	latitude, longitude := -31.648105, -60.707722
+	laSalle := model.School{
+		ID: 1, Name: "La Salle", Street: "San Jerónimo", Number: "2155", City: "Santa Fe", State: "Santa Fe", Country: "Argentina",
+		Latitude: &latitude, Longitude: &longitude, GeofenceRadius: 150, Timezone: model.DefaultSchoolTimezone,
+		BellSchedules: []model.BellSchedule{{Weekday: time.Monday, Start: "08:00:00", End: "12:00:00"}},
+		Calendar:      []model.CalendarEntry{{Date: "2023-07-09", Kind: model.CalendarEntryHoliday, Name: "Día de la Independencia"}},
+		CreatedAt:     "2023-01-10", UpdatedAt: "2023-01-10",
+	}
+
+	expectGetSchool := func() {
+		mock.ExpectQuery(positional(statementGetSchool)).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(schoolRowColumns).
+				AddRow(1, "La Salle", "San Jerónimo", "2155", "Santa Fe", "Santa Fe", "Argentina", "-31.648105", "-60.707722", 150, model.DefaultSchoolTimezone, "2023-01-10", "2023-01-10"))
+		mock.ExpectQuery(positional(statementGetSchoolBells)).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"school_id", "weekday", "start_time", "end_time"}).AddRow(1, 1, "08:00:00", "12:00:00"))
+		mock.ExpectQuery(positional(statementGetSchoolCalendar)).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows(calendarRowColumns).AddRow(1, "2023-07-09", model.CalendarEntryHoliday, "Día de la Independencia", nil, nil))
+	}
+
+	t.Run("GetSchool with its calendar", func(t *testing.T) {
+		expectGetSchool()
+
+		school, err := sr.GetSchool(1)
+		assert.NoError(t, err)
+		assert.Equal(t, &laSalle, school)
+	})
+
+	t.Run("GetSchool not found", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetSchool)).WithArgs(2).WillReturnRows(sqlmock.NewRows(schoolRowColumns))
+
+		school, err := sr.GetSchool(2)
+		assert.NoError(t, err)
+		assert.Nil(t, school)
+	})
+
+	t.Run("GetSchools without coordinates", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetSchools)).
+			WillReturnRows(sqlmock.NewRows(schoolRowColumns).
+				AddRow(2, "Normal 1", "", "", "", "", "", nil, nil, 150, model.DefaultSchoolTimezone, "2023-01-10", "2023-01-10"))
+		mock.ExpectQuery(positional(statementGetBellSchedules)).WillReturnRows(sqlmock.NewRows([]string{"school_id", "weekday", "start_time", "end_time"}))
+		mock.ExpectQuery(positional(statementGetCalendarEntries)).WillReturnRows(sqlmock.NewRows(calendarRowColumns))
+
+		schools, err := sr.GetSchools()
+		assert.NoError(t, err)
+		assert.Len(t, schools, 1)
+		assert.False(t, schools[0].HasLocation())
+		assert.Empty(t, schools[0].BellSchedules)
+	})
+
+	t.Run("SaveSchool inserts it with its calendar", func(t *testing.T) {
+		school := laSalle
+		school.ID = 0
+
+		mock.ExpectBegin()
+		mock.ExpectExec(positional(statementInsertSchool)).
+			WithArgs("La Salle", "San Jerónimo", "2155", "Santa Fe", "Santa Fe", "Argentina", latitude, longitude, 150, model.DefaultSchoolTimezone).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(positional(statementLastInsertID)).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(positional(statementDeleteBellSchedules)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(positional(statementInsertBellSchedule)).WithArgs(1, 1, "08:00:00", "12:00:00").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(positional(statementDeleteSchoolCalendar)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(positional(statementInsertSchoolCalendar)).WithArgs(1, "2023-07-09", model.CalendarEntryHoliday, "Día de la Independencia", nil, nil).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectGetSchool()
+
+		saved, err := sr.SaveSchool(school)
+		assert.NoError(t, err)
+		assert.Equal(t, &laSalle, saved)
+	})
+
+	t.Run("GetChild not found", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetChild)).WithArgs(7).WillReturnError(sql.ErrNoRows)
+
+		child, err := sr.GetChild(7)
+		assert.NoError(t, err)
+		assert.Nil(t, child)
+	})
+
+	t.Run("SetChildSchool", func(t *testing.T) {
+		mock.ExpectExec(positional(statementSetChildSchool)).WithArgs(1, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, sr.SetChildSchool(3, 1))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+// The FULLTEXT indexes use the accent and case insensitive collation of the tables, so "Martín" matches "martin".
+// A child matches when every term is in its name or in the name of its school, so the terms are checked one by one.
+const (
+	statementSearch = "SELECT kind, id, family_id, title, detail, score FROM (" +
+		"SELECT 'user' AS kind, id, id AS family_id, CONCAT(name, ' ', last_name) AS title, email AS detail, MATCH (name, last_name, email) AGAINST (@terms IN BOOLEAN MODE) AS score FROM Users WHERE MATCH (name, last_name, email) AGAINST (@terms IN BOOLEAN MODE)" +
+		" UNION ALL " +
+		"SELECT 'child' AS kind, c.id, c.observer_user_id AS family_id, CONCAT(c.name, ' ', c.last_name) AS title, s.name AS detail, MATCH (c.name, c.last_name) AGAINST (@words IN BOOLEAN MODE) + MATCH (s.name) AGAINST (@words IN BOOLEAN MODE) AS score FROM Children AS c INNER JOIN Schools AS s ON s.id = c.school_id WHERE %s" +
+		" UNION ALL " +
+		"SELECT 'school' AS kind, id, 0 AS family_id, name AS title, city AS detail, MATCH (name, city) AGAINST (@terms IN BOOLEAN MODE) AS score FROM Schools WHERE MATCH (name, city) AGAINST (@terms IN BOOLEAN MODE)" +
+		") AS results"
+	statementSearchChildTerm = "(MATCH (c.name, c.last_name) AGAINST (@term_%[1]d IN BOOLEAN MODE) OR MATCH (s.name) AGAINST (@term_%[1]d IN BOOLEAN MODE))"
+	statementSearchFamilies  = "SELECT oduoru.observer_user_id FROM ObservedUsersObserverUsers AS oduoru INNER JOIN ObservedUsers AS odu ON odu.user_id = oduoru.observed_user_id WHERE odu.company_name IN @companies"
+	statementSearchCompanies = " WHERE kind = 'school' OR family_id IN (" + statementSearchFamilies + ")" +
+		" OR (kind = 'user' AND id IN (SELECT cg.observer_user_id FROM ChildGuardians AS cg INNER JOIN Children AS c ON c.id = cg.child_id WHERE c.observer_user_id IN (" + statementSearchFamilies + ")))"
+	statementSearchOrder = " ORDER BY score DESC, kind, id LIMIT @limit"
+)
+
+func NewSearchRepository(db *gorm.DB, ctx context.Context) gateway.SearchRepository {
+	return &SearchRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// SearchRepository represents the repository for the full-text search backed by the MySQL FULLTEXT indexes.
+type SearchRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// Search obtains the users, children and schools matching every term of the query, as a word or a word prefix.
+// Company admins only see the users and children of the families riding with their companies, and the guardians
+// of those children.
+func (r SearchRepository) Search(query model.SearchQuery) (_ []model.SearchResult, err error) {
+	ctx, span := tracing.StartSpan(r.context, "SearchRepository.Search")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	terms := query.Terms()
+	childTerms := make([]string, len(terms))
+	args := []interface{}{sql.Named("terms", booleanTerms(terms)), sql.Named("words", optionalTerms(terms))}
+	for i, term := range terms {
+		childTerms[i] = fmt.Sprintf(statementSearchChildTerm, i)
+		args = append(args, sql.Named(fmt.Sprintf("term_%d", i), booleanTerms([]string{term})))
+	}
+
+	statement := strings.Builder{}
+	statement.WriteString(fmt.Sprintf(statementSearch, strings.Join(childTerms, " AND ")))
+
+	if query.Companies != nil {
+		statement.WriteString(statementSearchCompanies)
+		args = append(args, sql.Named("companies", query.Companies))
+	}
+
+	statement.WriteString(statementSearchOrder)
+	args = append(args, sql.Named("limit", query.Limit))
+
+	rows, err := r.DB.Raw(statement.String(), args...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []model.SearchResult{}
+	for rows.Next() {
+		var (
+			result model.SearchResult
+			detail sql.NullString
+		)
+
+		if err = rows.Scan(&result.Kind, &result.ID, &result.FamilyID, &result.Title, &detail, &result.Score); err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		result.Detail = detail.String
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// booleanTerms builds the FULLTEXT boolean mode expression requiring every term, either whole or as a prefix.
+func booleanTerms(terms []string) string {
+	expression := make([]string, len(terms))
+	for i, term := range terms {
+		expression[i] = "+" + term + "*"
+	}
+
+	return strings.Join(expression, " ")
+}
+
+// optionalTerms builds the FULLTEXT boolean mode expression scoring the terms, whole or as a prefix, without
+// requiring any of them.
+func optionalTerms(terms []string) string {
+	expression := make([]string, len(terms))
+	for i, term := range terms {
+		expression[i] = term + "*"
+	}
+
+	return strings.Join(expression, " ")
+}
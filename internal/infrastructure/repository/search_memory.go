@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+const (
+	wordMatchScore   = 2
+	prefixMatchScore = 1
+)
+
+// searchDocument is an indexed user, child or school with the words of its searchable fields.
+type searchDocument struct {
+	result model.SearchResult
+	words  []string
+}
+
+// MemorySearchRepository is an in-memory search index with the same matching rules as SearchRepository.
+// It backs the search where there is no MySQL, such as the tests.
+type MemorySearchRepository struct {
+	mutex     sync.RWMutex
+	documents []searchDocument
+	companies map[uint][]string
+	guardians map[uint][]uint
+}
+
+func NewMemorySearchRepository() *MemorySearchRepository {
+	return &MemorySearchRepository{companies: map[uint][]string{}, guardians: map[uint][]uint{}}
+}
+
+var _ gateway.SearchRepository = (*MemorySearchRepository)(nil)
+
+// IndexUser adds a user to the index by its name, last name and email.
+func (r *MemorySearchRepository) IndexUser(user model.User) {
+	r.index(model.SearchResult{
+		Kind:     model.SearchKindUser,
+		ID:       user.ID,
+		FamilyID: user.ID,
+		Title:    user.Name + " " + user.LastName,
+		Detail:   user.Email,
+	}, user.Name, user.LastName, user.Email)
+}
+
+// IndexChild adds a child to the index by its name, last name and the name of its school.
+func (r *MemorySearchRepository) IndexChild(child model.Children) {
+	r.index(model.SearchResult{
+		Kind:     model.SearchKindChild,
+		ID:       child.ID,
+		FamilyID: child.ObserverUserID,
+		Title:    child.Name + " " + child.LastName,
+		Detail:   child.SchoolName,
+	}, child.Name, child.LastName, child.SchoolName)
+}
+
+// IndexSchool adds a school to the index by its name and city.
+func (r *MemorySearchRepository) IndexSchool(school model.School) {
+	r.index(model.SearchResult{
+		Kind:   model.SearchKindSchool,
+		ID:     school.ID,
+		Title:  school.Name,
+		Detail: school.City,
+	}, school.Name, school.City)
+}
+
+// LinkFamily records that the family of an observer user rides with a driver of the company.
+func (r *MemorySearchRepository) LinkFamily(familyID uint, companyName string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.companies[familyID] = append(r.companies[familyID], companyName)
+}
+
+// LinkGuardian records that an observer user is a guardian of a child of the family.
+func (r *MemorySearchRepository) LinkGuardian(familyID, guardianUserID uint) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.guardians[guardianUserID] = append(r.guardians[guardianUserID], familyID)
+}
+
+// Search obtains the documents matching every term of the query. A term matching a whole word scores more
+// than one matching only the beginning of a word.
+func (r *MemorySearchRepository) Search(query model.SearchQuery) ([]model.SearchResult, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	terms := query.Terms()
+	results := []model.SearchResult{}
+
+	for _, document := range r.documents {
+		if query.Companies != nil && !r.inScope(document.result, query.Companies) {
+			continue
+		}
+
+		score := matchScore(document.words, terms)
+		if score == 0 {
+			continue
+		}
+
+		result := document.result
+		result.Score = float64(score)
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+
+		if results[i].Kind != results[j].Kind {
+			return results[i].Kind < results[j].Kind
+		}
+
+		return results[i].ID < results[j].ID
+	})
+
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+
+	return results, nil
+}
+
+func (r *MemorySearchRepository) index(result model.SearchResult, fields ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.documents = append(r.documents, searchDocument{
+		result: result,
+		words:  model.SearchTerms(strings.Join(fields, " ")),
+	})
+}
+
+// inScope tells whether a company admin of the companies can see the result: every school, and the users and
+// children of the families riding with the companies, along with the guardians of those children.
+func (r *MemorySearchRepository) inScope(result model.SearchResult, companies []string) bool {
+	if result.Kind == model.SearchKindSchool || r.inCompanies(result.FamilyID, companies) {
+		return true
+	}
+
+	if result.Kind != model.SearchKindUser {
+		return false
+	}
+
+	for _, familyID := range r.guardians[result.ID] {
+		if r.inCompanies(familyID, companies) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *MemorySearchRepository) inCompanies(familyID uint, companies []string) bool {
+	for _, company := range r.companies[familyID] {
+		if contains(companies, company) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchScore adds the best score of each term over the words. It is zero when a term matches no word,
+// or when there are no terms.
+func matchScore(words []string, terms []string) int {
+	total := 0
+
+	for _, term := range terms {
+		best := 0
+		for _, word := range words {
+			switch {
+			case word == term:
+				best = wordMatchScore
+			case best == 0 && strings.HasPrefix(word, term):
+				best = prefixMatchScore
+			}
+		}
+
+		if best == 0 {
+			return 0
+		}
+
+		total += best
+	}
+
+	return total
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
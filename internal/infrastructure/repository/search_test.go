@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+var searchColumns = []string{"kind", "id", "family_id", "title", "detail", "score"}
+
+func TestSearchRepository(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	sr := NewSearchRepository(gdb, context.Background())
+
+	t.Run("Search requires every term as a prefix", func(t *testing.T) {
+		statement := fmt.Sprintf(statementSearch, fmt.Sprintf(statementSearchChildTerm, 0)+" AND "+fmt.Sprintf(statementSearchChildTerm, 1))
+		mock.ExpectQuery(positional(statement+statementSearchOrder)).
+			WithArgs("+martin* +gom*", "+martin* +gom*", "martin* gom*", "martin* gom*", "+martin*", "+martin*", "+gom*", "+gom*", "+martin* +gom*", "+martin* +gom*", 20).
+			WillReturnRows(sqlmock.NewRows(searchColumns).
+				AddRow("child", 3, 2, "Martín Gómez", "Escuela N° 1", 2.5).
+				AddRow("user", 2, 2, "Martina Gómez", nil, 1.2))
+
+		results, err := sr.Search(model.SearchQuery{Text: "Martín Góm", Limit: 20})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.SearchResult{
+			{Kind: model.SearchKindChild, ID: 3, FamilyID: 2, Title: "Martín Gómez", Detail: "Escuela N° 1", Score: 2.5},
+			{Kind: model.SearchKindUser, ID: 2, FamilyID: 2, Title: "Martina Gómez", Score: 1.2},
+		}, results)
+	})
+
+	t.Run("Search within companies", func(t *testing.T) {
+		statement := fmt.Sprintf(statementSearch, fmt.Sprintf(statementSearchChildTerm, 0)) + strings.ReplaceAll(statementSearchCompanies, "@companies", "(?,?)") + statementSearchOrder
+		mock.ExpectQuery(positional(statement)).
+			WithArgs("+perez*", "+perez*", "perez*", "perez*", "+perez*", "+perez*", "+perez*", "+perez*", "bus a", "bus b", "bus a", "bus b", 5).
+			WillReturnRows(sqlmock.NewRows(searchColumns))
+
+		results, err := sr.Search(model.SearchQuery{Text: "Pérez", Companies: []string{"bus a", "bus b"}, Limit: 5})
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMemorySearchRepository(t *testing.T) {
+	sr := NewMemorySearchRepository()
+	sr.IndexUser(model.User{ID: 2, Name: "José", LastName: "Núñez", Email: "jose@mail.com"})
+	sr.IndexUser(model.User{ID: 4, Name: "Joaquín", LastName: "Peña", Email: "joaquin@mail.com"})
+	sr.IndexUser(model.User{ID: 5, Name: "Ana", LastName: "Ruiz", Email: "ana@mail.com"})
+	sr.IndexChild(model.Children{ID: 3, Name: "Martín", LastName: "Núñez", SchoolName: "San José", ObserverUserID: 2})
+	sr.IndexSchool(model.School{ID: 1, Name: "Colegio San José", City: "Santa Fe"})
+	sr.LinkFamily(2, "bus a")
+	sr.LinkFamily(4, "bus b")
+	sr.LinkGuardian(2, 5)
+
+	t.Run("accents are ignored", func(t *testing.T) {
+		results, err := sr.Search(model.SearchQuery{Text: "NUNEZ", Limit: 20})
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.Equal(t, model.SearchKindChild, results[0].Kind)
+		assert.Equal(t, model.SearchKindUser, results[1].Kind)
+	})
+
+	t.Run("whole words rank above prefixes", func(t *testing.T) {
+		results, err := sr.Search(model.SearchQuery{Text: "jose", Limit: 20})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.SearchResult{
+			{Kind: model.SearchKindChild, ID: 3, FamilyID: 2, Title: "Martín Núñez", Detail: "San José", Score: 2},
+			{Kind: model.SearchKindSchool, ID: 1, Title: "Colegio San José", Detail: "Santa Fe", Score: 2},
+			{Kind: model.SearchKindUser, ID: 2, FamilyID: 2, Title: "José Núñez", Detail: "jose@mail.com", Score: 2},
+		}, results)
+
+		results, err = sr.Search(model.SearchQuery{Text: "jo", Limit: 20})
+		assert.NoError(t, err)
+		assert.Len(t, results, 4)
+	})
+
+	t.Run("children match the name of their school", func(t *testing.T) {
+		results, err := sr.Search(model.SearchQuery{Text: "martin san jose", Limit: 20})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, model.SearchKindChild, results[0].Kind)
+	})
+
+	t.Run("every term must match", func(t *testing.T) {
+		results, err := sr.Search(model.SearchQuery{Text: "martin pena", Limit: 20})
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("within companies", func(t *testing.T) {
+		results, err := sr.Search(model.SearchQuery{Text: "jo", Companies: []string{"bus b"}, Limit: 20})
+		assert.NoError(t, err)
+		assert.Equal(t, []model.SearchResult{
+			{Kind: model.SearchKindSchool, ID: 1, Title: "Colegio San José", Detail: "Santa Fe", Score: 1},
+			{Kind: model.SearchKindUser, ID: 4, FamilyID: 4, Title: "Joaquín Peña", Detail: "joaquin@mail.com", Score: 1},
+		}, results)
+	})
+
+	t.Run("within companies includes the guardians of their children", func(t *testing.T) {
+		results, err := sr.Search(model.SearchQuery{Text: "ruiz", Companies: []string{"bus a"}, Limit: 20})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+		assert.Equal(t, uint(5), results[0].ID)
+
+		results, err = sr.Search(model.SearchQuery{Text: "ruiz", Companies: []string{"bus b"}, Limit: 20})
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		results, err := sr.Search(model.SearchQuery{Text: "jo", Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+}
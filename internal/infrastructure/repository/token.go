@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	statementInsertToken  = "INSERT INTO UserTokens (id, user_id, purpose, expires_at) VALUES (@id, @user_id, @purpose, @expires_at)"
+	statementConsumeToken = "UPDATE UserTokens SET used_at = CURRENT_TIMESTAMP WHERE id = @id AND used_at IS NULL"
+	statementRevokeTokens = "UPDATE UserTokens SET used_at = CURRENT_TIMESTAMP WHERE user_id = @user_id AND purpose = @purpose AND used_at IS NULL"
+	timestampLayout       = "2006-01-02 15:04:05"
+)
+
+func NewTokenRepository(db *gorm.DB, ctx context.Context) gateway.TokenRepository {
+	return &TokenRepository{
+		DB:      db,
+		context: ctx,
+	}
+}
+
+// TokenRepository represents the repository for manage the single-use tokens sent to users.
+type TokenRepository struct {
+	DB      *gorm.DB
+	context context.Context
+}
+
+// Save persists an issued token.
+func (r TokenRepository) Save(token model.Token) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "TokenRepository.Save")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementInsertToken,
+		sql.Named("id", token.ID),
+		sql.Named("user_id", token.UserID),
+		sql.Named("purpose", token.Purpose),
+		sql.Named("expires_at", time.Unix(token.ExpiresAt, 0).UTC().Format(timestampLayout)),
+	).Error
+}
+
+// Consume marks a token as used. It returns false when the token does not exist or was already used.
+func (r TokenRepository) Consume(id string) (_ bool, err error) {
+	ctx, span := tracing.StartSpan(r.context, "TokenRepository.Consume")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	result := r.DB.Exec(statementConsumeToken, sql.Named("id", id))
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Revoke marks every pending token of a user for the given purpose as used.
+func (r TokenRepository) Revoke(userID uint, purpose string) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "TokenRepository.Revoke")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementRevokeTokens, sql.Named("user_id", userID), sql.Named("purpose", purpose)).Error
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+)
+
+const (
+	statementGetTwoFactor        = "SELECT user_id, secret, enabled, last_used_step FROM UserTwoFactor WHERE user_id = @user_id"
+	statementSaveTwoFactor       = "INSERT INTO UserTwoFactor (user_id, secret, enabled, last_used_step) VALUES (@user_id, @secret, @enabled, @last_used_step) ON DUPLICATE KEY UPDATE secret = VALUES(secret), enabled = VALUES(enabled)"
+	statementDeleteTwoFactor     = "DELETE FROM UserTwoFactor WHERE user_id = @user_id"
+	statementUseTwoFactorStep    = "UPDATE UserTwoFactor SET last_used_step = @step WHERE user_id = @user_id AND last_used_step < @step"
+	statementDeleteRecoveryCodes = "DELETE FROM UserRecoveryCodes WHERE user_id = @user_id"
+	statementInsertRecoveryCode  = "INSERT INTO UserRecoveryCodes (user_id, code_hash) VALUES (@user_id, @code_hash)"
+	statementUseRecoveryCode     = "UPDATE UserRecoveryCodes SET used_at = CURRENT_TIMESTAMP WHERE user_id = @user_id AND code_hash = @code_hash AND used_at IS NULL"
+	statementGetTwoFactorSecrets = "SELECT user_id, secret FROM UserTwoFactor WHERE user_id > @user_id ORDER BY user_id LIMIT @limit"
+	statementUpdateTwoFactorKey  = "UPDATE UserTwoFactor SET secret = @secret WHERE user_id = @user_id"
+	statementGetTwoFactorPolicy  = "SELECT company_name, required FROM CompanyTwoFactorPolicies WHERE company_name = @company_name"
+	statementSaveTwoFactorPolicy = "INSERT INTO CompanyTwoFactorPolicies (company_name, required) VALUES (@company_name, @required) ON DUPLICATE KEY UPDATE required = VALUES(required)"
+)
+
+// NewTwoFactorRepository creates the two-factor repository. The TOTP secrets are encrypted with cipher before being stored.
+func NewTwoFactorRepository(db *gorm.DB, ctx context.Context, cipher gateway.FieldCipher) gateway.TwoFactorRepository {
+	return &TwoFactorRepository{
+		DB:      db,
+		context: ctx,
+		cipher:  cipher,
+	}
+}
+
+// TwoFactorRepository represents the repository for manage the second factors of the users.
+type TwoFactorRepository struct {
+	DB      *gorm.DB
+	context context.Context
+	cipher  gateway.FieldCipher
+}
+
+// Get obtains the second factor of a user. It returns nil when the user has none.
+func (r TwoFactorRepository) Get(userID uint) (_ *model.TwoFactor, err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.Get")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var twoFactor model.TwoFactor
+
+	err = r.DB.
+		Raw(statementGetTwoFactor, sql.Named("user_id", userID)).
+		Row().
+		Scan(&twoFactor.UserID, &twoFactor.Secret, &twoFactor.Enabled, &twoFactor.LastUsedStep)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return nil, err
+	}
+
+	if twoFactor.Secret, err = r.cipher.Decrypt(twoFactor.Secret); err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error decrypting two-factor secret")
+		return nil, err
+	}
+
+	return &twoFactor, nil
+}
+
+// Save persists the second factor of a user, replacing the previous one. The last used step is kept,
+// since it only grows with time and UseStep is the one that updates it.
+func (r TwoFactorRepository) Save(twoFactor model.TwoFactor) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.Save")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	secret, err := r.cipher.Encrypt(twoFactor.Secret)
+	if err != nil {
+		return err
+	}
+
+	return r.DB.Exec(
+		statementSaveTwoFactor,
+		sql.Named("user_id", twoFactor.UserID),
+		sql.Named("secret", secret),
+		sql.Named("enabled", twoFactor.Enabled),
+		sql.Named("last_used_step", twoFactor.LastUsedStep),
+	).Error
+}
+
+// Delete removes the second factor of a user and its recovery codes.
+func (r TwoFactorRepository) Delete(userID uint) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.Delete")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(statementDeleteRecoveryCodes, sql.Named("user_id", userID)).Error; err != nil {
+			return err
+		}
+
+		return tx.Exec(statementDeleteTwoFactor, sql.Named("user_id", userID)).Error
+	})
+}
+
+// GetEncryptedSecrets obtains the stored TOTP secrets of the users with an ID greater than afterUserID, without
+// decrypting them.
+func (r TwoFactorRepository) GetEncryptedSecrets(afterUserID uint, limit int) (_ []model.EncryptedValue, err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.GetEncryptedSecrets")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	rows, err := r.DB.Raw(statementGetTwoFactorSecrets, sql.Named("user_id", afterUserID), sql.Named("limit", limit)).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	return scanEncryptedValues(r.context, rows)
+}
+
+// UpdateEncryptedSecret replaces the stored TOTP secret of a user.
+func (r TwoFactorRepository) UpdateEncryptedSecret(value model.EncryptedValue) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.UpdateEncryptedSecret")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(statementUpdateTwoFactorKey, sql.Named("secret", value.Ciphertext), sql.Named("user_id", value.ID)).Error
+}
+
+// UseStep records the TOTP step of an accepted code. It returns false when a code of that step,
+// or a later one, was already used.
+func (r TwoFactorRepository) UseStep(userID uint, step int64) (_ bool, err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.UseStep")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	result := r.DB.Exec(statementUseTwoFactorStep, sql.Named("user_id", userID), sql.Named("step", step))
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes replaces the recovery codes of a user with the given hashes.
+func (r TwoFactorRepository) ReplaceRecoveryCodes(userID uint, hashes []string) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.ReplaceRecoveryCodes")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(statementDeleteRecoveryCodes, sql.Named("user_id", userID)).Error; err != nil {
+			return err
+		}
+
+		for _, hash := range hashes {
+			err := tx.Exec(statementInsertRecoveryCode, sql.Named("user_id", userID), sql.Named("code_hash", hash)).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// UseRecoveryCode marks a recovery code as used. It returns false when the code does not exist or was already used.
+func (r TwoFactorRepository) UseRecoveryCode(userID uint, hash string) (_ bool, err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.UseRecoveryCode")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	result := r.DB.Exec(statementUseRecoveryCode, sql.Named("user_id", userID), sql.Named("code_hash", hash))
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// GetPolicy obtains the two-factor policy of a company. Companies without a policy do not require a second factor.
+func (r TwoFactorRepository) GetPolicy(companyName string) (_ *model.TwoFactorPolicy, err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.GetPolicy")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	policy := model.TwoFactorPolicy{CompanyName: companyName}
+
+	err = r.DB.
+		Raw(statementGetTwoFactorPolicy, sql.Named("company_name", companyName)).
+		Row().
+		Scan(&policy.CompanyName, &policy.Required)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return nil, err
+	}
+
+	return &policy, nil
+}
+
+// SavePolicy persists the two-factor policy of a company.
+func (r TwoFactorRepository) SavePolicy(policy model.TwoFactorPolicy) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "TwoFactorRepository.SavePolicy")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementSaveTwoFactorPolicy,
+		sql.Named("company_name", policy.CompanyName),
+		sql.Named("required", policy.Required),
+	).Error
+}
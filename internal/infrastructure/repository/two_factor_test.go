@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestTwoFactorRepository(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+		t.Fail()
+	}
+
+	tr := NewTwoFactorRepository(gdb, context.Background(), testCipher)
+
+	t.Run("Get not enrolled", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetTwoFactor)).WithArgs(1).WillReturnError(sql.ErrNoRows)
+
+		twoFactor, err := tr.Get(1)
+		assert.NoError(t, err)
+		assert.Nil(t, twoFactor)
+	})
+
+	t.Run("Save encrypts the secret and Get decrypts it", func(t *testing.T) {
+		mock.ExpectExec(positional(statementSaveTwoFactor)).
+			WithArgs(1, encryptedWith("SECRET"), true, 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := tr.Save(model.TwoFactor{UserID: 1, Secret: "SECRET", Enabled: true})
+		assert.NoError(t, err)
+
+		encrypted, _ := testCipher.Encrypt("SECRET")
+		mock.ExpectQuery(positional(statementGetTwoFactor)).WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "secret", "enabled", "last_used_step"}).AddRow(1, encrypted, true, 42))
+
+		twoFactor, err := tr.Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, &model.TwoFactor{UserID: 1, Secret: "SECRET", Enabled: true, LastUsedStep: 42}, twoFactor)
+	})
+
+	t.Run("UseStep already used", func(t *testing.T) {
+		mock.ExpectExec(positional(statementUseTwoFactorStep)).WithArgs(42, 1, 42).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		used, err := tr.UseStep(1, 42)
+		assert.NoError(t, err)
+		assert.False(t, used)
+	})
+
+	t.Run("ReplaceRecoveryCodes", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(positional(statementDeleteRecoveryCodes)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 10))
+		mock.ExpectExec(positional(statementInsertRecoveryCode)).WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(positional(statementInsertRecoveryCode)).WithArgs(1, "b").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		err := tr.ReplaceRecoveryCodes(1, []string{"a", "b"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("UseRecoveryCode", func(t *testing.T) {
+		mock.ExpectExec(positional(statementUseRecoveryCode)).WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		used, err := tr.UseRecoveryCode(1, "a")
+		assert.NoError(t, err)
+		assert.True(t, used)
+	})
+
+	t.Run("GetPolicy without policy", func(t *testing.T) {
+		mock.ExpectQuery(positional(statementGetTwoFactorPolicy)).WithArgs("bus").WillReturnError(sql.ErrNoRows)
+
+		policy, err := tr.GetPolicy("bus")
+		assert.NoError(t, err)
+		assert.Equal(t, &model.TwoFactorPolicy{CompanyName: "bus"}, policy)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -4,20 +4,57 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"gorm.io/gorm"
 	"net/http"
+	"runtime/debug"
+	"strings"
 	"sync"
 
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
-	log "github.com/sirupsen/logrus"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/tracing"
+	"github.com/go-sql-driver/mysql"
 )
 
-func NewUserRepository(db *gorm.DB, ctx context.Context) gateway.UserRepository {
+const (
+	userColumns                 = "id, name, last_name, id_number, username, password, email, enabled, type, created_at, updated_at, email_verified, token_version, pending_email"
+	statementFindUserByUsername = "SELECT " + userColumns + " FROM Users WHERE username = @username"
+	statementFindUserByEmail    = "SELECT " + userColumns + " FROM Users WHERE email = @email"
+	statementFindUserByIDNumber = "SELECT " + userColumns + " FROM Users WHERE id_number_index = @id_number_index"
+	statementGetIDNumbers       = "SELECT id, id_number, id_number_index FROM Users WHERE id > @id ORDER BY id LIMIT @limit"
+	statementUpdateIDNumber     = "UPDATE Users SET id_number = @id_number, id_number_index = @id_number_index WHERE id = @id"
+	statementGetUsers           = "SELECT " + userColumns + " FROM Users WHERE 1 = 1"
+	statementCountUsers         = "SELECT COUNT(*) FROM Users WHERE 1 = 1"
+	statementUpdateProfile      = "UPDATE Users SET name = @name, last_name = @last_name, updated_at = CURRENT_TIMESTAMP WHERE id = @id"
+	statementSetPendingEmail    = "UPDATE Users SET pending_email = @pending_email, updated_at = CURRENT_TIMESTAMP WHERE id = @id"
+	statementConfirmEmail       = "UPDATE Users SET email = pending_email, pending_email = '', email_verified = TRUE, updated_at = CURRENT_TIMESTAMP WHERE id = @id AND pending_email = @pending_email"
+	// An observer user sees its own children and the ones it is currently a guardian of, along with the drivers of
+	// their families.
+	statementGuardedChildren  = "SELECT cg.child_id FROM ChildGuardians AS cg WHERE cg.observer_user_id = @id AND cg.starts_at <= UNIX_TIMESTAMP() AND (cg.ends_at = 0 OR cg.ends_at > UNIX_TIMESTAMP())"
+	statementObserverChildren = "SELECT c.id, c.name, c.last_name, c.school_id, s.name AS school_name, c.school_start_time, c.school_end_time, c.observer_user_id, c.created_at, c.updated_at FROM Children AS c INNER JOIN Schools AS s ON s.id = c.school_id WHERE c.observer_user_id = @id OR c.id IN (" + statementGuardedChildren + ")"
+	statementObserverDrivers  = "SELECT DISTINCT u.id, u.name, u.last_name, u.id_number, odu.company_name, odu.privacy_key, sb.id AS school_bus_id, sb.license_plate, sb.model, sb.brand, sb.school_bus_license, sb.created_at, sb.updated_at FROM ObservedUsersObserverUsers AS oduoru INNER JOIN ObservedUsers AS odu INNER JOIN Users AS u INNER JOIN SchoolBuses AS sb ON odu.user_id = oduoru.observed_user_id AND u.id = odu.user_id AND odu.school_bus_id = sb.id WHERE oduoru.observer_user_id = @id OR oduoru.observer_user_id IN (SELECT c.observer_user_id FROM Children AS c WHERE c.id IN (" + statementGuardedChildren + "))"
+)
+
+// mysqlErrDuplicateEntry is the error number of MySQL for a value repeated in a unique index.
+const mysqlErrDuplicateEntry = 1062
+
+// userSortColumns are the columns GetUsers sorts by for each sort field of the query.
+var userSortColumns = map[string]string{
+	model.UserSortID:        "id",
+	model.UserSortName:      "name",
+	model.UserSortLastName:  "last_name",
+	model.UserSortCreatedAt: "created_at",
+}
+
+// NewUserRepository creates the user repository. The ID numbers are encrypted with cipher before being stored.
+func NewUserRepository(db *gorm.DB, ctx context.Context, cipher gateway.FieldCipher) gateway.UserRepository {
 	return &UserRepository{
 		DB:      db,
 		context: ctx,
+		cipher:  cipher,
 	}
 }
 
@@ -25,10 +62,15 @@ func NewUserRepository(db *gorm.DB, ctx context.Context) gateway.UserRepository
 type UserRepository struct {
 	DB      *gorm.DB
 	context context.Context
+	cipher  gateway.FieldCipher
 }
 
 // Get obtains a user using UserRepository by ID.
-func (r UserRepository) Get(id uint) (*model.User, error) {
+func (r UserRepository) Get(id uint) (_ *model.User, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.Get")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
 	var user model.User
 	result := r.DB.First(&user, id)
 
@@ -39,66 +81,357 @@ func (r UserRepository) Get(id uint) (*model.User, error) {
 		return nil, result.Error
 	}
 
+	if err := r.decrypt(&user); err != nil {
+		return nil, err
+	}
+
 	return &user, nil
 }
 
-// Save persists a user using UserRepository.
-func (r UserRepository) Save(user model.User) (*model.User, error) {
+// Save persists a user using UserRepository. The ID number is stored encrypted, along with its blind index.
+func (r UserRepository) Save(user model.User) (_ *model.User, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.Save")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	idNumber := user.IDNumber
+
+	encrypted, err := r.cipher.Encrypt(idNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	user.IDNumber = encrypted
+	user.IDNumberIndex = r.cipher.BlindIndex(idNumber)
+
 	result := r.DB.Model(&user).Create(&user)
+	user.IDNumber = idNumber
 
 	return &user, result.Error
 }
 
-// FindByUsername obtains a user using UserRepository by username.
-func (r UserRepository) FindByUsername(username string) (*model.User, error) {
-	var user model.User
+// FindByUsername obtains a user using UserRepository by username. It returns nil when no user has the username.
+func (r UserRepository) FindByUsername(username string) (_ *model.User, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.FindByUsername")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
 
-	err := r.DB.
-		Raw("SELECT * FROM USERS WHERE username = @username", sql.Named("username", username)).
-		Row().
-		Scan(&user.ID, &user.Name, &user.LastName, &user.IDNumber, &user.Username, &user.Password, &user.Email,
-			&user.Enabled, &user.Type, &user.CreatedAt, &user.UpdatedAt,
+	return r.findUser(statementFindUserByUsername, sql.Named("username", username))
+}
+
+// FindByEmail obtains a user using UserRepository by email. It returns nil when no user has the email.
+func (r UserRepository) FindByEmail(email string) (_ *model.User, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.FindByEmail")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.findUser(statementFindUserByEmail, sql.Named("email", email))
+}
+
+// FindByIDNumber obtains a user using UserRepository by the blind index of its ID number.
+// It returns nil when no user has the ID number.
+func (r UserRepository) FindByIDNumber(idNumber string) (_ *model.User, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.FindByIDNumber")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.findUser(statementFindUserByIDNumber, sql.Named("id_number_index", r.cipher.BlindIndex(idNumber)))
+}
+
+// GetEncryptedIDNumbers obtains the stored ID numbers of the users with an ID greater than afterID, without decrypting them.
+func (r UserRepository) GetEncryptedIDNumbers(afterID uint, limit int) (_ []model.EncryptedValue, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.GetEncryptedIDNumbers")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var values []model.EncryptedValue
+
+	rows, err := r.DB.Raw(statementGetIDNumbers, sql.Named("id", afterID), sql.Named("limit", limit)).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			value      model.EncryptedValue
+			blindIndex sql.NullString
 		)
 
+		if err = rows.Scan(&value.ID, &value.Ciphertext, &blindIndex); err != nil {
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		value.BlindIndex = blindIndex.String
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// UpdateEncryptedIDNumber replaces the stored ID number of a user and its blind index.
+func (r UserRepository) UpdateEncryptedIDNumber(value model.EncryptedValue) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.UpdateEncryptedIDNumber")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementUpdateIDNumber,
+		sql.Named("id_number", value.Ciphertext),
+		sql.Named("id_number_index", value.BlindIndex),
+		sql.Named("id", value.ID),
+	).Error
+}
+
+// scanEncryptedValues reads the rows of an encrypted field without a blind index, made of the ID of the row and
+// the stored value.
+func scanEncryptedValues(ctx context.Context, rows *sql.Rows) ([]model.EncryptedValue, error) {
+	defer rows.Close()
+
+	var values []model.EncryptedValue
+	for rows.Next() {
+		var value model.EncryptedValue
+		if err := rows.Scan(&value.ID, &value.Ciphertext); err != nil {
+			logging.FromContext(ctx).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+func (r UserRepository) findUser(statement string, args ...interface{}) (*model.User, error) {
+	user, err := scanUser(r.DB.Raw(statement, args...).Row())
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+
 	if err != nil {
-		log.Error("error row scan")
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
 		return nil, err
 	}
 
-	return &user, nil
+	if err = r.decrypt(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
 
-// GetUsers obtains users using UserRepository .
-func (r UserRepository) GetUsers(limit string, offset string) (*[]model.User, error) {
-	var user model.User
-	var users []model.User
+// decrypt replaces the stored ID number of the user with its plain value.
+func (r UserRepository) decrypt(user *model.User) error {
+	idNumber, err := r.cipher.Decrypt(user.IDNumber)
+	if err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error decrypting id number")
+		return err
+	}
+
+	user.IDNumber = idNumber
 
-	rows, err := r.DB.Raw(
-		"SELECT * FROM USERS LIMIT @limit OFFSET @offset",
-		sql.Named("limit", limit),
-		sql.Named("offset", offset),
-	).Rows()
+	return nil
+}
+
+// SetEmailVerified marks the email of a user as verified or pending of verification.
+func (r UserRepository) SetEmailVerified(id uint, verified bool) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.SetEmailVerified")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		"UPDATE Users SET email_verified = @email_verified, updated_at = CURRENT_TIMESTAMP WHERE id = @id",
+		sql.Named("email_verified", verified),
+		sql.Named("id", id),
+	).Error
+}
+
+// UpdatePassword replaces the password of a user and bumps its token version, revoking its access tokens.
+func (r UserRepository) UpdatePassword(id uint, password string) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.UpdatePassword")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		"UPDATE Users SET password = @password, token_version = token_version + 1, updated_at = CURRENT_TIMESTAMP WHERE id = @id",
+		sql.Named("password", password),
+		sql.Named("id", id),
+	).Error
+}
+
+// UpdateProfile replaces the name and last name of a user. The email changes through SetPendingEmail.
+func (r UserRepository) UpdateProfile(user model.User) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.UpdateProfile")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementUpdateProfile,
+		sql.Named("name", user.Name),
+		sql.Named("last_name", user.LastName),
+		sql.Named("id", user.ID),
+	).Error
+}
+
+// SetPendingEmail stores the new email of a user, which replaces the current one once ConfirmPendingEmail verifies it.
+func (r UserRepository) SetPendingEmail(id uint, email string) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.SetPendingEmail")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		statementSetPendingEmail,
+		sql.Named("pending_email", email),
+		sql.Named("id", id),
+	).Error
+}
+
+// ConfirmPendingEmail replaces the email of a user with its pending email, if it is still the given one, and marks
+// it as verified. It fails with web.ErrConflict when another user registered the email in the meantime.
+func (r UserRepository) ConfirmPendingEmail(id uint, email string) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.ConfirmPendingEmail")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	err = r.DB.Exec(
+		statementConfirmEmail,
+		sql.Named("pending_email", email),
+		sql.Named("id", id),
+	).Error
+
+	if isDuplicateKey(err) {
+		return fmt.Errorf("%w: email already in use", web.ErrConflict)
+	}
+
+	return err
+}
+
+// SetEnabled enables or disables a user.
+func (r UserRepository) SetEnabled(id uint, enabled bool) (err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.SetEnabled")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	return r.DB.Exec(
+		"UPDATE Users SET enabled = @enabled, updated_at = CURRENT_TIMESTAMP WHERE id = @id",
+		sql.Named("enabled", enabled),
+		sql.Named("id", id),
+	).Error
+}
+
+// GetUsers obtains a page of the users matching the query, sorted by the query field and then by ID.
+func (r UserRepository) GetUsers(query model.UserQuery) (_ []model.User, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.GetUsers")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	statement := strings.Builder{}
+	statement.WriteString(statementGetUsers)
+	filters, args := userFilters(query)
+	statement.WriteString(filters)
+
+	column := userSortColumns[query.Sort]
+	operator, direction := ">", "ASC"
+	if query.Descending {
+		operator, direction = "<", "DESC"
+	}
+
+	if query.Cursor != nil {
+		if column == "id" {
+			statement.WriteString(fmt.Sprintf(" AND id %s @cursor_id", operator))
+		} else {
+			statement.WriteString(fmt.Sprintf(" AND (%[1]s %[2]s @cursor_value OR (%[1]s = @cursor_value AND id %[2]s @cursor_id))", column, operator))
+			args = append(args, sql.Named("cursor_value", query.Cursor.Value))
+		}
+		args = append(args, sql.Named("cursor_id", query.Cursor.ID))
+	}
+
+	if column == "id" {
+		statement.WriteString(fmt.Sprintf(" ORDER BY id %s LIMIT @limit", direction))
+	} else {
+		statement.WriteString(fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s LIMIT @limit", column, direction))
+	}
+	args = append(args, sql.Named("limit", query.Limit))
+
+	rows, err := r.DB.Raw(statement.String(), args...).Rows()
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	users := []model.User{}
 	for rows.Next() {
-		err := rows.Scan(&user.ID, &user.Name, &user.LastName, &user.IDNumber, &user.Username, &user.Password, &user.Email)
+		var user model.User
+
+		err = rows.Scan(&user.ID, &user.Name, &user.LastName, &user.IDNumber, &user.Username, &user.Password, &user.Email,
+			&user.Enabled, &user.Type, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerified, &user.TokenVersion, &user.PendingEmail)
 		if err != nil {
-			log.Error("error rows scan")
-			return &users, err
+			logging.FromContext(r.context).WithError(err).Error("error rows scan")
+			return nil, err
+		}
+
+		if err = r.decrypt(&user); err != nil {
+			return nil, err
 		}
 
 		users = append(users, user)
 	}
 
-	return &users, nil
+	return users, nil
+}
+
+// CountUsers counts every user matching the filters of the query, regardless of its cursor and limit.
+func (r UserRepository) CountUsers(query model.UserQuery) (_ int, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.CountUsers")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	var total int
+
+	filters, args := userFilters(query)
+	if err := r.DB.Raw(statementCountUsers+filters, args...).Row().Scan(&total); err != nil {
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// userFilters returns the conditions applied by GetUsers and CountUsers for each filter of the query.
+func userFilters(query model.UserQuery) (string, []interface{}) {
+	statement := strings.Builder{}
+	var args []interface{}
+
+	filters := []struct {
+		name      string
+		condition string
+		value     interface{}
+		set       bool
+	}{
+		{"type", "type = @type", query.Type, query.Type != ""},
+		{"enabled", "enabled = @enabled", query.Enabled != nil && *query.Enabled, query.Enabled != nil},
+		{"company_name", "id IN (SELECT user_id FROM ObservedUsers WHERE company_name = @company_name)", query.CompanyName, query.CompanyName != ""},
+		{"companies", "id IN (SELECT user_id FROM ObservedUsers WHERE company_name IN @companies)", query.Companies, query.Companies != nil},
+	}
+
+	for _, filter := range filters {
+		if filter.set {
+			statement.WriteString(" AND " + filter.condition)
+			args = append(args, sql.Named(filter.name, filter.value))
+		}
+	}
+
+	return statement.String(), args
 }
 
 // GetObservedUser obtains a observedUser using UserRepository by user_id.
-func (r UserRepository) GetObservedUser(user *model.ObservedUser) (*model.IUser, error) {
-	err := r.DB.
+func (r UserRepository) GetObservedUser(user *model.ObservedUser) (_ *model.IUser, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.GetObservedUser")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
+	err = r.DB.
 		Raw(
 			"SELECT ou.user_id, ou.school_bus_id, ou.privacy_key, ou.company_name, sb.license_plate, sb.model, sb.brand, sb.school_bus_license, sb.created_at, sb.updated_at FROM ObservedUsers AS ou INNER JOIN SchoolBuses AS sb WHERE user_id = @user_id",
 			sql.Named("user_id", user.User.ID),
@@ -118,7 +451,7 @@ func (r UserRepository) GetObservedUser(user *model.ObservedUser) (*model.IUser,
 		)
 
 	if err != nil {
-		log.Error("error row scan")
+		logging.FromContext(r.context).WithError(err).Error("error row scan")
 		return nil, err
 	}
 
@@ -126,28 +459,30 @@ func (r UserRepository) GetObservedUser(user *model.ObservedUser) (*model.IUser,
 	return &U, nil
 }
 
-// GetObserverUser obtains a observerUser using UserRepository by user_id.
-func (r UserRepository) GetObserverUser(user *model.ObserverUser) (*model.IUser, error) {
+// GetObserverUser obtains a observerUser using UserRepository by user_id. Its children, including the ones it is a
+// guardian of, and observed users are queried in parallel, traced as children of its span.
+func (r UserRepository) GetObserverUser(user *model.ObserverUser) (_ *model.IUser, err error) {
+	ctx, span := tracing.StartSpan(r.context, "UserRepository.GetObserverUser")
+	defer func() { span.End(err) }()
+	r.DB = r.DB.WithContext(ctx)
+
 	var (
-		errChildren           error
-		errObservedUser       error
-		err                   error
-		statementChildren     = "SELECT c.id, c.name, c.last_name, c.school_name, c.school_start_time, c.school_end_time, c.observer_user_id, c.created_at, c.updated_at FROM ObserverUsers AS oru INNER JOIN Children AS c ON  oru.user_id = c.observer_user_id;"
-		statementObservedUser = "SELECT u.id, u.name, u.last_name, u.id_number, odu.company_name, odu.privacy_key, sb.id AS school_bus_id, sb.license_plate, sb.model, sb.brand, sb.school_bus_license, sb.created_at, sb.updated_at FROM ObserverUsers AS oru INNER JOIN ObservedUsers AS odu INNER JOIN ObservedUsersObserverUsers AS oduoru INNER JOIN Users AS u INNER JOIN SchoolBuses AS sb ON odu.user_id = oduoru.observed_user_id AND oru.user_id = oduoru.observer_user_id AND u.id = odu.user_id AND odu.school_bus_id = sb.id;"
-		children              []model.Children
-		child                 model.Children
-		observedUsers         []odUser
-		observedUser          odUser
-		u                     model.IUser
-		wg                    = &sync.WaitGroup{}
-		usersCompleted        = make(chan struct{}, 3)
-		chanErr               = make(chan error, 1)
+		errChildren     error
+		errObservedUser error
+		children        []model.Children
+		child           model.Children
+		observedUsers   []odUser
+		observedUser    odUser
+		u               model.IUser
+		wg              = &sync.WaitGroup{}
+		usersCompleted  = make(chan struct{}, 3)
+		chanErr         = make(chan error, 1)
 	)
 
 	wg.Add(1)
 	go func(wg *sync.WaitGroup) {
-		defer handleGoRoutinePanic(wg)
-		children, errChildren = scanRows(r, statementChildren, children, child)
+		defer handleGoRoutinePanic(ctx, wg)
+		children, errChildren = scanRows(r, statementObserverChildren, children, child, sql.Named("id", user.GetUserID()))
 		if errChildren != nil {
 			chanErr <- errChildren
 			return
@@ -156,8 +491,8 @@ func (r UserRepository) GetObserverUser(user *model.ObserverUser) (*model.IUser,
 
 	wg.Add(1)
 	go func(wg *sync.WaitGroup) {
-		defer handleGoRoutinePanic(wg)
-		observedUsers, errObservedUser = scanRows(r, statementObservedUser, observedUsers, observedUser)
+		defer handleGoRoutinePanic(ctx, wg)
+		observedUsers, errObservedUser = scanRows(r, statementObserverDrivers, observedUsers, observedUser, sql.Named("id", user.GetUserID()))
 		if errObservedUser != nil {
 			chanErr <- errObservedUser
 			return
@@ -168,7 +503,7 @@ func (r UserRepository) GetObserverUser(user *model.ObserverUser) (*model.IUser,
 		wg.Wait()
 
 		user.Children = children
-		user.ObservedUsers = mapToObservedUser(observedUsers)
+		user.ObservedUsers = mapToObservedUser(r.decryptObservedUsers(observedUsers))
 
 		u = model.NewObserverUser(*user)
 
@@ -183,13 +518,40 @@ func (r UserRepository) GetObserverUser(user *model.ObserverUser) (*model.IUser,
 	}
 }
 
-func scanRows[T allowScan](r UserRepository, statement string, list []T, object T) ([]T, error) {
+// decryptObservedUsers replaces the stored ID numbers with their plain values. A value that cannot be
+// decrypted is left empty instead of failing the whole observer user.
+func (r UserRepository) decryptObservedUsers(users []odUser) []odUser {
+	for i := range users {
+		idNumber, err := r.cipher.Decrypt(users[i].IDNumber)
+		if err != nil {
+			logging.FromContext(r.context).WithError(err).Errorf("error decrypting id number of user %d", users[i].ID)
+		}
+		users[i].IDNumber = idNumber
+	}
+
+	return users
+}
+
+func scanUser(row *sql.Row) (*model.User, error) {
+	var user model.User
+
+	err := row.Scan(&user.ID, &user.Name, &user.LastName, &user.IDNumber, &user.Username, &user.Password, &user.Email,
+		&user.Enabled, &user.Type, &user.CreatedAt, &user.UpdatedAt, &user.EmailVerified, &user.TokenVersion, &user.PendingEmail,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func scanRows[T allowScan](r UserRepository, statement string, list []T, object T, args ...interface{}) ([]T, error) {
 	rows, err := r.DB.
-		Raw(statement).
+		Raw(statement, args...).
 		Rows()
 
 	if err != nil {
-		log.Error("error in scan rows ")
+		logging.FromContext(r.context).WithError(err).Error("error in scan rows ")
 		return nil, err
 	}
 
@@ -206,9 +568,11 @@ func scanRows[T allowScan](r UserRepository, statement string, list []T, object
 	return list, err
 }
 
-func handleGoRoutinePanic(wg *sync.WaitGroup) {
+// handleGoRoutinePanic logs the panic of a query run in parallel, with its stack and the request of ctx, and
+// marks the query done.
+func handleGoRoutinePanic(ctx context.Context, wg *sync.WaitGroup) {
 	if r := recover(); r != nil {
-		log.Errorf("%v", r)
+		logging.FromContext(ctx).WithField("stack", string(debug.Stack())).Errorf("panic: %v", r)
 	}
 
 	wg.Done()
@@ -258,3 +622,10 @@ type (
 		UpdatedAt        string `json:"updated_at"`
 	}
 )
+
+// isDuplicateKey tells whether a statement failed because it would repeat the value of a unique index.
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
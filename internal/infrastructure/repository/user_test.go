@@ -1,16 +1,24 @@
 package repository
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
 	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/logging"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/service/privacy"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"regexp"
+	"sync"
 	"testing"
 	"time"
 )
@@ -29,6 +37,31 @@ var u = model.User{
 	UpdatedAt: "2022-12-10 17:49:30",
 }
 
+var testCipher = newTestCipher()
+
+func newTestCipher() gateway.FieldCipher {
+	provider, err := privacy.NewKeyRing("test", map[string][]byte{"test": bytes.Repeat([]byte{1}, 32)}, bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when creating the test cipher", err)
+	}
+
+	return privacy.NewEnvelopeCipher(provider)
+}
+
+// encryptedWith matches a value encrypted by the cipher from the given plain text.
+type encryptedWith string
+
+func (e encryptedWith) Match(v driver.Value) bool {
+	value, ok := v.(string)
+	if !ok {
+		return false
+	}
+
+	plaintext, err := testCipher.Decrypt(value)
+
+	return err == nil && value != string(e) && plaintext == string(e)
+}
+
 func NewMock() (*sql.DB, sqlmock.Sqlmock) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -38,6 +71,11 @@ func NewMock() (*sql.DB, sqlmock.Sqlmock) {
 	return db, mock
 }
 
+// positional converts a statement with named parameters into the one gorm sends to the driver.
+func positional(statement string) string {
+	return regexp.QuoteMeta(regexp.MustCompile(`@[a-z_0-9]+`).ReplaceAllString(statement, "?"))
+}
+
 func TestGetUser(t *testing.T) {
 	db, mock := NewMock()
 	defer db.Close()
@@ -48,7 +86,7 @@ func TestGetUser(t *testing.T) {
 		t.Fail()
 	}
 
-	ur := NewUserRepository(gdb, context.Background())
+	ur := NewUserRepository(gdb, context.Background(), testCipher)
 
 	query := "SELECT * FROM `users` WHERE `users`.`id` = ? ORDER BY `users`.`id` LIMIT 1"
 
@@ -92,15 +130,15 @@ func TestSaveUser(t *testing.T) {
 		log.Error("error opening database connection")
 	}
 
-	ur := NewUserRepository(gdb, context.Background())
+	ur := NewUserRepository(gdb, context.Background(), testCipher)
 
-	query := "INSERT INTO `users` (`name`,`last_name`,`id_number`,`username`,`password`,`email`,`enabled`,`type`,`created_at`,`updated_at`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?)" //"INSERT INTO `users` VALUES `users`\\.`id` = \\?"
+	query := "INSERT INTO `users` (`name`,`last_name`,`id_number`,`username`,`password`,`email`,`enabled`,`type`,`created_at`,`updated_at`,`id_number_index`,`id`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?)" //"INSERT INTO `users` VALUES `users`\\.`id` = \\?"
 
 	t.Run("SaveUser successful", func(t *testing.T) {
 		mock.ExpectBegin()
 		mock.
 			ExpectExec(regexp.QuoteMeta(query)).
-			WithArgs(u.Name, u.LastName, u.IDNumber, u.Username, u.Password, u.Email, u.Enabled, u.Type, u.CreatedAt, u.UpdatedAt, u.ID).
+			WithArgs(u.Name, u.LastName, encryptedWith(u.IDNumber), u.Username, u.Password, u.Email, u.Enabled, u.Type, u.CreatedAt, u.UpdatedAt, testCipher.BlindIndex(u.IDNumber), u.ID).
 			WillReturnResult(sqlmock.NewResult(int64(1), 1))
 		mock.ExpectCommit()
 
@@ -108,6 +146,7 @@ func TestSaveUser(t *testing.T) {
 		assert.NotNil(t, user)
 		assert.NoError(t, err)
 		assert.Equal(t, u.ID, user.ID)
+		assert.Equal(t, u.IDNumber, user.IDNumber)
 	})
 
 }
@@ -121,13 +160,13 @@ func TestFindByUsername(t *testing.T) {
 		log.Error("error opening database connection")
 	}
 
-	ur := NewUserRepository(gdb, context.Background())
+	ur := NewUserRepository(gdb, context.Background(), testCipher)
 
-	query := "SELECT * FROM USERS WHERE username = ?"
+	query := "SELECT id, name, last_name, id_number, username, password, email, enabled, type, created_at, updated_at, email_verified, token_version, pending_email FROM Users WHERE username = ?"
 
 	t.Run("FindByUsername successful", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "name", "lastname", "id_number", "username", "password", "email", "enabled", "type", "created_at", "updated_at"}).
-			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password, u.Email, u.Enabled, u.Type, u.CreatedAt, u.UpdatedAt)
+		rows := sqlmock.NewRows([]string{"id", "name", "lastname", "id_number", "username", "password", "email", "enabled", "type", "created_at", "updated_at", "email_verified", "token_version", "pending_email"}).
+			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password, u.Email, u.Enabled, u.Type, u.CreatedAt, u.UpdatedAt, u.EmailVerified, u.TokenVersion, u.PendingEmail)
 		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs(u.Username).WillReturnRows(rows)
 
 		user, err := ur.FindByUsername(u.Username)
@@ -166,6 +205,102 @@ func TestFindByUsername(t *testing.T) {
 
 }
 
+func TestFindByIDNumber(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+	}
+
+	ur := NewUserRepository(gdb, context.Background(), testCipher)
+
+	query := positional(statementFindUserByIDNumber)
+	columns := []string{"id", "name", "lastname", "id_number", "username", "password", "email", "enabled", "type", "created_at", "updated_at", "email_verified", "token_version", "pending_email"}
+
+	t.Run("FindByIDNumber successful", func(t *testing.T) {
+		encrypted, _ := testCipher.Encrypt(u.IDNumber)
+		rows := sqlmock.NewRows(columns).
+			AddRow(u.ID, u.Name, u.LastName, encrypted, u.Username, u.Password, u.Email, u.Enabled, u.Type, u.CreatedAt, u.UpdatedAt, u.EmailVerified, u.TokenVersion, u.PendingEmail)
+		mock.ExpectQuery(query).WithArgs(testCipher.BlindIndex("11.100.011")).WillReturnRows(rows)
+
+		user, err := ur.FindByIDNumber("11.100.011")
+		assert.NoError(t, err)
+		assert.Equal(t, u.IDNumber, user.IDNumber)
+	})
+
+	t.Run("FindByIDNumber not found", func(t *testing.T) {
+		mock.ExpectQuery(query).WithArgs(testCipher.BlindIndex(u.IDNumber)).WillReturnRows(sqlmock.NewRows(columns))
+
+		user, err := ur.FindByIDNumber(u.IDNumber)
+		assert.NoError(t, err)
+		assert.Nil(t, user)
+	})
+
+	t.Run("FindByIDNumber undecryptable value", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(u.ID, u.Name, u.LastName, "v1:unknown:a:b", u.Username, u.Password, u.Email, u.Enabled, u.Type, u.CreatedAt, u.UpdatedAt, u.EmailVerified, u.TokenVersion, u.PendingEmail)
+		mock.ExpectQuery(query).WithArgs(testCipher.BlindIndex(u.IDNumber)).WillReturnRows(rows)
+
+		user, err := ur.FindByIDNumber(u.IDNumber)
+		assert.Error(t, err)
+		assert.Nil(t, user)
+	})
+}
+
+func TestUpdateProfile(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{}) // open gorm db
+	if err != nil {
+		log.Error("error opening database connection")
+	}
+
+	ur := NewUserRepository(gdb, context.Background(), testCipher)
+
+	t.Run("UpdateProfile successful", func(t *testing.T) {
+		mock.ExpectExec(positional(statementUpdateProfile)).
+			WithArgs("Juan Pablo", u.LastName, u.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		updated := u
+		updated.Name = "Juan Pablo"
+
+		assert.NoError(t, ur.UpdateProfile(updated))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("SetPendingEmail successful", func(t *testing.T) {
+		mock.ExpectExec(positional(statementSetPendingEmail)).
+			WithArgs("new@mail.com", u.ID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, ur.SetPendingEmail(u.ID, "new@mail.com"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ConfirmPendingEmail successful", func(t *testing.T) {
+		mock.ExpectExec(positional(statementConfirmEmail)).
+			WithArgs(u.ID, "new@mail.com").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, ur.ConfirmPendingEmail(u.ID, "new@mail.com"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ConfirmPendingEmail email already in use", func(t *testing.T) {
+		mock.ExpectExec(positional(statementConfirmEmail)).
+			WithArgs(u.ID, "new@mail.com").
+			WillReturnError(&mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry 'new@mail.com' for key 'email_UNIQUE'"})
+
+		err := ur.ConfirmPendingEmail(u.ID, "new@mail.com")
+		assert.True(t, errors.Is(err, web.ErrConflict))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
 func TestGetUsers(t *testing.T) {
 	db, mock := NewMock()
 	defer db.Close()
@@ -176,45 +311,75 @@ func TestGetUsers(t *testing.T) {
 		t.Fail()
 	}
 
-	ur := NewUserRepository(gdb, context.Background())
+	ur := NewUserRepository(gdb, context.Background(), testCipher)
 
-	query := "SELECT * FROM USERS LIMIT ? OFFSET ?"
+	columns := []string{"id", "name", "lastname", "id_number", "username", "password", "email", "enabled", "type", "created_at", "updated_at", "email_verified", "token_version", "pending_email"}
+	encrypted, _ := testCipher.Encrypt(u.IDNumber)
 
-	t.Run("GetUsers successful", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "name", "lastname", "numberID", "username", "password", "email"}).
-			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password, u.Email).
-			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password, u.Email).
-			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password, u.Email).
-			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password, u.Email)
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("1", "4").WillReturnRows(rows)
+	t.Run("GetUsers first page by id", func(t *testing.T) {
+		rows := sqlmock.NewRows(columns).
+			AddRow(u.ID, u.Name, u.LastName, encrypted, u.Username, u.Password, u.Email, u.Enabled, u.Type, u.CreatedAt, u.UpdatedAt, u.EmailVerified, u.TokenVersion, u.PendingEmail).
+			AddRow(2, u.Name, u.LastName, encrypted, "other", u.Password, "other@user.com", false, u.Type, u.CreatedAt, u.UpdatedAt, true, 0, "")
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT " + userColumns + " FROM Users WHERE 1 = 1 ORDER BY id ASC LIMIT ?")).
+			WithArgs(20).WillReturnRows(rows)
 
-		users, err := ur.GetUsers("1", "4")
-		assert.NotNil(t, users)
+		users, err := ur.GetUsers(model.UserQuery{Sort: model.UserSortID, Limit: 20})
 		assert.NoError(t, err)
-		assert.Equal(t, 4, len(*users))
+		assert.Equal(t, 2, len(users))
+		assert.Equal(t, u, users[0])
+		assert.Equal(t, "other@user.com", users[1].Email)
+		assert.True(t, users[1].EmailVerified)
 	})
 
-	t.Run("GetUsers users scan error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("1", "4").WillReturnError(web.ErrInternalServerError)
+	t.Run("GetUsers filtered after a cursor by descending name", func(t *testing.T) {
+		enabled := true
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT "+userColumns+" FROM Users WHERE 1 = 1 AND type = ? AND enabled = ? AND id IN (SELECT user_id FROM ObservedUsers WHERE company_name IN (?,?)) AND (name < ? OR (name = ? AND id < ?)) ORDER BY name DESC, id DESC LIMIT ?")).
+			WithArgs("observed", true, "bus a", "bus b", "Maria", "Maria", 7, 10).
+			WillReturnRows(sqlmock.NewRows(columns))
+
+		users, err := ur.GetUsers(model.UserQuery{
+			Type:       "observed",
+			Enabled:    &enabled,
+			Companies:  []string{"bus a", "bus b"},
+			Sort:       model.UserSortName,
+			Descending: true,
+			Cursor:     &model.UserCursor{Value: "Maria", ID: 7},
+			Limit:      10,
+		})
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+	})
+
+	t.Run("GetUsers query error", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT " + userColumns + " FROM Users WHERE 1 = 1 ORDER BY id ASC LIMIT ?")).
+			WithArgs(20).WillReturnError(web.ErrInternalServerError)
 
-		users, err := ur.GetUsers("1", "4")
+		users, err := ur.GetUsers(model.UserQuery{Sort: model.UserSortID, Limit: 20})
 		assert.Nil(t, users)
 		assert.Error(t, err)
 	})
 
-	t.Run("GetUsers users scan error", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "name", "lastname", "numberID", "username", "password"}).
-			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password).
-			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password).
-			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password).
-			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password)
-		mock.ExpectQuery(regexp.QuoteMeta(query)).WithArgs("1", "4").WillReturnRows(rows)
+	t.Run("GetUsers scan error", func(t *testing.T) {
+		rows := sqlmock.NewRows([]string{"id", "name", "lastname", "numberID", "username", "password", "email"}).
+			AddRow(u.ID, u.Name, u.LastName, u.IDNumber, u.Username, u.Password, u.Email)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT " + userColumns + " FROM Users WHERE 1 = 1 ORDER BY id ASC LIMIT ?")).
+			WithArgs(20).WillReturnRows(rows)
 
-		users, err := ur.GetUsers("1", "4")
-		assert.Nil(t, *users)
+		users, err := ur.GetUsers(model.UserQuery{Sort: model.UserSortID, Limit: 20})
+		assert.Nil(t, users)
 		assert.Error(t, err)
 	})
 
+	t.Run("CountUsers ignores the cursor", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(statementCountUsers + " AND id IN (SELECT user_id FROM ObservedUsers WHERE company_name = ?)")).
+			WithArgs("bus a").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+		total, err := ur.CountUsers(model.UserQuery{CompanyName: "bus a", Cursor: &model.UserCursor{ID: 7}})
+		assert.NoError(t, err)
+		assert.Equal(t, 42, total)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestGetObserverUser(t *testing.T) {
@@ -240,6 +405,7 @@ func TestGetObserverUser(t *testing.T) {
 					ObserverUserID:  2,
 					Name:            "Pilar",
 					LastName:        "Dominguez",
+					SchoolID:        1,
 					SchoolName:      "La Salle",
 					SchoolStartTime: "08:00:00",
 					SchoolEndTime:   "12:00:00",
@@ -264,11 +430,9 @@ func TestGetObserverUser(t *testing.T) {
 				},
 			},
 		}
-		expectedObserverUser  = model.NewObserverUser(expected)
-		observerUser          *model.IUser
-		statementChildren     = "SELECT c.id, c.name, c.last_name, c.school_name, c.school_start_time, c.school_end_time, c.observer_user_id, c.created_at, c.updated_at FROM ObserverUsers AS oru INNER JOIN Children AS c ON  oru.user_id = c.observer_user_id;"
-		statementObservedUser = "SELECT u.id, u.name, u.last_name, u.id_number, odu.company_name, odu.privacy_key, sb.id AS school_bus_id, sb.license_plate, sb.model, sb.brand, sb.school_bus_license, sb.created_at, sb.updated_at FROM ObserverUsers AS oru INNER JOIN ObservedUsers AS odu INNER JOIN ObservedUsersObserverUsers AS oduoru INNER JOIN Users AS u INNER JOIN SchoolBuses AS sb ON odu.user_id = oduoru.observed_user_id AND oru.user_id = oduoru.observer_user_id AND u.id = odu.user_id AND odu.school_bus_id = sb.id;"
-		user                  = model.ObserverUser{User: expected.User}
+		expectedObserverUser = model.NewObserverUser(expected)
+		observerUser         *model.IUser
+		user                 = model.ObserverUser{User: expected.User}
 	)
 	db, mock := NewMock()
 	defer db.Close()
@@ -279,16 +443,16 @@ func TestGetObserverUser(t *testing.T) {
 		t.Fail()
 	}
 
-	ur := NewUserRepository(gdb, context.Background())
+	ur := NewUserRepository(gdb, context.Background(), testCipher)
 
 	t.Run("GetObserverUser children scan error", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "name", "last_name", "school_name", "school_start_time", "school_end_time", "observer_user_id", "created_at", "updated_at"}).
-			AddRow(expected.Children[0].ID, expected.Children[0].Name, expected.Children[0].LastName, expected.Children[0].SchoolName, expected.Children[0].SchoolStartTime, expected.Children[0].SchoolEndTime, expected.Children[0].ObserverUserID, expected.Children[0].CreatedAt, expected.Children[0].UpdatedAt)
-		mock.ExpectQuery(statementChildren).WillReturnError(web.ErrInternalServerError)
+		rows := sqlmock.NewRows([]string{"id", "name", "last_name", "school_id", "school_name", "school_start_time", "school_end_time", "observer_user_id", "created_at", "updated_at"}).
+			AddRow(expected.Children[0].ID, expected.Children[0].Name, expected.Children[0].LastName, expected.Children[0].SchoolID, expected.Children[0].SchoolName, expected.Children[0].SchoolStartTime, expected.Children[0].SchoolEndTime, expected.Children[0].ObserverUserID, expected.Children[0].CreatedAt, expected.Children[0].UpdatedAt)
+		mock.ExpectQuery(positional(statementObserverChildren)).WithArgs(2, 2).WillReturnError(web.ErrInternalServerError)
 
 		rows = sqlmock.NewRows([]string{"id", "name", "last_name", "id_number", "company_name", "privacy_key", "school_bus_id", "license_plate", "model", "brand", "school_bus_license", "created_at", "updated_at"}).
 			AddRow(expected.ObservedUsers[0].GetUserID(), expected.ObservedUsers[0].GetName(), expected.ObservedUsers[0].GetLastName(), expected.ObservedUsers[0].GetIDNumber(), expected.ObservedUsers[0].CompanyName, expected.ObservedUsers[0].PrivacyKey, expected.ObservedUsers[0].SchoolBus.ID, expected.ObservedUsers[0].SchoolBus.LicensePlate, expected.ObservedUsers[0].SchoolBus.Model, expected.ObservedUsers[0].SchoolBus.Brand, expected.ObservedUsers[0].SchoolBus.SchoolBusLicense, expected.ObservedUsers[0].SchoolBus.CreatedAt, expected.ObservedUsers[0].SchoolBus.UpdatedAt)
-		mock.ExpectQuery(statementObservedUser).WillReturnRows(rows)
+		mock.ExpectQuery(positional(statementObserverDrivers)).WithArgs(2, 2).WillReturnRows(rows)
 
 		observerUser, err = ur.GetObserverUser(&user)
 
@@ -296,13 +460,13 @@ func TestGetObserverUser(t *testing.T) {
 	})
 
 	t.Run("GetObserverUser observed user scan error", func(t *testing.T) {
-		rows := sqlmock.NewRows([]string{"id", "name", "last_name", "school_name", "school_start_time", "school_end_time", "observer_user_id", "created_at", "updated_at"}).
-			AddRow(expected.Children[0].ID, expected.Children[0].Name, expected.Children[0].LastName, expected.Children[0].SchoolName, expected.Children[0].SchoolStartTime, expected.Children[0].SchoolEndTime, expected.Children[0].ObserverUserID, expected.Children[0].CreatedAt, expected.Children[0].UpdatedAt)
-		mock.ExpectQuery(statementChildren).WillReturnRows(rows)
+		rows := sqlmock.NewRows([]string{"id", "name", "last_name", "school_id", "school_name", "school_start_time", "school_end_time", "observer_user_id", "created_at", "updated_at"}).
+			AddRow(expected.Children[0].ID, expected.Children[0].Name, expected.Children[0].LastName, expected.Children[0].SchoolID, expected.Children[0].SchoolName, expected.Children[0].SchoolStartTime, expected.Children[0].SchoolEndTime, expected.Children[0].ObserverUserID, expected.Children[0].CreatedAt, expected.Children[0].UpdatedAt)
+		mock.ExpectQuery(positional(statementObserverChildren)).WithArgs(2, 2).WillReturnRows(rows)
 
 		rows = sqlmock.NewRows([]string{"id", "name", "last_name", "id_number", "company_name", "privacy_key", "school_bus_id", "license_plate", "model", "brand", "school_bus_license", "created_at", "updated_at"}).
 			AddRow(expected.ObservedUsers[0].GetUserID(), expected.ObservedUsers[0].GetName(), expected.ObservedUsers[0].GetLastName(), expected.ObservedUsers[0].GetIDNumber(), expected.ObservedUsers[0].CompanyName, expected.ObservedUsers[0].PrivacyKey, expected.ObservedUsers[0].SchoolBus.ID, expected.ObservedUsers[0].SchoolBus.LicensePlate, expected.ObservedUsers[0].SchoolBus.Model, expected.ObservedUsers[0].SchoolBus.Brand, expected.ObservedUsers[0].SchoolBus.SchoolBusLicense, expected.ObservedUsers[0].SchoolBus.CreatedAt, expected.ObservedUsers[0].SchoolBus.UpdatedAt)
-		mock.ExpectQuery(statementObservedUser).WillReturnError(web.ErrInternalServerError)
+		mock.ExpectQuery(positional(statementObserverDrivers)).WithArgs(2, 2).WillReturnError(web.ErrInternalServerError)
 
 		observerUser, err = ur.GetObserverUser(&user)
 
@@ -319,18 +483,18 @@ func TestGetObserverUser(t *testing.T) {
 			t.Fail()
 		}
 
-		urWithGoRoutine := NewUserRepository(gdbWithGoRoutine, context.Background())
+		urWithGoRoutine := NewUserRepository(gdbWithGoRoutine, context.Background(), testCipher)
 
 		// note this line is important for unordered expectation matching
 		mockWithGoRoutine.MatchExpectationsInOrder(false)
 
-		rows := sqlmock.NewRows([]string{"id", "name", "last_name", "school_name", "school_start_time", "school_end_time", "observer_user_id", "created_at", "updated_at"}).
-			AddRow(expected.Children[0].ID, expected.Children[0].Name, expected.Children[0].LastName, expected.Children[0].SchoolName, expected.Children[0].SchoolStartTime, expected.Children[0].SchoolEndTime, expected.Children[0].ObserverUserID, expected.Children[0].CreatedAt, expected.Children[0].UpdatedAt)
-		mockWithGoRoutine.ExpectQuery(statementChildren).WillReturnRows(rows)
+		rows := sqlmock.NewRows([]string{"id", "name", "last_name", "school_id", "school_name", "school_start_time", "school_end_time", "observer_user_id", "created_at", "updated_at"}).
+			AddRow(expected.Children[0].ID, expected.Children[0].Name, expected.Children[0].LastName, expected.Children[0].SchoolID, expected.Children[0].SchoolName, expected.Children[0].SchoolStartTime, expected.Children[0].SchoolEndTime, expected.Children[0].ObserverUserID, expected.Children[0].CreatedAt, expected.Children[0].UpdatedAt)
+		mockWithGoRoutine.ExpectQuery(positional(statementObserverChildren)).WithArgs(2, 2).WillReturnRows(rows)
 
 		rows = sqlmock.NewRows([]string{"id", "name", "last_name", "id_number", "company_name", "privacy_key", "school_bus_id", "license_plate", "model", "brand", "school_bus_license", "created_at", "updated_at"}).
 			AddRow(expected.ObservedUsers[0].GetUserID(), expected.ObservedUsers[0].GetName(), expected.ObservedUsers[0].GetLastName(), expected.ObservedUsers[0].GetIDNumber(), expected.ObservedUsers[0].CompanyName, expected.ObservedUsers[0].PrivacyKey, expected.ObservedUsers[0].SchoolBus.ID, expected.ObservedUsers[0].SchoolBus.LicensePlate, expected.ObservedUsers[0].SchoolBus.Model, expected.ObservedUsers[0].SchoolBus.Brand, expected.ObservedUsers[0].SchoolBus.SchoolBusLicense, expected.ObservedUsers[0].SchoolBus.CreatedAt, expected.ObservedUsers[0].SchoolBus.UpdatedAt)
-		mockWithGoRoutine.ExpectQuery(statementObservedUser).WillReturnRows(rows)
+		mockWithGoRoutine.ExpectQuery(positional(statementObserverDrivers)).WithArgs(2, 2).WillReturnRows(rows)
 
 		observerUser, err = urWithGoRoutine.GetObserverUser(&user)
 		time.Sleep(500 * time.Millisecond)
@@ -375,7 +539,7 @@ func TestGetObservedUser(t *testing.T) {
 		t.Fail()
 	}
 
-	ur := NewUserRepository(gdb, context.Background())
+	ur := NewUserRepository(gdb, context.Background(), testCipher)
 
 	t.Run("GetObservedUser successful", func(t *testing.T) {
 
@@ -397,3 +561,22 @@ func TestGetObservedUser(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 }
+
+func TestHandleGoRoutinePanic(t *testing.T) {
+	var output bytes.Buffer
+	logger := log.New()
+	logger.SetOutput(&output)
+	ctx := logging.NewContext(context.Background(), logger.WithField(logging.FieldRequestID, "abc"))
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer handleGoRoutinePanic(ctx, wg)
+		panic("boom")
+	}()
+	wg.Wait()
+
+	assert.Contains(t, output.String(), `msg="panic: boom"`)
+	assert.Contains(t, output.String(), "request_id=abc")
+	assert.Contains(t, output.String(), "handleGoRoutinePanic")
+}
@@ -0,0 +1,120 @@
+// Package certificate serves the TLS certificate of the web server from PEM files, reloading it when they change
+// so a renewed certificate is used without restarting.
+package certificate
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Reloader holds the certificate loaded from a certificate chain file and a private key file.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mutex       sync.RWMutex
+	certificate *tls.Certificate
+	modified    time.Time
+}
+
+// NewReloader loads the certificate from the files.
+func NewReloader(certFile string, keyFile string) (*Reloader, error) {
+	reloader := &Reloader{certFile: certFile, keyFile: keyFile}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+
+	return reloader, nil
+}
+
+// GetCertificate returns the current certificate, to be used as tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.certificate, nil
+}
+
+// Reload loads the files again. When they are invalid, such as while they are being replaced, the current
+// certificate is kept.
+func (r *Reloader) Reload() error {
+	modified, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("couldn't load the certificate %s: %w", r.certFile, err)
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.certificate, r.modified = &certificate, modified
+
+	return nil
+}
+
+// Watch checks the files every interval until the context is done, reloading them when either was modified.
+// Files are polled rather than watched, so replacing them through a symbolic link, as mounted secrets do, is
+// noticed too.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+
+			if err := r.Reload(); err != nil {
+				log.Error("certificate could not be reloaded. ", err)
+				continue
+			}
+
+			log.Info("certificate reloaded from ", r.certFile)
+		}
+	}
+}
+
+func (r *Reloader) changed() bool {
+	modified, err := r.lastModified()
+	if err != nil {
+		log.Error("certificate files could not be checked. ", err)
+		return false
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return !modified.Equal(r.modified)
+}
+
+// lastModified returns the latest modification time of the files.
+func (r *Reloader) lastModified() (time.Time, error) {
+	var latest time.Time
+
+	for _, path := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("couldn't read the certificate file: %w", err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,98 @@
+package certificate
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeCertificate writes a self-signed certificate for the common name, modified at the given time.
+func writeCertificate(t *testing.T, certFile string, keyFile string, commonName string, modified time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	assert.NoError(t, os.Chtimes(certFile, modified, modified))
+	assert.NoError(t, os.Chtimes(keyFile, modified, modified))
+}
+
+func commonName(t *testing.T, reloader *Reloader) string {
+	certificate, err := reloader.GetCertificate(&tls.ClientHelloInfo{})
+	assert.NoError(t, err)
+
+	parsed, err := x509.ParseCertificate(certificate.Certificate[0])
+	assert.NoError(t, err)
+
+	return parsed.Subject.CommonName
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, "old.dondeestan.app", time.Now().Add(-time.Minute))
+
+	reloader, err := NewReloader(certFile, keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "old.dondeestan.app", commonName(t, reloader))
+	assert.False(t, reloader.changed())
+
+	writeCertificate(t, certFile, keyFile, "new.dondeestan.app", time.Now())
+	assert.True(t, reloader.changed())
+	assert.NoError(t, reloader.Reload())
+	assert.Equal(t, "new.dondeestan.app", commonName(t, reloader))
+
+	assert.NoError(t, os.WriteFile(keyFile, []byte("half written"), 0o600))
+	assert.Error(t, reloader.Reload())
+	assert.Equal(t, "new.dondeestan.app", commonName(t, reloader))
+}
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, "old.dondeestan.app", time.Now().Add(-time.Minute))
+
+	reloader, err := NewReloader(certFile, keyFile)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	writeCertificate(t, certFile, keyFile, "new.dondeestan.app", time.Now())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for commonName(t, reloader) != "new.dondeestan.app" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, "new.dondeestan.app", commonName(t, reloader))
+}
+
+func TestNewReloaderMissingFiles(t *testing.T) {
+	_, err := NewReloader(filepath.Join(t.TempDir(), "cert.pem"), filepath.Join(t.TempDir(), "key.pem"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/infrastructure/migration"
+)
+
+type checkFunc struct {
+	name  string
+	check func(context.Context) error
+}
+
+// CheckFunc turns a function into a health check with the given name.
+func CheckFunc(name string, check func(context.Context) error) gateway.HealthCheck {
+	return checkFunc{name: name, check: check}
+}
+
+func (c checkFunc) Name() string {
+	return c.name
+}
+
+func (c checkFunc) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+// NewDatabaseCheck pings the database.
+func NewDatabaseCheck(db *sql.DB) gateway.HealthCheck {
+	return CheckFunc("database", db.PingContext)
+}
+
+// NewMigrationCheck requires every migration known by the binary to be applied, so a replica does not serve
+// requests against an older schema.
+func NewMigrationCheck(migrator *migration.Migrator) gateway.HealthCheck {
+	return CheckFunc("migrations", migrator.CheckVersion)
+}
@@ -0,0 +1,111 @@
+// Package health runs the checks telling whether the service can handle requests, caching their results so
+// frequent probes do not hammer the database.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+type registry struct {
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+
+	mutex  sync.Mutex
+	checks []gateway.HealthCheck
+	cached map[string]model.ComponentHealth
+}
+
+// NewRegistry creates a health checker reusing each result for ttl and failing the checks taking longer than
+// timeout.
+func NewRegistry(ttl time.Duration, timeout time.Duration) gateway.HealthChecker {
+	return &registry{
+		ttl:     ttl,
+		timeout: timeout,
+		now:     time.Now,
+		cached:  map[string]model.ComponentHealth{},
+	}
+}
+
+func (r *registry) Register(check gateway.HealthCheck) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.checks = append(r.checks, check)
+}
+
+// Check runs the checks whose cached result expired, concurrently. Concurrent calls wait for the running checks
+// instead of starting them again. Results are not cached when ctx is done, such as when the client went away.
+func (r *registry) Check(ctx context.Context) model.HealthReport {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	results := make([]model.ComponentHealth, len(r.checks))
+
+	var wait sync.WaitGroup
+	for i, check := range r.checks {
+		if cached, ok := r.cached[check.Name()]; ok && now.Sub(cached.CheckedAt) < r.ttl {
+			results[i] = cached
+			continue
+		}
+
+		wait.Add(1)
+		go func(i int, check gateway.HealthCheck) {
+			defer wait.Done()
+			results[i] = r.run(ctx, check)
+		}(i, check)
+	}
+
+	wait.Wait()
+
+	report := model.HealthReport{Status: model.HealthStatusUp, Components: make(map[string]model.ComponentHealth, len(r.checks))}
+	for i, check := range r.checks {
+		if ctx.Err() == nil {
+			r.cached[check.Name()] = results[i]
+		}
+
+		if results[i].Status != model.HealthStatusUp {
+			report.Status = model.HealthStatusDown
+		}
+
+		report.Components[check.Name()] = results[i]
+	}
+
+	return report
+}
+
+// run checks a component, giving up after the timeout even when the check ignores its context.
+func (r *registry) run(ctx context.Context, check gateway.HealthCheck) model.ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := r.now()
+
+	done := make(chan error, 1)
+	go func() { done <- check.Check(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	component := model.ComponentHealth{
+		Status:     model.HealthStatusUp,
+		CheckedAt:  start,
+		DurationMS: r.now().Sub(start).Milliseconds(),
+	}
+
+	if err != nil {
+		component.Status, component.Error = model.HealthStatusDown, err.Error()
+	}
+
+	return component
+}
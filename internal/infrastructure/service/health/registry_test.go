@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRegistry(now *time.Time) *registry {
+	checker := NewRegistry(10*time.Second, 50*time.Millisecond).(*registry)
+	checker.now = func() time.Time { return *now }
+
+	return checker
+}
+
+func TestCheckCachesResults(t *testing.T) {
+	now := time.Unix(1689000000, 0)
+	checker := newTestRegistry(&now)
+
+	pings := 0
+	checker.Register(CheckFunc("database", func(context.Context) error {
+		pings++
+		return nil
+	}))
+
+	report := checker.Check(context.Background())
+	assert.True(t, report.Up())
+	assert.Equal(t, model.ComponentHealth{Status: model.HealthStatusUp, CheckedAt: now}, report.Components["database"])
+
+	now = now.Add(5 * time.Second)
+	checker.Check(context.Background())
+	assert.Equal(t, 1, pings)
+
+	now = now.Add(5 * time.Second)
+	report = checker.Check(context.Background())
+	assert.Equal(t, 2, pings)
+	assert.Equal(t, now, report.Components["database"].CheckedAt)
+}
+
+func TestCheckReportsFailures(t *testing.T) {
+	now := time.Unix(1689000000, 0)
+	checker := newTestRegistry(&now)
+	checker.Register(CheckFunc("database", func(context.Context) error { return nil }))
+	checker.Register(CheckFunc("migrations", func(context.Context) error { return errors.New("migrations are pending") }))
+	checker.Register(CheckFunc("stuck", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}))
+
+	report := checker.Check(context.Background())
+	assert.False(t, report.Up())
+	assert.Equal(t, model.HealthStatusDown, report.Status)
+	assert.Equal(t, model.HealthStatusUp, report.Components["database"].Status)
+	assert.Equal(t, "migrations are pending", report.Components["migrations"].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Components["stuck"].Error)
+}
+
+func TestCheckDoesNotCacheCanceledRequests(t *testing.T) {
+	now := time.Unix(1689000000, 0)
+	checker := newTestRegistry(&now)
+	checker.Register(CheckFunc("database", func(ctx context.Context) error { return ctx.Err() }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, checker.Check(ctx).Up())
+
+	assert.True(t, checker.Check(context.Background()).Up())
+}
+
+func TestCheckWithoutChecks(t *testing.T) {
+	now := time.Unix(1689000000, 0)
+
+	assert.True(t, newTestRegistry(&now).Check(context.Background()).Up())
+}
@@ -0,0 +1,344 @@
+// Package ical encodes and decodes the school and company calendars in iCalendar format (RFC 5545).
+package ical
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+const (
+	productID = "-//DondeEstan//Calendar//ES"
+	uidDomain = "dondeestan"
+
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405"
+	clockLayout    = "15:04"
+
+	// maxLineLength is the length in octets after which the content lines are folded.
+	maxLineLength = 75
+	// maxEventDays caps the days an all-day event can span, such as the winter break.
+	maxEventDays = 366
+
+	propertyKind  = "X-DONDEESTAN-KIND"
+	propertyStart = "X-DONDEESTAN-START"
+	propertyEnd   = "X-DONDEESTAN-END"
+)
+
+var (
+	errNotCalendar = errors.New("the content is not an iCalendar")
+
+	// categories maps the kinds of calendar entries to the CATEGORIES values, which other calendars can read.
+	categories = map[string]string{
+		model.CalendarEntryHoliday:         "HOLIDAY",
+		model.CalendarEntryInService:       "IN-SERVICE",
+		model.CalendarEntryHalfDay:         "HALF-DAY",
+		model.CalendarEntrySpecialSchedule: "SPECIAL-SCHEDULE",
+	}
+
+	textEscapes   = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	textUnescapes = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+	unfolding     = strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "")
+)
+
+// NewCodec creates the iCalendar codec.
+func NewCodec() gateway.CalendarCodec {
+	return &codec{now: time.Now}
+}
+
+type codec struct {
+	now func() time.Time
+}
+
+// property is a content line: NAME;PARAM=VALUE:value.
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Encode writes the calendar with an event per entry. Entries with both times are timed events in the calendar
+// timezone, and the rest are all-day events. The kind and times are kept in X- properties, so a calendar encoded
+// here is decoded back as it was.
+func (c codec) Encode(calendar model.Calendar) ([]byte, error) {
+	location, err := time.LoadLocation(calendar.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+	stamp := c.now().UTC().Format(dateTimeLayout) + "Z"
+
+	writeLine(buffer, "BEGIN:VCALENDAR")
+	writeLine(buffer, "VERSION:2.0")
+	writeLine(buffer, "PRODID:"+productID)
+	writeLine(buffer, "CALSCALE:GREGORIAN")
+	writeLine(buffer, "X-WR-CALNAME:"+textEscapes.Replace(calendar.Name))
+	writeLine(buffer, "X-WR-TIMEZONE:"+calendar.Timezone)
+
+	for _, entry := range calendar.Entries {
+		date, err := time.ParseInLocation(model.CalendarDateLayout, entry.Date, location)
+		if err != nil {
+			return nil, err
+		}
+
+		writeLine(buffer, "BEGIN:VEVENT")
+		writeLine(buffer, fmt.Sprintf("UID:%s-%s@%s", date.Format(dateLayout), uidPart(calendar.ID), uidDomain))
+		writeLine(buffer, "DTSTAMP:"+stamp)
+
+		start, startErr := model.ParseClock(entry.Start)
+		end, endErr := model.ParseClock(entry.End)
+		if startErr == nil && endErr == nil {
+			writeLine(buffer, fmt.Sprintf("DTSTART;TZID=%s:%s", calendar.Timezone, date.Add(start).Format(dateTimeLayout)))
+			writeLine(buffer, fmt.Sprintf("DTEND;TZID=%s:%s", calendar.Timezone, date.Add(end).Format(dateTimeLayout)))
+		} else {
+			writeLine(buffer, "DTSTART;VALUE=DATE:"+date.Format(dateLayout))
+			writeLine(buffer, "DTEND;VALUE=DATE:"+date.AddDate(0, 0, 1).Format(dateLayout))
+		}
+
+		summary := entry.Name
+		if summary == "" {
+			summary = entry.Kind
+		}
+
+		writeLine(buffer, "SUMMARY:"+textEscapes.Replace(summary))
+		writeLine(buffer, "CATEGORIES:"+categories[entry.Kind])
+		writeLine(buffer, "TRANSP:TRANSPARENT")
+		writeLine(buffer, propertyKind+":"+entry.Kind)
+
+		if entry.Start != "" {
+			writeLine(buffer, propertyStart+":"+entry.Start)
+		}
+
+		if entry.End != "" {
+			writeLine(buffer, propertyEnd+":"+entry.End)
+		}
+
+		writeLine(buffer, "END:VEVENT")
+	}
+
+	writeLine(buffer, "END:VCALENDAR")
+
+	return buffer.Bytes(), nil
+}
+
+// Decode reads the events of a calendar as entries, in the given timezone. All-day events spanning several days
+// become an entry per day. The kind comes from the X-DONDEESTAN-KIND property or the CATEGORIES, otherwise
+// timed events are special schedules and all-day events are holidays.
+func (c codec) Decode(reader io.Reader, timezone string) ([]model.CalendarEntry, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(unfolding.Replace(strings.TrimPrefix(string(content), "\ufeff")), "\n")
+	if len(lines) == 0 || !strings.EqualFold(strings.TrimSpace(lines[0]), "BEGIN:VCALENDAR") {
+		return nil, errNotCalendar
+	}
+
+	var (
+		entries []model.CalendarEntry
+		event   map[string]property
+	)
+
+	for number, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number+1, err)
+		}
+
+		switch {
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VEVENT"):
+			event = map[string]property{}
+		case p.name == "END" && strings.EqualFold(p.value, "VEVENT") && event != nil:
+			decoded, err := decodeEvent(event, location)
+			if err != nil {
+				return nil, fmt.Errorf("event ending at line %d: %w", number+1, err)
+			}
+
+			entries = append(entries, decoded...)
+			event = nil
+		case event != nil:
+			if _, ok := event[p.name]; !ok {
+				event[p.name] = p
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func decodeEvent(event map[string]property, location *time.Location) ([]model.CalendarEntry, error) {
+	dtstart, ok := event["DTSTART"]
+	if !ok {
+		return nil, errors.New("missing DTSTART")
+	}
+
+	start, allDay, err := parseTime(dtstart, location)
+	if err != nil {
+		return nil, err
+	}
+
+	end := start
+	if dtend, ok := event["DTEND"]; ok {
+		if end, _, err = parseTime(dtend, location); err != nil {
+			return nil, err
+		}
+	}
+
+	entry := model.CalendarEntry{
+		Kind: eventKind(event, allDay),
+		Name: textUnescapes.Replace(event["SUMMARY"].value),
+	}
+
+	if !allDay {
+		entry.Start, entry.End = start.Format(clockLayout), end.Format(clockLayout)
+	}
+
+	if p, ok := event[propertyStart]; ok {
+		entry.Start = p.value
+	}
+
+	if p, ok := event[propertyEnd]; ok {
+		entry.End = p.value
+	}
+
+	if !allDay || !end.After(start) {
+		entry.Date = start.Format(model.CalendarDateLayout)
+		return []model.CalendarEntry{entry}, nil
+	}
+
+	var entries []model.CalendarEntry
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if len(entries) == maxEventDays {
+			return nil, fmt.Errorf("events cannot span more than %d days", maxEventDays)
+		}
+
+		entry.Date = day.Format(model.CalendarDateLayout)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// parseTime parses a DATE or DATE-TIME value in the given location. DATE-TIME values in UTC or with a TZID are
+// converted to it.
+func parseTime(p property, location *time.Location) (time.Time, bool, error) {
+	value := p.value
+
+	if p.params["VALUE"] == "DATE" || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, location)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeLayout, strings.TrimSuffix(value, "Z"))
+		return t.In(location), false, err
+	}
+
+	zone := location
+	if tzid, ok := p.params["TZID"]; ok {
+		loaded, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unknown timezone %s", tzid)
+		}
+		zone = loaded
+	}
+
+	t, err := time.ParseInLocation(dateTimeLayout, value, zone)
+
+	return t.In(location), false, err
+}
+
+func eventKind(event map[string]property, allDay bool) string {
+	if p, ok := event[propertyKind]; ok {
+		if _, known := categories[p.value]; known {
+			return p.value
+		}
+	}
+
+	for _, category := range strings.Split(event["CATEGORIES"].value, ",") {
+		for kind, name := range categories {
+			if strings.EqualFold(strings.TrimSpace(category), name) {
+				return kind
+			}
+		}
+	}
+
+	if allDay {
+		return model.CalendarEntryHoliday
+	}
+
+	return model.CalendarEntrySpecialSchedule
+}
+
+// parseLine splits a content line in its name, parameters and value. Colons and semicolons inside quoted
+// parameter values do not separate.
+func parseLine(line string) (property, error) {
+	quoted := false
+
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted:
+			parts := strings.Split(line[:i], ";")
+			p := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[i+1:]}
+
+			for _, param := range parts[1:] {
+				key, value, _ := strings.Cut(param, "=")
+				p.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+			}
+
+			return p, nil
+		}
+	}
+
+	return property{}, fmt.Errorf("malformed content line %q", line)
+}
+
+// writeLine writes a content line folded at maxLineLength octets, without splitting UTF-8 characters.
+// The continuation lines start with a space, which counts for their length.
+func writeLine(buffer *bytes.Buffer, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		buffer.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = maxLineLength - 1
+	}
+
+	buffer.WriteString(line + "\r\n")
+}
+
+// uidPart makes the owner of the calendar safe to be part of an event UID.
+func uidPart(id string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '@' {
+			return '-'
+		}
+
+		return r
+	}, id)
+}
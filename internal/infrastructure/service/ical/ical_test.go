@@ -0,0 +1,94 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCodec() codec {
+	return codec{now: func() time.Time { return time.Date(2023, 1, 10, 12, 0, 0, 0, time.UTC) }}
+}
+
+func TestEncode(t *testing.T) {
+	c := newTestCodec()
+
+	t.Run("Encode round trip", func(t *testing.T) {
+		entries := []model.CalendarEntry{
+			{Date: "2023-07-09", Kind: model.CalendarEntryHoliday, Name: "Día de la Independencia"},
+			{Date: "2023-07-10", Kind: model.CalendarEntryHalfDay, Name: "Acto, con familias; salida temprano", End: "12:00"},
+			{Date: "2023-07-11", Kind: model.CalendarEntrySpecialSchedule, Name: "Olimpíadas", Start: "10:00", End: "15:30"},
+		}
+
+		content, err := c.Encode(model.Calendar{ID: "school-1", Name: "La Salle", Timezone: model.DefaultSchoolTimezone, Entries: entries})
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), "DTSTART;TZID=America/Argentina/Buenos_Aires:20230711T100000\r\n")
+		assert.Contains(t, string(content), "DTSTART;VALUE=DATE:20230709\r\n")
+
+		decoded, err := c.Decode(strings.NewReader(string(content)), model.DefaultSchoolTimezone)
+		assert.NoError(t, err)
+		assert.Equal(t, entries, decoded)
+	})
+
+	t.Run("Encode folds long lines", func(t *testing.T) {
+		name := strings.Repeat("Jornada institucional ñandú ", 6)
+
+		content, err := c.Encode(model.Calendar{ID: "school-1", Timezone: "UTC", Entries: []model.CalendarEntry{{Date: "2023-07-09", Kind: model.CalendarEntryInService, Name: name}}})
+		assert.NoError(t, err)
+
+		for _, line := range strings.Split(string(content), "\r\n") {
+			assert.True(t, len(line) <= maxLineLength, line)
+		}
+
+		decoded, err := c.Decode(strings.NewReader(string(content)), "UTC")
+		assert.NoError(t, err)
+		assert.Equal(t, name, decoded[0].Name)
+	})
+
+	t.Run("Encode unknown timezone", func(t *testing.T) {
+		_, err := c.Encode(model.Calendar{Timezone: "Mars/Olympus"})
+		assert.Error(t, err)
+	})
+}
+
+func TestDecode(t *testing.T) {
+	c := newTestCodec()
+
+	t.Run("Decode expands all-day events and converts times", func(t *testing.T) {
+		content := "BEGIN:VCALENDAR\nVERSION:2.0\n" +
+			"BEGIN:VEVENT\nSUMMARY:Receso invernal\nDTSTART;VALUE=DATE:20230717\nDTEND;VALUE=DATE:20230720\nEND:VEVENT\n" +
+			"BEGIN:VEVENT\nSUMMARY:Jornada\nCATEGORIES:IN-SERVICE\nDTSTART:20230721\nEND:VEVENT\n" +
+			"BEGIN:VEVENT\nSUMMARY:Acto\nDTSTART:20230724T130000Z\nDTEND:20230724T160000Z\nEND:VEVENT\n" +
+			"BEGIN:VEVENT\nSUMMARY:Salida\nDTSTART;TZID=\"Europe/Madrid\":20230725T150000\nDTEND;TZID=Europe/Madrid:20230725T170000\nEND:VEVENT\n" +
+			"END:VCALENDAR\n"
+
+		entries, err := c.Decode(strings.NewReader(content), model.DefaultSchoolTimezone)
+		assert.NoError(t, err)
+		assert.Equal(t, []model.CalendarEntry{
+			{Date: "2023-07-17", Kind: model.CalendarEntryHoliday, Name: "Receso invernal"},
+			{Date: "2023-07-18", Kind: model.CalendarEntryHoliday, Name: "Receso invernal"},
+			{Date: "2023-07-19", Kind: model.CalendarEntryHoliday, Name: "Receso invernal"},
+			{Date: "2023-07-21", Kind: model.CalendarEntryInService, Name: "Jornada"},
+			{Date: "2023-07-24", Kind: model.CalendarEntrySpecialSchedule, Name: "Acto", Start: "10:00", End: "13:00"},
+			{Date: "2023-07-25", Kind: model.CalendarEntrySpecialSchedule, Name: "Salida", Start: "10:00", End: "12:00"},
+		}, entries)
+	})
+
+	t.Run("Decode rejects other content", func(t *testing.T) {
+		_, err := c.Decode(strings.NewReader("name,date\n"), "UTC")
+		assert.Error(t, err)
+
+		_, err = c.Decode(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:No start\nEND:VEVENT\nEND:VCALENDAR\n"), "UTC")
+		assert.Error(t, err)
+	})
+
+	t.Run("Decode caps long events", func(t *testing.T) {
+		content := "BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20230101\nDTEND;VALUE=DATE:20250101\nEND:VEVENT\nEND:VCALENDAR\n"
+
+		_, err := c.Decode(strings.NewReader(content), "UTC")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,81 @@
+// Package mail renders the email templates and delivers them through a pluggable sink.
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+// Sink delivers a rendered email.
+type Sink interface {
+	Deliver(model.Email) error
+}
+
+// NewMailer creates a mailer that sends the emails from the given address through the sink.
+func NewMailer(from string, sink Sink) (gateway.Mailer, error) {
+	templates := map[string]*template.Template{}
+
+	for _, name := range []string{model.EmailTemplateVerifyEmail, model.EmailTemplateResetPassword, model.EmailTemplateGuardianInvitation, model.EmailTemplateEmailChanged} {
+		t, err := template.ParseFS(templatesFS, "templates/"+name+".tmpl")
+		if err != nil {
+			return nil, err
+		}
+		templates[name] = t
+	}
+
+	return &mailer{
+		from:      from,
+		sink:      sink,
+		templates: templates,
+	}, nil
+}
+
+type mailer struct {
+	from      string
+	sink      Sink
+	templates map[string]*template.Template
+}
+
+// Send renders the template with the data and delivers the resulting email.
+func (m mailer) Send(to string, name string, data map[string]string) error {
+	email, err := m.render(name, data)
+	if err != nil {
+		return err
+	}
+
+	email.To = to
+
+	return m.sink.Deliver(email)
+}
+
+func (m mailer) render(name string, data map[string]string) (model.Email, error) {
+	t, ok := m.templates[name]
+	if !ok {
+		return model.Email{}, fmt.Errorf("unknown email template %s", name)
+	}
+
+	var subject, body bytes.Buffer
+
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return model.Email{}, err
+	}
+
+	if err := t.ExecuteTemplate(&body, "body", data); err != nil {
+		return model.Email{}, err
+	}
+
+	return model.Email{
+		From:    m.from,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()) + "\n",
+	}, nil
+}
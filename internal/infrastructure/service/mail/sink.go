@@ -0,0 +1,101 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+)
+
+const (
+	SinkStdout = "stdout"
+	SinkFile   = "file"
+	SinkSMTP   = "smtp"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// NewWriterSink creates a sink that writes the emails to w. It is meant for local development.
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{writer: w}
+}
+
+type writerSink struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// Deliver writes the email in RFC 822 format.
+func (s *writerSink) Deliver(email model.Email) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, err := s.writer.Write(format(email))
+
+	return err
+}
+
+// NewFileSink creates a sink that stores each email as an .eml file in dir. It is meant for local development.
+func NewFileSink(dir string) Sink {
+	return &fileSink{dir: dir}
+}
+
+type fileSink struct {
+	dir string
+}
+
+// Deliver writes the email to a new file named after the time and the recipient.
+func (s fileSink) Deliver(email model.Email) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(email.To, "_"))
+
+	return os.WriteFile(filepath.Join(s.dir, name), format(email), 0o600)
+}
+
+// NewSMTPSink creates a sink that delivers the emails through an SMTP server using PLAIN authentication.
+func NewSMTPSink(addr, username, password string) Sink {
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpSink{addr: addr, auth: auth}
+}
+
+type smtpSink struct {
+	addr string
+	auth smtp.Auth
+}
+
+// Deliver sends the email to the SMTP server.
+func (s smtpSink) Deliver(email model.Email) error {
+	return smtp.SendMail(s.addr, s.auth, email.From, []string{email.To}, format(email))
+}
+
+func format(email model.Email) []byte {
+	var builder strings.Builder
+
+	builder.WriteString("From: " + email.From + "\r\n")
+	builder.WriteString("To: " + email.To + "\r\n")
+	builder.WriteString("Subject: " + email.Subject + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(email.Body, "\n", "\r\n"))
+
+	return []byte(builder.String())
+}
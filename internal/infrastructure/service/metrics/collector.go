@@ -0,0 +1,129 @@
+// Package metrics exposes the activity of the web service in the Prometheus format: the HTTP requests by route,
+// the database queries and connection pool, and the domain events counted by the use cases.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+const (
+	namespace = "dondeestan"
+
+	// otherMethod labels the requests with a method outside the standard ones, so clients cannot create series.
+	otherMethod = "OTHER"
+)
+
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// Collector holds the metrics of the process in its own registry.
+type Collector struct {
+	registry *prometheus.Registry
+
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	queryDuration *prometheus.HistogramVec
+	loginFailures *prometheus.CounterVec
+	notifications *prometheus.CounterVec
+}
+
+// NewCollector creates the metrics, along with the ones of the Go runtime and the process.
+func NewCollector() *Collector {
+	c := &Collector{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of the HTTP requests by method and route pattern.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Duration of the database statements by GORM operation.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation"}),
+		loginFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_failures_total",
+			Help:      "Failed logins by reason.",
+		}, []string{"reason"}),
+		notifications: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "notifications_total",
+			Help:      "Notifications delivered by channel and outcome.",
+		}, []string{"channel", "outcome"}),
+	}
+
+	c.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		c.httpRequests,
+		c.httpDuration,
+		c.queryDuration,
+		c.loginFailures,
+		c.notifications,
+	)
+
+	return c
+}
+
+// Handler serves the metrics to Prometheus.
+func (c *Collector) Handler() http.Handler {
+	return promhttp.HandlerFor(c.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest records a request answered with the status code. route is the pattern the request matched,
+// such as /users/{id}, so the identifiers in the paths do not create a series each. Non standard methods are
+// counted as OTHER.
+func (c *Collector) ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+	if !standardMethods[method] {
+		method = otherMethod
+	}
+
+	c.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	c.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+func (c *Collector) CountLoginFailure(reason string) {
+	c.loginFailures.WithLabelValues(reason).Inc()
+}
+
+func (c *Collector) CountNotification(channel string, outcome string) {
+	c.notifications.WithLabelValues(channel, outcome).Inc()
+}
+
+// InstrumentDB times the statements run through db and exposes the statistics of its connection pool.
+func (c *Collector) InstrumentDB(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	if err = c.registry.Register(collectors.NewDBStatsCollector(sqlDB, namespace)); err != nil {
+		return err
+	}
+
+	return registerQueryCallbacks(db, c.queryDuration)
+}
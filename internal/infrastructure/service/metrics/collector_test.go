@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	collector := NewCollector()
+	collector.ObserveHTTPRequest(http.MethodGet, "/users/{id}", http.StatusOK, 20*time.Millisecond)
+	collector.ObserveHTTPRequest(http.MethodGet, "/users/{id}", http.StatusOK, 30*time.Millisecond)
+	collector.ObserveHTTPRequest(http.MethodGet, "/users/{id}", http.StatusNotFound, time.Millisecond)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(collector.httpRequests.WithLabelValues(http.MethodGet, "/users/{id}", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.httpRequests.WithLabelValues(http.MethodGet, "/users/{id}", "404")))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector.httpDuration))
+}
+
+func TestObserveHTTPRequestFoldsUnknownMethods(t *testing.T) {
+	collector := NewCollector()
+	collector.ObserveHTTPRequest("PROPFIND", "unmatched", http.StatusMethodNotAllowed, time.Millisecond)
+	collector.ObserveHTTPRequest("X-RANDOM-1", "unmatched", http.StatusMethodNotAllowed, time.Millisecond)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(collector.httpRequests.WithLabelValues("OTHER", "unmatched", "405")))
+	assert.Equal(t, 1, testutil.CollectAndCount(collector.httpRequests))
+}
+
+func TestCountDomainEvents(t *testing.T) {
+	collector := NewCollector()
+	collector.CountLoginFailure("invalid_credentials")
+	collector.CountLoginFailure("invalid_credentials")
+	collector.CountNotification("email", "sent")
+	collector.CountNotification("email", "failed")
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(collector.loginFailures.WithLabelValues("invalid_credentials")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.notifications.WithLabelValues("email", "sent")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(collector.notifications.WithLabelValues("email", "failed")))
+}
+
+func TestInstrumentDB(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{})
+	assert.NoError(t, err)
+
+	collector := NewCollector()
+	assert.NoError(t, collector.InstrumentDB(gdb))
+
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var one int
+	assert.NoError(t, gdb.Raw("SELECT 1").Scan(&one).Error)
+	assert.NoError(t, gdb.Exec("UPDATE users SET status = 'active'").Error)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, 2, testutil.CollectAndCount(collector.queryDuration))
+
+	recorder := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, recorder.Body.String(), `go_sql_max_open_connections{db_name="dondeestan"}`)
+	assert.Contains(t, recorder.Body.String(), `dondeestan_db_query_duration_seconds_count{operation="row"} 1`)
+	assert.Contains(t, recorder.Body.String(), `dondeestan_db_query_duration_seconds_count{operation="raw"} 1`)
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// registerer is the part of the GORM callbacks used to hook the timers around an operation.
+type registerer interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// registerQueryCallbacks times every GORM operation. The repositories mostly go through Raw, which runs the row
+// callbacks when scanning and the raw ones when executing.
+func registerQueryCallbacks(db *gorm.DB, duration *prometheus.HistogramVec) error {
+	start := func(db *gorm.DB) {
+		db.InstanceSet(startKey, time.Now())
+	}
+
+	observe := func(operation string) func(*gorm.DB) {
+		return func(db *gorm.DB) {
+			if started, ok := db.InstanceGet(startKey); ok {
+				duration.WithLabelValues(operation).Observe(time.Since(started.(time.Time)).Seconds())
+			}
+		}
+	}
+
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    registerer
+		after     registerer
+	}{
+		{"create", callbacks.Create().Before("gorm:create"), callbacks.Create().After("gorm:create")},
+		{"query", callbacks.Query().Before("gorm:query"), callbacks.Query().After("gorm:query")},
+		{"update", callbacks.Update().Before("gorm:update"), callbacks.Update().After("gorm:update")},
+		{"delete", callbacks.Delete().Before("gorm:delete"), callbacks.Delete().After("gorm:delete")},
+		{"row", callbacks.Row().Before("gorm:row"), callbacks.Row().After("gorm:row")},
+		{"raw", callbacks.Raw().Before("gorm:raw"), callbacks.Raw().After("gorm:raw")},
+	}
+
+	for _, processor := range processors {
+		if err := processor.before.Register("metrics:before_"+processor.operation, start); err != nil {
+			return err
+		}
+
+		if err := processor.after.Register("metrics:after_"+processor.operation, observe(processor.operation)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
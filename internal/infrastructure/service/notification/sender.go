@@ -0,0 +1,26 @@
+package notification
+
+import (
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// NewLogSender creates a sender that writes the notifications to the log instead of delivering them.
+// It is used until the push, email and sms providers are configured.
+func NewLogSender() gateway.NotificationSender {
+	return &logSender{}
+}
+
+type logSender struct{}
+
+// Send logs the notification with the channel it would have been delivered through.
+func (s logSender) Send(channel string, notification model.Notification) error {
+	log.WithFields(log.Fields{
+		"channel":          channel,
+		"observer_user_id": notification.ObserverUserID,
+		"event_type":       notification.EventType,
+	}).Info(notification.Title)
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package privacy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+)
+
+const (
+	version   = "v1"
+	separator = ":"
+)
+
+var encoding = base64.RawStdEncoding
+
+// NewEnvelopeCipher creates a field cipher that encrypts each value with its own random data key,
+// which is in turn encrypted with the current key of the provider. Encrypted values have the format
+// v1:<key id>:<wrapped data key>:<ciphertext>.
+func NewEnvelopeCipher(provider gateway.KeyProvider) gateway.FieldCipher {
+	return &envelopeCipher{provider: provider}
+}
+
+type envelopeCipher struct {
+	provider gateway.KeyProvider
+}
+
+// Encrypt encrypts the value with a new data key.
+func (c envelopeCipher) Encrypt(plaintext string) (string, error) {
+	keyID, key := c.provider.CurrentKey()
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(key, dataKey, []byte(keyID))
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(dataKey, []byte(plaintext), nil)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.Join([]string{version, keyID, encoding.EncodeToString(wrappedKey), encoding.EncodeToString(ciphertext)}, separator), nil
+}
+
+// Decrypt decrypts a value produced by Encrypt. Values stored before encryption was enabled are returned as they are.
+func (c envelopeCipher) Decrypt(value string) (string, error) {
+	if !isEncrypted(value) {
+		return value, nil
+	}
+
+	parts := strings.Split(value, separator)
+	if len(parts) != 4 {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	key, err := c.provider.Key(parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := open(key, wrappedKey, []byte(parts[1]))
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := encoding.DecodeString(parts[3])
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataKey, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// BlindIndex returns a keyed hash of the normalized value, so it can be looked up without decrypting every row.
+// Dots, dashes and spaces are ignored, so 12.345.678 and 12345678 have the same index.
+func (c envelopeCipher) BlindIndex(plaintext string) string {
+	mac := hmac.New(sha256.New, c.provider.IndexKey())
+	mac.Write([]byte(normalize(plaintext)))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NeedsRotation reports whether the value is not encrypted yet or was encrypted with an old key.
+func (c envelopeCipher) NeedsRotation(value string) bool {
+	if !isEncrypted(value) {
+		return true
+	}
+
+	current, _ := c.provider.CurrentKey()
+	parts := strings.SplitN(value, separator, 3)
+
+	return len(parts) < 3 || parts[1] != current
+}
+
+func isEncrypted(value string) bool {
+	return strings.HasPrefix(value, version+separator)
+}
+
+func normalize(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, value)
+}
+
+func seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
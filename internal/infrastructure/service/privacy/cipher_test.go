@@ -0,0 +1,182 @@
+package privacy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestProvider(t *testing.T, current string) *keyRing {
+	provider, err := NewKeyRing(current, map[string][]byte{
+		"old": bytes.Repeat([]byte{1}, keySize),
+		"new": bytes.Repeat([]byte{2}, keySize),
+	}, bytes.Repeat([]byte{3}, keySize))
+	assert.NoError(t, err)
+
+	return provider.(*keyRing)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	cipher := NewEnvelopeCipher(newTestProvider(t, "new"))
+
+	encrypted, err := cipher.Encrypt("12345678")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, "v1:new:"))
+	assert.NotContains(t, encrypted, "12345678")
+
+	again, _ := cipher.Encrypt("12345678")
+	assert.NotEqual(t, encrypted, again)
+
+	plaintext, err := cipher.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678", plaintext)
+}
+
+func TestDecryptLegacyAndTamperedValues(t *testing.T) {
+	cipher := NewEnvelopeCipher(newTestProvider(t, "new"))
+
+	plaintext, err := cipher.Decrypt("12345678")
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678", plaintext)
+
+	encrypted, _ := cipher.Encrypt("12345678")
+	_, err = cipher.Decrypt(strings.Replace(encrypted, "v1:new:", "v1:old:", 1))
+	assert.Error(t, err)
+
+	_, err = cipher.Decrypt("v1:missing:a:b")
+	assert.Error(t, err)
+
+	_, err = cipher.Decrypt("v1:new:a")
+	assert.Error(t, err)
+}
+
+func TestRotation(t *testing.T) {
+	oldCipher := NewEnvelopeCipher(newTestProvider(t, "old"))
+	newCipher := NewEnvelopeCipher(newTestProvider(t, "new"))
+
+	encrypted, _ := oldCipher.Encrypt("12345678")
+	assert.False(t, oldCipher.NeedsRotation(encrypted))
+	assert.True(t, newCipher.NeedsRotation(encrypted))
+	assert.True(t, newCipher.NeedsRotation("12345678"))
+
+	plaintext, err := newCipher.Decrypt(encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678", plaintext)
+}
+
+func TestBlindIndex(t *testing.T) {
+	cipher := NewEnvelopeCipher(newTestProvider(t, "new"))
+
+	assert.Equal(t, cipher.BlindIndex("12345678"), cipher.BlindIndex("12.345.678"))
+	assert.Equal(t, cipher.BlindIndex("ab-123"), cipher.BlindIndex("AB 123"))
+	assert.NotEqual(t, cipher.BlindIndex("12345678"), cipher.BlindIndex("12345679"))
+	assert.Len(t, cipher.BlindIndex("12345678"), 64)
+}
+
+func TestLocalKeyProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	_, err := NewLocalKeyProvider(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.NoFileExists(t, path)
+
+	file, err := NewKeyFile()
+	assert.NoError(t, err)
+	assert.NoError(t, CreateKeyFile(path, file))
+	assert.ErrorIs(t, CreateKeyFile(path, file), os.ErrExist)
+
+	created, err := file.KeyProvider()
+	assert.NoError(t, err)
+
+	loaded, err := NewLocalKeyProvider(path)
+	assert.NoError(t, err)
+
+	createdID, createdKey := created.CurrentKey()
+	loadedID, loadedKey := loaded.CurrentKey()
+	assert.Equal(t, createdID, loadedID)
+	assert.Equal(t, createdKey, loadedKey)
+	assert.Equal(t, created.IndexKey(), loaded.IndexKey())
+}
+
+func TestRotateKeyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	file, err := NewKeyFile()
+	assert.NoError(t, err)
+	previous := file.Current
+
+	file.Current = "old"
+	file.Keys["old"] = file.Keys[previous]
+	delete(file.Keys, previous)
+	assert.NoError(t, file.Rotate())
+	assert.NoError(t, WriteKeyFile(path, file))
+
+	provider, err := NewLocalKeyProvider(path)
+	assert.NoError(t, err)
+
+	current, _ := provider.CurrentKey()
+	assert.NotEqual(t, "old", current)
+
+	_, err = provider.Key("old")
+	assert.NoError(t, err)
+}
+
+func TestRotateTwiceInTheSameSecond(t *testing.T) {
+	file, err := NewKeyFile()
+	assert.NoError(t, err)
+
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, file.rotate(now))
+	first := file.Keys[file.Current]
+
+	assert.EqualError(t, file.rotate(now.Add(500*time.Millisecond)), "key 20240301100000 already exists, rotate again in a second")
+	assert.Equal(t, "20240301100000", file.Current)
+	assert.Equal(t, first, file.Keys["20240301100000"])
+
+	assert.NoError(t, file.rotate(now.Add(time.Second)))
+	assert.Equal(t, "20240301100001", file.Current)
+	assert.Len(t, file.Keys, 3)
+}
+
+func TestWriteKeyFileReplacesTheFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys.json")
+	assert.NoError(t, os.WriteFile(path, []byte("previous"), 0o644))
+
+	file, err := NewKeyFile()
+	assert.NoError(t, err)
+	assert.NoError(t, WriteKeyFile(path, file))
+
+	written, err := ReadKeyFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, file, written)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestNewKeyRingValidation(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, keySize)
+
+	_, err := NewKeyRing("missing", map[string][]byte{"a": key}, key)
+	assert.Error(t, err)
+
+	_, err = NewKeyRing("a", map[string][]byte{"a": key[:16]}, key)
+	assert.Error(t, err)
+
+	_, err = NewKeyRing("a:b", map[string][]byte{"a:b": key}, key)
+	assert.Error(t, err)
+
+	_, err = NewKeyRing("a", map[string][]byte{"a": key}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,255 @@
+// Package privacy encrypts the sensitive fields of the users with envelope encryption.
+package privacy
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+)
+
+const keySize = 32
+
+// KeyFile is the content of the local key file. Keys are base64 encoded and Current is the ID of the key
+// used to encrypt new values. Old keys must be kept until every value is re-encrypted with the current one.
+type KeyFile struct {
+	Current  string            `json:"current"`
+	Keys     map[string]string `json:"keys"`
+	IndexKey string            `json:"index_key"`
+}
+
+// NewKeyRing creates a key provider from keys already in memory.
+func NewKeyRing(current string, keys map[string][]byte, indexKey []byte) (gateway.KeyProvider, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("current key %s not found", current)
+	}
+
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, separator) {
+			return nil, fmt.Errorf("invalid key id %q", id)
+		}
+
+		if len(key) != keySize {
+			return nil, fmt.Errorf("key %s must have %d bytes", id, keySize)
+		}
+	}
+
+	if len(indexKey) != keySize {
+		return nil, fmt.Errorf("index key must have %d bytes", keySize)
+	}
+
+	return &keyRing{current: current, keys: keys, indexKey: indexKey}, nil
+}
+
+// NewLocalKeyProvider loads the keys from a JSON key file. A missing file is an error rather than a new key, since
+// the values encrypted with the lost keys could not be read anymore; privacy-key init creates the first one.
+func NewLocalKeyProvider(path string) (gateway.KeyProvider, error) {
+	file, err := ReadKeyFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("privacy key file %s not found, create it with privacy-key init: %w", path, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return file.KeyProvider()
+}
+
+// ReadKeyFile loads a JSON key file.
+func ReadKeyFile(path string) (KeyFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return KeyFile{}, err
+	}
+
+	return parseKeyFile(content)
+}
+
+func parseKeyFile(content []byte) (KeyFile, error) {
+	var file KeyFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return KeyFile{}, err
+	}
+
+	if file.Keys == nil {
+		file.Keys = map[string]string{}
+	}
+
+	return file, nil
+}
+
+// NewKeyFile creates a key file with a new random current key and index key.
+func NewKeyFile() (KeyFile, error) {
+	indexKey, err := randomKey()
+	if err != nil {
+		return KeyFile{}, err
+	}
+
+	file := KeyFile{Keys: map[string]string{}, IndexKey: indexKey}
+
+	return file, file.Rotate()
+}
+
+// Rotate adds a new random key and makes it the current one. Keys are named after the second they are created in,
+// so a second rotation within the same second fails rather than replacing the key of the first one.
+func (f *KeyFile) Rotate() error {
+	return f.rotate(time.Now())
+}
+
+func (f *KeyFile) rotate(now time.Time) error {
+	id := now.UTC().Format("20060102150405")
+	if _, ok := f.Keys[id]; ok {
+		return fmt.Errorf("key %s already exists, rotate again in a second", id)
+	}
+
+	key, err := randomKey()
+	if err != nil {
+		return err
+	}
+
+	f.Keys[id] = key
+	f.Current = id
+
+	return nil
+}
+
+// KeyProvider decodes the keys of the file.
+func (f KeyFile) KeyProvider() (gateway.KeyProvider, error) {
+	keys := map[string][]byte{}
+
+	for id, encoded := range f.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %s is not base64 encoded", id)
+		}
+		keys[id] = key
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(f.IndexKey)
+	if err != nil {
+		return nil, errors.New("index key is not base64 encoded")
+	}
+
+	return NewKeyRing(f.Current, keys, indexKey)
+}
+
+// CreateKeyFile stores a new key file readable only by its owner, failing when the file already exists.
+func CreateKeyFile(path string, file KeyFile) error {
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+
+	if _, err = out.Write(content); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// WriteKeyFile replaces the key file, readable only by its owner. The new content is synced to a temporary file of
+// the same directory and renamed over the old one, so a crash or a full disk never leaves the keys half written.
+func WriteKeyFile(path string, file KeyFile) error {
+	content, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	out, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+
+	if err = writeSynced(out, content); err != nil {
+		return err
+	}
+
+	if err = os.Rename(out.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// writeSynced writes content to a file readable only by its owner, flushes it to the disk and closes it.
+func writeSynced(out *os.File, content []byte) error {
+	err := out.Chmod(0o600)
+	if err == nil {
+		_, err = out.Write(content)
+	}
+	if err == nil {
+		err = out.Sync()
+	}
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// syncDir flushes a directory to the disk, so a rename in it survives a crash.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	if err = dir.Sync(); err != nil {
+		dir.Close()
+		return err
+	}
+
+	return dir.Close()
+}
+
+type keyRing struct {
+	current  string
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+// CurrentKey returns the ID and the value of the key used to encrypt new values.
+func (k keyRing) CurrentKey() (string, []byte) {
+	return k.current, k.keys[k.current]
+}
+
+// Key returns the key with the given ID.
+func (k keyRing) Key(id string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("key %s not found", id)
+	}
+
+	return key, nil
+}
+
+// IndexKey returns the key used to compute blind indexes.
+func (k keyRing) IndexKey() []byte {
+	return k.indexKey
+}
+
+func randomKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
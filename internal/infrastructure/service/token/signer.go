@@ -0,0 +1,80 @@
+// Package token signs and verifies the tokens sent to users using HMAC-SHA256.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+)
+
+const separator = "."
+
+var encoding = base64.RawURLEncoding
+
+// NewSigner creates a token service that signs the claims with the given secret.
+func NewSigner(secret []byte) gateway.TokenService {
+	return &signer{
+		secret: secret,
+		now:    time.Now,
+	}
+}
+
+type signer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// Sign serializes the claims and appends their signature.
+func (s signer) Sign(token model.Token) (string, error) {
+	payload, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := encoding.EncodeToString(payload)
+
+	return encoded + separator + encoding.EncodeToString(s.signature(encoded)), nil
+}
+
+// Parse verifies the signature and expiration of the token and returns its claims.
+func (s signer) Parse(value string) (*model.Token, error) {
+	parts := strings.Split(value, separator)
+	if len(parts) != 2 {
+		return nil, web.ErrInvalidToken
+	}
+
+	signature, err := encoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, s.signature(parts[0])) {
+		return nil, web.ErrInvalidToken
+	}
+
+	payload, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, web.ErrInvalidToken
+	}
+
+	var token model.Token
+	if err = json.Unmarshal(payload, &token); err != nil {
+		return nil, web.ErrInvalidToken
+	}
+
+	if s.now().Unix() >= token.ExpiresAt {
+		return nil, web.ErrInvalidToken
+	}
+
+	return &token, nil
+}
+
+func (s signer) signature(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+
+	return mac.Sum(nil)
+}
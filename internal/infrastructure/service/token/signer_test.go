@@ -0,0 +1,65 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model"
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/model/web"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner(t *testing.T) {
+	var (
+		now    = time.Date(2022, 12, 10, 17, 49, 30, 0, time.UTC)
+		claims = model.Token{
+			ID:        "4f1c2a",
+			UserID:    1,
+			Purpose:   model.TokenPurposeResetPassword,
+			Email:     "jperez@mail.com",
+			ExpiresAt: now.Add(time.Hour).Unix(),
+		}
+		s = &signer{secret: []byte("secret"), now: func() time.Time { return now }}
+	)
+
+	t.Run("Parse successful", func(t *testing.T) {
+		signed, err := s.Sign(claims)
+		assert.NoError(t, err)
+
+		token, err := s.Parse(signed)
+		assert.NoError(t, err)
+		assert.Equal(t, claims, *token)
+	})
+
+	t.Run("Parse tampered token", func(t *testing.T) {
+		signed, _ := s.Sign(claims)
+		other, _ := s.Sign(model.Token{ID: "4f1c2a", UserID: 2, Purpose: model.TokenPurposeResetPassword, ExpiresAt: claims.ExpiresAt})
+
+		token, err := s.Parse(other[:len(other)-43] + signed[len(signed)-43:])
+		assert.Nil(t, token)
+		assert.Equal(t, web.ErrInvalidToken, err)
+	})
+
+	t.Run("Parse other secret", func(t *testing.T) {
+		signed, _ := (&signer{secret: []byte("other"), now: s.now}).Sign(claims)
+
+		token, err := s.Parse(signed)
+		assert.Nil(t, token)
+		assert.Equal(t, web.ErrInvalidToken, err)
+	})
+
+	t.Run("Parse expired token", func(t *testing.T) {
+		signed, _ := s.Sign(claims)
+		expired := &signer{secret: s.secret, now: func() time.Time { return now.Add(2 * time.Hour) }}
+
+		token, err := expired.Parse(signed)
+		assert.Nil(t, token)
+		assert.Equal(t, web.ErrInvalidToken, err)
+	})
+
+	t.Run("Parse malformed token", func(t *testing.T) {
+		token, err := s.Parse("not-a-token")
+		assert.Nil(t, token)
+		assert.Equal(t, web.ErrInvalidToken, err)
+	})
+}
@@ -0,0 +1,111 @@
+// Package totp implements the time-based one-time passwords used as second factor.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"github.com/skip2/go-qrcode"
+)
+
+const (
+	secretSize = 20
+	digits     = 6
+	period     = 30
+	// skew is the number of steps before and after the current one whose codes are accepted,
+	// to tolerate clock drift between the server and the authenticator app.
+	skew   = 1
+	qrSize = 256
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// NewService creates the TOTP service. Issuer is the name shown by the authenticator apps.
+func NewService(issuer string) gateway.TOTPService {
+	return &service{issuer: issuer}
+}
+
+type service struct {
+	issuer string
+}
+
+// NewSecret returns a new random base32 encoded secret.
+func (s service) NewSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// ProvisioningURI returns the otpauth URI that authenticator apps use to register the secret.
+func (s service) ProvisioningURI(secret, account string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", s.issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(period))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + s.issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+
+	return uri.String()
+}
+
+// QRCode encodes the URI as a PNG QR code, returned as a data URI.
+func (s service) QRCode(uri string) (string, error) {
+	png, err := qrcode.Encode(uri, qrcode.Medium, qrSize)
+	if err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(png), nil
+}
+
+// Validate checks the code against the steps around at and returns the step it belongs to.
+func (s service) Validate(secret, code string, at time.Time) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != digits {
+		return 0, false
+	}
+
+	current := at.Unix() / period
+	for step := current - skew; step <= current+skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(generate(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// generate computes the HOTP value (RFC 4226) of the step.
+func generate(key []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
@@ -0,0 +1,69 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 secret of the test vectors of RFC 6238.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestValidateRFCVectors(t *testing.T) {
+	s := NewService("DondeEstan")
+
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+
+	for at, code := range vectors {
+		step, ok := s.Validate(rfcSecret, code, time.Unix(at, 0))
+		assert.True(t, ok, "code of %d", at)
+		assert.Equal(t, at/period, step)
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	s := NewService("DondeEstan")
+	at := time.Unix(59, 0)
+
+	_, ok := s.Validate(rfcSecret, "287082", at.Add(period*time.Second))
+	assert.True(t, ok)
+
+	_, ok = s.Validate(rfcSecret, "287082", at.Add(3*period*time.Second))
+	assert.False(t, ok)
+
+	_, ok = s.Validate(rfcSecret, "28708", at)
+	assert.False(t, ok)
+
+	_, ok = s.Validate("not base32!", "287082", at)
+	assert.False(t, ok)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	s := NewService("DondeEstan")
+
+	secret, err := s.NewSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	uri, err := url.Parse(s.ProvisioningURI(secret, "mdominguez"))
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/DondeEstan:mdominguez", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "DondeEstan", uri.Query().Get("issuer"))
+
+	qr, err := s.QRCode(uri.String())
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(qr, "data:image/png;base64,"))
+}
@@ -0,0 +1,79 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// registerer is the part of the GORM callbacks used to hook the spans around an operation.
+type registerer interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+// InstrumentDB traces the statements run through db as children of the span in their context, which the
+// repositories get from the request with gorm.DB.WithContext.
+func InstrumentDB(db *gorm.DB) error {
+	tracer := otel.Tracer(instrumentation + "/gorm")
+
+	start := func(operation string) func(*gorm.DB) {
+		return func(db *gorm.DB) {
+			ctx, span := tracer.Start(db.Statement.Context, "gorm."+operation,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(semconv.DBSystemMySQL, semconv.DBOperation(operation)),
+			)
+			db.Statement.Context = ctx
+			db.InstanceSet(spanKey, span)
+		}
+	}
+
+	end := func(db *gorm.DB) {
+		value, ok := db.InstanceGet(spanKey)
+		if !ok {
+			return
+		}
+
+		span := value.(trace.Span)
+		span.SetAttributes(semconv.DBStatement(db.Statement.SQL.String()))
+		if db.Statement.Table != "" {
+			span.SetAttributes(semconv.DBSQLTable(db.Statement.Table))
+		}
+		if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+			span.RecordError(db.Error)
+			span.SetStatus(codes.Error, db.Error.Error())
+		}
+		span.End()
+	}
+
+	callbacks := db.Callback()
+	processors := []struct {
+		operation string
+		before    registerer
+		after     registerer
+	}{
+		{"create", callbacks.Create().Before("gorm:create"), callbacks.Create().After("gorm:create")},
+		{"query", callbacks.Query().Before("gorm:query"), callbacks.Query().After("gorm:query")},
+		{"update", callbacks.Update().Before("gorm:update"), callbacks.Update().After("gorm:update")},
+		{"delete", callbacks.Delete().Before("gorm:delete"), callbacks.Delete().After("gorm:delete")},
+		{"row", callbacks.Row().Before("gorm:row"), callbacks.Row().After("gorm:row")},
+		{"raw", callbacks.Raw().Before("gorm:raw"), callbacks.Raw().After("gorm:raw")},
+	}
+
+	for _, processor := range processors {
+		if err := processor.before.Register("tracing:before_"+processor.operation, start(processor.operation)); err != nil {
+			return err
+		}
+
+		if err := processor.after.Register("tracing:after_"+processor.operation, end); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
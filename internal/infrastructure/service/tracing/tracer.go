@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/gcoron/donde-estan-ws/internal/bussiness/gateway"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracer struct {
+	ctx    context.Context
+	tracer trace.Tracer
+}
+
+// NewTracer creates the tracer of the use cases run for a request, starting their spans in ctx.
+func NewTracer(ctx context.Context) gateway.Tracer {
+	return tracer{ctx: ctx, tracer: otel.Tracer(instrumentation + "/usecase")}
+}
+
+func (t tracer) Start(name string) gateway.Span {
+	_, s := t.tracer.Start(t.ctx, name)
+	return span{s}
+}
+
+type span struct {
+	trace.Span
+}
+
+func (s span) End(err error) {
+	if err != nil {
+		s.Span.RecordError(err)
+		s.Span.SetStatus(codes.Error, err.Error())
+	}
+	s.Span.End()
+}
+
+// StartSpan starts a span named name in ctx, for the work of the infrastructure such as a repository call. The
+// returned context carries the span, for its children.
+func StartSpan(ctx context.Context, name string) (context.Context, gateway.Span) {
+	ctx, s := otel.Tracer(instrumentation).Start(ctx, name)
+	return ctx, span{s}
+}
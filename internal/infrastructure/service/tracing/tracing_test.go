@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func TestTracerRecordsErrors(t *testing.T) {
+	recorder := recordSpans(t)
+
+	ctx, parent := StartSpan(context.Background(), "request")
+	tracer := NewTracer(ctx)
+	tracer.Start("LoginUseCase.Login").End(errors.New("invalid credentials"))
+	tracer.Start("UserUseCase.Get").End(nil)
+	parent.End(nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "LoginUseCase.Login", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "invalid credentials", spans[0].Status().Description)
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+	assert.Equal(t, spans[2].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, spans[2].SpanContext().SpanID(), spans[1].Parent().SpanID())
+}
+
+func TestInstrumentDB(t *testing.T) {
+	recorder := recordSpans(t)
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	gdb, err := gorm.Open(mysql.New(mysql.Config{Conn: db, SkipInitializeWithVersion: true}), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, InstrumentDB(gdb))
+
+	mock.ExpectQuery("SELECT id FROM Users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("UPDATE Users").WillReturnError(errors.New("lock wait timeout exceeded"))
+
+	ctx, parent := StartSpan(context.Background(), "UserRepository.GetObserverUser")
+	var id int
+	assert.NoError(t, gdb.WithContext(ctx).Raw("SELECT id FROM Users").Scan(&id).Error)
+	assert.Error(t, gdb.WithContext(ctx).Exec("UPDATE Users SET enabled = false").Error)
+	parent.End(nil)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+
+	assert.Equal(t, "gorm.row", spans[0].Name())
+	assert.Equal(t, spans[2].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Contains(t, spans[0].Attributes(), semconv.DBStatement("SELECT id FROM Users"))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "gorm.raw", spans[1].Name())
+	assert.Equal(t, spans[2].SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
+
+func TestSetup(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := Setup(context.Background(), Options{Exporter: ExporterFile, File: path, SampleRatio: 1, ServiceName: "donde-estan-ws"})
+	assert.NoError(t, err)
+
+	_, span := StartSpan(context.Background(), "UserUseCase.Get")
+	span.End(nil)
+	assert.NoError(t, shutdown(context.Background()))
+
+	exported, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(exported), `"Name":"UserUseCase.Get"`)
+	assert.Contains(t, string(exported), `"Value":"donde-estan-ws"`)
+
+	_, err = Setup(context.Background(), Options{Exporter: "zipkin"})
+	assert.EqualError(t, err, `unknown trace exporter "zipkin"`)
+}